@@ -0,0 +1,65 @@
+// Package analyze is the public API for embedding iguana's evidence bundle
+// generation in other Go programs.
+//
+// The implementation lives in iguana/internal/evidence; this package re-exports
+// its bundle types as aliases so callers can use them without importing an
+// internal package. Bundles carry both YAML and JSON tags with identical keys.
+//
+//	b, err := analyze.File("server/server.go")
+//	if err != nil { ... }
+//	fmt.Println(b.Package.Name, len(b.Symbols.Functions))
+package analyze
+
+import "iguana/internal/evidence"
+
+// Bundle is an evidence bundle for one Go source file.
+type Bundle = evidence.EvidenceBundle
+
+// Bundle section types.
+type (
+	FileMeta    = evidence.FileMeta
+	PackageMeta = evidence.PackageMeta
+	Import      = evidence.Import
+	Symbols     = evidence.Symbols
+	Function    = evidence.Function
+	TypeDecl    = evidence.TypeDecl
+	FieldDecl   = evidence.FieldDecl
+	VarDecl     = evidence.VarDecl
+	Call        = evidence.Call
+	Signals     = evidence.Signals
+)
+
+// DirOptions controls Dir; see evidence.WalkOptions.
+type DirOptions = evidence.WalkOptions
+
+// File analyzes the Go source file at path and returns its evidence bundle.
+// No files are written (INV-20). Type information is used when the file's
+// package loads; otherwise analysis falls back to the AST alone.
+func File(path string) (*Bundle, error) {
+	return evidence.CreateEvidenceBundle(path)
+}
+
+// Dir walks root and writes a companion <file>.evidence.yaml next to every
+// analyzed .go file, returning counts of written and skipped (up-to-date)
+// bundles plus any per-file errors.
+func Dir(root string, opts DirOptions) (written, skipped int, errs []error) {
+	return evidence.WalkAndGenerate(root, opts)
+}
+
+// Write writes b to <b.File.Path>.evidence.yaml. If force is false and the
+// existing bundle has the same source hash, nothing is written and skipped is
+// true (INV-50).
+func Write(b *Bundle, force bool) (skipped bool, err error) {
+	return evidence.WriteEvidenceBundle(b, force)
+}
+
+// ErrUnsupportedVersion is wrapped by Decode errors for bundles with a
+// missing, unknown, or newer schema version.
+var ErrUnsupportedVersion = evidence.ErrUnsupportedVersion
+
+// Decode reads a YAML evidence bundle of any supported schema version,
+// migrating older bundles forward. Bundles from a newer iguana are rejected
+// with an error wrapping ErrUnsupportedVersion.
+func Decode(data []byte) (*Bundle, error) {
+	return evidence.Decode(data)
+}
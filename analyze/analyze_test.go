@@ -0,0 +1,56 @@
+package analyze
+
+// analyze_test.go — Tests for the public embedding API.
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFile verifies File analyzes a source file without writing anything and
+// that the result marshals to JSON with the same keys as the YAML schema.
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "widget.go")
+	src := "package widget\n\ntype Widget struct{ Name string }\n\nfunc NewWidget() *Widget { return &Widget{} }\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := File(path)
+	if err != nil {
+		t.Fatalf("File: %v", err)
+	}
+	if b.Package.Name != "widget" {
+		t.Errorf("Package.Name = %q, want %q", b.Package.Name, "widget")
+	}
+	if len(b.Symbols.Functions) != 1 || b.Symbols.Functions[0].Name != "NewWidget" {
+		t.Errorf("Functions = %v, want [NewWidget]", b.Symbols.Functions)
+	}
+	if len(b.Symbols.Constructors) != 1 {
+		t.Errorf("Constructors = %v, want [NewWidget]", b.Symbols.Constructors)
+	}
+	if _, err := os.Stat(path + ".evidence.yaml"); !os.IsNotExist(err) {
+		t.Error("File must not write a companion bundle")
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	for _, key := range []string{`"version":2`, `"sha256":`, `"functions":`, `"fs_reads":false`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("JSON missing %s: %s", key, data)
+		}
+	}
+}
+
+// TestFile_NotFound verifies a missing file returns an error.
+func TestFile_NotFound(t *testing.T) {
+	if _, err := File(filepath.Join(t.TempDir(), "missing.go")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -11,15 +11,23 @@ import (
 
 	b "iguana/baml_client"
 	"iguana/baml_client/types"
+	"iguana/internal/llm"
 )
 
 // classifierFunc is the signature of the LLM-backed state classifier.
 type classifierFunc func(ctx context.Context, content string) (types.State, error)
 
 // typeOfState is the active classifier; tests may replace it with a mock.
-// The closure drops the variadic opts since categorizeFile does not need them.
+// It runs TypeOfState against the LLM providers configured for it (see
+// settings.LLMConfig), or the compiled-in client when there are none.
 var typeOfState classifierFunc = func(ctx context.Context, content string) (types.State, error) {
-	return b.TypeOfState(ctx, content)
+	chain, err := userConfig.LLM.Chain("TypeOfState")
+	if err != nil {
+		return "", err
+	}
+	return llm.Call(ctx, chain, func(ctx context.Context, opts ...b.CallOptionFunc) (types.State, error) {
+		return b.TypeOfState(ctx, content, opts...)
+	})
 }
 
 // categorizeFile reads the Go source file at filePath and returns its
@@ -1,8 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"iguana/internal/evidence"
+	"iguana/internal/model"
+	"iguana/internal/settings"
+	"iguana/internal/store"
 )
 
 // CLI Dispatch Invariants (from INVARIANT.md §CLI Dispatch Invariants)
@@ -148,7 +160,7 @@ func TestDispatchUnknownNonExistentArg(t *testing.T) {
 func TestSubcommandBadArgsGivesUsage(t *testing.T) {
 	// Commands that require args: system-model, obsidian-vault both need a dir.
 	// analyze needs a dir/file. clean has an optional arg so it won't fail.
-	requireArgs := []string{"system-model", "obsidian-vault", "analyze"}
+	requireArgs := []string{"system-model", "obsidian-vault", "analyze", "check", "bundle-info"}
 	for _, name := range requireArgs {
 		t.Run(name, func(t *testing.T) {
 			err := dispatch([]string{name}) // no args after subcommand name
@@ -187,3 +199,534 @@ func TestCommandsHaveRequiredFields(t *testing.T) {
 		}
 	}
 }
+
+// TestExtractFlagValues verifies repeatable value flags are collected in
+// order from both "--name value" and "--name=value" forms.
+func TestExtractFlagValues(t *testing.T) {
+	args := []string{"--include", "internal/**", "--force", "--include=cmd/*.go", "."}
+	values, rest, err := extractFlagValues(args, "--include")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(values, ",") != "internal/**,cmd/*.go" {
+		t.Errorf("values = %v, want [internal/** cmd/*.go]", values)
+	}
+	if strings.Join(rest, ",") != "--force,." {
+		t.Errorf("rest = %v, want [--force .]", rest)
+	}
+
+	if _, _, err := extractFlagValues([]string{"--include"}, "--include"); err == nil {
+		t.Error("expected error for --include without a value")
+	}
+}
+
+// TestCheckCommand runs "iguana check" against a clean tree (pass), a tree
+// with a modified source file (fail), and the same tree after --fix (pass).
+func TestCheckCommand(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "main.go")
+	if err := os.WriteFile(src, []byte("package main\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"analyze", root}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	if err := dispatch([]string{"check", root}); err != nil {
+		t.Errorf("check on fresh tree: %v", err)
+	}
+
+	if err := os.WriteFile(src, []byte("package main\nfunc main() {}\nfunc helper() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"check", root}); err == nil {
+		t.Error("check on stale tree should fail")
+	}
+
+	if err := dispatch([]string{"check", "--fix", root}); err != nil {
+		t.Errorf("check --fix: %v", err)
+	}
+	if err := dispatch([]string{"check", root}); err != nil {
+		t.Errorf("check after --fix: %v", err)
+	}
+
+	// A model generated without inference is fresh for its bundle set.
+	modelPath := filepath.Join(root, "system_model.yaml")
+	if err := dispatch([]string{"system-model", "--no-llm", root}); err != nil {
+		t.Fatalf("system-model --no-llm: %v", err)
+	}
+	if err := dispatch([]string{"check", "--model", modelPath, root}); err != nil {
+		t.Errorf("check --model on a fresh --no-llm model: %v", err)
+	}
+}
+
+// TestValidateCommand runs "iguana validate" on a fresh tree (pass) and
+// after a source file is deleted, leaving its bundle orphaned (fail).
+func TestValidateCommand(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dispatch([]string{"analyze", root}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if err := dispatch([]string{"validate", root}); err != nil {
+		t.Errorf("validate on fresh tree: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(root, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"validate", root}); err == nil {
+		t.Error("validate with an orphaned bundle should fail")
+	}
+}
+
+// TestConfigValidateCommand verifies that config validate accepts a
+// missing or valid .iguana.yaml and fails on an invalid one.
+func TestConfigValidateCommand(t *testing.T) {
+	root := t.TempDir()
+	if err := dispatch([]string{"config", "validate", root}); err != nil {
+		t.Errorf("no .iguana.yaml: %v", err)
+	}
+	path := filepath.Join(root, settings.RepoFileName)
+	if err := os.WriteFile(path, []byte("deny: [\"gen/**\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"config", "validate", root}); err != nil {
+		t.Errorf("valid .iguana.yaml: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("deny: [\"gen/**\"]\nincludes_tests: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"config", "validate", root}); err == nil {
+		t.Error("unknown key: want error")
+	}
+	if err := dispatch([]string{"config"}); err == nil {
+		t.Error("config without a verb: want usage error")
+	}
+}
+
+// TestWriteBundleDiffs verifies that empty sections are omitted from the
+// YAML and JSON output and that no diffs print nothing.
+func TestWriteBundleDiffs(t *testing.T) {
+	diffs := []evidence.BundleDiff{{
+		Path:      "a.go",
+		Status:    evidence.DiffChanged,
+		Functions: evidence.SetDiff{Added: []string{"A2"}},
+	}}
+	for _, asJSON := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := writeBundleDiffs(&buf, diffs, asJSON); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "A2") || strings.Contains(out, "imports") {
+			t.Errorf("json=%v: unexpected output:\n%s", asJSON, out)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundleDiffs(&buf, nil, false); err != nil || buf.Len() != 0 {
+		t.Errorf("no diffs: output %q, err %v; want none", buf.String(), err)
+	}
+}
+
+// TestAnalyzeFile_CustomSignals verifies that analyzing a single file
+// records the user-defined signals of the file's directory's settings, not
+// the working directory's.
+func TestAnalyzeFile_CustomSignals(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	writeSettings := func(dir, signal string) {
+		if err := os.MkdirAll(filepath.Join(dir, ".iguana"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".iguana", "settings.yaml"), []byte("signals:\n  - {name: "+signal+", imports: [os]}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	writeSettings(root, "files")
+	cwd := t.TempDir()
+	writeSettings(cwd, "elsewhere")
+	t.Chdir(cwd)
+	src := filepath.Join(root, "store.go")
+	if err := os.WriteFile(src, []byte("package store\n\nimport \"os\"\n\nfunc Remove() error { return os.Remove(\"x\") }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"analyze", src}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	data, err := os.ReadFile(src + ".evidence.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := evidence.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"files"}; !slices.Equal(b.Signals.Custom, want) {
+		t.Errorf("custom signals = %v, want %v", b.Signals.Custom, want)
+	}
+}
+
+// TestAnalyze_Store verifies that analyze --store writes bundles to the
+// database instead of companion files and drops deleted files' bundles on
+// the next run.
+func TestAnalyze_Store(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := filepath.Join(t.TempDir(), "evidence.db")
+	if err := dispatch([]string{"analyze", "--store", db, root}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*.evidence.yaml")); len(matches) != 0 {
+		t.Errorf("companion bundles written with --store: %v", matches)
+	}
+
+	if err := os.Remove(filepath.Join(root, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"analyze", "--store", db, root}); err != nil {
+		t.Fatalf("second analyze: %v", err)
+	}
+	st, err := store.Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	bundles, err := st.Bundles()
+	if err != nil || len(bundles) != 1 || bundles[0].File.Path != "a.go" {
+		t.Errorf("stored bundles = %v, %v; want only a.go", bundles, err)
+	}
+
+	if err := dispatch([]string{"analyze", "--store", db, "--diff-base", "HEAD", root}); err == nil {
+		t.Error("expected --store with --diff-base to fail")
+	}
+}
+
+// TestBundleInfo generates a bundle for a known source file and verifies the
+// printed counts and validation result, before and after the source changes.
+func TestBundleInfo(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "store.go")
+	code := `package store
+
+import "os"
+
+type Store struct{ Path string }
+
+func NewStore() *Store { return &Store{} }
+
+func (s *Store) Save() error { return os.WriteFile(s.Path, nil, 0o644) }
+
+const Mode = 1
+`
+	if err := os.WriteFile(src, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatch([]string{"analyze", src}); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	info, err := evidence.InspectBundle(src + ".evidence.yaml")
+	if err != nil {
+		t.Fatalf("InspectBundle: %v", err)
+	}
+	var sb strings.Builder
+	if err := writeBundleInfo(&sb, info, false); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"package:      store",
+		"imports:      1",
+		"functions:    2",
+		"types:        1",
+		"constants:    1",
+		"constructors: 1",
+		"signals:      fs_writes",
+		"validation:   fresh",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := os.WriteFile(src, []byte(code+"\nvar X int\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = evidence.InspectBundle(src + ".evidence.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Reset()
+	if err := writeBundleInfo(&sb, info, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `"validation": "stale"`) {
+		t.Errorf("JSON output should report stale bundle:\n%s", sb.String())
+	}
+}
+
+// TestWriteModelTable verifies that --format table renders aligned rows for
+// packages, state domains, and boundaries.
+func TestWriteModelTable(t *testing.T) {
+	m := &model.SystemModel{
+		Inventory: model.Inventory{
+			Packages: []model.PackageEntry{
+				{Name: "auth", Files: []string{"auth/a.go"}, ExportedSymbolCount: 3},
+			},
+		},
+		StateDomains: []model.StateDomain{
+			{ID: "auth.sessions", Aggregate: "Session", Owners: []string{"auth"}, Confidence: 0.85},
+			{ID: "cache", Aggregate: "Entry", Confidence: 0.5},
+		},
+		Boundaries: model.Boundaries{
+			Persistence: []model.PersistenceBoundary{{Kind: "db", Writers: []model.SymbolRef{{File: "auth/a.go"}}}},
+		},
+	}
+	var sb strings.Builder
+	if err := writeModelTable(&sb, m); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"DOMAIN         AGGREGATE  CONFIDENCE  OWNERS\n",
+		"auth.sessions  Session    0.85        auth\n",
+		"cache          Entry      0.50        -\n",
+		"auth     1      3         -\n",
+		"persistence  db    1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestDispatchAppliesGlobalConfig verifies that a format set in the global
+// ~/.iguana/config.yaml becomes the default when no flag overrides it.
+func TestDispatchAppliesGlobalConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".iguana"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".iguana", "config.yaml"), []byte("format: table\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { userConfig = settings.Config{} })
+
+	if err := dispatch([]string{"clean", t.TempDir()}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if got := resolveFormat(nil); got != "table" {
+		t.Errorf("resolveFormat(nil) = %q, want %q", got, "table")
+	}
+	if got := resolveFormat([]string{"yaml"}); got != "yaml" {
+		t.Errorf("flag should override config, got %q", got)
+	}
+}
+
+// TestDispatchProfile verifies that a leading --profile flag writes a
+// non-empty CPU profile once the command finishes.
+func TestDispatchProfile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prof := filepath.Join(t.TempDir(), "cpu.prof")
+
+	if err := dispatch([]string{"--profile", prof, "analyze", root}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	info, err := os.Stat(prof)
+	if err != nil {
+		t.Fatalf("profile not written: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("profile file is empty")
+	}
+}
+
+// TestCompletionBashListsCommands verifies the bash completion script is
+// derived from the commands slice: every registered name and each
+// command's flags appear in it.
+func TestCompletionBashListsCommands(t *testing.T) {
+	var sb strings.Builder
+	if err := writeCompletion(&sb, "bash"); err != nil {
+		t.Fatalf("writeCompletion: %v", err)
+	}
+	script := sb.String()
+	for _, cmd := range commands {
+		if !strings.Contains(script, cmd.name) {
+			t.Errorf("bash completion missing command %q", cmd.name)
+		}
+	}
+	if !strings.Contains(script, "--diff-base") || !strings.Contains(script, "complete -o default -F _iguana iguana") {
+		t.Errorf("bash completion missing flags or registration:\n%s", script)
+	}
+	if err := writeCompletion(&sb, "powershell"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+// TestRunReview verifies that review records a decision per answered domain,
+// moves accepted and edited domains into the model, and leaves skipped ones
+// pending.
+func TestRunReview(t *testing.T) {
+	pending := []model.StateDomain{
+		{ID: "cache", Description: "Hot cache", Owners: []string{"cache"}, Confidence: 0.4},
+		{ID: "jobs", Description: "Jobs", Aggregate: "Job", Owners: []string{"queue"}, Confidence: 0.5},
+		{ID: "scratch", Confidence: 0.3},
+		{ID: "tmp", Confidence: 0.2},
+	}
+	var out bytes.Buffer
+	in := strings.NewReader("a\ne\nJob queue\n\nworker, queue\nx\nr\ns\n")
+	decisions, err := reviewDomains(in, &out, pending)
+	if err != nil {
+		t.Fatalf("reviewDomains: %v", err)
+	}
+	if len(decisions) != 3 {
+		t.Fatalf("decisions = %+v, want accept, edit, reject", decisions)
+	}
+	edited := decisions[1].Domain
+	if decisions[1].Action != model.ReviewEdit || edited == nil || edited.Description != "Job queue" ||
+		edited.Aggregate != "Job" || strings.Join(edited.Owners, ",") != "queue,worker" {
+		t.Errorf("edit decision = %+v (domain %+v)", decisions[1], edited)
+	}
+	if decisions[2].ID != "scratch" || decisions[2].Action != model.ReviewReject {
+		t.Errorf("third decision = %+v, want reject scratch (unknown answer re-asked)", decisions[2])
+	}
+
+	dir := t.TempDir()
+	if err := model.WriteReviewDecisions(dir, decisions); err != nil {
+		t.Fatalf("WriteReviewDecisions: %v", err)
+	}
+	read, err := model.ReadReviewDecisions(dir)
+	if err != nil || len(read) != 3 {
+		t.Fatalf("ReadReviewDecisions = %+v, %v", read, err)
+	}
+	m := &model.SystemModel{PendingReview: pending}
+	model.ApplyReviewDecisions(m, read)
+	if len(m.StateDomains) != 2 || m.StateDomains[0].ID != "cache" || m.StateDomains[1].Description != "Job queue" {
+		t.Errorf("StateDomains = %+v, want cache and the edited jobs", m.StateDomains)
+	}
+	if len(m.PendingReview) != 1 || m.PendingReview[0].ID != "tmp" {
+		t.Errorf("PendingReview = %+v, want only the skipped tmp", m.PendingReview)
+	}
+}
+
+// TestDashboard drives an iguana tui session: an empty bundle list,
+// analyze, a bundle preview, the files with signals, and the model's open
+// questions.
+func TestDashboard(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	src := "package store\n\nimport \"os\"\n\nfunc Save() error { return os.WriteFile(\"x\", nil, 0o644) }\n"
+	if err := os.MkdirAll(filepath.Join(dir, "store"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, code := range map[string]string{"main.go": "package main\n\nfunc main() {}\n", "store/store.go": src} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &model.SystemModel{OpenQuestions: []model.OpenQuestion{{Question: "Who owns the cache?", RelatedDomain: "cache"}}}
+	if err := model.WriteSystemModel(m, filepath.Join(dir, "system_model.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	analyzed := 0
+	d := newDashboard(dir, filepath.Join(dir, "system_model.yaml"), func(dir string) error {
+		analyzed++
+		return runAnalyze([]string{dir})
+	})
+	if err := d.load(); err != nil {
+		t.Fatal(err)
+	}
+	d.refresh()
+	send := func(msg tea.Msg) tea.Cmd {
+		_, cmd := d.Update(msg)
+		return cmd
+	}
+	key := func(k string) tea.Msg {
+		switch k {
+		case "tab":
+			return tea.KeyMsg{Type: tea.KeyTab}
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			return tea.KeyMsg{Type: tea.KeyDown}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+	expect := func(step string, want ...string) {
+		t.Helper()
+		view := d.View()
+		for _, w := range want {
+			if !strings.Contains(view, w) {
+				t.Errorf("%s: view missing %q:\n%s", step, w, view)
+			}
+		}
+	}
+
+	send(tea.WindowSizeMsg{Width: 100, Height: 30})
+	expect("overview", "0 bundles in 0 packages", "press a to analyze", "1 open questions")
+	send(key("2"))
+	expect("empty bundles", "No bundles")
+
+	// "a" hands analyze to the program to run with the terminal released;
+	// run it as the program would and report back.
+	if cmd := send(key("a")); cmd == nil {
+		t.Fatal("analyze key returned no command")
+	}
+	if analyzed != 0 {
+		t.Fatal("analyze ran inside Update")
+	}
+	send(analyzedMsg{d.analyze(dir)})
+	if analyzed != 1 {
+		t.Errorf("analyze ran %d times, want 1", analyzed)
+	}
+	expect("bundles", "analyze finished", "main.go", "store/store.go", "package store")
+
+	send(key("down"))
+	send(key("enter"))
+	expect("preview", "name: store", "store/store.go", "esc back")
+	send(key("esc"))
+	expect("back", "enter preview")
+
+	send(key("tab"))
+	expect("signals", "store/store.go", "fs_writes")
+	if strings.Contains(d.View(), "main.go") {
+		t.Errorf("signals lists a file without signals:\n%s", d.View())
+	}
+	send(key("tab"))
+	expect("questions", "1. Who owns the cache?", "domain: cache")
+	if cmd := send(key("q")); cmd == nil || cmd() != tea.Quit() {
+		t.Error("q did not quit")
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	got := progressLine(evidence.Progress{Phase: evidence.PhaseAnalyze, Dir: "pkg", Files: 5, Total: 10, Elapsed: 35 * time.Millisecond})
+	if want := "[==========>         ] 5/10 files  analyze pkg (35ms)"; got != want {
+		t.Errorf("progressLine = %q, want %q", got, want)
+	}
+	got = progressLine(evidence.Progress{Phase: evidence.PhaseAnalyze, Dir: "pkg", Files: 5, Elapsed: time.Second})
+	if want := "5 files  analyze pkg (1s)"; got != want {
+		t.Errorf("progressLine without total = %q, want %q", got, want)
+	}
+	if _, err := newAnalyzeLog("xml", os.Stderr); err == nil {
+		t.Error("newAnalyzeLog accepted an unknown format")
+	}
+}
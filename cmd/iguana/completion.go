@@ -0,0 +1,136 @@
+package main
+
+// completion.go — "iguana completion <shell>": shell completion scripts.
+//
+// Command names and flags are read from the commands slice (invariant 38),
+// flags being every "--name" in a command's usage line, so the scripts
+// never drift from what dispatch accepts.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// The completion command reads commands, so it is registered here rather
+// than in the slice literal, which would be an initialization cycle.
+func init() {
+	commands = append(commands, command{
+		name:  "completion",
+		short: "Print a shell completion script",
+		usage: "iguana completion bash|zsh|fish",
+		long: `Print a completion script for bash, zsh, or fish to stdout.
+
+Completes command names, "help <command>", and each command's flags;
+other arguments complete as file names.
+
+  bash:  source <(iguana completion bash)
+  zsh:   source <(iguana completion zsh)   (after compinit)
+  fish:  iguana completion fish | source
+`,
+		run: runCompletion,
+	})
+}
+
+// runCompletion implements the "completion" subcommand.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: iguana completion bash|zsh|fish")
+	}
+	return writeCompletion(os.Stdout, args[0])
+}
+
+// usageFlag matches a long flag in a usage line.
+var usageFlag = regexp.MustCompile(`--[a-z][a-z0-9-]*`)
+
+// commandFlags returns the long flags named in cmd's usage line, in order
+// and without duplicates.
+func commandFlags(cmd command) []string {
+	var flags []string
+	seen := make(map[string]bool)
+	for _, f := range usageFlag.FindAllString(cmd.usage, -1) {
+		if !seen[f] {
+			seen[f] = true
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// commandNames returns the registered command names followed by "help".
+func commandNames() []string {
+	names := make([]string, 0, len(commands)+1)
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return append(names, "help")
+}
+
+// writeCompletion writes the completion script for shell to w.
+func writeCompletion(w io.Writer, shell string) error {
+	var b strings.Builder
+	names := strings.Join(commandNames(), " ")
+	switch shell {
+	case "bash":
+		b.WriteString("# bash completion for iguana\n")
+		b.WriteString("_iguana() {\n")
+		b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" flags=\"\"\n")
+		b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+		fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", names)
+		b.WriteString("        return\n")
+		b.WriteString("    fi\n")
+		b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+		fmt.Fprintf(&b, "        help) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", names)
+		for _, cmd := range commands {
+			if flags := commandFlags(cmd); len(flags) > 0 {
+				fmt.Fprintf(&b, "        %s) flags=%q ;;\n", cmd.name, strings.Join(flags, " "))
+			}
+		}
+		b.WriteString("    esac\n")
+		b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+		b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+		b.WriteString("    fi\n")
+		b.WriteString("}\n")
+		b.WriteString("complete -o default -F _iguana iguana\n")
+	case "zsh":
+		b.WriteString("#compdef iguana\n")
+		b.WriteString("_iguana() {\n")
+		b.WriteString("  if (( CURRENT == 2 )) || [[ $words[2] == help ]]; then\n")
+		fmt.Fprintf(&b, "    compadd -- %s\n", names)
+		b.WriteString("    return\n")
+		b.WriteString("  fi\n")
+		b.WriteString("  if [[ $PREFIX == -* ]]; then\n")
+		b.WriteString("    case $words[2] in\n")
+		for _, cmd := range commands {
+			if flags := commandFlags(cmd); len(flags) > 0 {
+				fmt.Fprintf(&b, "      %s) compadd -- %s ;;\n", cmd.name, strings.Join(flags, " "))
+			}
+		}
+		b.WriteString("    esac\n")
+		b.WriteString("  else\n")
+		b.WriteString("    _files\n")
+		b.WriteString("  fi\n")
+		b.WriteString("}\n")
+		b.WriteString("compdef _iguana iguana\n")
+	case "fish":
+		b.WriteString("# fish completion for iguana\n")
+		b.WriteString("complete -c iguana -f -n __fish_use_subcommand -a help -d 'Show help for a command'\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(&b, "complete -c iguana -f -n __fish_use_subcommand -a %s -d '%s'\n",
+				cmd.name, strings.ReplaceAll(cmd.short, "'", `\'`))
+		}
+		fmt.Fprintf(&b, "complete -c iguana -f -n '__fish_seen_subcommand_from help' -a '%s'\n", names)
+		for _, cmd := range commands {
+			for _, f := range commandFlags(cmd) {
+				fmt.Fprintf(&b, "complete -c iguana -n '__fish_seen_subcommand_from %s' -l %s\n",
+					cmd.name, strings.TrimPrefix(f, "--"))
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh, or fish)", shell)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
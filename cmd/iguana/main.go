@@ -2,16 +2,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"iguana/internal/canonical"
 	"iguana/internal/evidence"
 	"iguana/internal/export"
 	"iguana/internal/model"
+	"iguana/internal/obsidian"
+	"iguana/internal/server"
+	"iguana/internal/settings"
+	"iguana/internal/store"
 )
 
 // command describes a CLI subcommand.
@@ -30,40 +43,413 @@ var commands = []command{
 	{
 		name:  "analyze",
 		short: "Generate evidence bundles from Go source files",
-		usage: "iguana analyze <dir-or-file>",
+		usage: "iguana analyze [--force] [--clean] [--stream] [--include <glob>]... [--bundle-version <v>] [--diff-base <ref>] [--pin-commit <rev>] [--concurrency-budget <n>] [--include-tests] [--per-package] [--store <db>] [--log-format <text|json>] <dir-or-file>",
 		long: `Generate evidence bundles from Go source files.
 
 When given a directory, walks all .go files (excluding test files,
 vendor/, testdata/, examples/, docs/) and writes companion
-<file>.evidence.yaml bundles.
+<file>.evidence.yaml bundles. .proto files get bundles too, recording
+their services, RPC methods, and message types.
 
-When given a single .go file, writes one <file>.evidence.yaml bundle.
+When given a single .go or .proto file, writes one <file>.evidence.yaml
+bundle.
+
+In directory mode, executables in ~/.iguana/plugins are run as external
+evidence producers for the file extensions they declare; they speak JSON
+over stdin/stdout (configure and analyze verbs).
+
+Paths are filtered by the deny and allow globs of .iguana.yaml and
+.iguana/settings.yaml (see "iguana help config").
+
+Flags:
+  --force, -f       Regenerate bundles even when the source is unchanged.
+  --clean           Remove every existing *.evidence.yaml under the
+                    directory first, dropping bundles of deleted files.
+                    Not with --include, which would regenerate only
+                    part of what was removed.
+  --stream          Process each directory as it is reached instead of
+                    listing the whole tree first; for very large
+                    monorepos. Output is identical.
+  --include <glob>  Only analyze files whose root-relative path matches
+                    the glob (repeatable; "**" matches any depth).
+                    Built-in and settings skips still apply.
+  --bundle-version <v>
+                    Bundle schema to emit: "latest" (default),
+                    "2-classic", which omits fields added after the
+                    original v2 layout for older consumers, or
+                    "compact", which omits false signals and empty
+                    lists. Implies --force so existing bundles switch
+                    schema.
+  --diff-base <ref> Regenerate bundles only for .go files changed
+                    between <ref> and HEAD (git diff --name-status),
+                    drop bundles of deleted files, and write the list
+                    to <dir>/changes.yaml. With --include, only
+                    changed files matching a glob are regenerated.
+                    Directory mode only.
+  --pin-commit <rev>
+                    Fail unless the repository's HEAD is at <rev>, so a
+                    moved branch is never analyzed by surprise. Defaults
+                    to the "pin_commit" key of the analyzed directory's
+                    .iguana/config.yaml (the global config's is ignored).
+  --concurrency-budget <n>
+                    Load and analyze up to n directories at once
+                    (default 1). Each holds its package's full type
+                    information, so n bounds peak memory; lower it on
+                    very large repos. Output is identical.
+  --include-tests   Also write <file>.test.evidence.yaml for each
+                    _test.go file, recording the functions each test
+                    exercises; system-model then reports tested
+                    symbols. Directory mode only. Also enabled by
+                    include_tests in .iguana.yaml.
+  --per-package     Write one <dir>/package.evidence.yaml per directory,
+                    merging its Go files, instead of a bundle per file.
+                    Faster to aggregate on large trees. Directory mode
+                    only; not with --include, --diff-base, or
+                    --bundle-version 2-classic.
+  --store <db>      Write bundles to the SQLite database <db>, keyed by
+                    path and source hash, instead of companion files,
+                    and drop the bundles of deleted files from it. With
+                    --clean the database is recreated. Pass <db> to
+                    system-model in place of <dir>. Directory mode
+                    only; not with --diff-base, --per-package, or
+                    --include-tests, and the on_bundle hook does not
+                    run.
+  --log-format <text|json>
+                    Directory mode progress output on stderr. "text"
+                    (default) draws a progress bar when stderr is a
+                    terminal; "json" writes one JSON object per line
+                    for each step (phase, directory, files done and
+                    total, elapsed time), each error, and the summary.
 `,
 		run: runAnalyze,
 	},
+	{
+		name:  "watch",
+		short: "Regenerate bundles, and optionally the model and vault, on change",
+		usage: "iguana watch [--include <glob>]... [--model] [--no-llm] [--vault <dir>] [--interval <duration>] <dir>",
+		long: `Watch <dir> and keep its evidence bundles current until interrupted.
+
+Analyzes <dir> once, then polls the files analyze would read and, once a
+change has been stable for one interval, regenerates the bundles whose
+source changed and removes the bundles of deleted sources.
+
+Flags:
+  --include <glob>       Only watch and analyze matching files
+                         (repeatable), as for analyze.
+  --model                After bundles change, regenerate
+                         <dir>/system_model.yaml. LLM inference results
+                         are cached by bundle set, as for system-model.
+  --no-llm               With --model, skip LLM inference (deterministic
+                         sections only).
+  --vault <dir>          After the model is regenerated, sync the
+                         Obsidian vault in <dir>. Implies --model.
+  --interval <duration>  Polling and debounce interval (default 1s).
+`,
+		run: runWatch,
+	},
 	{
 		name:  "system-model",
 		short: "Aggregate evidence bundles into a system model",
-		usage: "iguana system-model <dir> [output.yaml]",
+		usage: "iguana system-model [--force] [--no-llm] [--refresh] [--provenance] [--parallel-llm] [--merge-summaries] [--summary-fields <list>] [--group-concurrency] [--call-graph-limit <n>] [--sort-effects by-kind|by-file] [--format yaml|table] <dir> [output.yaml]",
 		long: `Aggregate evidence bundles in <dir> into a system model YAML.
 
 Reads all *.evidence.yaml files under <dir>, infers state domains,
 effects, and trust zones, and writes the result to output.yaml
-(default: <dir>/system_model.yaml).
+(default: <dir>/system_model.yaml). <dir> may instead be a file written
+by iguana aggregate or an evidence store written by analyze --store; the
+default output then goes beside it.
+
+The LLM backends tried for inference, with their fallback order and
+timeouts, come from the "llm" key of .iguana/config.yaml (repo, then
+~/.iguana); without one the client compiled into baml_client is used.
+
+Flags:
+  --force, -f            Regenerate even when the model is up to date.
+  --no-llm               Skip LLM inference and write only the
+                         deterministic sections (inventory, boundaries,
+                         effects, concurrency); state domains and trust
+                         zones stay empty. Needs no API keys, so CI can
+                         generate reproducible models. Such a model is
+                         never considered up to date.
+  --refresh              Call the LLM even when ~/.iguana/cache holds an
+                         inference for the same bundle set, replacing
+                         the cached result. Without it, a cached
+                         inference is reused when the bundle set hash
+                         and package summaries match.
+  --provenance           Write the package summaries sent to the LLM
+                         and its parsed response to
+                         <dir>/.iguana/provenance/<bundle hash>/ and
+                         record the path in inputs.provenance, so the
+                         inferred sections can be audited.
+  --parallel-llm         Run LLM inference concurrently with the
+                         deterministic sections to cut wall-clock time.
+  --merge-summaries      Send packages of one or two files that share a
+                         parent directory to the LLM as one combined
+                         summary, freeing slots in the 60-package
+                         budget. Domains owning the combined summary
+                         are attributed to each member package.
+  --summary-fields <list>
+                         Comma-separated package summary fields sent to
+                         the LLM: types, type_descriptions, functions,
+                         imports (default: all). Prefix names with "-"
+                         to drop them instead, e.g. -type_descriptions
+                         for smaller prompts.
+  --group-concurrency    Combine the concurrency domains of files whose
+                         effects belong to the same state domain into
+                         one domain named after it (default: one per
+                         file).
+  --call-graph-limit <n> Keep at most n call-graph edges (default 10000;
+                         negative for no limit).
+  --sort-effects by-kind|by-file
+                         Order effects by kind then file (default) or
+                         by file then kind. Exports follow the model's
+                         order. Use --force to re-sort an up-to-date
+                         model.
+  --format yaml|table    "table" prints packages, state domains, and
+                         boundaries as aligned console tables instead of
+                         writing YAML. An up-to-date output.yaml is read
+                         rather than regenerated. Defaults to the
+                         "format" key of .iguana/config.yaml (repo, then
+                         ~/.iguana), else yaml.
 `,
 		run: runSystemModel,
 	},
+	{
+		name:  "review",
+		short: "Review state domains held below the confidence threshold",
+		usage: "iguana review <dir> [model.yaml]",
+		long: `Interactively review the state domains in the pending_review section
+of a system model (default: <dir>/system_model.yaml).
+
+system-model holds inferred domains whose confidence is below the
+min_confidence key of .iguana/settings.yaml in pending_review instead of
+using them. For each one, answer accept, reject, edit (description,
+aggregate, owners), skip, or quit. Decisions are saved to
+<dir>/.iguana/review.yaml and applied by every later system-model run,
+so a domain is only asked about once; the model file is updated in
+place. Regenerate with --force to link effects to accepted domains.
+`,
+		run: runReview,
+	},
+	{
+		name:  "tui",
+		short: "Browse bundles, signals, and open questions interactively",
+		usage: "iguana tui <dir> [model.yaml]",
+		long: `Open a full-screen dashboard for <dir> and its system model
+(default: <dir>/system_model.yaml), with four pages:
+
+  1 Overview   The Go modules, the bundle and package counts, and the
+               model's state domains, open questions, and pending reviews.
+  2 Bundles    Every bundle; / filters by path and enter opens a
+               scrollable YAML preview (esc closes it).
+  3 Signals    A table of each file's signals.
+  4 Questions  The model's open questions.
+
+Keys: tab, shift+tab, or 1-4 switch pages; arrows and pgup/pgdown move;
+a runs iguana analyze on <dir> and reloads; q or ctrl+c quits.
+`,
+		run: runTUI,
+	},
+	{
+		name:  "model",
+		short: "Compare or export system models",
+		usage: "iguana model diff [--json] <old.yaml> <new.yaml> | iguana model export --format dot <model.yaml> [output] | iguana model unreferenced [--json] <model.yaml>",
+		long: `Work with system_model.yaml files.
+
+diff compares two models and prints the architectural drift between
+them as YAML: state domains added, removed, or changed; effects added,
+removed, or re-linked to another domain; trust zones added or removed
+and packages joining or leaving a zone; and open questions raised or
+resolved. Prints nothing when the models agree.
+
+export renders a model as a graph file, written to [output] or stdout.
+The dot format holds the package import graph, effect edges, and one
+cluster per state domain, for graphviz (dot, sfdp) on graphs too large
+for Mermaid.
+
+unreferenced lists the exported functions and types that no call or
+type in the analyzed bundles refers to (unreferenced_symbols), one
+"<symbol>\t<kind>" per line: candidates for dead code, or API used
+only by tests or other modules.
+
+Flags:
+  --json          diff, unreferenced: print JSON instead of YAML or
+                  text.
+  --format <fmt>  export: output format; only "dot".
+`,
+		run: runModel,
+	},
 	{
 		name:  "obsidian-vault",
 		short: "Convert system model to an Obsidian vault",
-		usage: "iguana obsidian-vault <model.yaml> [output-dir]",
+		usage: "iguana obsidian-vault [--watch-export] [--diff] <model.yaml> [output-dir]",
 		long: `Convert a system model YAML into an Obsidian-compatible vault.
 
 Reads <model.yaml> and writes Markdown files into [output-dir]
 (default: a directory named after the model file, without the extension).
+
+Flags:
+  --watch-export  Keep running and regenerate the vault whenever
+                  <model.yaml> changes, printing the pages rewritten.
+                  Stop with Ctrl-C.
+  --diff          Print a unified diff of the pages that would be
+                  created, rewritten, or removed, and write nothing.
 `,
 		run: runObsidianVault,
 	},
+	{
+		name:  "risk-report",
+		short: "Render the risk report as a standalone HTML dashboard",
+		usage: "iguana risk-report [--report html|markdown|sarif] <model.yaml> [output]",
+		long: `Render the risk report for a system model.
+
+Reads <model.yaml> and writes the report to [output], or to stdout when
+omitted. The HTML dashboard holds in-degree, write domains, network
+resilience, confidence calibration, ownership conflicts, import cycles,
+and open questions in one self-contained page with sortable tables.
+
+Flags:
+  --report html|markdown|sarif
+                          Output format (default html). markdown is the
+                          risk.md page of the Obsidian vault. sarif is a
+                          SARIF 2.1.0 log of import cycles, high
+                          in-degree packages, and state domains written
+                          from concurrent code, for GitHub Code Scanning.
+`,
+		run: runRiskReport,
+	},
+	{
+		name:  "check",
+		short: "Verify bundles are fresh and canonical (pre-commit friendly)",
+		usage: "iguana check [--fix] [--model <model.yaml>] <dir>",
+		long: `Run every read-only consistency check in one pass.
+
+Checks that each analyzed .go file under <dir> has an up-to-date
+evidence bundle, and that every bundle satisfies the sort-order
+invariants. With --model, also checks that the system model was
+generated from the current bundle set. Exits non-zero if any check
+fails, which makes it suitable for a git pre-commit hook.
+
+Flags:
+  --fix                 Regenerate stale or non-canonical bundles, then
+                        re-run the checks. Model freshness is never fixed
+                        automatically because it requires LLM inference.
+  --model <model.yaml>  Also check the system model's bundle_set_sha256.
+`,
+		run: runCheck,
+	},
+	{
+		name:  "validate",
+		short: "Report stale, missing, and orphaned evidence bundles",
+		usage: "iguana validate [dir]",
+		long: `Re-hash every analyzed source file under [dir] (default: current
+directory) and report each bundle that is out of date:
+
+  <file>: missing evidence bundle     source has no bundle
+  <file>: evidence bundle is stale    source changed since analysis
+  <file>: orphaned evidence bundle    bundle's source no longer exists
+
+Exits non-zero if any bundle is out of date, for use as a pre-commit or
+CI gate. Nothing is written; run iguana analyze (or clean) to fix.
+`,
+		run: runValidate,
+	},
+	{
+		name:  "config",
+		short: "Validate the .iguana.yaml path configuration",
+		usage: "iguana config validate [dir]",
+		long: `Check the .iguana.yaml file in [dir] (default: current directory),
+which every command that walks the tree reads:
+
+  deny:                 globs of root-relative paths never read
+    - "gen/**"
+  allow:                exceptions to deny, even beneath a denied
+    - "gen/api/**"      directory
+  include_tests: true   also write test bundles, as --include-tests
+  include_generated: false
+                        skip Go files marked "// Code generated ...
+                        DO NOT EDIT." (default true)
+
+Globs use the deny-rule syntax of .iguana/settings.yaml: "**" matches
+any number of path segments, "*" stays within one. Unknown keys, values
+of the wrong type, and malformed globs are reported and the command
+exits non-zero. A missing file is valid.
+`,
+		run: runConfig,
+	},
+	{
+		name:  "diff",
+		short: "Compare evidence bundles semantically",
+		usage: "iguana diff [--json] <old> <new>",
+		long: `Compare two evidence bundles, or every bundle of two analyzed trees
+(paired by source path), and print what changed as YAML: files added or
+removed, and per file the added, removed, and changed imports,
+functions, types, variables, constants, and calls, plus signals whose
+value differs. Hashes are ignored. Prints nothing when the bundles
+describe the same code.
+
+Typical use: analyze two checkouts (e.g. git worktrees of two commits)
+and diff their roots.
+
+Flags:
+  --json  Print JSON instead of YAML.
+`,
+		run: runDiff,
+	},
+	{
+		name:  "bundle-info",
+		short: "Summarize and validate a single evidence bundle",
+		usage: "iguana bundle-info [--json] <file.evidence.yaml>",
+		long: `Print a summary of one evidence bundle.
+
+Shows the bundle version, source path and hash, package, symbol counts,
+call count, and the signals that are set. The source file next to the
+bundle is re-hashed to report whether the bundle is fresh or stale.
+
+Flags:
+  --json  Print the summary as JSON.
+`,
+		run: runBundleInfo,
+	},
+	{
+		name:  "hover",
+		short: "Describe the symbol at a source position as JSON (for editors)",
+		usage: "iguana hover [--root <dir>] <file.go> <line> <col>",
+		long: `Print JSON describing the function or type declared at <line>:<col>
+(1-based) of <file.go>: its signature, the signals its own calls raise,
+and the functions in the corpus that call it.
+
+The file's bundle is used when fresh and regenerated in memory otherwise;
+nothing is written.
+
+Flags:
+  --root <dir>  Corpus whose bundles are searched for callers
+                (default: the current directory).
+`,
+		run: runHover,
+	},
+	{
+		name:  "calls",
+		short: "Print call paths between symbols",
+		usage: "iguana calls --from <symbol> [--to <symbol>] [--max <n>] <dir>",
+		long: `Stitch the calls of every bundle under <dir> (or an aggregate file)
+into one call graph and print the shortest call paths from one symbol to
+another, one path per line:
+
+  main.main -> cli.Run -> store.Store.Save -> os.WriteFile
+
+Symbols are <pkg>.<func> or <pkg>.<type>.<method> for the module's own
+code; calls out of the module keep their qualified target, such as
+os.WriteFile. Closures count as their enclosing function.
+
+Flags:
+  --from <symbol>  Symbol the paths start at (required).
+  --to <symbol>    Symbol or call target the paths end at. Without it,
+                   every symbol reachable from --from is listed.
+  --max <n>        Most paths to print (default 20).
+`,
+		run: runCalls,
+	},
 	{
 		name:  "clean",
 		short: "Remove generated *.evidence.yaml files",
@@ -75,8 +461,62 @@ matching *.evidence.yaml.
 `,
 		run: runClean,
 	},
+	{
+		name:  "compact",
+		short: "Rewrite bundles in the compact profile",
+		usage: "iguana compact [dir]",
+		long: `Rewrite every evidence bundle under [dir] (default: current
+directory) in the compact profile: false signals and empty lists are
+omitted. Decoded content and source hashes are unchanged.
+
+Bundles whose source has changed since generation are reported and left
+alone; run iguana analyze first.
+`,
+		run: runCompact,
+	},
+	{
+		name:  "aggregate",
+		short: "Collect all evidence bundles into one YAML file",
+		usage: "iguana aggregate [-o <file>] <dir>",
+		long: `Collect every evidence bundle under <dir> into a single YAML document:
+{version, bundle_set_sha256, bundles: [...]}, bundles sorted by path.
+The same directories and files are included as for system-model.
+
+The aggregate can be passed to system-model in place of <dir>.
+
+Flags:
+  -o <file>  Output path (default: <dir>/corpus.yaml).
+`,
+		run: runAggregate,
+	},
+	{
+		name:  "serve",
+		short: "Serve bundles and the system model over HTTP/JSON",
+		usage: "iguana serve [--addr <host:port>] [--model <file>] <dir>",
+		long: `Serve the evidence bundles under <dir> and its system model as a
+JSON API until interrupted:
+
+  GET  /api/bundles         bundle-set hash and a summary of each bundle
+  GET  /api/bundles/<path>  one bundle by source path, e.g. store/db.go
+  GET  /api/model           the system model
+  POST /api/analyze         regenerate changed bundles (iguana analyze)
+
+Requests must use a loopback Host (localhost, 127.0.0.1) and, when
+present, Origin. POST /api/analyze also needs the X-Iguana-Token header
+set to the session token printed at startup.
+
+Flags:
+  --addr <host:port>  Listen address (default localhost:7070).
+  --model <file>      System model to serve (default:
+                      <dir>/system_model.yaml).
+`,
+		run: runServe,
+	},
 }
 
+// userConfig holds the merged global and repo preferences loaded by dispatch.
+var userConfig settings.Config
+
 // printUsage writes the overall help listing to w.
 func printUsage(w io.Writer) {
 	fmt.Fprintf(w, "iguana — Go codebase static analysis tool\n\n")
@@ -85,9 +525,28 @@ func printUsage(w io.Writer) {
 	for _, cmd := range commands {
 		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.short)
 	}
+	fmt.Fprintf(w, "\nGlobal flags (before the command):\n")
+	fmt.Fprintf(w, "  --profile <file>  Write a pprof CPU profile of the run to <file>.\n")
 	fmt.Fprintf(w, "\nRun 'iguana help <command>' for details on a specific command.\n")
 }
 
+// startCPUProfile begins CPU profiling into path. The returned stop function
+// ends profiling and closes the file.
+func startCPUProfile(path string) (stop func() error, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("start cpu profile: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		return f.Close()
+	}, nil
+}
+
 // printCommandHelp writes the long help for the named command to w.
 // If the name is unknown, it writes an error message.
 func printCommandHelp(w io.Writer, name string) {
@@ -102,7 +561,30 @@ func printCommandHelp(w io.Writer, name string) {
 
 // dispatch is the core dispatch function, separated from main() to allow testing.
 // args is os.Args[1:].
-func dispatch(args []string) error {
+func dispatch(args []string) (err error) {
+	// Global "--profile <file>" before the command: CPU-profile the whole run.
+	if len(args) > 0 && (args[0] == "--profile" || strings.HasPrefix(args[0], "--profile=")) {
+		n := 1
+		if args[0] == "--profile" {
+			n = 2
+		}
+		values, _, err := extractFlagValues(args[:min(n, len(args))], "--profile")
+		if err != nil {
+			return err
+		}
+		stop, err := startCPUProfile(values[0])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// Flush the profile even when the command failed.
+			if stopErr := stop(); err == nil {
+				err = stopErr
+			}
+		}()
+		args = args[n:]
+	}
+
 	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
 		printUsage(os.Stdout)
 		return nil
@@ -118,6 +600,17 @@ func dispatch(args []string) error {
 		return nil
 	}
 
+	// User preferences: ~/.iguana/config.yaml beneath ./.iguana/config.yaml.
+	// Flags parsed by each command take precedence over these.
+	cfg, err := settings.LoadConfig(".")
+	if err != nil {
+		return err
+	}
+	for _, w := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	userConfig = cfg
+
 	// Known subcommand?
 	for _, cmd := range commands {
 		if cmd.name == args[0] {
@@ -128,7 +621,7 @@ func dispatch(args []string) error {
 	// Unknown first arg: if it names an existing file or directory, fall
 	// through to the legacy file/dir handler (backward compat, invariant 35).
 	if _, err := os.Stat(args[0]); err == nil {
-		return legacyFilePath(args[0], false)
+		return legacyFilePath(args[0], evidence.WalkOptions{}, logFormatText)
 	}
 
 	// Unknown and not a file/dir: helpful error (invariant 34).
@@ -138,118 +631,1059 @@ func dispatch(args []string) error {
 // runAnalyze implements the "analyze" subcommand.
 func runAnalyze(args []string) error {
 	force, rest := parseForceFlag(args)
-	if len(rest) < 1 {
-		return fmt.Errorf("usage: iguana analyze [--force] <dir-or-file>")
+	include, rest, err := extractFlagValues(rest, "--include")
+	if err != nil {
+		return err
 	}
-	return legacyFilePath(rest[0], force)
-}
-
-// legacyFilePath contains the original file/dir dispatch logic.
-func legacyFilePath(filePath string, force bool) error {
-	// Directory mode: walk all .go files under the root.
-	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
-		written, skipped, errs := evidence.WalkAndGenerate(filePath, force)
-		for _, e := range errs {
-			fmt.Fprintf(os.Stderr, "error: %v\n", e)
+	versions, rest, err := extractFlagValues(rest, "--bundle-version")
+	if err != nil {
+		return err
+	}
+	var schema string
+	if len(versions) > 0 {
+		schema = versions[len(versions)-1]
+		if err := evidence.ValidateSchema(schema); err != nil {
+			return err
 		}
-		fmt.Printf("wrote %d, skipped %d (up to date), %d errors\n", written, skipped, len(errs))
-		if len(errs) > 0 {
-			return fmt.Errorf("%d errors during analysis", len(errs))
+		force = true // unchanged sources must still be rewritten in the new schema
+	}
+	bases, rest, err := extractFlagValues(rest, "--diff-base")
+	if err != nil {
+		return err
+	}
+	pins, rest, err := extractFlagValues(rest, "--pin-commit")
+	if err != nil {
+		return err
+	}
+	budgets, rest, err := extractFlagValues(rest, "--concurrency-budget")
+	if err != nil {
+		return err
+	}
+	budget := 1
+	if len(budgets) > 0 {
+		budget, err = strconv.Atoi(budgets[len(budgets)-1])
+		if err != nil || budget < 1 {
+			return fmt.Errorf("invalid --concurrency-budget %q (want a positive integer)", budgets[len(budgets)-1])
 		}
-		return nil
 	}
-
-	if strings.HasSuffix(filePath, ".go") {
-		bundle, err := evidence.CreateEvidenceBundle(filePath)
-		if err != nil {
-			return err
+	formats, rest, err := extractFlagValues(rest, "--log-format")
+	if err != nil {
+		return err
+	}
+	logFormat := logFormatText
+	if len(formats) > 0 {
+		logFormat = formats[len(formats)-1]
+	}
+	stores, rest, err := extractFlagValues(rest, "--store")
+	if err != nil {
+		return err
+	}
+	var clean, stream, includeTests, perPackage bool
+	rest = removeBoolFlag(rest, "--include-tests", &includeTests)
+	rest = removeBoolFlag(rest, "--per-package", &perPackage)
+	var paths []string
+	for _, a := range rest {
+		if a == "--clean" {
+			clean = true
+		} else if a == "--stream" {
+			stream = true
+		} else {
+			paths = append(paths, a)
 		}
-		skipped, err := evidence.WriteEvidenceBundle(bundle, force)
-		if err != nil {
+	}
+	if len(paths) < 1 {
+		return fmt.Errorf("usage: iguana analyze [--force] [--clean] [--stream] [--include <glob>]... [--bundle-version <v>] [--diff-base <ref>] [--pin-commit <rev>] [--concurrency-budget <n>] [--include-tests] [--per-package] [--store <db>] [--log-format <text|json>] <dir-or-file>")
+	}
+	if clean && len(include) > 0 {
+		return fmt.Errorf("--clean removes every bundle and cannot be combined with --include")
+	}
+	dir := paths[0]
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if err := warnSettings(dir); err != nil {
+		return err
+	}
+	pin, err := settings.PinnedCommit(dir)
+	if err != nil {
+		return err
+	}
+	if len(pins) > 0 {
+		pin = pins[len(pins)-1]
+	}
+	if pin != "" {
+		if err := evidence.VerifyPinnedCommit(dir, pin); err != nil {
 			return err
 		}
-		if skipped {
-			fmt.Printf("up to date %s.evidence.yaml\n", filePath)
-		} else {
-			fmt.Printf("wrote %s.evidence.yaml\n", filePath)
+	}
+	opts := evidence.WalkOptions{Force: force, Include: include, Clean: clean, Schema: schema, Stream: stream, ConcurrencyBudget: budget, PluginDir: evidence.DefaultPluginDir(), IncludeTests: includeTests, PerPackage: perPackage}
+	if len(stores) > 0 {
+		if len(bases) > 0 {
+			return fmt.Errorf("--store cannot be combined with --diff-base")
 		}
-		return nil
+		return analyzeToStore(paths[0], stores[len(stores)-1], opts, logFormat)
 	}
-
-	return fmt.Errorf("not a .go file or directory: %s", filePath)
+	if len(bases) > 0 {
+		return runDiffBase(paths[0], bases[len(bases)-1], opts)
+	}
+	return legacyFilePath(paths[0], opts, logFormat)
 }
 
-// runSystemModel implements the "system-model" subcommand.
-func runSystemModel(args []string) error {
-	force, rest := parseForceFlag(args)
-	if len(rest) < 1 {
-		return fmt.Errorf("usage: iguana system-model [--force] <dir> [output.yaml]")
-	}
-	root := rest[0]
-	outputPath := filepath.Join(root, "system_model.yaml")
-	if len(rest) >= 2 {
-		outputPath = rest[1]
+// analyzeToStore implements "analyze --store": analyze root into the
+// evidence store at dbPath, then drop the bundles of deleted files. With
+// opts.Clean the database is removed first; companion bundles under root
+// are left alone.
+func analyzeToStore(root, dbPath string, opts evidence.WalkOptions, logFormat string) error {
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("--store requires a directory: %s", root)
 	}
-	if !force {
-		upToDate, err := model.SystemModelUpToDate(root, outputPath)
-		if err != nil {
-			return fmt.Errorf("check up-to-date: %w", err)
-		}
-		if upToDate {
-			fmt.Printf("system model up to date: %s\n", outputPath)
-			return nil
+	if opts.Clean {
+		if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
+			return err
 		}
+		opts.Clean = false
 	}
-	m, err := model.GenerateSystemModel(context.Background(), root)
+	st, err := store.Open(dbPath)
 	if err != nil {
 		return err
 	}
-	if err := model.WriteSystemModel(m, outputPath); err != nil {
+	defer st.Close()
+	log, err := newAnalyzeLog(logFormat, os.Stderr)
+	if err != nil {
 		return err
 	}
-	fmt.Printf("wrote %s (%d state domains, %d effects)\n",
-		outputPath, len(m.StateDomains), len(m.Effects))
+	opts.Store = st
+	opts.Progress = log.progress
+	written, skipped, errs := evidence.WalkAndGenerate(root, opts)
+	if pruned, err := st.Prune(root); err != nil {
+		errs = append(errs, err)
+	} else if len(pruned) > 0 {
+		fmt.Printf("dropped %d bundle(s) of deleted files from %s\n", len(pruned), dbPath)
+	}
+	return log.finish(written, skipped, errs)
+}
+
+// warnSettings prints the warnings of root's settings, such as an ignored
+// repo on_bundle hook, to stderr.
+func warnSettings(root string) error {
+	s, err := settings.LoadSettings(root)
+	if err != nil || s == nil {
+		return err
+	}
+	for _, w := range s.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
 	return nil
 }
 
-// parseForceFlag extracts --force / -f from args, returning the flag value
-// and the remaining args with the flag removed.
-func parseForceFlag(args []string) (force bool, rest []string) {
-	for _, a := range args {
-		if a == "--force" || a == "-f" {
-			force = true
-		} else {
-			rest = append(rest, a)
-		}
+// runDiffBase implements "analyze --diff-base": regenerate only files
+// changed since base and write the change manifest.
+func runDiffBase(root, base string, opts evidence.WalkOptions) error {
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("--diff-base requires a directory: %s", root)
 	}
-	return
+	manifest, written, errs := evidence.GenerateChanged(root, base, opts)
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "error: %v\n", e)
+	}
+	if manifest != nil {
+		fmt.Printf("%d changed file(s) since %s, wrote %d, manifest %s\n",
+			len(manifest.Files), base, written, filepath.Join(root, evidence.ChangeManifestName))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors during analysis", len(errs))
+	}
+	return nil
 }
 
-// runObsidianVault implements the "obsidian-vault" subcommand.
-func runObsidianVault(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("usage: iguana obsidian-vault <model.yaml> [output-dir]")
+// legacyFilePath contains the original file/dir dispatch logic.
+// opts.Include, opts.Clean, and logFormat only apply in directory mode; an
+// explicit file is always analyzed.
+func legacyFilePath(filePath string, opts evidence.WalkOptions, logFormat string) error {
+	log, err := newAnalyzeLog(logFormat, os.Stderr)
+	if err != nil {
+		return err
 	}
-	modelPath := args[0]
-	outputDir := "obsidian-vault"
-	if len(args) >= 2 {
-		outputDir = args[1]
+	// Directory mode: walk all .go and .proto files under the root.
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		opts.Progress = log.progress
+		written, skipped, errs := evidence.WalkAndGenerate(filePath, opts)
+		return log.finish(written, skipped, errs)
+	}
+
+	var bundle *evidence.EvidenceBundle
+	switch {
+	case strings.HasSuffix(filePath, ".go"):
+		bundle, err = evidence.CreateEvidenceBundle(filePath)
+	case strings.HasSuffix(filePath, ".proto"):
+		bundle, err = evidence.CreateProtoBundle(filePath, filepath.ToSlash(filePath))
+	default:
+		return fmt.Errorf("not a .go or .proto file or directory: %s", filePath)
 	}
-	m, err := model.ReadSystemModel(modelPath)
 	if err != nil {
 		return err
 	}
-	bundle, err := export.GenerateKnowledgeBundle(m)
+	// The file's directory is the analyzed root, as for pin_commit, so its
+	// settings define the custom signals, not the working directory's.
+	s, err := settings.LoadSettings(filepath.Dir(filePath))
+	if err != nil {
+		return fmt.Errorf("load settings: %w", err)
+	}
+	evidence.SetCustomSignals(bundle, s)
+	skipped, err := evidence.WriteEvidenceBundleAs(bundle, opts.Force, opts.Schema)
 	if err != nil {
 		return err
 	}
-	if err := export.WriteKnowledgeBundle(bundle, outputDir); err != nil {
+	if skipped {
+		fmt.Printf("up to date %s.evidence.yaml\n", filePath)
+	} else {
+		fmt.Printf("wrote %s.evidence.yaml\n", filePath)
+	}
+	return nil
+}
+
+// runWatch implements the "watch" subcommand.
+func runWatch(args []string) error {
+	var regenModel, noLLM bool
+	args = removeBoolFlag(args, "--model", &regenModel)
+	args = removeBoolFlag(args, "--no-llm", &noLLM)
+	include, rest, err := extractFlagValues(args, "--include")
+	if err != nil {
 		return err
 	}
-	fmt.Printf("wrote knowledge bundle to %s\n", outputDir)
+	vaults, rest, err := extractFlagValues(rest, "--vault")
+	if err != nil {
+		return err
+	}
+	intervals, rest, err := extractFlagValues(rest, "--interval")
+	if err != nil {
+		return err
+	}
+	interval := time.Second
+	if len(intervals) > 0 {
+		interval, err = time.ParseDuration(intervals[len(intervals)-1])
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid --interval %q (want a positive duration such as 500ms)", intervals[len(intervals)-1])
+		}
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("usage: iguana watch [--include <glob>]... [--model] [--no-llm] [--vault <dir>] [--interval <duration>] <dir>")
+	}
+	root := rest[0]
+	if err := warnSettings(root); err != nil {
+		return err
+	}
+	var vault string
+	if len(vaults) > 0 {
+		vault = vaults[len(vaults)-1]
+		regenModel = true
+	}
+	opts := model.GenerateOptions{NoLLM: noLLM, CacheDir: model.DefaultCacheDir()}
+	opts.LLM, err = userConfig.LLM.Chain("InferSystemModel")
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Printf("watching %s (Ctrl-C to stop)\n", root)
+	return evidence.WatchTree(ctx, root, evidence.WalkOptions{Include: include, PluginDir: evidence.DefaultPluginDir()}, interval, func(written, skipped, removed int, errs []error) {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "error: %v\n", e)
+		}
+		fmt.Printf("%s: %d bundle(s) written, %d removed, %d up to date\n", time.Now().Format(time.TimeOnly), written, removed, skipped)
+		if !regenModel || written+removed == 0 {
+			return
+		}
+		if err := refreshModel(ctx, root, vault, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	})
+}
+
+// refreshModel regenerates <root>/system_model.yaml and, when vault is set,
+// syncs the Obsidian vault from it.
+func refreshModel(ctx context.Context, root, vault string, opts model.GenerateOptions) error {
+	m, err := model.GenerateSystemModel(ctx, root, opts)
+	if err != nil {
+		return err
+	}
+	modelPath := filepath.Join(root, "system_model.yaml")
+	if err := model.WriteSystemModel(m, modelPath); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (%d state domains, %d effects)\n", modelPath, len(m.StateDomains), len(m.Effects))
+	if vault == "" {
+		return nil
+	}
+	changed, err := obsidian.SyncObsidianVault(m, vault)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("synced %s: %d page(s) changed\n", vault, len(changed))
 	return nil
 }
 
+// runSystemModel implements the "system-model" subcommand.
+func runSystemModel(args []string) error {
+	force, rest := parseForceFlag(args)
+	var opts model.GenerateOptions
+	rest = removeBoolFlag(rest, "--no-llm", &opts.NoLLM)
+	rest = removeBoolFlag(rest, "--refresh", &opts.RefreshCache)
+	rest = removeBoolFlag(rest, "--provenance", &opts.Provenance)
+	opts.CacheDir = model.DefaultCacheDir()
+	rest = removeBoolFlag(rest, "--parallel-llm", &opts.ParallelLLM)
+	rest = removeBoolFlag(rest, "--merge-summaries", &opts.MergeSummaries)
+	rest = removeBoolFlag(rest, "--group-concurrency", &opts.GroupConcurrency)
+	limits, rest, err := extractFlagValues(rest, "--call-graph-limit")
+	if err != nil {
+		return err
+	}
+	if len(limits) > 0 {
+		n, err := strconv.Atoi(limits[len(limits)-1])
+		if err != nil {
+			return fmt.Errorf("invalid --call-graph-limit: %w", err)
+		}
+		opts.CallGraphLimit = n
+	}
+	fieldSpecs, rest, err := extractFlagValues(rest, "--summary-fields")
+	if err != nil {
+		return err
+	}
+	if len(fieldSpecs) > 0 {
+		opts.SummaryFields, err = model.ParseSummaryFields(fieldSpecs[len(fieldSpecs)-1])
+		if err != nil {
+			return fmt.Errorf("invalid --summary-fields: %w", err)
+		}
+	}
+	sorts, rest, err := extractFlagValues(rest, "--sort-effects")
+	if err != nil {
+		return err
+	}
+	if len(sorts) > 0 {
+		opts.EffectSort = sorts[len(sorts)-1]
+		if opts.EffectSort != model.EffectSortByKind && opts.EffectSort != model.EffectSortByFile {
+			return fmt.Errorf("unknown --sort-effects %q (want by-kind or by-file)", opts.EffectSort)
+		}
+	}
+	opts.LLM, err = userConfig.LLM.Chain("InferSystemModel")
+	if err != nil {
+		return err
+	}
+	formats, rest, err := extractFlagValues(rest, "--format")
+	if err != nil {
+		return err
+	}
+	format := resolveFormat(formats)
+	if format != "yaml" && format != "table" {
+		return fmt.Errorf("unknown --format %q (want yaml or table)", format)
+	}
+	if len(rest) < 1 {
+		return fmt.Errorf("usage: iguana system-model [--force] [--no-llm] [--refresh] [--provenance] [--parallel-llm] [--merge-summaries] [--summary-fields <list>] [--group-concurrency] [--call-graph-limit <n>] [--sort-effects by-kind|by-file] [--format yaml|table] <dir> [output.yaml]")
+	}
+	root := rest[0]
+	outputPath := filepath.Join(root, "system_model.yaml")
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		outputPath = filepath.Join(filepath.Dir(root), "system_model.yaml")
+	}
+	if len(rest) >= 2 {
+		outputPath = rest[1]
+	}
+	if !force {
+		upToDate, err := model.SystemModelUpToDate(root, outputPath)
+		if err != nil {
+			return fmt.Errorf("check up-to-date: %w", err)
+		}
+		var existing *model.SystemModel
+		if upToDate {
+			if existing, err = model.ReadSystemModel(outputPath); err != nil {
+				return err
+			}
+		}
+		// A --no-llm model is current for its bundles, but a run with
+		// inference regenerates it to fill in the inferred sections.
+		if upToDate && (opts.NoLLM || !existing.Inputs.InferenceSkipped) {
+			if format == "table" {
+				return writeModelTable(os.Stdout, existing)
+			}
+			fmt.Printf("system model up to date: %s\n", outputPath)
+			return nil
+		}
+	}
+	m, err := model.GenerateSystemModel(context.Background(), root, opts)
+	if err != nil {
+		return err
+	}
+	if format == "table" {
+		return writeModelTable(os.Stdout, m)
+	}
+	if err := model.WriteSystemModel(m, outputPath); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s (%d state domains, %d effects)\n",
+		outputPath, len(m.StateDomains), len(m.Effects))
+	return nil
+}
+
+// resolveFormat picks the output format: the last --format flag value, then
+// the configured default, then "yaml".
+func resolveFormat(flagValues []string) string {
+	if len(flagValues) > 0 {
+		return flagValues[len(flagValues)-1]
+	}
+	if userConfig.Format != "" {
+		return userConfig.Format
+	}
+	return "yaml"
+}
+
+// writeModelTable renders the packages, state domains, and boundaries of m
+// to w as tabwriter-aligned tables.
+func writeModelTable(w io.Writer, m *model.SystemModel) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "PACKAGE\tFILES\tEXPORTED\tIMPORTS")
+	for _, p := range m.Inventory.Packages {
+		imports := strings.Join(p.Imports, ", ")
+		if imports == "" {
+			imports = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", p.Name, len(p.Files), p.ExportedSymbolCount, imports)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "DOMAIN\tAGGREGATE\tCONFIDENCE\tOWNERS")
+	for _, d := range m.StateDomains {
+		owners := strings.Join(d.Owners, ", ")
+		if owners == "" {
+			owners = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\n", d.ID, d.Aggregate, d.Confidence, owners)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "BOUNDARY\tKIND\tFILES")
+	for _, p := range m.Boundaries.Process {
+		fmt.Fprintf(tw, "process\t%s\t-\n", p.Kind)
+	}
+	for _, p := range m.Boundaries.Persistence {
+		fmt.Fprintf(tw, "persistence\t%s\t%d\n", p.Kind, len(p.Writers))
+	}
+	if n := m.Boundaries.Network; n != nil {
+		fmt.Fprintf(tw, "network\toutbound\t%d\n", len(n.Outbound))
+		if len(n.Resilient) > 0 {
+			fmt.Fprintf(tw, "network\tresilient\t%d\n", len(n.Resilient))
+		}
+		if len(n.Routes) > 0 {
+			fmt.Fprintf(tw, "network\troutes\t%d\n", len(n.Routes))
+		}
+		if len(n.Endpoints) > 0 {
+			fmt.Fprintf(tw, "network\tendpoints\t%d\n", len(n.Endpoints))
+		}
+	}
+	return tw.Flush()
+}
+
+// parseForceFlag extracts --force / -f from args, returning the flag value
+// and the remaining args with the flag removed.
+func parseForceFlag(args []string) (force bool, rest []string) {
+	for _, a := range args {
+		if a == "--force" || a == "-f" {
+			force = true
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	return
+}
+
+// removeBoolFlag drops every occurrence of a boolean flag from args, setting
+// *set to true if it was present, and returns the remaining args.
+func removeBoolFlag(args []string, name string, set *bool) []string {
+	var rest []string
+	for _, a := range args {
+		if a == name {
+			*set = true
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	return rest
+}
+
+// extractFlagValues removes every occurrence of a value-taking flag from args,
+// accepting both "--name value" and "--name=value" forms. It returns the
+// collected values in order and the remaining args.
+func extractFlagValues(args []string, name string) (values, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == name:
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag %s requires a value", name)
+			}
+			i++
+			values = append(values, args[i])
+		case strings.HasPrefix(a, name+"="):
+			values = append(values, strings.TrimPrefix(a, name+"="))
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return values, rest, nil
+}
+
+// runObsidianVault implements the "obsidian-vault" subcommand.
+func runObsidianVault(args []string) error {
+	var watch, diff bool
+	args = removeBoolFlag(args, "--watch-export", &watch)
+	args = removeBoolFlag(args, "--diff", &diff)
+	if len(args) < 1 {
+		return fmt.Errorf("usage: iguana obsidian-vault [--watch-export] [--diff] <model.yaml> [output-dir]")
+	}
+	modelPath := args[0]
+	outputDir := "obsidian-vault"
+	if len(args) >= 2 {
+		outputDir = args[1]
+	}
+	if watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Printf("watching %s (Ctrl-C to stop)\n", modelPath)
+		return obsidian.WatchModel(ctx, modelPath, outputDir, 500*time.Millisecond, func(changed []string) {
+			fmt.Printf("synced %s: %d page(s) changed\n", outputDir, len(changed))
+			for _, p := range changed {
+				fmt.Printf("  %s\n", p)
+			}
+		})
+	}
+	m, err := model.ReadSystemModel(modelPath)
+	if err != nil {
+		return err
+	}
+	bundle, err := export.GenerateKnowledgeBundle(m)
+	if err != nil {
+		return err
+	}
+	if diff {
+		d, err := export.DiffKnowledgeBundle(bundle, outputDir)
+		if err != nil {
+			return err
+		}
+		if d == "" {
+			fmt.Printf("%s is up to date\n", outputDir)
+		}
+		fmt.Print(d)
+		return nil
+	}
+	if err := export.WriteKnowledgeBundle(bundle, outputDir); err != nil {
+		return err
+	}
+	fmt.Printf("wrote knowledge bundle to %s\n", outputDir)
+	return nil
+}
+
+// runRiskReport implements the "risk-report" subcommand.
+func runRiskReport(args []string) error {
+	formats, rest, err := extractFlagValues(args, "--report")
+	if err != nil {
+		return err
+	}
+	format := export.RiskReportHTML
+	if len(formats) > 0 {
+		format = formats[len(formats)-1]
+	}
+	if len(rest) < 1 {
+		return fmt.Errorf("usage: iguana risk-report [--report html|markdown|sarif] <model.yaml> [output]")
+	}
+	m, err := model.ReadSystemModel(rest[0])
+	if err != nil {
+		return err
+	}
+	report, err := export.RenderRiskReport(m, format)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		fmt.Print(report)
+		return nil
+	}
+	if err := os.WriteFile(rest[1], []byte(report), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", rest[1], err)
+	}
+	fmt.Printf("wrote %s\n", rest[1])
+	return nil
+}
+
+// runCheck implements the "check" subcommand.
+func runCheck(args []string) error {
+	var fix bool
+	var rest []string
+	for _, a := range args {
+		if a == "--fix" {
+			fix = true
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	modelPaths, rest, err := extractFlagValues(rest, "--model")
+	if err != nil {
+		return err
+	}
+	if len(rest) < 1 {
+		return fmt.Errorf("usage: iguana check [--fix] [--model <model.yaml>] <dir>")
+	}
+	root := rest[0]
+
+	problems, reorder, err := checkTree(root, modelPaths)
+	if err != nil {
+		return err
+	}
+	if fix && len(problems) > 0 {
+		_, _, errs := evidence.WalkAndGenerate(root, evidence.WalkOptions{Force: reorder})
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "error: %v\n", e)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%d errors during fix", len(errs))
+		}
+		if problems, _, err = checkTree(root, modelPaths); err != nil {
+			return err
+		}
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("check failed: %d problem(s)", len(problems))
+	}
+	fmt.Println("check passed")
+	return nil
+}
+
+// runValidate implements the "validate" subcommand.
+func runValidate(args []string) error {
+	root := "."
+	if len(args) >= 1 {
+		root = args[0]
+	}
+	problems, err := evidence.CheckFreshness(root)
+	if err != nil {
+		return err
+	}
+	orphans, err := evidence.CheckOrphans(root)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, orphans...)
+	sort.Strings(problems)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("validate failed: %d bundle(s) out of date", len(problems))
+	}
+	fmt.Println("all bundles up to date")
+	return nil
+}
+
+// checkTree runs the bundle freshness, bundle ordering, and (for each model
+// path) model freshness checks. reorder reports whether any ordering problem
+// was found, since those bundles can only be fixed by a forced rewrite.
+func checkTree(root string, modelPaths []string) (problems []string, reorder bool, err error) {
+	stale, err := evidence.CheckFreshness(root)
+	if err != nil {
+		return nil, false, err
+	}
+	unsorted, err := evidence.CheckOrder(root)
+	if err != nil {
+		return nil, false, err
+	}
+	problems = append(stale, unsorted...)
+	for _, mp := range modelPaths {
+		upToDate, err := model.SystemModelUpToDate(root, mp)
+		if err != nil {
+			return nil, false, fmt.Errorf("check up-to-date: %w", err)
+		}
+		if !upToDate {
+			problems = append(problems, mp+": system model is stale")
+		}
+	}
+	return problems, len(unsorted) > 0, nil
+}
+
+// runBundleInfo implements the "bundle-info" subcommand.
+func runBundleInfo(args []string) error {
+	var asJSON bool
+	var rest []string
+	for _, a := range args {
+		if a == "--json" {
+			asJSON = true
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	if len(rest) < 1 {
+		return fmt.Errorf("usage: iguana bundle-info [--json] <file.evidence.yaml>")
+	}
+	info, err := evidence.InspectBundle(rest[0])
+	if err != nil {
+		return err
+	}
+	return writeBundleInfo(os.Stdout, info, asJSON)
+}
+
+// runConfig implements the "config" subcommand.
+func runConfig(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "validate" {
+		return fmt.Errorf("usage: iguana config validate [dir]")
+	}
+	root := "."
+	if len(args) == 2 {
+		root = args[1]
+	}
+	c, err := settings.ReadRepoConfig(root)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, settings.RepoFileName)
+	if c == nil {
+		fmt.Printf("%s: not found (nothing to validate)\n", path)
+		return nil
+	}
+	fmt.Printf("%s: ok (%d deny, %d allow)\n", path, len(c.Deny), len(c.Allow))
+	return nil
+}
+
+// runModel implements the "model" subcommand, dispatching to its diff,
+// export, and unreferenced verbs.
+func runModel(args []string) error {
+	if len(args) > 0 && args[0] == "export" {
+		return runModelExport(args[1:])
+	}
+	var asJSON bool
+	args = removeBoolFlag(args, "--json", &asJSON)
+	if len(args) > 0 && args[0] == "unreferenced" {
+		return runModelUnreferenced(os.Stdout, args[1:], asJSON)
+	}
+	if len(args) != 3 || args[0] != "diff" {
+		return fmt.Errorf("usage: iguana model diff [--json] <old.yaml> <new.yaml> | iguana model export --format dot <model.yaml> [output] | iguana model unreferenced [--json] <model.yaml>")
+	}
+	d, err := model.DiffSystemModelFiles(args[1], args[2])
+	if err != nil {
+		return err
+	}
+	if d.IsZero() {
+		return nil
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	data, err := canonical.MarshalYAML(d)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// runModelExport implements "model export".
+func runModelExport(args []string) error {
+	formats, rest, err := extractFlagValues(args, "--format")
+	if err != nil {
+		return err
+	}
+	if len(formats) == 0 || len(rest) < 1 || len(rest) > 2 {
+		return fmt.Errorf("usage: iguana model export --format dot <model.yaml> [output]")
+	}
+	m, err := model.ReadSystemModel(rest[0])
+	if err != nil {
+		return err
+	}
+	out, err := export.RenderModel(m, formats[len(formats)-1])
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(rest[1], []byte(out), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", rest[1], err)
+	}
+	fmt.Printf("wrote %s\n", rest[1])
+	return nil
+}
+
+// runModelUnreferenced implements "model unreferenced", writing to w.
+func runModelUnreferenced(w io.Writer, args []string, asJSON bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: iguana model unreferenced [--json] <model.yaml>")
+	}
+	m, err := model.ReadSystemModel(args[0])
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(append([]model.UnreferencedSymbol{}, m.Unreferenced...))
+	}
+	for _, u := range m.Unreferenced {
+		fmt.Fprintf(w, "%s\t%s\n", u.Symbol, u.Kind)
+	}
+	return nil
+}
+
+// runDiff implements the "diff" subcommand.
+func runDiff(args []string) error {
+	var asJSON bool
+	args = removeBoolFlag(args, "--json", &asJSON)
+	if len(args) != 2 {
+		return fmt.Errorf("usage: iguana diff [--json] <old> <new>")
+	}
+	oldInfo, err := os.Stat(args[0])
+	if err != nil {
+		return err
+	}
+	newInfo, err := os.Stat(args[1])
+	if err != nil {
+		return err
+	}
+
+	var diffs []evidence.BundleDiff
+	switch {
+	case oldInfo.IsDir() && newInfo.IsDir():
+		if diffs, err = evidence.DiffTrees(args[0], args[1]); err != nil {
+			return err
+		}
+	case !oldInfo.IsDir() && !newInfo.IsDir():
+		d, err := evidence.DiffBundleFiles(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			diffs = append(diffs, *d)
+		}
+	default:
+		return fmt.Errorf("diff needs two bundle files or two directories")
+	}
+	return writeBundleDiffs(os.Stdout, diffs, asJSON)
+}
+
+// writeBundleDiffs prints diffs as YAML or indented JSON; nothing when
+// there are none.
+func writeBundleDiffs(w io.Writer, diffs []evidence.BundleDiff, asJSON bool) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+	data, err := canonical.MarshalYAML(diffs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// runHover implements the "hover" subcommand.
+func runHover(args []string) error {
+	roots, rest, err := extractFlagValues(args, "--root")
+	if err != nil {
+		return err
+	}
+	root := "."
+	if len(roots) > 0 {
+		root = roots[len(roots)-1]
+	}
+	if len(rest) != 3 {
+		return fmt.Errorf("usage: iguana hover [--root <dir>] <file.go> <line> <col>")
+	}
+	line, err := strconv.Atoi(rest[1])
+	if err != nil {
+		return fmt.Errorf("invalid line: %w", err)
+	}
+	col, err := strconv.Atoi(rest[2])
+	if err != nil {
+		return fmt.Errorf("invalid column: %w", err)
+	}
+	h, err := evidence.HoverAt(rest[0], line, col, root)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h)
+}
+
+// runCalls implements the "calls" subcommand.
+func runCalls(args []string) error {
+	froms, rest, err := extractFlagValues(args, "--from")
+	if err != nil {
+		return err
+	}
+	tos, rest, err := extractFlagValues(rest, "--to")
+	if err != nil {
+		return err
+	}
+	maxes, rest, err := extractFlagValues(rest, "--max")
+	if err != nil {
+		return err
+	}
+	if len(froms) == 0 || len(rest) != 1 {
+		return fmt.Errorf("usage: iguana calls --from <symbol> [--to <symbol>] [--max <n>] <dir>")
+	}
+	from := froms[len(froms)-1]
+	if len(tos) == 0 {
+		reached, err := model.Reachable(rest[0], from)
+		if err != nil {
+			return err
+		}
+		for _, s := range reached {
+			fmt.Println(s)
+		}
+		return nil
+	}
+	limit := model.DefaultCallPathLimit
+	if len(maxes) > 0 {
+		limit, err = strconv.Atoi(maxes[len(maxes)-1])
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid --max %q (want a positive integer)", maxes[len(maxes)-1])
+		}
+	}
+	to := tos[len(tos)-1]
+	paths, err := model.CallPaths(rest[0], from, to, limit)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no call path from %s to %s", from, to)
+	}
+	for _, p := range paths {
+		fmt.Println(strings.Join(p, " -> "))
+	}
+	return nil
+}
+
+// writeBundleInfo renders info to w as aligned text or indented JSON.
+func writeBundleInfo(w io.Writer, info *evidence.BundleInfo, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	signals := strings.Join(info.Signals, ", ")
+	if signals == "" {
+		signals = "(none)"
+	}
+	fmt.Fprintf(w, "version:      %d\n", info.Version)
+	fmt.Fprintf(w, "path:         %s\n", info.Path)
+	fmt.Fprintf(w, "sha256:       %s\n", info.SHA256)
+	fmt.Fprintf(w, "package:      %s\n", info.Package)
+	fmt.Fprintf(w, "imports:      %d\n", info.Imports)
+	fmt.Fprintf(w, "functions:    %d\n", info.Functions)
+	fmt.Fprintf(w, "types:        %d\n", info.Types)
+	fmt.Fprintf(w, "variables:    %d\n", info.Variables)
+	fmt.Fprintf(w, "constants:    %d\n", info.Constants)
+	fmt.Fprintf(w, "constructors: %d\n", info.Constructors)
+	fmt.Fprintf(w, "calls:        %d\n", info.Calls)
+	fmt.Fprintf(w, "signals:      %s\n", signals)
+	fmt.Fprintf(w, "validation:   %s\n", info.Validation)
+	return nil
+}
+
+// runCompact implements the "compact" subcommand.
+func runCompact(args []string) error {
+	root := "."
+	if len(args) >= 1 {
+		root = args[0]
+	}
+	rewritten, errs := evidence.CompactBundles(root)
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "error: %v\n", e)
+	}
+	fmt.Printf("compacted %d evidence file(s)\n", rewritten)
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors during compaction", len(errs))
+	}
+	return nil
+}
+
+// runAggregate implements the "aggregate" subcommand.
+func runAggregate(args []string) error {
+	outputs, rest, err := extractFlagValues(args, "-o")
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("usage: iguana aggregate [-o <file>] <dir>")
+	}
+	root := rest[0]
+	outputPath := filepath.Join(root, "corpus.yaml")
+	if len(outputs) > 0 {
+		outputPath = outputs[len(outputs)-1]
+	}
+	agg, err := model.BuildAggregate(root)
+	if err != nil {
+		return err
+	}
+	if err := model.WriteAggregate(agg, outputPath); err != nil {
+		return err
+	}
+	fmt.Printf("aggregated %d bundles: %s\n", len(agg.Bundles), outputPath)
+	return nil
+}
+
+// runServe implements the "serve" subcommand.
+func runServe(args []string) error {
+	addrs, rest, err := extractFlagValues(args, "--addr")
+	if err != nil {
+		return err
+	}
+	models, rest, err := extractFlagValues(rest, "--model")
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("usage: iguana serve [--addr <host:port>] [--model <file>] <dir>")
+	}
+	root := rest[0]
+	if err := warnSettings(root); err != nil {
+		return err
+	}
+	addr := "localhost:7070"
+	if len(addrs) > 0 {
+		addr = addrs[len(addrs)-1]
+	}
+	modelPath := filepath.Join(root, "system_model.yaml")
+	if len(models) > 0 {
+		modelPath = models[len(models)-1]
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	api := server.New(root, modelPath)
+	srv := &http.Server{Addr: addr, Handler: api.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Printf("serving %s on http://%s (Ctrl-C to stop)\n", root, addr)
+	fmt.Printf("POST /api/analyze requires the header %s: %s\n", server.TokenHeader, api.Token())
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
 // runClean implements the "clean" subcommand.
 func runClean(args []string) error {
 	root := "."
@@ -0,0 +1,107 @@
+package main
+
+// progress.go — Progress output for "iguana analyze" in directory mode.
+//
+// The text format draws a one-line progress bar on stderr when stderr is a
+// terminal (and stays quiet otherwise, so CI logs hold only the summary).
+// The json format writes one log/slog JSON object per line to stderr for
+// every step, error, and the final summary.
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"iguana/internal/evidence"
+)
+
+// Log formats accepted by --log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// analyzeLog reports the progress and outcome of a directory analyze run.
+type analyzeLog struct {
+	out   io.Writer
+	json  *slog.Logger // non-nil for logFormatJSON
+	bar   bool         // draw a progress bar (text format on a terminal)
+	drawn bool         // a bar line is on screen
+}
+
+// newAnalyzeLog returns a log writing to stderr in format.
+func newAnalyzeLog(format string, stderr *os.File) (*analyzeLog, error) {
+	l := &analyzeLog{out: stderr}
+	switch format {
+	case "", logFormatText:
+		if info, err := stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			l.bar = true
+		}
+	case logFormatJSON:
+		l.json = slog.New(slog.NewJSONHandler(stderr, nil))
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q (want %q or %q)", format, logFormatText, logFormatJSON)
+	}
+	return l, nil
+}
+
+// progress is the evidence.WalkOptions.Progress callback.
+func (l *analyzeLog) progress(p evidence.Progress) {
+	switch {
+	case l.json != nil:
+		l.json.Info("progress", "phase", p.Phase, "dir", p.Dir, "files", p.Files,
+			"total", p.Total, "elapsed_ms", p.Elapsed.Milliseconds())
+	case l.bar:
+		fmt.Fprintf(l.out, "\r%s\x1b[K", progressLine(p))
+		l.drawn = true
+	}
+}
+
+// finish reports errs and the summary, and returns the command's error.
+func (l *analyzeLog) finish(written, skipped int, errs []error) error {
+	if l.drawn {
+		fmt.Fprint(l.out, "\r\x1b[K")
+	}
+	if l.json != nil {
+		for _, e := range errs {
+			l.json.Error("analyze", "error", e.Error())
+		}
+		l.json.Info("done", "written", written, "skipped", skipped, "errors", len(errs))
+	} else {
+		for _, e := range errs {
+			fmt.Fprintf(l.out, "error: %v\n", e)
+		}
+		fmt.Printf("wrote %d, skipped %d (up to date), %d errors\n", written, skipped, len(errs))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors during analysis", len(errs))
+	}
+	return nil
+}
+
+// progressLine renders p as a progress bar line, e.g.
+// "[=========>          ] 12/40 files  analyze internal/model (35ms)".
+// Without a known total only the count is shown.
+func progressLine(p evidence.Progress) string {
+	const width = 20
+	var b strings.Builder
+	if p.Total > 0 {
+		done := width * p.Files / p.Total
+		b.WriteString("[" + strings.Repeat("=", done))
+		if done < width {
+			b.WriteString(">" + strings.Repeat(" ", width-done-1))
+		}
+		fmt.Fprintf(&b, "] %d/%d files", p.Files, p.Total)
+	} else {
+		fmt.Fprintf(&b, "%d files", p.Files)
+	}
+	fmt.Fprintf(&b, "  %s", p.Phase)
+	if p.Dir != "" {
+		b.WriteString(" " + p.Dir)
+	}
+	fmt.Fprintf(&b, " (%s)", p.Elapsed.Round(time.Millisecond))
+	return b.String()
+}
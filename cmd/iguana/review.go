@@ -0,0 +1,140 @@
+package main
+
+// review.go — "iguana review": interactive review of pending state domains.
+//
+// Walks the pending_review section of a system model one domain at a time,
+// reading accept / reject / edit / skip / quit answers from the terminal.
+// Decisions are stored in <dir>/.iguana/review.yaml so later system-model
+// runs apply them without asking again, and the model file is updated in
+// place.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iguana/internal/model"
+)
+
+// runReview implements the "review" subcommand.
+func runReview(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: iguana review <dir> [model.yaml]")
+	}
+	dir := args[0]
+	modelPath := filepath.Join(dir, "system_model.yaml")
+	if len(args) == 2 {
+		modelPath = args[1]
+	}
+	m, err := model.ReadSystemModel(modelPath)
+	if err != nil {
+		return err
+	}
+	if len(m.PendingReview) == 0 {
+		fmt.Println("no state domains pending review")
+		return nil
+	}
+	decisions, err := reviewDomains(os.Stdin, os.Stdout, m.PendingReview)
+	if err != nil {
+		return err
+	}
+	if len(decisions) == 0 {
+		return nil
+	}
+	if err := model.WriteReviewDecisions(dir, decisions); err != nil {
+		return err
+	}
+	model.ApplyReviewDecisions(m, decisions)
+	if err := model.WriteSystemModel(m, modelPath); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d decisions in %s; run iguana system-model --force to relink effects\n",
+		len(decisions), model.ReviewPath(dir))
+	return nil
+}
+
+// reviewDomains prompts on out for a decision on each of pending, reading
+// answers from in. Skipped domains get no decision; "quit" or end of input
+// stops early, keeping the decisions made so far.
+func reviewDomains(in io.Reader, out io.Writer, pending []model.StateDomain) ([]model.ReviewDecision, error) {
+	r := bufio.NewReader(in)
+	var decisions []model.ReviewDecision
+	for i, d := range pending {
+		fmt.Fprintf(out, "\n[%d/%d] %s (confidence %.2f)\n", i+1, len(pending), d.ID, d.Confidence)
+		fmt.Fprintf(out, "  %s\n", d.Description)
+		fmt.Fprintf(out, "  aggregate: %s\n  owners:    %s\n", d.Aggregate, strings.Join(d.Owners, ", "))
+		for {
+			answer, ok := prompt(r, out, "accept, reject, edit, skip, or quit? [a/r/e/s/q] ")
+			if !ok {
+				return decisions, nil
+			}
+			switch answer {
+			case "a", "accept":
+				decisions = append(decisions, model.ReviewDecision{ID: d.ID, Action: model.ReviewAccept})
+			case "r", "reject":
+				decisions = append(decisions, model.ReviewDecision{ID: d.ID, Action: model.ReviewReject})
+			case "e", "edit":
+				edited, ok := editDomain(r, out, d)
+				if !ok {
+					return decisions, nil
+				}
+				decisions = append(decisions, model.ReviewDecision{ID: d.ID, Action: model.ReviewEdit, Domain: &edited})
+			case "s", "skip":
+			case "q", "quit":
+				return decisions, nil
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return decisions, nil
+}
+
+// editDomain prompts for a new description, aggregate, and owner list,
+// keeping the current value on an empty answer.
+func editDomain(r *bufio.Reader, out io.Writer, d model.StateDomain) (model.StateDomain, bool) {
+	description, ok := prompt(r, out, fmt.Sprintf("  description [%s]: ", d.Description))
+	if !ok {
+		return d, false
+	}
+	aggregate, ok := prompt(r, out, fmt.Sprintf("  aggregate [%s]: ", d.Aggregate))
+	if !ok {
+		return d, false
+	}
+	owners, ok := prompt(r, out, fmt.Sprintf("  owners, comma-separated [%s]: ", strings.Join(d.Owners, ", ")))
+	if !ok {
+		return d, false
+	}
+	if description != "" {
+		d.Description = description
+	}
+	if aggregate != "" {
+		d.Aggregate = aggregate
+	}
+	if owners != "" {
+		d.Owners = nil
+		for _, o := range strings.Split(owners, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				d.Owners = append(d.Owners, o)
+			}
+		}
+		sort.Strings(d.Owners)
+	}
+	return d, true
+}
+
+// prompt writes question to out and returns the next trimmed line of r,
+// or false at end of input.
+func prompt(r *bufio.Reader, out io.Writer, question string) (string, bool) {
+	fmt.Fprint(out, question)
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
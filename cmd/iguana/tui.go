@@ -0,0 +1,363 @@
+package main
+
+// tui.go — "iguana tui": full-screen dashboard for one analyzed directory.
+//
+// A bubbletea program with four tabs: an overview of the directory's
+// modules, bundles, and system model; a filterable list of bundles that
+// opens a scrollable YAML preview; a table of each file's signals; and the
+// model's open questions. "a" re-runs analyze with the terminal released,
+// then reloads everything.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"iguana/internal/evidence"
+	"iguana/internal/model"
+)
+
+// tuiTab is one page of the dashboard.
+type tuiTab int
+
+const (
+	tabOverview tuiTab = iota
+	tabBundles
+	tabSignals
+	tabQuestions
+	tuiTabCount
+)
+
+var tuiTabNames = [tuiTabCount]string{"Overview", "Bundles", "Signals", "Questions"}
+
+var (
+	tuiTabStyle       = lipgloss.NewStyle().Padding(0, 1)
+	tuiActiveTabStyle = tuiTabStyle.Bold(true).Reverse(true)
+	tuiStatusStyle    = lipgloss.NewStyle().Faint(true)
+)
+
+// dashboard is the bubbletea model of one iguana tui session.
+type dashboard struct {
+	dir       string
+	modelPath string
+	bundles   []*evidence.EvidenceBundle
+	model     *model.SystemModel // nil when modelPath does not exist
+
+	tab     tuiTab
+	list    list.Model     // the Bundles tab
+	table   table.Model    // the Signals tab
+	view    viewport.Model // the Overview and Questions tabs, and bundle previews
+	preview string         // path of the bundle previewed in view, if any
+	status  string         // outcome of the last analyze
+
+	// analyze regenerates the bundles of dir; runAnalyze outside tests.
+	analyze func(dir string) error
+}
+
+// analyzedMsg reports the end of an analyze run.
+type analyzedMsg struct{ err error }
+
+// bundleItem is a bundle in the Bundles list.
+type bundleItem struct{ b *evidence.EvidenceBundle }
+
+func (i bundleItem) Title() string { return i.b.File.Path }
+
+func (i bundleItem) Description() string {
+	return fmt.Sprintf("package %s · %d functions · %d calls", i.b.Package.Name, len(i.b.Symbols.Functions), len(i.b.Calls))
+}
+
+func (i bundleItem) FilterValue() string { return i.b.File.Path }
+
+// analyzeExec runs an analyze as a tea.ExecCommand, so the program gives up
+// the terminal while analyze writes its progress to stdout and stderr.
+type analyzeExec struct{ run func() error }
+
+func (e analyzeExec) Run() error        { return e.run() }
+func (analyzeExec) SetStdin(io.Reader)  {}
+func (analyzeExec) SetStdout(io.Writer) {}
+func (analyzeExec) SetStderr(io.Writer) {}
+
+// runTUI implements the "tui" subcommand.
+func runTUI(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: iguana tui <dir> [model.yaml]")
+	}
+	modelPath := filepath.Join(args[0], "system_model.yaml")
+	if len(args) == 2 {
+		modelPath = args[1]
+	}
+	d := newDashboard(args[0], modelPath, func(dir string) error { return runAnalyze([]string{dir}) })
+	if err := d.load(); err != nil {
+		return err
+	}
+	d.refresh()
+	_, err := tea.NewProgram(d, tea.WithAltScreen()).Run()
+	return err
+}
+
+// newDashboard returns a dashboard for dir, sized for an 80x24 terminal
+// until the program reports the real size. Call load and refresh before
+// showing it.
+func newDashboard(dir, modelPath string, analyze func(dir string) error) *dashboard {
+	d := &dashboard{
+		dir:       dir,
+		modelPath: modelPath,
+		list:      list.New(nil, list.NewDefaultDelegate(), 0, 0),
+		table:     table.New(table.WithFocused(true)),
+		view:      viewport.New(0, 0),
+		analyze:   analyze,
+	}
+	d.list.SetShowTitle(false)
+	d.list.SetShowHelp(false)
+	d.list.SetStatusBarItemName("bundle", "bundles")
+	d.resize(80, 24)
+	return d
+}
+
+// load reads the bundles under d.dir and the system model, if there is one.
+func (d *dashboard) load() error {
+	bundles, err := model.LoadBundles(d.dir)
+	if err != nil {
+		return err
+	}
+	d.bundles = bundles
+	d.model, err = model.ReadSystemModel(d.modelPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		d.model, err = nil, nil
+	}
+	return err
+}
+
+// refresh fills the list, table, and current page from the loaded data.
+func (d *dashboard) refresh() {
+	items := make([]list.Item, len(d.bundles))
+	for i, b := range d.bundles {
+		items[i] = bundleItem{b}
+	}
+	d.list.SetItems(items)
+
+	var rows []table.Row
+	for _, b := range d.bundles {
+		if names := b.Signals.Names(); len(names) > 0 {
+			rows = append(rows, table.Row{b.File.Path, strings.Join(names, ", ")})
+		}
+	}
+	d.table.SetRows(rows)
+	d.setTab(d.tab)
+}
+
+// resize lays the pages out for a width x height terminal: one line of
+// tabs above the page and one status line below it.
+func (d *dashboard) resize(width, height int) {
+	body := max(height-2, 1)
+	d.list.SetSize(width, body)
+	d.table.SetColumns([]table.Column{
+		{Title: "File", Width: max(width/2, 10)},
+		{Title: "Signals", Width: max(width-width/2-4, 10)},
+	})
+	d.table.SetWidth(width)
+	d.table.SetHeight(body)
+	d.view.Width = width
+	d.view.Height = body
+}
+
+// setTab switches to tab t, closing any bundle preview.
+func (d *dashboard) setTab(t tuiTab) {
+	d.tab = t
+	d.preview = ""
+	var b strings.Builder
+	switch t {
+	case tabOverview:
+		d.overview(&b)
+	case tabQuestions:
+		d.questions(&b)
+	}
+	d.view.SetContent(b.String())
+	d.view.GotoTop()
+}
+
+// openPreview shows the YAML of the selected bundle in the viewport.
+func (d *dashboard) openPreview() {
+	item, ok := d.list.SelectedItem().(bundleItem)
+	if !ok {
+		return
+	}
+	data, err := evidence.MarshalBundle(item.b, evidence.SchemaLatest)
+	if err != nil {
+		d.status = fmt.Sprintf("marshal %s: %v", item.b.File.Path, err)
+		return
+	}
+	d.preview = item.b.File.Path
+	d.view.SetContent(string(data))
+	d.view.GotoTop()
+}
+
+// Init implements tea.Model.
+func (d *dashboard) Init() tea.Cmd { return nil }
+
+// Update implements tea.Model. Keys the dashboard does not use go to the
+// component of the current page.
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.resize(msg.Width, msg.Height)
+		return d, nil
+	case analyzedMsg:
+		d.status = "analyze finished"
+		if msg.err != nil {
+			d.status = fmt.Sprintf("analyze: %v", msg.err)
+		}
+		if err := d.load(); err != nil {
+			d.status = fmt.Sprintf("reload: %v", err)
+		}
+		d.refresh()
+		return d, nil
+	case tea.KeyMsg:
+		if d.tab == tabBundles && d.preview == "" && d.list.SettingFilter() {
+			break // the filter input takes every key
+		}
+		switch key := msg.String(); key {
+		case "ctrl+c", "q":
+			return d, tea.Quit
+		case "tab":
+			d.setTab((d.tab + 1) % tuiTabCount)
+			return d, nil
+		case "shift+tab":
+			d.setTab((d.tab + tuiTabCount - 1) % tuiTabCount)
+			return d, nil
+		case "1", "2", "3", "4":
+			d.setTab(tuiTab(key[0] - '1'))
+			return d, nil
+		case "a":
+			d.status = "analyzing…"
+			return d, tea.Exec(analyzeExec{func() error { return d.analyze(d.dir) }}, func(err error) tea.Msg {
+				return analyzedMsg{err}
+			})
+		case "esc":
+			if d.preview != "" {
+				d.preview = ""
+				return d, nil
+			}
+		case "enter":
+			if d.tab == tabBundles && d.preview == "" {
+				d.openPreview()
+				return d, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	switch {
+	case d.tab == tabBundles && d.preview == "":
+		d.list, cmd = d.list.Update(msg)
+	case d.tab == tabSignals:
+		d.table, cmd = d.table.Update(msg)
+	default:
+		d.view, cmd = d.view.Update(msg)
+	}
+	return d, cmd
+}
+
+// View implements tea.Model.
+func (d *dashboard) View() string {
+	tabs := make([]string, tuiTabCount)
+	for i, name := range tuiTabNames {
+		style := tuiTabStyle
+		if tuiTab(i) == d.tab {
+			style = tuiActiveTabStyle
+		}
+		tabs[i] = style.Render(fmt.Sprintf("%d %s", i+1, name))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "iguana tui — %s  %s\n", d.dir, strings.Join(tabs, ""))
+	switch {
+	case d.tab == tabBundles && d.preview == "":
+		b.WriteString(d.list.View())
+	case d.tab == tabSignals && len(d.table.Rows()) == 0:
+		b.WriteString("no files with signals")
+	case d.tab == tabSignals:
+		b.WriteString(d.table.View())
+	default:
+		b.WriteString(d.view.View())
+	}
+	b.WriteString("\n" + tuiStatusStyle.Render(d.statusLine()))
+	return b.String()
+}
+
+// statusLine returns the key help of the current page, after the outcome of
+// the last analyze.
+func (d *dashboard) statusLine() string {
+	help := "tab/1-4 page · a analyze · q quit"
+	switch {
+	case d.preview != "":
+		help = fmt.Sprintf("%s %3.f%% · ↑/↓ scroll · esc back · ", d.preview, d.view.ScrollPercent()*100) + help
+	case d.tab == tabBundles:
+		help = "↑/↓ select · enter preview · / filter · " + help
+	case d.tab == tabSignals:
+		help = "↑/↓ select · " + help
+	default:
+		help = "↑/↓ scroll · " + help
+	}
+	if d.status != "" {
+		return d.status + " · " + help
+	}
+	return help
+}
+
+// overview writes the directory's modules, bundle and package counts, and
+// the state of its system model.
+func (d *dashboard) overview(w io.Writer) {
+	modules := map[string]bool{}
+	packages := map[string]bool{}
+	for _, b := range d.bundles {
+		packages[path.Dir(b.File.Path)+" "+b.Package.Name] = true
+		if b.Package.Module != "" {
+			modules[b.Package.Module] = true
+		}
+	}
+	for _, m := range slices.Sorted(maps.Keys(modules)) {
+		fmt.Fprintf(w, "module %s\n", m)
+	}
+	fmt.Fprintf(w, "%d bundles in %d packages\n", len(d.bundles), len(packages))
+	if len(d.bundles) == 0 {
+		fmt.Fprintln(w, "no bundles yet; press a to analyze")
+	}
+	if d.model == nil {
+		fmt.Fprintf(w, "no system model at %s (run iguana system-model)\n", d.modelPath)
+		return
+	}
+	fmt.Fprintf(w, "model %s, generated %s:\n  %d state domains\n  %d open questions\n  %d pending review\n",
+		d.modelPath, d.model.GeneratedAt, len(d.model.StateDomains), len(d.model.OpenQuestions), len(d.model.PendingReview))
+}
+
+// questions writes the model's open questions.
+func (d *dashboard) questions(w io.Writer) {
+	if d.model == nil {
+		fmt.Fprintf(w, "no system model at %s (run iguana system-model)\n", d.modelPath)
+		return
+	}
+	if len(d.model.OpenQuestions) == 0 {
+		fmt.Fprintln(w, "no open questions")
+		return
+	}
+	for i, q := range d.model.OpenQuestions {
+		fmt.Fprintf(w, "%d. %s\n", i+1, q.Question)
+		if q.RelatedDomain != "" {
+			fmt.Fprintf(w, "   domain: %s\n", q.RelatedDomain)
+		}
+		if len(q.MissingEvidence) > 0 {
+			fmt.Fprintf(w, "   missing evidence: %s\n", strings.Join(q.MissingEvidence, "; "))
+		}
+	}
+}
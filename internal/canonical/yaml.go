@@ -0,0 +1,54 @@
+package canonical
+
+// yaml.go — Byte-stable YAML marshaling for every artifact iguana writes.
+//
+// Evidence bundles and the system model must be byte-identical across runs
+// (INV-4, INV-44). yaml.Marshal is stable today, but its indentation and
+// node styles are library defaults. MarshalYAML pins them: 4-space indent,
+// block style throughout, and no anchors or aliases.
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Indent is the number of spaces per nesting level in canonical output.
+const Indent = 4
+
+// MarshalYAML encodes v as canonical YAML: block style only, Indent-space
+// indentation, and every alias expanded in place of its anchor.
+func MarshalYAML(v any) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	canonicalize(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(Indent)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalize rewrites n in place: aliases are replaced by a copy of the
+// node they point to, anchors are dropped, and flow styles are cleared so
+// collections render in block style.
+func canonicalize(n *yaml.Node) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		*n = *n.Alias
+	}
+	n.Anchor = ""
+	n.Style &^= yaml.FlowStyle
+	for i, c := range n.Content {
+		cp := *c
+		canonicalize(&cp)
+		n.Content[i] = &cp
+	}
+}
@@ -0,0 +1,75 @@
+package canonical
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type point struct {
+	X int `yaml:"x"`
+	Y int `yaml:"y"`
+}
+
+type shape struct {
+	Name string   `yaml:"name"`
+	From *point   `yaml:"from"`
+	To   *point   `yaml:"to"`
+	Tags []string `yaml:"tags,flow"`
+}
+
+// TestMarshalYAML_NoAnchors verifies that a sub-value shared by two fields
+// is written out twice rather than as an anchor/alias pair, and that flow
+// style requested by a struct tag is rendered as block style.
+func TestMarshalYAML_NoAnchors(t *testing.T) {
+	p := &point{X: 1, Y: 2}
+	data, err := MarshalYAML(shape{Name: "line", From: p, To: p, Tags: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if strings.ContainsAny(out, "&*[") {
+		t.Errorf("expected no anchors, aliases, or flow sequences:\n%s", out)
+	}
+	if strings.Count(out, "x: 1") != 2 {
+		t.Errorf("expected the shared point to be written twice:\n%s", out)
+	}
+}
+
+// TestMarshalYAML_ExpandsAliases verifies that aliases present in a decoded
+// node tree are expanded in place.
+func TestMarshalYAML_ExpandsAliases(t *testing.T) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte("a: &p {x: 1}\nb: *p\n"), &node); err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalYAML(&node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a:\n    x: 1\nb:\n    x: 1\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+// TestMarshalYAML_MatchesMarshal verifies that canonical output equals
+// yaml.Marshal for plain structs, so existing artifacts stay byte-identical.
+func TestMarshalYAML_MatchesMarshal(t *testing.T) {
+	v := struct {
+		Name   string  `yaml:"name"`
+		Points []point `yaml:"points"`
+	}{Name: "poly", Points: []point{{1, 2}, {3, 4}}}
+	got, err := MarshalYAML(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -6,10 +6,12 @@ package evidence
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
@@ -36,6 +38,7 @@ func loadTypeInfoForFile(filePath string) (*ast.File, *types.Info, *types.Packag
 			packages.NeedTypesInfo |
 			packages.NeedImports,
 		Dir:  filepath.Dir(absPath),
+		Env:  loadEnv(filepath.Dir(absPath)),
 		Fset: fset,
 	}
 
@@ -74,6 +77,7 @@ func loadPackageForDir(dir string) (*packages.Package, *token.FileSet, error) {
 			packages.NeedTypesInfo |
 			packages.NeedImports,
 		Dir:  dir,
+		Env:  loadEnv(dir),
 		Fset: fset,
 	}
 	pkgs, err := packages.Load(cfg, ".")
@@ -122,9 +126,28 @@ func extractPackageMeta(file *ast.File) PackageMeta {
 	sort.Slice(meta.Imports, func(i, j int) bool {
 		return meta.Imports[i].Path < meta.Imports[j].Path
 	})
+	meta.GoGenerate = extractGoGenerate(file)
 	return meta
 }
 
+// extractGoGenerate returns the command text following each
+// "//go:generate " directive in the file's comments, sorted. Only the text
+// is kept — never comment positions (INV-5).
+func extractGoGenerate(file *ast.File) []string {
+	var cmds []string
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if cmd, ok := strings.CutPrefix(c.Text, "//go:generate "); ok {
+				if cmd = strings.TrimSpace(cmd); cmd != "" {
+					cmds = append(cmds, cmd)
+				}
+			}
+		}
+	}
+	sort.Strings(cmds)
+	return cmds
+}
+
 // ---------------------------------------------------------------------------
 // Extraction — symbols
 // ---------------------------------------------------------------------------
@@ -135,6 +158,7 @@ func extractPackageMeta(file *ast.File) PackageMeta {
 // All result slices are sorted alphabetically by name (INV-8..11).
 func extractSymbols(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) Symbols {
 	var syms Symbols
+	var ifaces []*types.TypeName // computed on first concrete type
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.FuncDecl:
@@ -150,19 +174,52 @@ func extractSymbols(file *ast.File, typesInfo *types.Info, pkg *types.Package, q
 						Kind:     typeKind(ts.Type),
 						Exported: ast.IsExported(ts.Name.Name),
 					}
+					// Prefer the type checker's view when available: it sees
+					// through named types to their underlying struct/interface.
+					if kind, underlying, ok := typeKindFromInfo(ts, typesInfo, qualifier); ok {
+						td.Kind = kind
+						td.Underlying = underlying
+					}
+					td.TypeParams = typeParamsFromAST(ts.TypeParams)
+					if typesInfo != nil {
+						if obj, ok := typesInfo.Defs[ts.Name].(*types.TypeName); ok {
+							if named, ok := obj.Type().(*types.Named); ok {
+								td.TypeParams = typeParamsFromInfo(named.TypeParams(), qualifier)
+							}
+						}
+					}
 					// INV-48: extract exported fields for struct types.
-					if st, ok := ts.Type.(*ast.StructType); ok {
-						td.Fields = extractStructFields(st)
+					switch t := ts.Type.(type) {
+					case *ast.StructType:
+						td.Fields = extractStructFields(t)
+					case *ast.InterfaceType:
+						td.Embeds = extractInterfaceEmbeds(t)
+						td.Methods = interfaceMethods(t, typesInfo, qualifier)
+					default:
+						// "type T Base": the kind came from Base's underlying
+						// type, so its members come from there too.
+						td.Fields, td.Embeds, td.Methods = membersFromInfo(ts, typesInfo, qualifier)
+					}
+					if typesInfo != nil && pkg != nil && td.Kind != "interface" {
+						if ifaces == nil {
+							ifaces = candidateInterfaces(pkg)
+						}
+						td.Implements = implementedInterfaces(ts, typesInfo, ifaces, qualifier)
 					}
 					syms.Types = append(syms.Types, td)
 				}
 			case "var":
 				for _, spec := range d.Specs {
 					vs := spec.(*ast.ValueSpec)
+					if claim, ok := interfaceAssertion(vs, typesInfo); ok {
+						syms.InterfaceAssertions = append(syms.InterfaceAssertions, claim)
+					}
+					syms.ErrorSentinels = append(syms.ErrorSentinels, errorSentinels(vs)...)
 					for _, name := range vs.Names {
 						syms.Variables = append(syms.Variables, VarDecl{
 							Name:     name.Name,
 							Exported: ast.IsExported(name.Name),
+							TypeStr:  valueSpecType(vs, name, typesInfo, qualifier),
 						})
 					}
 				}
@@ -173,6 +230,7 @@ func extractSymbols(file *ast.File, typesInfo *types.Info, pkg *types.Package, q
 						syms.Constants = append(syms.Constants, VarDecl{
 							Name:     name.Name,
 							Exported: ast.IsExported(name.Name),
+							TypeStr:  valueSpecType(vs, name, typesInfo, qualifier),
 						})
 					}
 				}
@@ -182,6 +240,24 @@ func extractSymbols(file *ast.File, typesInfo *types.Info, pkg *types.Package, q
 
 	sort.Slice(syms.Functions, func(i, j int) bool { return syms.Functions[i].Name < syms.Functions[j].Name })
 	sort.Slice(syms.Types, func(i, j int) bool { return syms.Types[i].Name < syms.Types[j].Name })
+
+	// Attach methods to the types of this file they are declared on.
+	// Functions are sorted, so each method list is too.
+	typeIndex := make(map[string]int, len(syms.Types))
+	for i, td := range syms.Types {
+		typeIndex[td.Name] = i
+	}
+	for _, fn := range syms.Functions {
+		if i, ok := typeIndex[fn.ReceiverType()]; ok && fn.Receiver != "" {
+			syms.Types[i].Methods = append(syms.Types[i].Methods, Method{
+				Name: fn.Name, Exported: fn.Exported, Params: fn.Params, Returns: fn.Returns,
+			})
+		}
+	}
+	for i := range syms.Types {
+		m := syms.Types[i].Methods
+		sort.SliceStable(m, func(a, b int) bool { return m[a].Name < m[b].Name })
+	}
 	sort.Slice(syms.Variables, func(i, j int) bool { return syms.Variables[i].Name < syms.Variables[j].Name })
 	sort.Slice(syms.Constants, func(i, j int) bool { return syms.Constants[i].Name < syms.Constants[j].Name })
 
@@ -209,10 +285,64 @@ func extractSymbols(file *ast.File, typesInfo *types.Info, pkg *types.Package, q
 		}
 	}
 	sort.Strings(syms.Constructors)
+	sort.Strings(syms.InterfaceAssertions)
+	sort.Strings(syms.ErrorSentinels)
 
 	return syms
 }
 
+// errorSentinels returns the names in vs that start with "Err" and are
+// initialized by a call to errors.New or fmt.Errorf.
+func errorSentinels(vs *ast.ValueSpec) []string {
+	if len(vs.Values) != len(vs.Names) {
+		return nil
+	}
+	var names []string
+	for i, name := range vs.Names {
+		if !strings.HasPrefix(name.Name, "Err") {
+			continue
+		}
+		call, ok := ast.Unparen(vs.Values[i]).(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		if fn := exprToString(call.Fun); fn == "errors.New" || fn == "fmt.Errorf" {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// interfaceAssertion recognizes the compile-time satisfaction idiom
+// "var _ I = (*T)(nil)" (or "T(nil)") and returns "T:I", e.g.
+// "MyType:io.Writer". With type info the declared type must be an
+// interface; without it the declared type is taken on trust.
+func interfaceAssertion(vs *ast.ValueSpec, typesInfo *types.Info) (string, bool) {
+	if len(vs.Names) != 1 || vs.Names[0].Name != "_" || vs.Type == nil || len(vs.Values) != 1 {
+		return "", false
+	}
+	conv, ok := vs.Values[0].(*ast.CallExpr)
+	if !ok || len(conv.Args) != 1 {
+		return "", false
+	}
+	if arg, ok := conv.Args[0].(*ast.Ident); !ok || arg.Name != "nil" {
+		return "", false
+	}
+	impl := conv.Fun
+	if paren, ok := impl.(*ast.ParenExpr); ok {
+		impl = paren.X
+	}
+	if star, ok := impl.(*ast.StarExpr); ok {
+		impl = star.X
+	}
+	if typesInfo != nil {
+		if t := typesInfo.TypeOf(vs.Type); t == nil || !types.IsInterface(t) {
+			return "", false
+		}
+	}
+	return exprToString(impl) + ":" + exprToString(vs.Type), true
+}
+
 // extractFunction builds a Function from an ast.FuncDecl.
 // Uses type info when available for accurate receiver and parameter types.
 func extractFunction(decl *ast.FuncDecl, typesInfo *types.Info, qualifier types.Qualifier) Function {
@@ -227,19 +357,8 @@ func extractFunction(decl *ast.FuncDecl, typesInfo *types.Info, qualifier types.
 				if recv := sig.Recv(); recv != nil {
 					fn.Receiver = types.TypeString(recv.Type(), qualifier)
 				}
-				params := sig.Params()
-				for i := 0; i < params.Len(); i++ {
-					typeStr := types.TypeString(params.At(i).Type(), qualifier)
-					// Mark variadic last parameter.
-					if sig.Variadic() && i == params.Len()-1 {
-						typeStr = "..." + typeStr
-					}
-					fn.Params = append(fn.Params, typeStr)
-				}
-				results := sig.Results()
-				for i := 0; i < results.Len(); i++ {
-					fn.Returns = append(fn.Returns, types.TypeString(results.At(i).Type(), qualifier))
-				}
+				fn.TypeParams = typeParamsFromInfo(sig.TypeParams(), qualifier)
+				fn.Params, fn.Returns = signatureTypes(sig, qualifier)
 				return fn
 			}
 		}
@@ -249,31 +368,95 @@ func extractFunction(decl *ast.FuncDecl, typesInfo *types.Info, qualifier types.
 	if decl.Recv != nil && len(decl.Recv.List) > 0 {
 		fn.Receiver = exprToString(decl.Recv.List[0].Type)
 	}
-	if decl.Type.Params != nil {
-		for _, field := range decl.Type.Params.List {
-			typeStr := exprToString(field.Type)
-			if len(field.Names) == 0 {
-				fn.Params = append(fn.Params, typeStr)
-			} else {
-				for range field.Names {
-					fn.Params = append(fn.Params, typeStr)
-				}
-			}
+	fn.TypeParams = typeParamsFromAST(decl.Type.TypeParams)
+	fn.Params = fieldListTypes(decl.Type.Params)
+	fn.Returns = fieldListTypes(decl.Type.Results)
+	return fn
+}
+
+// typeParamsFromInfo renders a type parameter list with constraints, one
+// entry per parameter: "[K comparable, V any]". An empty list yields "".
+func typeParamsFromInfo(tps *types.TypeParamList, qualifier types.Qualifier) string {
+	if tps.Len() == 0 {
+		return ""
+	}
+	parts := make([]string, tps.Len())
+	for i := range parts {
+		tp := tps.At(i)
+		parts[i] = tp.Obj().Name() + " " + types.TypeString(tp.Constraint(), qualifier)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// typeParamsFromAST renders a type parameter list from source in the same
+// form as typeParamsFromInfo; grouped names ("A, B any") are expanded.
+func typeParamsFromAST(fl *ast.FieldList) string {
+	if fl == nil || len(fl.List) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, field := range fl.List {
+		constraint := exprToString(field.Type)
+		for _, name := range field.Names {
+			parts = append(parts, name.Name+" "+constraint)
 		}
 	}
-	if decl.Type.Results != nil {
-		for _, field := range decl.Type.Results.List {
-			typeStr := exprToString(field.Type)
-			if len(field.Names) == 0 {
-				fn.Returns = append(fn.Returns, typeStr)
-			} else {
-				for range field.Names {
-					fn.Returns = append(fn.Returns, typeStr)
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// signatureTypes returns the parameter and result types of sig, the last
+// parameter of a variadic signature prefixed with "...".
+func signatureTypes(sig *types.Signature, qualifier types.Qualifier) (params, returns []string) {
+	for i := 0; i < sig.Params().Len(); i++ {
+		typeStr := types.TypeString(sig.Params().At(i).Type(), qualifier)
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			typeStr = "..." + typeStr
+		}
+		params = append(params, typeStr)
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		returns = append(returns, types.TypeString(sig.Results().At(i).Type(), qualifier))
+	}
+	return params, returns
+}
+
+// fieldListTypes returns one type string per entry of fl, repeating a
+// type shared by several names ("a, b int"). A nil list yields nil.
+func fieldListTypes(fl *ast.FieldList) []string {
+	if fl == nil {
+		return nil
+	}
+	var out []string
+	for _, field := range fl.List {
+		typeStr := exprToString(field.Type)
+		for range max(len(field.Names), 1) {
+			out = append(out, typeStr)
+		}
+	}
+	return out
+}
+
+// interfaceMethods returns the methods declared in it (embedded interfaces
+// are not expanded), typed by the checker when typesInfo is available.
+func interfaceMethods(it *ast.InterfaceType, typesInfo *types.Info, qualifier types.Qualifier) []Method {
+	var methods []Method
+	for _, field := range it.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		for _, name := range field.Names {
+			m := Method{Name: name.Name, Exported: ast.IsExported(name.Name)}
+			m.Params, m.Returns = fieldListTypes(ft.Params), fieldListTypes(ft.Results)
+			if typesInfo != nil {
+				if obj, ok := typesInfo.Defs[name].(*types.Func); ok {
+					m.Params, m.Returns = signatureTypes(obj.Type().(*types.Signature), qualifier)
 				}
 			}
+			methods = append(methods, m)
 		}
 	}
-	return fn
+	return methods
 }
 
 // typeKind classifies an AST type expression as "struct", "interface", or "alias".
@@ -288,6 +471,158 @@ func typeKind(expr ast.Expr) string {
 	}
 }
 
+// typeKindFromInfo classifies a type spec using go/types. The kind is derived
+// from the underlying type, so "type T Base" where Base is a struct is a
+// "struct". For kinds other than struct and interface, underlying holds the
+// qualified underlying type string (e.g. "int" for "type Status int").
+// Returns ok=false when typesInfo is nil or has no definition for the spec,
+// in which case callers fall back to typeKind.
+func typeKindFromInfo(ts *ast.TypeSpec, typesInfo *types.Info, qualifier types.Qualifier) (kind, underlying string, ok bool) {
+	if typesInfo == nil {
+		return "", "", false
+	}
+	obj, isTypeName := typesInfo.Defs[ts.Name].(*types.TypeName)
+	if !isTypeName || obj == nil {
+		return "", "", false
+	}
+	under := obj.Type().Underlying()
+	switch under.(type) {
+	case *types.Struct:
+		return "struct", "", true
+	case *types.Interface:
+		return "interface", "", true
+	default:
+		return "alias", types.TypeString(under, qualifier), true
+	}
+}
+
+// membersFromInfo returns the exported fields of a type spec whose
+// underlying type is a struct, or the embeds and methods of one whose
+// underlying type is an interface, as go/types sees them; used when the spec
+// names another type instead of spelling out a literal. All are nil without
+// type info or for other kinds.
+func membersFromInfo(ts *ast.TypeSpec, typesInfo *types.Info, qualifier types.Qualifier) (fields []FieldDecl, embeds []string, methods []Method) {
+	if typesInfo == nil {
+		return nil, nil, nil
+	}
+	obj, ok := typesInfo.Defs[ts.Name].(*types.TypeName)
+	if !ok || obj == nil {
+		return nil, nil, nil
+	}
+	switch under := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		for f := range under.Fields() {
+			if f.Exported() {
+				fields = append(fields, FieldDecl{Name: f.Name(), TypeStr: types.TypeString(f.Type(), qualifier)})
+			}
+		}
+	case *types.Interface:
+		for t := range under.EmbeddedTypes() {
+			if _, ok := types.Unalias(t).(*types.Named); ok {
+				embeds = append(embeds, types.TypeString(t, qualifier))
+			}
+		}
+		for m := range under.ExplicitMethods() {
+			params, returns := signatureTypes(m.Type().(*types.Signature), qualifier)
+			methods = append(methods, Method{Name: m.Name(), Exported: m.Exported(), Params: params, Returns: returns})
+		}
+	}
+	return fields, embeds, methods
+}
+
+// candidateInterfaces returns the interfaces a type in pkg is checked
+// against: those declared in pkg and the exported ones of its direct
+// imports. Empty interfaces, constraint-only interfaces, and generic
+// interfaces are left out — every type satisfies the first, and the others
+// are not implemented in the ordinary sense.
+func candidateInterfaces(pkg *types.Package) []*types.TypeName {
+	ifaces := []*types.TypeName{}
+	add := func(p *types.Package, exportedOnly bool) {
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || (exportedOnly && !tn.Exported()) {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			it, ok := named.Underlying().(*types.Interface)
+			if !ok || it.NumMethods() == 0 || !it.IsMethodSet() {
+				continue
+			}
+			ifaces = append(ifaces, tn)
+		}
+	}
+	add(pkg, false)
+	for _, imp := range pkg.Imports() {
+		add(imp, true)
+	}
+	return ifaces
+}
+
+// implementedInterfaces returns the sorted names of the ifaces that the
+// non-generic type declared by ts satisfies with its value or pointer
+// method set.
+func implementedInterfaces(ts *ast.TypeSpec, typesInfo *types.Info, ifaces []*types.TypeName, qualifier types.Qualifier) []string {
+	obj, ok := typesInfo.Defs[ts.Name].(*types.TypeName)
+	if !ok || obj == nil {
+		return nil
+	}
+	if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil
+	}
+	t := obj.Type()
+	var names []string
+	for _, tn := range ifaces {
+		it := tn.Type().Underlying().(*types.Interface)
+		if types.Implements(t, it) || types.Implements(types.NewPointer(t), it) {
+			names = append(names, types.TypeString(tn.Type(), qualifier))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractInterfaceEmbeds returns the interfaces embedded in it, in
+// declaration order: the unnamed entries of its method list that are plain
+// or package-qualified names. Type-set terms such as ~int | string are not
+// interfaces and are skipped.
+func extractInterfaceEmbeds(it *ast.InterfaceType) []string {
+	var embeds []string
+	for _, field := range it.Methods.List {
+		if len(field.Names) != 0 {
+			continue
+		}
+		switch field.Type.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			embeds = append(embeds, exprToString(field.Type))
+		}
+	}
+	return embeds
+}
+
+// valueSpecType returns the type of the variable or constant name declared
+// in vs: the written type, else the type-checked one. Untyped constants and
+// values whose type is unknown without type info yield "".
+func valueSpecType(vs *ast.ValueSpec, name *ast.Ident, typesInfo *types.Info, qualifier types.Qualifier) string {
+	if vs.Type != nil {
+		return exprToString(vs.Type)
+	}
+	if typesInfo == nil {
+		return ""
+	}
+	obj := typesInfo.Defs[name]
+	if obj == nil {
+		return ""
+	}
+	if b, ok := obj.Type().(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		return ""
+	}
+	return types.TypeString(obj.Type(), qualifier)
+}
+
 // extractStructFields collects exported fields from an ast.StructType in
 // declaration order (INV-48). Embedded types use their base type name as the
 // field name. Unexported fields are skipped.
@@ -373,6 +708,24 @@ func exprToString(expr ast.Expr) string {
 		return "..."
 	case *ast.ParenExpr:
 		return "(" + exprToString(e.X) + ")"
+	case *ast.IndexExpr:
+		return exprToString(e.X) + "[" + exprToString(e.Index) + "]"
+	case *ast.IndexListExpr:
+		args := make([]string, len(e.Indices))
+		for i, idx := range e.Indices {
+			args[i] = exprToString(idx)
+		}
+		return exprToString(e.X) + "[" + strings.Join(args, ", ") + "]"
+	case *ast.UnaryExpr:
+		if e.Op == token.TILDE {
+			return "~" + exprToString(e.X)
+		}
+		return ""
+	case *ast.BinaryExpr:
+		if e.Op == token.OR {
+			return exprToString(e.X) + " | " + exprToString(e.Y)
+		}
+		return ""
 	default:
 		return ""
 	}
@@ -490,6 +843,9 @@ func funcDeclName(decl *ast.FuncDecl, typesInfo *types.Info, qualifier types.Qua
 // resolveCallTarget returns the qualified call target string for an AST call
 // expression function node. Returns "" for unresolvable or anonymous targets.
 func resolveCallTarget(expr ast.Expr, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) string {
+	if fun, ok := instantiatedFunc(expr, typesInfo); ok {
+		expr = fun
+	}
 	switch e := expr.(type) {
 	case *ast.SelectorExpr:
 		if typesInfo != nil {
@@ -544,6 +900,40 @@ func resolveCallTarget(expr ast.Expr, typesInfo *types.Info, pkg *types.Package,
 	}
 }
 
+// instantiatedFunc unwraps an explicit instantiation of a generic function,
+// "Map[int, string]" or "slices.Index[[]int]", to the function expression
+// so the call is attributed to the generic function. With type info the
+// indexed expression must be a function; without it only a multi-argument
+// index, which cannot be an element access, is unwrapped.
+func instantiatedFunc(expr ast.Expr, typesInfo *types.Info) (ast.Expr, bool) {
+	var x ast.Expr
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		x = e.X
+	case *ast.IndexListExpr:
+		x = e.X
+		if typesInfo == nil {
+			return x, true
+		}
+	default:
+		return nil, false
+	}
+	if typesInfo == nil {
+		return nil, false
+	}
+	ident, ok := x.(*ast.Ident)
+	if sel, isSel := x.(*ast.SelectorExpr); isSel {
+		ident, ok = sel.Sel, true
+	}
+	if !ok {
+		return nil, false
+	}
+	if _, isFunc := typesInfo.Uses[ident].(*types.Func); !isFunc {
+		return nil, false
+	}
+	return x, true
+}
+
 // ---------------------------------------------------------------------------
 // Extraction — signals
 // ---------------------------------------------------------------------------
@@ -657,5 +1047,649 @@ func extractSignals(meta PackageMeta, calls []Call, file *ast.File) Signals {
 		}
 	}
 
+	// execs_subprocess: os/exec import or a process-spawning call.
+	if importSet["os/exec"] {
+		sig.ExecsSubprocess = true
+	}
+	for _, fn := range []string{"exec.Command", "exec.CommandContext", "syscall.Exec"} {
+		if callSet[fn] {
+			sig.ExecsSubprocess = true
+			break
+		}
+	}
+
+	// concurrency_kinds: primitives named in struct field / var types or
+	// called via sync/atomic functions.
+	sig.ConcurrencyKinds = extractConcurrencyKinds(file, calls)
+	if len(sig.ConcurrencyKinds) > 0 {
+		sig.Concurrency = true
+	}
+
+	// unbounded_http_client: net calls through an http.Client{} literal
+	// that never sets Timeout.
+	if sig.NetCalls && hasTimeoutlessHTTPClient(file) {
+		sig.UnboundedHTTPClient = true
+	}
+
+	// uses_default_http_client: package-level http helpers or
+	// http.DefaultClient.
+	sig.UsesDefaultHTTPClient = usesDefaultHTTPClient(file, callSet)
+
+	// blocking_channel_ops: bare send/receive outside a select.
+	sig.BlockingChannelOps = hasBlockingChannelOps(file)
+
+	// large_switches: switch statements with many cases.
+	sig.LargeSwitches = countLargeSwitches(file, largeSwitchThreshold)
+
+	// dynamic_serialization: struct tags read through reflection.
+	sig.DynamicSerialization = hasReflectTagParsing(file)
+
+	// unchecked_assertions: x.(T) without the comma-ok form.
+	sig.UncheckedAssertions = countUncheckedAssertions(file)
+
+	// panics / recovers / exits: abrupt termination and its handling.
+	for _, fn := range []string{"panic", "log.Panic", "log.Panicf", "log.Panicln"} {
+		if callSet[fn] {
+			sig.Panics = true
+			break
+		}
+	}
+	sig.Recovers = callSet["recover"]
+	for _, fn := range []string{"os.Exit", "syscall.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln"} {
+		if callSet[fn] {
+			sig.Exits = true
+			break
+		}
+	}
+
+	// resilience: imports a retry/backoff or circuit-breaker library.
+	for path := range importSet {
+		if isResilienceImport(path) {
+			sig.Resilience = true
+			break
+		}
+	}
+
 	return sig
 }
+
+// countIgnoredErrors counts assignments whose right-hand side is a single
+// call returning error as its last result while the matching left-hand
+// operand is the blank identifier. It needs type info to know the call's
+// result types and returns 0 when typesInfo is nil.
+func countIgnoredErrors(file *ast.File, typesInfo *types.Info) int {
+	if typesInfo == nil {
+		return 0
+	}
+	errType := types.Universe.Lookup("error").Type()
+	n := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		last, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+		if !ok || last.Name != "_" {
+			return true
+		}
+		var result types.Type
+		switch t := typesInfo.TypeOf(call).(type) {
+		case *types.Tuple:
+			if t.Len() != len(assign.Lhs) {
+				return true
+			}
+			result = t.At(t.Len() - 1).Type()
+		case nil:
+			return true
+		default:
+			if len(assign.Lhs) != 1 {
+				return true
+			}
+			result = t
+		}
+		if types.Identical(result, errType) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// extractConfigInputs records the environment variables and viper keys the
+// file reads by constant name: the first argument of os.Getenv,
+// os.LookupEnv, syscall.Getenv, and viper.Get* calls. The key must be a
+// string literal, or a string constant when type info is available. From
+// is the enclosing function as in extractCalls (closures count as their
+// enclosing function; package-level initializers are "<global>").
+// Sorted by (source, key, from) and deduplicated.
+func extractConfigInputs(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) []ConfigInput {
+	seen := make(map[ConfigInput]bool)
+	var inputs []ConfigInput
+	inspectCalls(file, typesInfo, qualifier, func(from string, call *ast.CallExpr) {
+		source := configSource(resolveCallTarget(call.Fun, typesInfo, pkg, qualifier))
+		if source == "" || len(call.Args) == 0 {
+			return
+		}
+		key, ok := constantString(call.Args[0], typesInfo)
+		if !ok {
+			return
+		}
+		in := ConfigInput{Source: source, Key: key, From: from}
+		if !seen[in] {
+			seen[in] = true
+			inputs = append(inputs, in)
+		}
+	})
+	sort.Slice(inputs, func(i, j int) bool {
+		a, b := inputs[i], inputs[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.From < b.From
+	})
+	return inputs
+}
+
+// inspectCalls calls fn for every call expression in file with the name of
+// its enclosing top-level function, or "<global>" for package-level
+// initializers. Closures count as their enclosing function.
+func inspectCalls(file *ast.File, typesInfo *types.Info, qualifier types.Qualifier, fn func(from string, call *ast.CallExpr)) {
+	for _, decl := range file.Decls {
+		from := "<global>"
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			from = funcDeclName(fd, typesInfo, qualifier)
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				fn(from, call)
+			}
+			return true
+		})
+	}
+}
+
+// configSource returns the ConfigInput source of a call target, or "" when
+// the target reads no configuration.
+func configSource(target string) string {
+	switch target {
+	case "os.Getenv", "os.LookupEnv", "syscall.Getenv":
+		return ConfigEnv
+	}
+	if strings.HasPrefix(target, "viper.Get") {
+		return ConfigViper
+	}
+	return ""
+}
+
+// constantString returns the value of expr when it is a string literal or,
+// with type info, any string constant expression.
+func constantString(expr ast.Expr, typesInfo *types.Info) (string, bool) {
+	if typesInfo != nil {
+		if tv, ok := typesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return constant.StringVal(tv.Value), true
+		}
+	}
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// flagValueTypes maps the value-type part of flag and pflag registration
+// functions ("String" in flag.StringVar, pflag.StringP) to CLIFlag.Type.
+var flagValueTypes = map[string]string{
+	"Bool": "bool", "Duration": "duration", "Float32": "float32", "Float64": "float64",
+	"Int": "int", "Int8": "int8", "Int16": "int16", "Int32": "int32", "Int64": "int64",
+	"Uint": "uint", "Uint8": "uint8", "Uint16": "uint16", "Uint32": "uint32", "Uint64": "uint64",
+	"String": "string", "StringSlice": "stringSlice", "StringArray": "stringArray",
+	"StringToString": "stringToString", "IntSlice": "intSlice", "IP": "ip",
+}
+
+// extractCLIFlags records the command-line flags file registers through
+// flag.<Type>[Var] and pflag.<Type>[Var][P], package-level or on a
+// FlagSet. With type info cobra's cmd.Flags().StringP(...) resolves to
+// pflag; without it, registrations on a Flags() or PersistentFlags()
+// result are still recognized. From is the enclosing function as in
+// extractConfigInputs. Sorted by (name, from) and deduplicated.
+func extractCLIFlags(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) []CLIFlag {
+	seen := make(map[CLIFlag]bool)
+	var flags []CLIFlag
+	inspectCalls(file, typesInfo, qualifier, func(from string, call *ast.CallExpr) {
+		fn, ok := flagRegistration(call, typesInfo, pkg, qualifier)
+		if !ok {
+			return
+		}
+		f, ok := parseFlagCall(fn, call.Args, typesInfo)
+		if !ok {
+			return
+		}
+		f.From = from
+		if !seen[f] {
+			seen[f] = true
+			flags = append(flags, f)
+		}
+	})
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].Name != flags[j].Name {
+			return flags[i].Name < flags[j].Name
+		}
+		return flags[i].From < flags[j].From
+	})
+	return flags
+}
+
+// flagRegistration returns the function name of a flag or pflag
+// registration call ("StringVarP"), or false for any other call.
+func flagRegistration(call *ast.CallExpr, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) (string, bool) {
+	target := resolveCallTarget(call.Fun, typesInfo, pkg, qualifier)
+	for _, prefix := range []string{"flag.", "pflag."} {
+		if name, ok := strings.CutPrefix(target, prefix); ok {
+			return name, true
+		}
+	}
+	// AST fallback for cobra: <cmd>.Flags().<Name>(...).
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if inner, ok := sel.X.(*ast.CallExpr); ok {
+			if fs, ok := inner.Fun.(*ast.SelectorExpr); ok {
+				switch fs.Sel.Name {
+				case "Flags", "PersistentFlags", "LocalFlags":
+					return sel.Sel.Name, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// parseFlagCall reads the flag from the arguments of registration
+// function fn: [pointer,] name, [shorthand,] default, usage.
+func parseFlagCall(fn string, args []ast.Expr, typesInfo *types.Info) (CLIFlag, bool) {
+	var f CLIFlag
+	var suffix string
+	for _, suffix = range []string{"VarP", "Var", "P", ""} {
+		if t, ok := flagValueTypes[strings.TrimSuffix(fn, suffix)]; ok && strings.HasSuffix(fn, suffix) {
+			f.Type = t
+			break
+		}
+	}
+	if f.Type == "" {
+		return f, false
+	}
+	i := 0
+	if strings.HasPrefix(suffix, "Var") {
+		i++ // the destination pointer
+	}
+	want := i + 3
+	if strings.HasSuffix(suffix, "P") {
+		want++
+	}
+	if len(args) != want {
+		return f, false
+	}
+	name, ok := constantString(args[i], typesInfo)
+	if !ok {
+		return f, false
+	}
+	f.Name = name
+	i++
+	if strings.HasSuffix(suffix, "P") {
+		f.Shorthand, _ = constantString(args[i], typesInfo)
+		i++
+	}
+	if s, ok := constantString(args[i], typesInfo); ok {
+		f.Default = s
+	} else {
+		f.Default = types.ExprString(args[i])
+	}
+	f.Usage, _ = constantString(args[i+1], typesInfo)
+	return f, true
+}
+
+// countDroppedErrors counts expression statements that call a function
+// returning error as its last result, so the error is silently dropped.
+// fmt print functions and strings.Builder / bytes.Buffer methods, whose
+// errors are conventionally ignored, are skipped. It needs type info and
+// returns 0 when typesInfo is nil.
+func countDroppedErrors(file *ast.File, typesInfo *types.Info) int {
+	if typesInfo == nil {
+		return 0
+	}
+	errType := types.Universe.Lookup("error").Type()
+	n := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		stmt, ok := node.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		var result types.Type
+		switch t := typesInfo.TypeOf(call).(type) {
+		case *types.Tuple:
+			if t.Len() == 0 {
+				return true
+			}
+			result = t.At(t.Len() - 1).Type()
+		case nil:
+			return true
+		default:
+			result = t
+		}
+		if types.Identical(result, errType) && !errorConventionallyIgnored(call, typesInfo) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// errorConventionallyIgnored reports whether call is an fmt print function
+// or a strings.Builder / bytes.Buffer method.
+func errorConventionallyIgnored(call *ast.CallExpr, typesInfo *types.Info) bool {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return false
+	}
+	fn, ok := typesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	sig, _ := fn.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil {
+		return fn.Pkg().Path() == "fmt" && (strings.HasPrefix(fn.Name(), "Print") || strings.HasPrefix(fn.Name(), "Fprint"))
+	}
+	recv := sig.Recv().Type()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	switch named.Obj().Pkg().Path() + "." + named.Obj().Name() {
+	case "strings.Builder", "bytes.Buffer":
+		return true
+	}
+	return false
+}
+
+// hasTimeoutlessHTTPClient reports whether the file contains an http.Client
+// composite literal (value or &-address form) with no Timeout key.
+func hasTimeoutlessHTTPClient(file *ast.File) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || found || lit.Type == nil || exprToString(lit.Type) != "http.Client" {
+			return !found
+		}
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Timeout" {
+					return true
+				}
+			}
+		}
+		found = true
+		return false
+	})
+	return found
+}
+
+// defaultClientCalls are the net/http helpers that send through
+// http.DefaultClient.
+var defaultClientCalls = []string{"http.Get", "http.Head", "http.Post", "http.PostForm"}
+
+// usesDefaultHTTPClient reports whether callSet includes one of
+// defaultClientCalls or the file references http.DefaultClient.
+func usesDefaultHTTPClient(file *ast.File, callSet map[string]bool) bool {
+	for _, fn := range defaultClientCalls {
+		if callSet[fn] {
+			return true
+		}
+	}
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && exprToString(sel) == "http.DefaultClient" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// hasBlockingChannelOps reports whether the file has a channel send
+// (*ast.SendStmt) or receive (*ast.UnaryExpr with token.ARROW) that is not
+// the communication of a select case. Operations inside a case body are
+// still bare. Receives from a Done() call (context cancellation) are exempt.
+func hasBlockingChannelOps(file *ast.File) bool {
+	// First pass: the select-case communications, which cannot block forever
+	// on their own when the select has other cases.
+	inSelect := make(map[ast.Node]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		cc, ok := n.(*ast.CommClause)
+		if !ok || cc.Comm == nil {
+			return true
+		}
+		switch comm := cc.Comm.(type) {
+		case *ast.SendStmt:
+			inSelect[comm] = true
+		case *ast.ExprStmt:
+			inSelect[comm.X] = true
+		case *ast.AssignStmt:
+			for _, rhs := range comm.Rhs {
+				inSelect[rhs] = true
+			}
+		}
+		return true
+	})
+
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.SendStmt:
+			found = !inSelect[node]
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW && !inSelect[node] && !isDoneCall(node.X) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// largeSwitchThreshold is the case-clause count a switch must exceed to be
+// counted in Signals.LargeSwitches.
+const largeSwitchThreshold = 10
+
+// countLargeSwitches counts expression and type switches with more than
+// threshold case clauses (default included).
+func countLargeSwitches(file *ast.File, threshold int) int {
+	n := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch sw := node.(type) {
+		case *ast.SwitchStmt:
+			body = sw.Body
+		case *ast.TypeSwitchStmt:
+			body = sw.Body
+		}
+		if body != nil && len(body.List) > threshold {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// countUncheckedAssertions counts type assertions used for a single value.
+// An assertion is checked when it is the sole right-hand side of a
+// two-operand assignment or var declaration ("v, ok := x.(T)"); the
+// x.(type) guard of a type switch is not an assertion.
+func countUncheckedAssertions(file *ast.File) int {
+	checked := make(map[ast.Expr]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Lhs) == 2 && len(node.Rhs) == 1 {
+				checked[ast.Unparen(node.Rhs[0])] = true
+			}
+		case *ast.ValueSpec:
+			if len(node.Names) == 2 && len(node.Values) == 1 {
+				checked[ast.Unparen(node.Values[0])] = true
+			}
+		}
+		return true
+	})
+	n := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ta, ok := node.(*ast.TypeAssertExpr); ok && ta.Type != nil && !checked[ta] {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// hasReflectTagParsing reports whether the file names reflect.StructTag or
+// calls Get or Lookup on a .Tag selector, as in field.Tag.Get("json").
+func hasReflectTagParsing(file *ast.File) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := node.X.(*ast.Ident); ok && id.Name == "reflect" && node.Sel.Name == "StructTag" {
+				found = true
+			}
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "Get" && sel.Sel.Name != "Lookup") {
+				return true
+			}
+			if tag, ok := sel.X.(*ast.SelectorExpr); ok && tag.Sel.Name == "Tag" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isDoneCall reports whether expr is a call of a method named Done, as in
+// <-ctx.Done().
+func isDoneCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Done"
+}
+
+// syncTypeKinds maps sync and sync/atomic type names to concurrency kinds.
+var syncTypeKinds = map[string]string{
+	"sync.Map":       "sync_map",
+	"sync.Mutex":     "mutex",
+	"sync.Once":      "once",
+	"sync.RWMutex":   "rwmutex",
+	"sync.WaitGroup": "waitgroup",
+	"atomic.Value":   "atomic_value",
+	"atomic.Bool":    "atomic",
+	"atomic.Int32":   "atomic",
+	"atomic.Int64":   "atomic",
+	"atomic.Pointer": "atomic",
+	"atomic.Uint32":  "atomic",
+	"atomic.Uint64":  "atomic",
+	"atomic.Uintptr": "atomic",
+}
+
+// extractConcurrencyKinds classifies the synchronization primitives a file
+// uses. Type strings of struct fields (exported or not) and package-level
+// vars are matched against syncTypeKinds after stripping pointer, slice, and
+// type-argument decoration; calls to atomic.* functions (e.g. atomic.AddInt64)
+// add "atomic". Returns a sorted, deduplicated list.
+func extractConcurrencyKinds(file *ast.File, calls []Call) []string {
+	found := make(map[string]bool)
+	addType := func(expr ast.Expr) {
+		if kind, ok := syncKind(expr); ok {
+			found[kind] = true
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.StructType:
+			for _, field := range node.Fields.List {
+				addType(field.Type)
+			}
+		case *ast.ValueSpec:
+			if node.Type != nil {
+				addType(node.Type)
+			}
+		}
+		return true
+	})
+
+	for _, c := range calls {
+		if strings.HasPrefix(c.To, "atomic.") {
+			if kind, ok := syncTypeKinds[c.To]; ok {
+				found[kind] = true // conversion-like use of an atomic type
+			} else {
+				found["atomic"] = true
+			}
+		}
+	}
+
+	kinds := make([]string, 0, len(found))
+	for k := range found {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	if len(kinds) == 0 {
+		return nil
+	}
+	return kinds
+}
+
+// resilienceModules lists module paths of retry/backoff and circuit-breaker
+// libraries. Major-version suffixes (e.g. "/v4") and subpackages also match.
+var resilienceModules = []string{
+	"github.com/avast/retry-go",
+	"github.com/cenkalti/backoff",
+	"github.com/eapache/go-resiliency",
+	"github.com/hashicorp/go-retryablehttp",
+	"github.com/sony/gobreaker",
+}
+
+// isResilienceImport reports whether path is one of resilienceModules or a
+// package beneath it.
+func isResilienceImport(path string) bool {
+	for _, mod := range resilienceModules {
+		if path == mod || strings.HasPrefix(path, mod+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,18 @@ package evidence
 
 // bundle.go — EvidenceBundle type definitions.
 //
-// An evidence bundle captures four sections derived from static analysis:
+// An evidence bundle captures ten sections derived from static analysis:
 //
-//	package  — package name and sorted import list
-//	symbols  — all top-level declarations (functions, types, vars, consts)
-//	calls    — deduplicated, sorted outbound call graph for the file
-//	signals  — deterministic boolean heuristics (fs, db, net, concurrency)
+//	package       — package name and sorted import list
+//	symbols       — all top-level declarations (functions, types, vars, consts)
+//	calls         — deduplicated, sorted outbound call graph for the file
+//	config_inputs — environment variables and viper keys the file reads
+//	cli_flags     — command-line flags the file registers (flag, pflag, cobra)
+//	queries       — SQL string literals passed to Query/Exec (with db_calls)
+//	routes        — HTTP routes the file registers (net/http, gorilla, gin, chi)
+//	endpoints     — outbound HTTP requests to literal URLs
+//	concurrency   — goroutine launch sites, sync primitives, and channels
+//	signals       — deterministic boolean heuristics (fs, db, net, concurrency)
 //
 // Implementation separation (see INVARIANT.md INV-20..22):
 //
@@ -15,89 +21,338 @@ package evidence
 //	writeEvidenceBundle    — marshals + writes companion .evidence.yaml
 //	validateEvidenceBundle — re-hashes file, returns error if stale
 
+import "strings"
+
 // FileMeta holds the path and integrity hash of the analyzed source file.
+// SignalsSHA256 is the settings' SignalsSHA256 when Signals.Custom was
+// computed, empty when no signals were defined; a bundle is fresh only
+// while both hashes match.
 type FileMeta struct {
-	Path   string `yaml:"path"`
-	SHA256 string `yaml:"sha256"`
+	Path          string `yaml:"path" json:"path"`
+	SHA256        string `yaml:"sha256" json:"sha256"`
+	SignalsSHA256 string `yaml:"signals_sha256,omitempty" json:"signals_sha256,omitempty"`
 }
 
 // EvidenceBundle is the top-level container for an evidence bundle.
 // Field order matches the desired YAML output order; yaml.v3 respects struct
 // field order, so no additional sorting is needed at the top level.
+// JSON tags mirror the YAML keys so embedders get the same schema.
+// Language is empty for Go bundles; other analyzers set it (e.g. "python").
+// Files is set only on package bundles (see pkgbundle.go) and lists the
+// merged source files with their hashes.
 type EvidenceBundle struct {
-	Version int         `yaml:"version"`
-	File    FileMeta    `yaml:"file"`
-	Package PackageMeta `yaml:"package"`
-	Symbols Symbols     `yaml:"symbols"`
-	Calls   []Call      `yaml:"calls,omitempty"`
-	Signals Signals     `yaml:"signals"`
+	Version      int           `yaml:"version" json:"version"`
+	Language     string        `yaml:"language,omitempty" json:"language,omitempty"`
+	File         FileMeta      `yaml:"file" json:"file"`
+	Files        []FileMeta    `yaml:"files,omitempty" json:"files,omitempty"`
+	Package      PackageMeta   `yaml:"package" json:"package"`
+	Symbols      Symbols       `yaml:"symbols" json:"symbols"`
+	Calls        []Call        `yaml:"calls,omitempty" json:"calls,omitempty"`
+	ConfigInputs []ConfigInput `yaml:"config_inputs,omitempty" json:"config_inputs,omitempty"`
+	CLIFlags     []CLIFlag     `yaml:"cli_flags,omitempty" json:"cli_flags,omitempty"`
+	Queries      []Query       `yaml:"queries,omitempty" json:"queries,omitempty"`
+	Routes       []Route       `yaml:"routes,omitempty" json:"routes,omitempty"`
+	Endpoints    []Endpoint    `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+	Concurrency  *Concurrency  `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	Signals      Signals       `yaml:"signals" json:"signals"`
+}
+
+// LanguageGo is the language of bundles produced by this package.
+const LanguageGo = "go"
+
+// Lang returns the bundle's source language, defaulting to LanguageGo.
+func (b *EvidenceBundle) Lang() string {
+	if b.Language == "" {
+		return LanguageGo
+	}
+	return b.Language
 }
 
 // PackageMeta holds the package name and sorted import list.
+// Module is the path of the Go module the file belongs to.
+// GoGenerate lists the command text of //go:generate directives, sorted.
 type PackageMeta struct {
-	Name    string   `yaml:"name"`
-	Imports []Import `yaml:"imports,omitempty"`
+	Name       string   `yaml:"name" json:"name"`
+	Module     string   `yaml:"module,omitempty" json:"module,omitempty"`
+	Imports    []Import `yaml:"imports,omitempty" json:"imports,omitempty"`
+	GoGenerate []string `yaml:"go_generate,omitempty" json:"go_generate,omitempty"`
 }
 
 // Import represents a single import statement.
 // Alias is omitted from YAML when empty (no alias).
+// Class is "stdlib", "internal" (under the file's module path), or
+// "external"; empty when the bundle was built without a module context.
 type Import struct {
-	Path  string `yaml:"path"`
-	Alias string `yaml:"alias,omitempty"`
+	Path  string `yaml:"path" json:"path"`
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+	Class string `yaml:"class,omitempty" json:"class,omitempty"`
+}
+
+// Import classes (see ClassifyImport).
+const (
+	ImportStdlib   = "stdlib"
+	ImportInternal = "internal"
+	ImportExternal = "external"
+)
+
+// ClassifyImport tags an import path relative to module: paths equal to or
+// under module are internal, paths whose first segment has no dot are
+// stdlib, and everything else is external. The module check runs first so
+// dotless module names (e.g. "iguana") are still recognized.
+func ClassifyImport(path, module string) string {
+	if module != "" && (path == module || strings.HasPrefix(path, module+"/")) {
+		return ImportInternal
+	}
+	first, _, _ := strings.Cut(path, "/")
+	if !strings.Contains(first, ".") {
+		return ImportStdlib
+	}
+	return ImportExternal
 }
 
 // Symbols groups all top-level declarations in the file.
 type Symbols struct {
-	Functions    []Function `yaml:"functions,omitempty"`
-	Types        []TypeDecl `yaml:"types,omitempty"`
-	Variables    []VarDecl  `yaml:"variables,omitempty"`
-	Constants    []VarDecl  `yaml:"constants,omitempty"`
-	Constructors []string   `yaml:"constructors,omitempty"` // INV-49: functions returning package-local types
+	Functions    []Function `yaml:"functions,omitempty" json:"functions,omitempty"`
+	Types        []TypeDecl `yaml:"types,omitempty" json:"types,omitempty"`
+	Variables    []VarDecl  `yaml:"variables,omitempty" json:"variables,omitempty"`
+	Constants    []VarDecl  `yaml:"constants,omitempty" json:"constants,omitempty"`
+	Constructors []string   `yaml:"constructors,omitempty" json:"constructors,omitempty"` // INV-49: functions returning package-local types
+
+	// InterfaceAssertions records "var _ I = (*T)(nil)" satisfaction claims
+	// as "T:I", sorted.
+	InterfaceAssertions []string `yaml:"interface_assertions,omitempty" json:"interface_assertions,omitempty"`
+
+	// ErrorSentinels names package-level Err* variables initialized with
+	// errors.New or fmt.Errorf — the errors callers match with errors.Is —
+	// sorted.
+	ErrorSentinels []string `yaml:"error_sentinels,omitempty" json:"error_sentinels,omitempty"`
 }
 
 // Function describes a top-level function or method declaration.
 type Function struct {
-	Name     string   `yaml:"name"`
-	Exported bool     `yaml:"exported"`
-	Receiver string   `yaml:"receiver,omitempty"` // non-empty for methods
-	Params   []string `yaml:"params,omitempty"`
-	Returns  []string `yaml:"returns,omitempty"`
+	Name       string   `yaml:"name" json:"name"`
+	Exported   bool     `yaml:"exported" json:"exported"`
+	Receiver   string   `yaml:"receiver,omitempty" json:"receiver,omitempty"`       // non-empty for methods
+	TypeParams string   `yaml:"type_params,omitempty" json:"type_params,omitempty"` // generic functions only, e.g. "[K comparable, V any]"
+	Params     []string `yaml:"params,omitempty" json:"params,omitempty"`
+	Returns    []string `yaml:"returns,omitempty" json:"returns,omitempty"`
+}
+
+// ReceiverType returns the receiver's base type name for grouping methods by
+// type: pointer and value receivers of the same type ("*T" and "T") both map
+// to "T", and type arguments are dropped ("*List[E]" → "List"). Receiver
+// itself is left untouched for accuracy. Returns "" for plain functions.
+func (f Function) ReceiverType() string {
+	recv := strings.TrimPrefix(f.Receiver, "*")
+	if i := strings.IndexByte(recv, '['); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv
 }
 
 // FieldDecl describes a single exported field of a struct type.
 type FieldDecl struct {
-	Name    string `yaml:"name"`
-	TypeStr string `yaml:"type"`
+	Name    string `yaml:"name" json:"name"`
+	TypeStr string `yaml:"type" json:"type"`
 }
 
 // TypeDecl describes a top-level type declaration.
 type TypeDecl struct {
-	Name     string      `yaml:"name"`
-	Kind     string      `yaml:"kind"` // "struct" | "interface" | "alias"
-	Exported bool        `yaml:"exported"`
-	Fields   []FieldDecl `yaml:"fields,omitempty"` // INV-48: struct only, declaration order
+	Name       string      `yaml:"name" json:"name"`
+	Kind       string      `yaml:"kind" json:"kind"`                                   // "struct" | "interface" | "alias"
+	TypeParams string      `yaml:"type_params,omitempty" json:"type_params,omitempty"` // generic types only, e.g. "[E any]"
+	Exported   bool        `yaml:"exported" json:"exported"`
+	Underlying string      `yaml:"underlying,omitempty" json:"underlying,omitempty"` // type-info only: underlying type of non-struct/interface kinds
+	Fields     []FieldDecl `yaml:"fields,omitempty" json:"fields,omitempty"`         // INV-48: struct only, declaration order
+	Embeds     []string    `yaml:"embeds,omitempty" json:"embeds,omitempty"`         // interface only: embedded interfaces, declaration order
+	Implements []string    `yaml:"implements,omitempty" json:"implements,omitempty"` // type-info only: interfaces satisfied by T or *T, sorted
+	Methods    []Method    `yaml:"methods,omitempty" json:"methods,omitempty"`       // methods on T or *T declared in this file, or an interface's own methods; sorted
+}
+
+// Method describes one method of a type: a signature without a receiver.
+type Method struct {
+	Name     string   `yaml:"name" json:"name"`
+	Exported bool     `yaml:"exported" json:"exported"`
+	Params   []string `yaml:"params,omitempty" json:"params,omitempty"`
+	Returns  []string `yaml:"returns,omitempty" json:"returns,omitempty"`
 }
 
 // VarDecl describes a top-level variable or constant declaration.
 type VarDecl struct {
-	Name     string `yaml:"name"`
-	Exported bool   `yaml:"exported"`
+	Name     string `yaml:"name" json:"name"`
+	Exported bool   `yaml:"exported" json:"exported"`
+	TypeStr  string `yaml:"type,omitempty" json:"type,omitempty"` // declared or inferred type; empty when unknown or untyped
 }
 
 // Call represents a single deduplicated outbound function call.
 type Call struct {
-	From string `yaml:"from"` // enclosing function name
-	To   string `yaml:"to"`   // qualified call target
+	From string `yaml:"from" json:"from"` // enclosing function name
+	To   string `yaml:"to" json:"to"`     // qualified call target
+}
+
+// Configuration sources (ConfigInput.Source).
+const (
+	ConfigEnv   = "env"   // os.Getenv, os.LookupEnv, syscall.Getenv
+	ConfigViper = "viper" // viper.Get*, package-level or on a *viper.Viper
+)
+
+// ConfigInput is one configuration key the file reads by a constant name.
+// Keys computed at run time are not recorded.
+type ConfigInput struct {
+	Source string `yaml:"source" json:"source"` // ConfigEnv | ConfigViper
+	Key    string `yaml:"key" json:"key"`
+	From   string `yaml:"from" json:"from"` // enclosing function, as in Call.From
+}
+
+// CLIFlag is one command-line flag the file registers with the standard
+// flag package or spf13/pflag (which cobra commands use). Type is the
+// registering function's value type ("string", "duration", "stringSlice");
+// Default is the default value as written, or its string value when it
+// is a string constant. Flags with a computed name are not recorded.
+type CLIFlag struct {
+	Name      string `yaml:"name" json:"name"`
+	Shorthand string `yaml:"shorthand,omitempty" json:"shorthand,omitempty"`
+	Type      string `yaml:"type" json:"type"`
+	Default   string `yaml:"default,omitempty" json:"default,omitempty"`
+	Usage     string `yaml:"usage,omitempty" json:"usage,omitempty"`
+	From      string `yaml:"from" json:"from"` // enclosing function, as in Call.From
+}
+
+// Query is one constant SQL statement passed to a Query, QueryRow, or Exec
+// method (see extractQueries). SQL longer than MaxQueryLen is cut and
+// Truncated set; Writes and Reads are the tables the full statement
+// modifies and reads, lowercased and sorted.
+type Query struct {
+	SQL       string   `yaml:"sql" json:"sql"`
+	Truncated bool     `yaml:"truncated,omitempty" json:"truncated,omitempty"`
+	Writes    []string `yaml:"writes,omitempty" json:"writes,omitempty"`
+	Reads     []string `yaml:"reads,omitempty" json:"reads,omitempty"`
+	From      string   `yaml:"from" json:"from"` // enclosing function, as in Call.From
+}
+
+// Route is one HTTP route the file registers (see extractRoutes). Method
+// is empty when the route accepts any method.
+type Route struct {
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+	Path   string `yaml:"path" json:"path"`
+	From   string `yaml:"from" json:"from"` // enclosing function, as in Call.From
+}
+
+// Endpoint is one outbound HTTP request to a constant URL (see
+// extractEndpoints). Host is empty for relative URLs.
+type Endpoint struct {
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+	URL    string `yaml:"url" json:"url"`
+	Host   string `yaml:"host,omitempty" json:"host,omitempty"`
+	From   string `yaml:"from" json:"from"` // enclosing function, as in Call.From
+}
+
+// Concurrency inventories the file's concurrent code (see
+// extractConcurrency); Signals.Concurrency and Signals.ConcurrencyKinds
+// summarize it. Owner is the struct type declaring a field, the function
+// declaring a parameter or local, or "<global>" for package-level vars.
+type Concurrency struct {
+	Goroutines  []GoroutineSite `yaml:"goroutines,omitempty" json:"goroutines,omitempty"`
+	Primitives  []SyncPrimitive `yaml:"primitives,omitempty" json:"primitives,omitempty"`
+	Channels    []Channel       `yaml:"channels,omitempty" json:"channels,omitempty"`
+	AtomicCalls []string        `yaml:"atomic_calls,omitempty" json:"atomic_calls,omitempty"` // e.g. "atomic.AddInt64"
+}
+
+// GoroutineSite is one or more go statements in From launching Target (a
+// call target as in Call.To, or "<anonymous>" for a function literal).
+type GoroutineSite struct {
+	From   string `yaml:"from" json:"from"`
+	Target string `yaml:"target" json:"target"`
+	Count  int    `yaml:"count" json:"count"`
+}
+
+// SyncPrimitive is a named sync or sync/atomic value. Kind is one of the
+// Signals.ConcurrencyKinds names ("mutex", "rwmutex", "waitgroup", ...).
+type SyncPrimitive struct {
+	Owner string `yaml:"owner" json:"owner"`
+	Name  string `yaml:"name" json:"name"`
+	Kind  string `yaml:"kind" json:"kind"`
+}
+
+// Channel is a named channel. Dir is "both", "send", or "recv".
+type Channel struct {
+	Owner string `yaml:"owner" json:"owner"`
+	Name  string `yaml:"name" json:"name"`
+	Dir   string `yaml:"dir" json:"dir"`
+	Elem  string `yaml:"elem" json:"elem"`
 }
 
 // Signals are deterministic boolean heuristics derived from static analysis.
 // They are purely syntactic — no runtime inspection is performed.
 type Signals struct {
-	FSReads     bool `yaml:"fs_reads"`
-	FSWrites    bool `yaml:"fs_writes"`
-	DBCalls     bool `yaml:"db_calls"`
-	NetCalls    bool `yaml:"net_calls"`
-	Concurrency bool `yaml:"concurrency"`
-	YAMLio      bool `yaml:"yaml_io"` // INV-49: imports yaml library or calls yaml.*
-	JSONio      bool `yaml:"json_io"` // INV-49: imports encoding/json or calls json.*
+	FSReads     bool `yaml:"fs_reads" json:"fs_reads"`
+	FSWrites    bool `yaml:"fs_writes" json:"fs_writes"`
+	DBCalls     bool `yaml:"db_calls" json:"db_calls"`
+	NetCalls    bool `yaml:"net_calls" json:"net_calls"`
+	Concurrency bool `yaml:"concurrency" json:"concurrency"`
+	YAMLio      bool `yaml:"yaml_io" json:"yaml_io"`       // INV-49: imports yaml library or calls yaml.*
+	JSONio      bool `yaml:"json_io" json:"json_io"`       // INV-49: imports encoding/json or calls json.*
+	Resilience  bool `yaml:"resilience" json:"resilience"` // imports a retry/backoff or circuit-breaker library
+
+	// ConcurrencyKinds names the synchronization primitives in use, sorted:
+	// "atomic", "atomic_value", "mutex", "once", "rwmutex", "sync_map",
+	// "waitgroup". Distinguishes lock-free patterns from plain mutexes.
+	ConcurrencyKinds []string `yaml:"concurrency_kinds,omitempty" json:"concurrency_kinds,omitempty"`
+
+	// Custom names the user-defined signals (settings "signals") the file
+	// matches, sorted. Only set by WalkAndGenerate, which has the settings,
+	// and single-file analyze (see SetCustomSignals).
+	Custom []string `yaml:"custom,omitempty" json:"custom,omitempty"`
+
+	// ExecsSubprocess is set when the file imports os/exec or calls
+	// exec.Command, exec.CommandContext, or syscall.Exec: a process boundary.
+	ExecsSubprocess bool `yaml:"execs_subprocess,omitempty" json:"execs_subprocess,omitempty"`
+
+	// IgnoredErrors counts assignments that discard an error result to the
+	// blank identifier (e.g. "v, _ := strconv.Atoi(s)"). Type-info only:
+	// always 0 in the AST-only fallback.
+	IgnoredErrors int `yaml:"ignored_errors,omitempty" json:"ignored_errors,omitempty"`
+
+	// UnboundedHTTPClient is set when the file makes net calls and builds an
+	// http.Client composite literal without a Timeout field.
+	UnboundedHTTPClient bool `yaml:"unbounded_http_client,omitempty" json:"unbounded_http_client,omitempty"`
+
+	// UsesDefaultHTTPClient is set when the file calls the package-level
+	// http.Get, http.Head, http.Post, or http.PostForm, or references
+	// http.DefaultClient: requests with no timeout and no injectable client.
+	UsesDefaultHTTPClient bool `yaml:"uses_default_http_client,omitempty" json:"uses_default_http_client,omitempty"`
+
+	// BlockingChannelOps is set when a channel send or receive appears
+	// outside a select case, where it can block forever with no way to
+	// cancel. Waiting on <-x.Done() is not counted.
+	BlockingChannelOps bool `yaml:"blocking_channel_ops,omitempty" json:"blocking_channel_ops,omitempty"`
+
+	// LargeSwitches counts switch and type-switch statements with more than
+	// largeSwitchThreshold case clauses — likely polymorphism hotspots.
+	LargeSwitches int `yaml:"large_switches,omitempty" json:"large_switches,omitempty"`
+
+	// DynamicSerialization is set when the file reads struct tags via
+	// reflection (reflect.StructTag or x.Tag.Get/Lookup), i.e. a custom
+	// encoder that json_io and yaml_io do not see.
+	DynamicSerialization bool `yaml:"dynamic_serialization,omitempty" json:"dynamic_serialization,omitempty"`
+
+	// UncheckedAssertions counts single-value type assertions (x.(T) outside
+	// a "v, ok :=" form), which panic when the dynamic type does not match.
+	UncheckedAssertions int `yaml:"unchecked_assertions,omitempty" json:"unchecked_assertions,omitempty"`
+
+	// Panics is set when the file calls panic or log.Panic*; Recovers when
+	// it calls recover.
+	Panics   bool `yaml:"panics,omitempty" json:"panics,omitempty"`
+	Recovers bool `yaml:"recovers,omitempty" json:"recovers,omitempty"`
+
+	// Exits is set when the file ends the process directly: os.Exit,
+	// syscall.Exit, or log.Fatal*, which skip deferred calls.
+	Exits bool `yaml:"exits,omitempty" json:"exits,omitempty"`
+
+	// ErrorReturnsIgnored counts call statements whose error result is
+	// dropped without assignment (e.g. "f.Close()"), apart from fmt printing
+	// and strings.Builder / bytes.Buffer writes, which callers conventionally
+	// ignore. Deferred and go calls are not counted. Type-info only: always
+	// 0 in the AST-only fallback.
+	ErrorReturnsIgnored int `yaml:"error_returns_ignored,omitempty" json:"error_returns_ignored,omitempty"`
 }
@@ -0,0 +1,239 @@
+package evidence
+
+// bundlediff.go — Semantic diff of evidence bundles.
+//
+// DiffBundles compares two bundles of the same file section by section, so
+// a reviewer sees "Save gained a context parameter, net_calls turned on"
+// instead of a YAML diff. Hashes and key order are ignored; only content
+// that describes the source is compared. DiffTrees pairs the bundles of two
+// analyzed trees by source path.
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Bundle diff statuses.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// BundleDiff is the semantic difference between two bundles of one source
+// file. Status is DiffAdded or DiffRemoved when only one side has a bundle
+// (sections are then left empty), DiffChanged otherwise.
+type BundleDiff struct {
+	Path      string         `yaml:"path" json:"path"`
+	Status    string         `yaml:"status" json:"status"`
+	Package   *ValueChange   `yaml:"package,omitempty" json:"package,omitempty"`
+	Imports   SetDiff        `yaml:"imports,omitempty" json:"imports,omitzero"`
+	Functions SetDiff        `yaml:"functions,omitempty" json:"functions,omitzero"`
+	Types     SetDiff        `yaml:"types,omitempty" json:"types,omitzero"`
+	Variables SetDiff        `yaml:"variables,omitempty" json:"variables,omitzero"`
+	Constants SetDiff        `yaml:"constants,omitempty" json:"constants,omitzero"`
+	Calls     SetDiff        `yaml:"calls,omitempty" json:"calls,omitzero"`
+	Signals   []SignalChange `yaml:"signals,omitempty" json:"signals,omitempty"`
+}
+
+// SetDiff lists the keys of one section that were added, removed, or kept
+// with different content, each sorted. Functions are keyed "Recv.Name" for
+// methods, calls "from -> to".
+type SetDiff struct {
+	Added   []string `yaml:"added,omitempty" json:"added,omitempty"`
+	Removed []string `yaml:"removed,omitempty" json:"removed,omitempty"`
+	Changed []string `yaml:"changed,omitempty" json:"changed,omitempty"`
+}
+
+// IsZero reports whether d records no difference; it also lets omitempty
+// (YAML) and omitzero (JSON) drop empty sections.
+func (d SetDiff) IsZero() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ValueChange is a scalar that changed from From to To.
+type ValueChange struct {
+	From string `yaml:"from" json:"from"`
+	To   string `yaml:"to" json:"to"`
+}
+
+// SignalChange is one signal whose value differs, by its YAML name.
+type SignalChange struct {
+	Name string `yaml:"name" json:"name"`
+	From string `yaml:"from" json:"from"`
+	To   string `yaml:"to" json:"to"`
+}
+
+// DiffBundles returns the semantic difference from old to new, or nil when
+// they describe the same source. Either side may be nil for a file that
+// was added or removed. The diff's Path is new's (or old's) file path.
+func DiffBundles(old, new *EvidenceBundle) *BundleDiff {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return &BundleDiff{Path: new.File.Path, Status: DiffAdded}
+	case new == nil:
+		return &BundleDiff{Path: old.File.Path, Status: DiffRemoved}
+	}
+
+	d := &BundleDiff{Path: new.File.Path, Status: DiffChanged}
+	if old.Package.Name != new.Package.Name {
+		d.Package = &ValueChange{From: old.Package.Name, To: new.Package.Name}
+	}
+	d.Imports = diffKeyed(old.Package.Imports, new.Package.Imports, func(i Import) string { return i.Path })
+	d.Functions = diffKeyed(old.Symbols.Functions, new.Symbols.Functions, func(f Function) string {
+		if f.Receiver != "" {
+			return f.ReceiverType() + "." + f.Name
+		}
+		return f.Name
+	})
+	d.Types = diffKeyed(old.Symbols.Types, new.Symbols.Types, func(t TypeDecl) string { return t.Name })
+	d.Variables = diffKeyed(old.Symbols.Variables, new.Symbols.Variables, func(v VarDecl) string { return v.Name })
+	d.Constants = diffKeyed(old.Symbols.Constants, new.Symbols.Constants, func(v VarDecl) string { return v.Name })
+	d.Calls = diffKeyed(old.Calls, new.Calls, func(c Call) string { return c.From + " -> " + c.To })
+	d.Signals = diffSignals(old.Signals, new.Signals)
+
+	if d.Package == nil && d.Imports.IsZero() && d.Functions.IsZero() && d.Types.IsZero() &&
+		d.Variables.IsZero() && d.Constants.IsZero() && d.Calls.IsZero() && len(d.Signals) == 0 {
+		return nil
+	}
+	return d
+}
+
+// DiffBundleFiles reads two bundle files and diffs them (see DiffBundles).
+func DiffBundleFiles(oldPath, newPath string) (*BundleDiff, error) {
+	old, err := readBundle(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	new, err := readBundle(newPath)
+	if err != nil {
+		return nil, err
+	}
+	return DiffBundles(old, new), nil
+}
+
+// diffKeyed compares two sections by key: keys only in new are added, only
+// in old removed, and in both with unequal entries changed.
+func diffKeyed[T any](old, new []T, key func(T) string) SetDiff {
+	before := make(map[string]T, len(old))
+	for _, v := range old {
+		before[key(v)] = v
+	}
+	var d SetDiff
+	seen := make(map[string]bool, len(new))
+	for _, v := range new {
+		k := key(v)
+		seen[k] = true
+		prev, ok := before[k]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, k)
+		case !reflect.DeepEqual(prev, v):
+			d.Changed = append(d.Changed, k)
+		}
+	}
+	for k := range before {
+		if !seen[k] {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// diffSignals returns one change per Signals field that differs, in field
+// order.
+func diffSignals(old, new Signals) []SignalChange {
+	var changes []SignalChange
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, SignalChange{
+			Name: strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0],
+			From: fmt.Sprint(a),
+			To:   fmt.Sprint(b),
+		})
+	}
+	return changes
+}
+
+// DiffTrees compares the bundles under oldRoot with those under newRoot,
+// paired by their root-relative source paths, and returns one BundleDiff
+// per file that differs, sorted by path. Directories skipped by
+// WalkAndGenerate (INV-24) are not searched.
+func DiffTrees(oldRoot, newRoot string) ([]BundleDiff, error) {
+	before, err := treeBundles(oldRoot)
+	if err != nil {
+		return nil, err
+	}
+	after, err := treeBundles(newRoot)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool, len(before)+len(after))
+	for p := range before {
+		paths[p] = true
+	}
+	for p := range after {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var diffs []BundleDiff
+	for _, p := range sorted {
+		if d := DiffBundles(before[p], after[p]); d != nil {
+			d.Path = p
+			diffs = append(diffs, *d)
+		}
+	}
+	return diffs, nil
+}
+
+// treeBundles reads every bundle under root, keyed by the root-relative
+// path of its source file.
+func treeBundles(root string) (map[string]*EvidenceBundle, error) {
+	bundles := make(map[string]*EvidenceBundle)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path != root && skipWalkDir(d.Name(), rel, nil) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		src, ok := strings.CutSuffix(rel, ".evidence.yaml")
+		if !ok || IsTestBundle(rel) {
+			return nil
+		}
+		b, err := readBundle(path)
+		if err != nil {
+			return err
+		}
+		bundles[src] = b
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+	return bundles, nil
+}
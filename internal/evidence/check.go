@@ -0,0 +1,275 @@
+package evidence
+
+// check.go — Tree-wide bundle checks: freshness (INV-1, INV-2), orphans, and
+// ordering (INV-7..12). Checks are read-only; they never write or regenerate
+// bundles.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iguana/internal/settings"
+)
+
+// CheckFreshness walks root with the same rules as WalkAndGenerate and reports
+// every source file whose companion bundle is missing or was generated from
+// different content or signal definitions, and every package bundle whose Files differ from the
+// Go files now in its directory. Each problem is one line of the form
+// "<rel-path>: <reason>", sorted by path. It does not modify anything.
+func CheckFreshness(root string) ([]string, error) {
+	s, err := settings.LoadSettings(root)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	files, err := walkedFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	walked := make(map[string]bool, len(files))
+	for _, f := range files {
+		walked[f.rel] = true
+	}
+	checked := make(map[string]bool) // package bundles, checked once per directory
+	for _, f := range files {
+		if pkgProblem, ok := packageFilesProblem(filepath.Dir(f.abs), path.Dir(f.rel), walked, checked); ok {
+			problems = append(problems, pkgProblem)
+		}
+		raw, err := os.ReadFile(f.abs)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f.rel, err)
+		}
+		sum := sha256.Sum256(raw)
+		existing, err := readBundle(f.abs + ".evidence.yaml")
+		if os.IsNotExist(err) {
+			if meta, ok := packageFileMeta(filepath.Dir(f.abs), f.rel); ok {
+				existing, err = &EvidenceBundle{File: meta}, nil
+			}
+		}
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, f.rel+": missing evidence bundle")
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: unreadable evidence bundle: %v", f.rel, err))
+		case existing.File.SHA256 != hex.EncodeToString(sum[:]):
+			problems = append(problems, f.rel+": evidence bundle is stale")
+		case existing.File.SignalsSHA256 != s.SignalsSHA256():
+			problems = append(problems, f.rel+": evidence bundle is stale (signal definitions changed)")
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// packageFilesProblem reports a problem line if the package bundle in
+// absDir (root-relative relDir) records a Go file that is no longer walked,
+// e.g. one deleted since analyze. Files walked but not recorded are
+// reported by CheckFreshness as missing bundles. Each directory is checked
+// once, tracked in checked.
+func packageFilesProblem(absDir, relDir string, walked, checked map[string]bool) (string, bool) {
+	if checked[absDir] {
+		return "", false
+	}
+	checked[absDir] = true
+	b, err := readBundle(filepath.Join(absDir, PackageBundleName+".evidence.yaml"))
+	if err != nil || !b.IsPackageBundle() {
+		return "", false
+	}
+	var gone []string
+	for _, f := range b.Files {
+		if !walked[f.Path] {
+			gone = append(gone, path.Base(f.Path))
+		}
+	}
+	if len(gone) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s: evidence bundle is stale (%s no longer analyzed)", path.Join(relDir, PackageBundleName), strings.Join(gone, ", ")), true
+}
+
+// CheckOrphans walks root with the same directory rules as WalkAndGenerate
+// and reports every *.evidence.yaml whose source file no longer exists, one
+// "<rel-path>: orphaned evidence bundle" line each, sorted by path, where
+// rel-path names the missing source. A package bundle is orphaned once all
+// its files are gone; until then each deleted file it still records is
+// reported instead. It does not modify anything.
+func CheckOrphans(root string) ([]string, error) {
+	s, err := settings.LoadSettings(root)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	var problems []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path != root && skipWalkDir(d.Name(), rel, s) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		suffix := ".evidence.yaml"
+		if IsTestBundle(path) {
+			suffix = TestBundleSuffix
+		}
+		src, ok := strings.CutSuffix(path, suffix)
+		if !ok {
+			return nil
+		}
+		if d.Name() == PackageBundleName+".evidence.yaml" {
+			if b, err := readBundle(path); err == nil && b.IsPackageBundle() {
+				var gone []string
+				for _, f := range b.Files {
+					if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.Path))); os.IsNotExist(err) {
+						gone = append(gone, f.Path+": orphaned evidence bundle")
+					}
+				}
+				if len(gone) == len(b.Files) {
+					gone = []string{strings.TrimSuffix(rel, suffix) + ": orphaned evidence bundle"}
+				}
+				problems = append(problems, gone...)
+				return nil
+			}
+		}
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			problems = append(problems, strings.TrimSuffix(rel, suffix)+": orphaned evidence bundle")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// CheckOrder loads every existing companion bundle for the files
+// WalkAndGenerate would analyze and reports sort-order violations
+// (INV-7..12), one "<rel-path>: <violation>" line each.
+func CheckOrder(root string) ([]string, error) {
+	files, err := walkedFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	checked := make(map[string]bool) // package bundles, checked once per directory
+	for _, f := range files {
+		name, rel := f.abs+".evidence.yaml", f.rel
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			name = filepath.Join(filepath.Dir(f.abs), PackageBundleName+".evidence.yaml")
+			rel = path.Join(path.Dir(f.rel), PackageBundleName)
+			if checked[name] {
+				continue
+			}
+			checked[name] = true
+		}
+		b, err := readBundle(name)
+		if err != nil {
+			continue // missing or unreadable bundles are CheckFreshness's concern
+		}
+		for _, v := range orderViolations(b) {
+			problems = append(problems, rel+": "+v)
+		}
+	}
+	return problems, nil
+}
+
+// orderViolations returns a description of each sorted-section invariant
+// (INV-7..12) that b violates.
+func orderViolations(b *EvidenceBundle) []string {
+	var out []string
+	check := func(section string, n int, less func(i, j int) bool) {
+		for i := 1; i < n; i++ {
+			if less(i, i-1) {
+				out = append(out, section+" not sorted")
+				return
+			}
+		}
+	}
+	check("package.imports", len(b.Package.Imports), func(i, j int) bool {
+		return b.Package.Imports[i].Path < b.Package.Imports[j].Path
+	})
+	check("package.go_generate", len(b.Package.GoGenerate), func(i, j int) bool {
+		return b.Package.GoGenerate[i] < b.Package.GoGenerate[j]
+	})
+	check("symbols.functions", len(b.Symbols.Functions), func(i, j int) bool {
+		return b.Symbols.Functions[i].Name < b.Symbols.Functions[j].Name
+	})
+	check("symbols.types", len(b.Symbols.Types), func(i, j int) bool {
+		return b.Symbols.Types[i].Name < b.Symbols.Types[j].Name
+	})
+	check("symbols.variables", len(b.Symbols.Variables), func(i, j int) bool {
+		return b.Symbols.Variables[i].Name < b.Symbols.Variables[j].Name
+	})
+	check("symbols.constants", len(b.Symbols.Constants), func(i, j int) bool {
+		return b.Symbols.Constants[i].Name < b.Symbols.Constants[j].Name
+	})
+	check("symbols.interface_assertions", len(b.Symbols.InterfaceAssertions), func(i, j int) bool {
+		return b.Symbols.InterfaceAssertions[i] < b.Symbols.InterfaceAssertions[j]
+	})
+	check("symbols.error_sentinels", len(b.Symbols.ErrorSentinels), func(i, j int) bool {
+		return b.Symbols.ErrorSentinels[i] < b.Symbols.ErrorSentinels[j]
+	})
+	check("calls", len(b.Calls), func(i, j int) bool {
+		if b.Calls[i].From != b.Calls[j].From {
+			return b.Calls[i].From < b.Calls[j].From
+		}
+		return b.Calls[i].To < b.Calls[j].To
+	})
+	return out
+}
+
+// walkedFile pairs a source file's filesystem path with its root-relative,
+// forward-slash path (INV-23).
+type walkedFile struct {
+	abs, rel string
+}
+
+// walkedFiles returns the files WalkAndGenerate would analyze under root,
+// sorted by relative path.
+func walkedFiles(root string) ([]walkedFile, error) {
+	s, err := settings.LoadSettings(root)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	filesByDir, err := collectGoFiles(root, s, fileFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+	var files []walkedFile
+	for _, paths := range filesByDir {
+		for _, p := range paths {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return nil, fmt.Errorf("rel path %s: %w", p, err)
+			}
+			files = append(files, walkedFile{abs: p, rel: filepath.ToSlash(rel)})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, nil
+}
+
+// readBundle reads and decodes the evidence bundle at path (see Decode).
+// The returned error satisfies os.IsNotExist when the file is absent.
+func readBundle(path string) (*EvidenceBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return b, nil
+}
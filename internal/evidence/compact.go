@@ -0,0 +1,64 @@
+package evidence
+
+// compact.go — Corpus-wide rewrite of bundles into the compact profile.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// CompactBundles rewrites the companion bundle of every file WalkAndGenerate
+// would analyze under root in the SchemaCompact profile. A bundle is only
+// rewritten when its stored hash still matches the source (INV-2), and its
+// content is otherwise unchanged; stale bundles are reported in errs and
+// left alone. Files without a bundle are skipped. Returns the number of
+// bundles whose bytes changed.
+func CompactBundles(root string) (rewritten int, errs []error) {
+	files, err := walkedFiles(root)
+	if err != nil {
+		return 0, []error{err}
+	}
+	for _, f := range files {
+		bundlePath := f.abs + ".evidence.yaml"
+		existing, err := os.ReadFile(bundlePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read %s: %w", bundlePath, err))
+			continue
+		}
+		b, err := readBundle(bundlePath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		raw, err := os.ReadFile(f.abs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read %s: %w", f.rel, err))
+			continue
+		}
+		sum := sha256.Sum256(raw)
+		if b.File.SHA256 != hex.EncodeToString(sum[:]) {
+			errs = append(errs, fmt.Errorf("%s: evidence bundle is stale; regenerate before compacting", f.rel))
+			continue
+		}
+		data, err := MarshalBundle(b, SchemaCompact)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("marshal %s: %w", f.rel, err))
+			continue
+		}
+		if bytes.Equal(data, existing) {
+			continue
+		}
+		if err := os.WriteFile(bundlePath, data, 0o644); err != nil {
+			errs = append(errs, fmt.Errorf("write %s: %w", bundlePath, err))
+			continue
+		}
+		rewritten++
+	}
+	return rewritten, errs
+}
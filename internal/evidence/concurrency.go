@@ -0,0 +1,182 @@
+package evidence
+
+// concurrency.go — Inventory of goroutines, sync primitives, and channels.
+//
+// Where Signals.Concurrency only says that a file is concurrent, the
+// concurrency section names what it uses: each go statement's launching
+// function and target, each mutex, WaitGroup, Once, or atomic value
+// declared as a struct field, parameter, or variable, each named channel
+// with its direction and element type, and the sync/atomic functions
+// called. Everything is syntactic; types come from the declarations.
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// extractConcurrency builds the concurrency section of file, or returns nil
+// when the file has no goroutines, primitives, channels, or atomic calls.
+func extractConcurrency(file *ast.File, calls []Call, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) *Concurrency {
+	var c Concurrency
+	goroutines := make(map[[2]string]int)
+
+	addVar := func(owner, name string, typ, value ast.Expr) {
+		if typ == nil {
+			typ = valueType(value)
+		}
+		switch t := typ.(type) {
+		case nil:
+		case *ast.ChanType:
+			c.Channels = append(c.Channels, Channel{Owner: owner, Name: name, Dir: chanDir(t.Dir), Elem: exprToString(t.Value)})
+		default:
+			if kind, ok := syncKind(typ); ok {
+				c.Primitives = append(c.Primitives, SyncPrimitive{Owner: owner, Name: name, Kind: kind})
+			}
+		}
+	}
+	addFields := func(owner string, fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			if len(field.Names) == 0 { // embedded, e.g. sync.Mutex
+				name := exprToString(field.Type)
+				addVar(owner, strings.TrimLeft(name[strings.LastIndexByte(name, '.')+1:], "*"), field.Type, nil)
+			}
+			for _, n := range field.Names {
+				addVar(owner, n.Name, field.Type, nil)
+			}
+		}
+	}
+	addSpec := func(owner string, vs *ast.ValueSpec) {
+		for i, n := range vs.Names {
+			var value ast.Expr
+			if i < len(vs.Values) {
+				value = vs.Values[i]
+			}
+			addVar(owner, n.Name, vs.Type, value)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if st, ok := s.Type.(*ast.StructType); ok {
+						addFields(s.Name.Name, st.Fields)
+					}
+				case *ast.ValueSpec:
+					addSpec("<global>", s)
+				}
+			}
+		case *ast.FuncDecl:
+			from := funcDeclName(d, typesInfo, qualifier)
+			addFields(from, d.Type.Params)
+			if d.Body == nil {
+				continue
+			}
+			ast.Inspect(d.Body, func(n ast.Node) bool {
+				switch node := n.(type) {
+				case *ast.GoStmt:
+					target := resolveCallTarget(node.Call.Fun, typesInfo, pkg, qualifier)
+					if target == "" {
+						target = "<anonymous>"
+					}
+					goroutines[[2]string{from, target}]++
+				case *ast.ValueSpec:
+					addSpec(from, node)
+				case *ast.AssignStmt:
+					if node.Tok != token.DEFINE || len(node.Lhs) != len(node.Rhs) {
+						break
+					}
+					for i, lhs := range node.Lhs {
+						if id, ok := lhs.(*ast.Ident); ok && id.Name != "_" {
+							addVar(from, id.Name, nil, node.Rhs[i])
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	for k, n := range goroutines {
+		c.Goroutines = append(c.Goroutines, GoroutineSite{From: k[0], Target: k[1], Count: n})
+	}
+	for _, call := range calls {
+		if _, isType := syncTypeKinds[call.To]; strings.HasPrefix(call.To, "atomic.") && !isType {
+			c.AtomicCalls = append(c.AtomicCalls, call.To)
+		}
+	}
+	if len(c.Goroutines)+len(c.Primitives)+len(c.Channels)+len(c.AtomicCalls) == 0 {
+		return nil
+	}
+
+	sort.Slice(c.Goroutines, func(i, j int) bool {
+		a, b := c.Goroutines[i], c.Goroutines[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.Target < b.Target
+	})
+	sort.Slice(c.Primitives, func(i, j int) bool {
+		a, b := c.Primitives[i], c.Primitives[j]
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(c.Channels, func(i, j int) bool {
+		a, b := c.Channels[i], c.Channels[j]
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		return a.Name < b.Name
+	})
+	sort.Strings(c.AtomicCalls)
+	return &c
+}
+
+// valueType returns the type of a variable initialized to value when it is
+// evident from the syntax: make(chan T), T{}, &T{}, or new(T).
+func valueType(value ast.Expr) ast.Expr {
+	if u, ok := value.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		value = u.X
+	}
+	switch v := value.(type) {
+	case *ast.CompositeLit:
+		return v.Type
+	case *ast.CallExpr:
+		if id, ok := v.Fun.(*ast.Ident); ok && (id.Name == "make" || id.Name == "new") && len(v.Args) > 0 {
+			return v.Args[0]
+		}
+	}
+	return nil
+}
+
+// syncKind returns the concurrency kind of a sync or sync/atomic type
+// expression, ignoring pointer, slice, and type-argument decoration.
+func syncKind(typ ast.Expr) (string, bool) {
+	ts := strings.TrimLeft(exprToString(typ), "*[]")
+	if i := strings.IndexByte(ts, '['); i >= 0 {
+		ts = ts[:i]
+	}
+	kind, ok := syncTypeKinds[ts]
+	return kind, ok
+}
+
+// chanDir names a channel direction.
+func chanDir(dir ast.ChanDir) string {
+	switch dir {
+	case ast.SEND:
+		return "send"
+	case ast.RECV:
+		return "recv"
+	}
+	return "both"
+}
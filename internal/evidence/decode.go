@@ -0,0 +1,105 @@
+package evidence
+
+// decode.go — Bundle schema versions and version-aware decoding.
+//
+// Every bundle records the schema version it was written at (INV-3).
+// Decode is the one way to read a bundle: it inspects version, migrates
+// older documents forward one version at a time until they reach
+// CurrentVersion, and rejects versions it does not know — in particular
+// bundles written by a newer iguana — with an error wrapping
+// ErrUnsupportedVersion instead of silently dropping their fields.
+//
+// Version 1 is the original layout: file, package, symbols, calls, and
+// signals under the same keys as today, without the sections version 2
+// added. Migrations work on the generic YAML document so a future version
+// can rename or restructure keys.
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the EvidenceBundle.Version every writer emits.
+const CurrentVersion = 2
+
+// ErrUnsupportedVersion is wrapped by Decode errors for bundles with a
+// missing, unknown, or newer version.
+var ErrUnsupportedVersion = errors.New("unsupported evidence bundle version")
+
+// bundleMigrations is the schema registry: the migration at key v upgrades
+// a version v document to version v+1. Every version below CurrentVersion
+// must have one.
+var bundleMigrations = map[int]func(doc map[string]any) error{
+	1: migrateV1,
+}
+
+// migrateV1 upgrades a version 1 document. Version 2 only added keys, all
+// optional, so the sections carry over unchanged.
+func migrateV1(doc map[string]any) error {
+	return nil
+}
+
+// Decode unmarshals a YAML evidence bundle of any supported version,
+// migrating it to CurrentVersion.
+func Decode(data []byte) (*EvidenceBundle, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	v, err := documentVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if v != CurrentVersion {
+		for ; v < CurrentVersion; v++ {
+			if err := bundleMigrations[v](doc); err != nil {
+				return nil, fmt.Errorf("migrate version %d: %w", v, err)
+			}
+		}
+		doc["version"] = CurrentVersion
+		if data, err = yaml.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+	var b EvidenceBundle
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// documentVersion returns the version of a decoded bundle document, or an
+// error if Decode cannot bring it to CurrentVersion.
+func documentVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["version"]
+	if !ok {
+		return 0, fmt.Errorf("%w: no version key", ErrUnsupportedVersion)
+	}
+	v, ok := raw.(int)
+	if !ok {
+		return 0, fmt.Errorf("%w: version %v is not an integer", ErrUnsupportedVersion, raw)
+	}
+	if err := checkVersion(v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// checkVersion returns an error unless v is CurrentVersion or an older
+// version with a migration path.
+func checkVersion(v int) error {
+	switch {
+	case v > CurrentVersion:
+		return fmt.Errorf("%w: version %d is newer than %d; upgrade iguana to read it", ErrUnsupportedVersion, v, CurrentVersion)
+	case v == CurrentVersion:
+		return nil
+	}
+	for u := v; u < CurrentVersion; u++ {
+		if bundleMigrations[u] == nil {
+			return fmt.Errorf("%w: version %d", ErrUnsupportedVersion, v)
+		}
+	}
+	return nil
+}
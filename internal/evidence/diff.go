@@ -0,0 +1,153 @@
+package evidence
+
+// diff.go — git-aware analysis: regenerate only files changed since a base
+// ref and record them in a change manifest, or verify HEAD is at a pinned
+// commit.
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"iguana/internal/canonical"
+)
+
+// ChangeManifestName is the file GenerateChanged writes under root.
+const ChangeManifestName = "changes.yaml"
+
+// FileChange is one .go file that differs from the base ref. Status is the
+// git status letter ("A", "M", or "D"; a rename is a deletion plus an
+// addition). Bundle is the companion bundle path, empty for deletions.
+type FileChange struct {
+	Path   string `yaml:"path"`
+	Status string `yaml:"status"`
+	Bundle string `yaml:"bundle,omitempty"`
+}
+
+// ChangeManifest lists the .go files changed between Base and HEAD, sorted
+// by path. Paths are root-relative with forward slashes.
+type ChangeManifest struct {
+	Base  string       `yaml:"base"`
+	Files []FileChange `yaml:"files,omitempty"`
+}
+
+// VerifyPinnedCommit resolves pin (a full or abbreviated SHA, tag, or other
+// revision) in the repository containing dir and returns an error unless it
+// names the same commit as HEAD.
+func VerifyPinnedCommit(dir, pin string) error {
+	want, err := gitRevParse(dir, pin+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("resolve pinned commit %s: %w", pin, err)
+	}
+	head, err := gitRevParse(dir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	if head != want {
+		return fmt.Errorf("HEAD is at %s, not pinned commit %s (%s)", head, pin, want)
+	}
+	return nil
+}
+
+// gitRevParse returns the full object name rev resolves to in dir.
+func gitRevParse(dir, rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "--end-of-options", rev)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitChanges runs "git diff --name-status --relative base..HEAD" in root and
+// returns the changed .go files (test files excluded, as in WalkAndGenerate).
+// base must name a commit; it is resolved first so it is never read as an
+// option, and paths are read NUL-separated so git does not quote them.
+func GitChanges(root, base string) ([]FileChange, error) {
+	commit, err := gitRevParse(root, base+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("resolve base %s: %w", base, err)
+	}
+	cmd := exec.Command("git", "diff", "-z", "--name-status", "--relative", "--no-renames", "--end-of-options", commit+"..HEAD")
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s..HEAD: %w: %s", base, err, strings.TrimSpace(stderr.String()))
+	}
+	// Records are status NUL path NUL, with a trailing NUL.
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var changes []FileChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+		if status == "" || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		changes = append(changes, FileChange{Path: filepath.ToSlash(path), Status: status[:1]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// GenerateChanged regenerates bundles only for the .go files changed since
+// base, removes bundles of deleted files, and writes the manifest to
+// root/changes.yaml. The walk is limited to the changed paths, within
+// opts.Include when it is set, and bundles are always rewritten; built-in
+// and settings skips still apply. A change's Bundle is set only when its
+// bundle was written.
+func GenerateChanged(root, base string, opts WalkOptions) (manifest *ChangeManifest, written int, errs []error) {
+	changes, err := GitChanges(root, base)
+	if err != nil {
+		return nil, 0, []error{err}
+	}
+
+	paths := map[string]bool{}
+	for _, c := range changes {
+		if c.Status == "D" {
+			bundle := filepath.Join(root, filepath.FromSlash(c.Path)) + ".evidence.yaml"
+			if err := os.Remove(bundle); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("remove %s: %w", bundle, err))
+			}
+			continue
+		}
+		paths[c.Path] = true
+	}
+
+	if len(paths) > 0 {
+		var mu sync.Mutex
+		generated := map[string]bool{}
+		opts.paths = paths
+		opts.onWrite = func(rel string) {
+			mu.Lock()
+			generated[rel] = true
+			mu.Unlock()
+		}
+		opts.Force = true
+		opts.Clean = false
+		w, _, walkErrs := WalkAndGenerate(root, opts)
+		written = w
+		errs = append(errs, walkErrs...)
+		for i, c := range changes {
+			if generated[c.Path] {
+				changes[i].Bundle = c.Path + ".evidence.yaml"
+			}
+		}
+	}
+
+	manifest = &ChangeManifest{Base: base, Files: changes}
+	data, err := canonical.MarshalYAML(manifest)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(root, ChangeManifestName), data, 0o644)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("write %s: %w", ChangeManifestName, err))
+	}
+	return manifest, written, errs
+}
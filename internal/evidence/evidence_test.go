@@ -16,17 +16,26 @@ package evidence
 //   INV-20..22 Generation/serialization/validation separation
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"maps"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"golang.org/x/tools/go/packages"
 	"gopkg.in/yaml.v3"
 )
 
@@ -55,6 +64,27 @@ var noTypePkg *types.Package = nil
 // nullQualifier always returns the package name; used when pkg is nil.
 func nullQualifier(p *types.Package) string { return p.Name() }
 
+// checkSource parses and type-checks a self-contained Go source string,
+// returning the AST file with its *types.Info and *types.Package.
+func checkSource(t *testing.T, src string) (*ast.File, *types.Info, *types.Package) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", src, 0)
+	if err != nil {
+		t.Fatalf("checkSource parse: %v", err)
+	}
+	info := &types.Info{
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+		Types: make(map[ast.Expr]types.TypeAndValue),
+	}
+	pkg, err := new(types.Config).Check("pkg", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatalf("checkSource check: %v", err)
+	}
+	return f, info, pkg
+}
+
 // --------------------------------------------------------------------------
 // Unit tests — exprToString
 // --------------------------------------------------------------------------
@@ -285,6 +315,108 @@ func TestExtractPackageMeta_NoImports(t *testing.T) {
 	}
 }
 
+func TestClassifyImport(t *testing.T) {
+	tests := []struct {
+		path, module, want string
+	}{
+		{"os", "iguana", ImportStdlib},
+		{"net/http", "github.com/acme/app", ImportStdlib},
+		{"iguana/internal/settings", "iguana", ImportInternal},
+		{"github.com/acme/app/store", "github.com/acme/app", ImportInternal},
+		{"gopkg.in/yaml.v3", "iguana", ImportExternal},
+		{"github.com/acme/application", "github.com/acme/app", ImportExternal},
+		{"iguana/internal/settings", "", ImportStdlib}, // no module context
+	}
+	for _, tt := range tests {
+		if got := ClassifyImport(tt.path, tt.module); got != tt.want {
+			t.Errorf("ClassifyImport(%q, %q) = %q, want %q", tt.path, tt.module, got, tt.want)
+		}
+	}
+}
+
+// TestCreateEvidenceBundle_ImportClasses verifies that imports are classified
+// against the module path found in the nearest go.mod.
+func TestCreateEvidenceBundle_ImportClasses(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "api")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "api.go")
+	code := `package api
+
+import (
+	_ "example.com/app/store"
+	_ "github.com/acme/lib"
+	_ "os"
+)
+`
+	if err := os.WriteFile(src, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := CreateEvidenceBundle(src)
+	if err != nil {
+		t.Fatalf("CreateEvidenceBundle: %v", err)
+	}
+	got := make(map[string]string)
+	for _, imp := range b.Package.Imports {
+		got[imp.Path] = imp.Class
+	}
+	want := map[string]string{
+		"example.com/app/store": ImportInternal,
+		"github.com/acme/lib":   ImportExternal,
+		"os":                    ImportStdlib,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("import classes = %v, want %v", got, want)
+	}
+}
+
+// TestExtractPackageMeta_GoGenerate verifies that //go:generate directive
+// commands are captured (text only) and sorted.
+func TestExtractPackageMeta_GoGenerate(t *testing.T) {
+	src := `package store
+
+//go:generate stringer -type=Kind
+//go:generate mockgen -source=store.go -destination=mock_store.go
+
+// Store is not a directive: go:generate must start the comment.
+type Store struct{}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "store.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := extractPackageMeta(f)
+
+	want := []string{
+		"mockgen -source=store.go -destination=mock_store.go",
+		"stringer -type=Kind",
+	}
+	if !reflect.DeepEqual(meta.GoGenerate, want) {
+		t.Errorf("GoGenerate = %v, want %v", meta.GoGenerate, want)
+	}
+}
+
+func TestExtractPackageMeta_NoGoGenerate(t *testing.T) {
+	src := `package store
+
+// Store holds data.
+type Store struct{}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "store.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractPackageMeta(f).GoGenerate; got != nil {
+		t.Errorf("GoGenerate = %v, want nil", got)
+	}
+}
+
 // --------------------------------------------------------------------------
 // Unit tests — extractSymbols
 // --------------------------------------------------------------------------
@@ -342,6 +474,25 @@ func (s *Server) Start() error { return nil }
 	}
 }
 
+// TestFunctionReceiverType verifies receiver normalization for grouping:
+// pointer and value receivers share a base type and type arguments are dropped.
+func TestFunctionReceiverType(t *testing.T) {
+	tests := []struct {
+		recv, want string
+	}{
+		{"*Server", "Server"},
+		{"Server", "Server"},
+		{"*List[E]", "List"},
+		{"Pair[K, V]", "Pair"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := (Function{Receiver: tt.recv}).ReceiverType(); got != tt.want {
+			t.Errorf("ReceiverType(%q) = %q, want %q", tt.recv, got, tt.want)
+		}
+	}
+}
+
 // TestExtractSymbols_Types verifies type declarations (INV-9, INV-16).
 func TestExtractSymbols_Types(t *testing.T) {
 	src := `package pkg
@@ -375,6 +526,261 @@ type Middle = string
 	}
 }
 
+// TestExtractSymbols_InterfaceAssertions verifies that "var _ I = (*T)(nil)"
+// is recorded as a satisfaction claim while an ordinary blank var is not.
+func TestExtractSymbols_InterfaceAssertions(t *testing.T) {
+	src := `package pkg
+type Writer interface{ Write(p []byte) (int, error) }
+type Buffer struct{}
+func (b *Buffer) Write(p []byte) (int, error) { return len(p), nil }
+func compute() int { return 1 }
+var _ Writer = (*Buffer)(nil)
+var _ = compute()
+var _ int = 0
+`
+	for _, tc := range []struct {
+		name string
+		info func() (*ast.File, *types.Info)
+	}{
+		{"ast-only", func() (*ast.File, *types.Info) { return parseSource(t, src), nil }},
+		{"type-info", func() (*ast.File, *types.Info) {
+			f, info, _ := checkSource(t, src)
+			return f, info
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, info := tc.info()
+			syms := extractSymbols(f, info, noTypePkg, nullQualifier)
+			want := []string{"Buffer:Writer"}
+			if !reflect.DeepEqual(syms.InterfaceAssertions, want) {
+				t.Errorf("InterfaceAssertions = %v, want %v", syms.InterfaceAssertions, want)
+			}
+		})
+	}
+}
+
+// TestExtractSymbols_Implements verifies that type-checked extraction lists
+// the interfaces a type satisfies through its value or pointer method set,
+// skipping empty ones, and that AST-only extraction lists none.
+func TestExtractSymbols_Implements(t *testing.T) {
+	src := `package pkg
+
+type Any interface{}
+type Sizer interface{ Size() int }
+type Writer interface{ Write(p []byte) (int, error) }
+type Buffer struct{}
+func (b *Buffer) Write(p []byte) (int, error) { return len(p), nil }
+func (b Buffer) Size() int { return 0 }
+type Plain struct{}
+`
+	f, info, pkg := checkSource(t, src)
+	got := make(map[string][]string)
+	for _, td := range extractSymbols(f, info, pkg, makeQualifier(pkg)).Types {
+		got[td.Name] = td.Implements
+	}
+	if want := []string{"Sizer", "Writer"}; !reflect.DeepEqual(got["Buffer"], want) {
+		t.Errorf("Buffer implements %v, want %v", got["Buffer"], want)
+	}
+	if got["Plain"] != nil || got["Sizer"] != nil {
+		t.Errorf("Plain implements %v, Sizer implements %v, want none", got["Plain"], got["Sizer"])
+	}
+	for _, td := range extractSymbols(parseSource(t, src), noTypeInfo, noTypePkg, nullQualifier).Types {
+		if td.Implements != nil {
+			t.Errorf("AST-only %s implements %v, want none", td.Name, td.Implements)
+		}
+	}
+}
+
+// TestExtractSymbols_Methods verifies that value and pointer methods attach
+// to their type in name order, interface methods to the interface, and
+// methods on types declared elsewhere to no type.
+func TestExtractSymbols_Methods(t *testing.T) {
+	src := `package pkg
+
+type Store interface {
+	Put(key string, v []byte) error
+	Get(key string) ([]byte, error)
+}
+type Mem struct{}
+func (m *Mem) Put(key string, v []byte) error { return nil }
+func (m Mem) Get(key string) ([]byte, error) { return nil, nil }
+func (m *Mem) reset() {}
+func NewMem() *Mem { return &Mem{} }
+func (e *elsewhere) Name() string { return "" }
+type Elsewhere int
+`
+
+	want := map[string][]Method{
+		"Store": {
+			{Name: "Get", Exported: true, Params: []string{"string"}, Returns: []string{"[]byte", "error"}},
+			{Name: "Put", Exported: true, Params: []string{"string", "[]byte"}, Returns: []string{"error"}},
+		},
+		"Mem": {
+			{Name: "Get", Exported: true, Params: []string{"string"}, Returns: []string{"[]byte", "error"}},
+			{Name: "Put", Exported: true, Params: []string{"string", "[]byte"}, Returns: []string{"error"}},
+			{Name: "reset"},
+		},
+		"Elsewhere": nil,
+	}
+	for _, td := range extractSymbols(parseSource(t, src), noTypeInfo, noTypePkg, nullQualifier).Types {
+		if !reflect.DeepEqual(td.Methods, want[td.Name]) {
+			t.Errorf("%s methods = %+v, want %+v", td.Name, td.Methods, want[td.Name])
+		}
+	}
+}
+
+// TestExtractSymbols_Generics verifies that type parameter lists are
+// rendered with their constraints for generic functions and types, the same
+// with and without type info, and that receivers keep their type arguments.
+func TestExtractSymbols_Generics(t *testing.T) {
+	src := `package pkg
+
+type Number interface{ ~int | ~float64 }
+type List[E any] struct{ items []E }
+type Pair[K comparable, V any] struct{}
+func (l *List[E]) Push(v E) {}
+func Map[T, U any](xs []T, f func(T) U) []U { return nil }
+func Sum[N Number](xs []N) N { var n N; return n }
+`
+	for _, tc := range []struct {
+		name string
+		syms func() Symbols
+	}{
+		{"ast-only", func() Symbols {
+			return extractSymbols(parseSource(t, src), noTypeInfo, noTypePkg, nullQualifier)
+		}},
+		{"type-info", func() Symbols {
+			f, info, pkg := checkSource(t, src)
+			return extractSymbols(f, info, pkg, makeQualifier(pkg))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			syms := tc.syms()
+			funcs := make(map[string]Function)
+			for _, fn := range syms.Functions {
+				funcs[fn.Name] = fn
+			}
+			types := make(map[string]string)
+			for _, td := range syms.Types {
+				types[td.Name] = td.TypeParams
+			}
+			wantFuncs := map[string]string{"Map": "[T any, U any]", "Sum": "[N Number]", "Push": ""}
+			for name, want := range wantFuncs {
+				if got := funcs[name].TypeParams; got != want {
+					t.Errorf("%s type params = %q, want %q", name, got, want)
+				}
+			}
+			wantTypes := map[string]string{"List": "[E any]", "Pair": "[K comparable, V any]", "Number": ""}
+			for name, want := range wantTypes {
+				if got := types[name]; got != want {
+					t.Errorf("type %s type params = %q, want %q", name, got, want)
+				}
+			}
+			if got := funcs["Push"].Receiver; got != "*List[E]" {
+				t.Errorf("Push receiver = %q, want *List[E]", got)
+			}
+		})
+	}
+}
+
+// TestExtractCalls_Instantiations verifies that explicitly instantiated
+// generic calls are attributed to the generic function, while an indexed
+// function value is only unwrapped when type info shows it is not one.
+func TestExtractCalls_Instantiations(t *testing.T) {
+	src := `package pkg
+
+func Map[T, U any](xs []T, f func(T) U) []U { return nil }
+func First[T any](xs []T) T { var t T; return t }
+func run(handlers []func()) {
+	Map[int, string](nil, nil)
+	First[int](nil)
+	handlers[0]()
+}
+`
+	has := func(calls []Call, to string) bool {
+		for _, c := range calls {
+			if c.From == "run" && c.To == to {
+				return true
+			}
+		}
+		return false
+	}
+
+	astCalls := extractCalls(parseSource(t, src), noTypeInfo, noTypePkg, nullQualifier)
+	if !has(astCalls, "Map") {
+		t.Errorf("AST-only calls = %v, want run -> Map", astCalls)
+	}
+
+	f, info, pkg := checkSource(t, src)
+	calls := extractCalls(f, info, pkg, makeQualifier(pkg))
+	for _, to := range []string{"Map", "First"} {
+		if !has(calls, to) {
+			t.Errorf("type-info calls = %v, want run -> %s", calls, to)
+		}
+	}
+	if has(calls, "handlers") {
+		t.Errorf("type-info calls = %v, want no call to handlers", calls)
+	}
+}
+
+// TestExtractSymbols_TypeInfoKinds compares AST-only and type-info
+// classification. A defined type over a struct alias looks like an alias to
+// the AST but is a struct to the type checker, and a defined integer type
+// records its underlying type only when type info is available.
+func TestExtractSymbols_TypeInfoKinds(t *testing.T) {
+	src := `package pkg
+
+type Base struct{ Name string }
+type BaseAlias = Base
+type Derived BaseAlias
+type Status int
+type Doer interface{ Do() }
+type Runner Doer
+`
+	kinds := func(syms Symbols) map[string]TypeDecl {
+		m := make(map[string]TypeDecl)
+		for _, td := range syms.Types {
+			m[td.Name] = td
+		}
+		return m
+	}
+
+	astOnly := kinds(extractSymbols(parseSource(t, src), noTypeInfo, noTypePkg, nullQualifier))
+	f, info, pkg := checkSource(t, src)
+	withInfo := kinds(extractSymbols(f, info, pkg, makeQualifier(pkg)))
+
+	tests := []struct {
+		name                          string
+		astKind, infoKind             string
+		astUnderlying, infoUnderlying string
+	}{
+		{"Base", "struct", "struct", "", ""},
+		{"Derived", "alias", "struct", "", ""},
+		{"Status", "alias", "alias", "", "int"},
+		{"Doer", "interface", "interface", "", ""},
+		{"Runner", "alias", "interface", "", ""},
+	}
+	for _, tt := range tests {
+		a, w := astOnly[tt.name], withInfo[tt.name]
+		if a.Kind != tt.astKind || a.Underlying != tt.astUnderlying {
+			t.Errorf("AST-only %s: kind=%q underlying=%q, want %q/%q",
+				tt.name, a.Kind, a.Underlying, tt.astKind, tt.astUnderlying)
+		}
+		if w.Kind != tt.infoKind || w.Underlying != tt.infoUnderlying {
+			t.Errorf("type-info %s: kind=%q underlying=%q, want %q/%q",
+				tt.name, w.Kind, w.Underlying, tt.infoKind, tt.infoUnderlying)
+		}
+	}
+
+	// A kind taken from the base type brings the base type's members.
+	if want := []FieldDecl{{Name: "Name", TypeStr: "string"}}; !reflect.DeepEqual(withInfo["Derived"].Fields, want) {
+		t.Errorf("type-info Derived fields = %+v, want %+v", withInfo["Derived"].Fields, want)
+	}
+	if got, want := withInfo["Runner"].Methods, withInfo["Doer"].Methods; len(got) != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("type-info Runner methods = %+v, want Doer's %+v", got, want)
+	}
+}
+
 // TestExtractSymbols_VarsConsts verifies var/const declarations (INV-10, INV-11, INV-17).
 func TestExtractSymbols_VarsConsts(t *testing.T) {
 	src := `package pkg
@@ -406,6 +812,40 @@ const aye = "a"
 	}
 }
 
+// TestExtractSymbols_VarConstTypes verifies that variables and constants
+// record their written type, or the type-checked one, and that untyped
+// constants record none.
+func TestExtractSymbols_VarConstTypes(t *testing.T) {
+	src := `package pkg
+
+type Level int
+type Client struct{}
+
+func New() *Client { return nil }
+
+var Default = New()
+var names []string
+
+const Debug Level = 1
+const max = 10
+`
+	f, info, pkg := checkSource(t, src)
+	syms := extractSymbols(f, info, pkg, makeQualifier(pkg))
+	wantVars := []VarDecl{{Name: "Default", Exported: true, TypeStr: "*Client"}, {Name: "names", TypeStr: "[]string"}}
+	if !reflect.DeepEqual(syms.Variables, wantVars) {
+		t.Errorf("variables = %+v, want %+v", syms.Variables, wantVars)
+	}
+	wantConsts := []VarDecl{{Name: "Debug", Exported: true, TypeStr: "Level"}, {Name: "max"}}
+	if !reflect.DeepEqual(syms.Constants, wantConsts) {
+		t.Errorf("constants = %+v, want %+v", syms.Constants, wantConsts)
+	}
+
+	syms = extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+	if got := syms.Variables[0].TypeStr; got != "" {
+		t.Errorf("inferred type without type info = %q, want none", got)
+	}
+}
+
 // --------------------------------------------------------------------------
 // Unit tests — extractSignals
 // --------------------------------------------------------------------------
@@ -868,7 +1308,7 @@ func TestWalkAndGenerate_Basic(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	written, _, errs := WalkAndGenerate(root, false)
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
 	if len(errs) != 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -899,6 +1339,105 @@ func TestWalkAndGenerate_Basic(t *testing.T) {
 	}
 }
 
+// walkFixture is a multi-directory tree with skipped directories and files.
+var walkFixture = map[string]string{
+	"main.go":          "package main\nfunc main() {}\n",
+	"pkg/a.go":         "package pkg\nimport \"os\"\nfunc A() { os.Exit(0) }\n",
+	"pkg/a_test.go":    "package pkg\n",
+	"pkg/sub/b.go":     "package sub\nfunc B() {}\n",
+	"pkg-two/c.go":     "package two\nfunc C() {}\n",
+	"vendor/dep/d.go":  "package dep\n",
+	".hidden/e.go":     "package hidden\n",
+	"pkg/sub/notes.md": "not go\n",
+}
+
+// generateTree writes files under a fresh root, runs WalkAndGenerate with
+// opts, and returns the written bundles keyed by root-relative path.
+func generateTree(t *testing.T, files map[string]string, opts WalkOptions) map[string]string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, errs := WalkAndGenerate(root, opts); len(errs) != 0 {
+		t.Fatalf("WalkAndGenerate(%+v): %v", opts, errs)
+	}
+	bundles := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".evidence.yaml") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		rel, _ := filepath.Rel(root, path)
+		bundles[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundles
+}
+
+// TestWalkAndGenerate_StreamMatchesBatch verifies that streaming mode writes
+// exactly the bundles batch mode writes over a multi-directory tree.
+func TestWalkAndGenerate_StreamMatchesBatch(t *testing.T) {
+	batch := generateTree(t, walkFixture, WalkOptions{})
+	stream := generateTree(t, walkFixture, WalkOptions{Stream: true})
+	if len(batch) != 4 {
+		t.Errorf("batch wrote %d bundles, want 4", len(batch))
+	}
+	if !reflect.DeepEqual(batch, stream) {
+		t.Errorf("stream bundles differ from batch:\nbatch:  %v\nstream: %v", batch, stream)
+	}
+}
+
+// TestWalkAndGenerate_ConcurrencyBudget verifies that loading several
+// directories at once writes exactly the bundles of one-at-a-time mode.
+func TestWalkAndGenerate_ConcurrencyBudget(t *testing.T) {
+	serial := generateTree(t, walkFixture, WalkOptions{})
+	for _, opts := range []WalkOptions{
+		{ConcurrencyBudget: 3},
+		{ConcurrencyBudget: 3, Stream: true},
+	} {
+		if got := generateTree(t, walkFixture, opts); !reflect.DeepEqual(got, serial) {
+			t.Errorf("%+v: bundles differ from serial:\nserial: %v\ngot:    %v", opts, serial, got)
+		}
+	}
+}
+
+// TestWalkAndGenerate_Progress verifies that the listing and every analyzed
+// directory are reported, ending with all files handled, in both modes.
+func TestWalkAndGenerate_Progress(t *testing.T) {
+	for _, opts := range []WalkOptions{{}, {Stream: true, ConcurrencyBudget: 3}} {
+		var events []Progress
+		opts.Progress = func(p Progress) { events = append(events, p) }
+		generateTree(t, walkFixture, opts)
+
+		var dirs []string
+		for _, p := range events {
+			if p.Phase == PhaseAnalyze {
+				dirs = append(dirs, p.Dir)
+			}
+		}
+		slices.Sort(dirs)
+		if want := []string{".", "pkg", "pkg-two", "pkg/sub"}; !reflect.DeepEqual(dirs, want) {
+			t.Errorf("stream=%v: analyzed dirs = %v, want %v", opts.Stream, dirs, want)
+		}
+		if last := events[len(events)-1]; last.Files != 4 || last.Total != 4 {
+			t.Errorf("stream=%v: last event %+v, want 4/4 files", opts.Stream, last)
+		}
+		if !opts.Stream && (events[0].Phase != PhaseWalk || events[0].Total != 4) {
+			t.Errorf("first event %+v, want walk with total 4", events[0])
+		}
+	}
+}
+
 // TestWalkAndGenerate_SkipsVendor verifies that a vendor/ subdirectory is not
 // processed during directory walking (INV-24).
 func TestWalkAndGenerate_SkipsVendor(t *testing.T) {
@@ -921,7 +1460,7 @@ func TestWalkAndGenerate_SkipsVendor(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	written, _, errs := WalkAndGenerate(root, false)
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
 	if len(errs) != 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -954,7 +1493,7 @@ func TestWalkAndGenerate_RelativePaths(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Remove(subFile + ".evidence.yaml") })
 
-	written, _, errs := WalkAndGenerate(root, false)
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
 	if len(errs) != 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -986,51 +1525,320 @@ func TestWalkAndGenerate_RelativePaths(t *testing.T) {
 	}
 }
 
-// --------------------------------------------------------------------------
-// Unit tests — extractSymbols constructors (INV-49)
-// --------------------------------------------------------------------------
-
-// TestConstructorsExtracted verifies that a function returning a package-local
-// type is included in symbols.constructors (INV-49).
-func TestConstructorsExtracted(t *testing.T) {
-	src := `package pkg
-
-type Widget struct{}
+// TestWalkAndGenerate_Include verifies that a non-empty include list limits
+// analysis to files matching at least one glob.
+func TestWalkAndGenerate_Include(t *testing.T) {
+	root := t.TempDir()
 
-func NewWidget() *Widget { return nil }
-func helper() int       { return 0 }
-`
-	f := parseSource(t, src)
-	syms := extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+	files := map[string]string{
+		"main.go":                 "package main\nfunc main() {}\n",
+		"internal/store/store.go": "package store\nfunc Save() {}\n",
+		"internal/api/api.go":     "package api\nfunc Serve() {}\n",
+		"cmd/tool/tool.go":        "package main\nfunc Run() {}\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-	if len(syms.Constructors) != 1 {
-		t.Fatalf("expected 1 constructor, got %d: %v", len(syms.Constructors), syms.Constructors)
+	written, _, errs := WalkAndGenerate(root, WalkOptions{Include: []string{"internal/**/*.go"}})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
 	}
-	if syms.Constructors[0] != "NewWidget" {
-		t.Errorf("constructor = %q, want %q", syms.Constructors[0], "NewWidget")
+	if written != 2 {
+		t.Errorf("written = %d, want 2", written)
+	}
+
+	for name := range files {
+		companion := filepath.Join(root, filepath.FromSlash(name)) + ".evidence.yaml"
+		_, err := os.Stat(companion)
+		want := strings.HasPrefix(name, "internal/")
+		if got := err == nil; got != want {
+			t.Errorf("%s: companion exists = %v, want %v", name, got, want)
+		}
 	}
 }
 
-// TestConstructors_SliceReturn verifies that a function returning []LocalType
-// is also recognized as a constructor (INV-49).
-func TestConstructors_SliceReturn(t *testing.T) {
-	src := `package pkg
+// TestWalkAndGenerate_Clean verifies that Clean removes a bundle whose source
+// file no longer exists, while a normal run leaves it in place.
+func TestWalkAndGenerate_Clean(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "kept.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(root, "deleted.go.evidence.yaml")
+	if err := os.WriteFile(orphan, []byte("version: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
 
-type Item struct{}
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("orphan bundle should survive a normal run: %v", err)
+	}
 
-func NewItems() []Item { return nil }
-`
-	f := parseSource(t, src)
-	syms := extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+	written, _, errs := WalkAndGenerate(root, WalkOptions{Clean: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("orphan bundle should be removed by a clean run (err=%v)", err)
+	}
+	// The cleaned bundle for kept.go is regenerated, not skipped.
+	if written != 1 {
+		t.Errorf("written = %d, want 1", written)
+	}
 
-	if !containsStr(syms.Constructors, "NewItems") {
-		t.Errorf("expected NewItems in constructors, got %v", syms.Constructors)
+	// With include globs a clean would drop bundles the walk never
+	// regenerates, so the combination is rejected before anything is removed.
+	_, _, errs = WalkAndGenerate(root, WalkOptions{Clean: true, Include: []string{"other.go"}})
+	if len(errs) != 1 {
+		t.Fatalf("errs = %v, want one error for clean with include", errs)
+	}
+	if _, err := os.Stat(filepath.Join(root, "kept.go.evidence.yaml")); err != nil {
+		t.Errorf("bundle removed by a rejected clean: %v", err)
 	}
 }
 
-// TestConstructors_MethodNotConstructor verifies that methods (with receivers)
-// are never listed as constructors (INV-49).
-func TestConstructors_MethodNotConstructor(t *testing.T) {
+// memStore is an in-memory BundleStore for walk tests.
+type memStore map[string]string // path → "sha256\x00data"
+
+func (m memStore) Has(path, sha256 string) (bool, error) {
+	v, ok := m[path]
+	return ok && strings.HasPrefix(v, sha256+"\x00"), nil
+}
+
+func (m memStore) Put(path, sha256 string, data []byte) error {
+	m[path] = sha256 + "\x00" + string(data)
+	return nil
+}
+
+// TestWalkAndGenerate_Store verifies that a walk with a Store writes every
+// bundle to it instead of companion files, skips bundles the store holds
+// at the current hash, and rejects package and test bundles.
+func TestWalkAndGenerate_Store(t *testing.T) {
+	root := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go":     "package main\n",
+		"sub/b.go": "package sub\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := memStore{}
+	written, _, errs := WalkAndGenerate(root, WalkOptions{Store: st})
+	if len(errs) != 0 || written != 2 {
+		t.Fatalf("written = %d, errs = %v; want 2, none", written, errs)
+	}
+	if got := slices.Sorted(maps.Keys(st)); !slices.Equal(got, []string{"a.go", "sub/b.go"}) {
+		t.Errorf("stored paths = %v", got)
+	}
+	b, err := Decode([]byte(st["sub/b.go"][strings.IndexByte(st["sub/b.go"], 0)+1:]))
+	if err != nil || b.Package.Name != "sub" {
+		t.Errorf("stored bundle = %+v, %v", b, err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*.evidence.yaml")); len(matches) != 0 {
+		t.Errorf("companion bundles written with a store: %v", matches)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package main\n\nfunc A() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	written, skipped, errs := WalkAndGenerate(root, WalkOptions{Store: st})
+	if len(errs) != 0 || written != 1 || skipped != 1 {
+		t.Errorf("second run: written = %d, skipped = %d, errs = %v; want 1, 1, none", written, skipped, errs)
+	}
+
+	for _, opts := range []WalkOptions{{Store: st, PerPackage: true}, {Store: st, IncludeTests: true}} {
+		if _, _, errs := WalkAndGenerate(root, opts); len(errs) != 1 {
+			t.Errorf("%+v: errs = %v, want one", opts, errs)
+		}
+	}
+}
+
+// TestWalkAndGenerate_OnBundleHook verifies that a repo's on_bundle command
+// does not run until the global settings trust it, that it then runs once
+// per written bundle with {file} substituted, and that a failing hook is
+// reported without stopping generation.
+func TestWalkAndGenerate_OnBundleHook(t *testing.T) {
+	if _, err := exec.LookPath("touch"); err != nil {
+		t.Skip("touch not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSettings := func(yaml string) {
+		if err := os.MkdirAll(filepath.Join(root, ".iguana"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, ".iguana", "settings.yaml"), []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeSettings("on_bundle: [touch, \"{file}.hooked\"]\n")
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 || written != 2 {
+		t.Fatalf("untrusted: written = %d, errs = %v", written, errs)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*.hooked")); len(matches) != 0 {
+		t.Fatalf("untrusted repo hook ran: %v", matches)
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".iguana"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".iguana", "settings.yaml"), []byte("trust_repo_hooks: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	written, _, errs = WalkAndGenerate(root, WalkOptions{Force: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if written != 2 {
+		t.Fatalf("written = %d, want 2", written)
+	}
+	for _, name := range []string{"a.go.hooked", "b.go.hooked"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("hook marker %s missing: %v", name, err)
+		}
+	}
+
+	writeSettings("on_bundle: [\"iguana-no-such-hook\"]\n")
+	written, _, errs = WalkAndGenerate(root, WalkOptions{Force: true})
+	if written != 2 {
+		t.Errorf("written = %d, want 2 despite failing hook", written)
+	}
+	if len(errs) != 2 {
+		t.Errorf("errs = %v, want one per bundle", errs)
+	}
+}
+
+// TestWalkAndGenerate_CustomSignals verifies that settings-defined signals
+// are recorded in signals.custom of the bundles whose imports or calls match.
+func TestWalkAndGenerate_CustomSignals(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":                  "package main\n\nimport \"crypto/sha256\"\n\nvar _ = sha256.Sum256\n",
+		"b.go":                  "package main\n\nimport \"strconv\"\n\nfunc B() { strconv.Itoa(1) }\n",
+		"c.go":                  "package main\n\nfunc C() {}\n",
+		".iguana/settings.yaml": "signals:\n  - name: crypto\n    imports: [crypto/]\n  - name: conversion\n    calls: [\"strconv.*\"]\n",
+	}
+	for rel, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for name, want := range map[string][]string{"a.go": {"crypto"}, "b.go": {"conversion"}, "c.go": nil} {
+		b, err := readBundle(filepath.Join(root, name+".evidence.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(b.Signals.Custom, want) {
+			t.Errorf("%s: custom = %v, want %v", name, b.Signals.Custom, want)
+		}
+	}
+
+	// Editing the definitions makes every bundle stale, for check and for
+	// an incremental walk alike.
+	if err := os.WriteFile(filepath.Join(root, ".iguana", "settings.yaml"), []byte("signals:\n  - name: parsing\n    calls: [\"strconv.*\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := CheckFreshness(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 3 || problems[0] != "a.go: evidence bundle is stale (signal definitions changed)" {
+		t.Errorf("problems = %v, want three stale bundles", problems)
+	}
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if written != 3 {
+		t.Errorf("written = %d, want 3", written)
+	}
+	b, err := readBundle(filepath.Join(root, "b.go.evidence.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"parsing"}; !reflect.DeepEqual(b.Signals.Custom, want) {
+		t.Errorf("b.go: custom = %v, want %v", b.Signals.Custom, want)
+	}
+	if problems, err := CheckFreshness(root); err != nil || len(problems) != 0 {
+		t.Errorf("after regenerating: problems = %v, err = %v", problems, err)
+	}
+}
+
+// --------------------------------------------------------------------------
+// Unit tests — extractSymbols constructors (INV-49)
+// --------------------------------------------------------------------------
+
+// TestConstructorsExtracted verifies that a function returning a package-local
+// type is included in symbols.constructors (INV-49).
+func TestConstructorsExtracted(t *testing.T) {
+	src := `package pkg
+
+type Widget struct{}
+
+func NewWidget() *Widget { return nil }
+func helper() int       { return 0 }
+`
+	f := parseSource(t, src)
+	syms := extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+
+	if len(syms.Constructors) != 1 {
+		t.Fatalf("expected 1 constructor, got %d: %v", len(syms.Constructors), syms.Constructors)
+	}
+	if syms.Constructors[0] != "NewWidget" {
+		t.Errorf("constructor = %q, want %q", syms.Constructors[0], "NewWidget")
+	}
+}
+
+// TestConstructors_SliceReturn verifies that a function returning []LocalType
+// is also recognized as a constructor (INV-49).
+func TestConstructors_SliceReturn(t *testing.T) {
+	src := `package pkg
+
+type Item struct{}
+
+func NewItems() []Item { return nil }
+`
+	f := parseSource(t, src)
+	syms := extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+
+	if !containsStr(syms.Constructors, "NewItems") {
+		t.Errorf("expected NewItems in constructors, got %v", syms.Constructors)
+	}
+}
+
+// TestConstructors_MethodNotConstructor verifies that methods (with receivers)
+// are never listed as constructors (INV-49).
+func TestConstructors_MethodNotConstructor(t *testing.T) {
 	src := `package pkg
 
 type Foo struct{}
@@ -1185,6 +1993,77 @@ type ID = string
 	}
 }
 
+// TestErrorSentinels verifies that Err* vars built with errors.New or
+// fmt.Errorf are recorded, sorted, and other vars are not.
+func TestErrorSentinels(t *testing.T) {
+	src := `package pkg
+import (
+	"errors"
+	"fmt"
+)
+var ErrNotFound = errors.New("not found")
+var (
+	ErrClosed = fmt.Errorf("closed: %w", errBase)
+	errBase   = errors.New("base")
+	ErrCount  = 3
+	Errs      []error
+)
+`
+	syms := extractSymbols(parseSource(t, src), nil, nil, nullQualifier)
+	if want := []string{"ErrClosed", "ErrNotFound"}; !reflect.DeepEqual(syms.ErrorSentinels, want) {
+		t.Errorf("ErrorSentinels = %v, want %v", syms.ErrorSentinels, want)
+	}
+}
+
+// TestErrorSentinels_NonErrorVar verifies that an ordinary var is not a
+// sentinel.
+func TestErrorSentinels_NonErrorVar(t *testing.T) {
+	src := `package pkg
+var Limit = 10
+`
+	if got := extractSymbols(parseSource(t, src), nil, nil, nullQualifier).ErrorSentinels; got != nil {
+		t.Errorf("ErrorSentinels = %v, want none", got)
+	}
+}
+
+// TestInterfaceEmbeds verifies that embedded interfaces are recorded in
+// declaration order, apart from declared methods, and that a plain interface
+// has none.
+func TestInterfaceEmbeds(t *testing.T) {
+	src := `package pkg
+
+import "io"
+
+type Flusher interface{ Flush() error }
+
+type ReadWriteFlusher interface {
+	io.Reader
+	Flush() error
+	io.Writer
+	Flusher
+}
+
+type Number interface{ ~int | ~float64 }
+`
+	f := parseSource(t, src)
+	syms := extractSymbols(f, noTypeInfo, noTypePkg, nullQualifier)
+
+	embeds := make(map[string][]string)
+	for _, td := range syms.Types {
+		embeds[td.Name] = td.Embeds
+	}
+	want := []string{"io.Reader", "io.Writer", "Flusher"}
+	if !reflect.DeepEqual(embeds["ReadWriteFlusher"], want) {
+		t.Errorf("ReadWriteFlusher embeds = %v, want %v", embeds["ReadWriteFlusher"], want)
+	}
+	if embeds["Flusher"] != nil {
+		t.Errorf("Flusher embeds = %v, want none", embeds["Flusher"])
+	}
+	if embeds["Number"] != nil {
+		t.Errorf("Number embeds = %v, want none for type-set terms", embeds["Number"])
+	}
+}
+
 // TestStructFields_EmbeddedExported verifies that embedded exported types are
 // captured with their type name as the field name (INV-48).
 func TestStructFields_EmbeddedExported(t *testing.T) {
@@ -1311,331 +2190,2233 @@ func f() {}
 	}
 }
 
-// --------------------------------------------------------------------------
-// Fuzz tests
-// --------------------------------------------------------------------------
+// TestExtractSignals_ResilienceImport verifies resilience is set when a
+// retry/backoff library is imported, including major-version module paths.
+func TestExtractSignals_ResilienceImport(t *testing.T) {
+	src := `package pkg
+import (
+	"net/http"
+	_ "github.com/cenkalti/backoff/v4"
+)
+func f() { http.Get("x") }
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
 
-// FuzzExprToString verifies exprToString never panics on arbitrary AST exprs.
-// The fuzzer drives node selection by index.
-func FuzzExprToString(f *testing.F) {
-	// Seed with a few representative expressions
-	f.Add(0, "int")
-	f.Add(1, "Server")
-	f.Add(2, "os")
+	if !sig.Resilience {
+		t.Error("expected resilience = true when github.com/cenkalti/backoff/v4 is imported")
+	}
+}
 
-	f.Fuzz(func(t *testing.T, kind int, name string) {
-		var expr ast.Expr
-		switch kind % 6 {
-		case 0:
-			expr = &ast.Ident{Name: name}
-		case 1:
-			expr = &ast.StarExpr{X: &ast.Ident{Name: name}}
-		case 2:
-			expr = &ast.ArrayType{Elt: &ast.Ident{Name: name}}
-		case 3:
-			expr = &ast.ChanType{Dir: ast.SEND, Value: &ast.Ident{Name: name}}
-		case 4:
-			expr = &ast.MapType{
-				Key:   &ast.Ident{Name: name},
-				Value: &ast.Ident{Name: name},
-			}
-		case 5:
-			expr = nil
-		}
-		// Must not panic
-		_ = exprToString(expr)
-	})
+// TestExtractSignals_ResiliencePlainNet verifies a file that only makes
+// network calls is not marked resilient.
+func TestExtractSignals_ResiliencePlainNet(t *testing.T) {
+	src := `package pkg
+import "net/http"
+func f() { http.Get("x") }
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
+
+	if !sig.NetCalls {
+		t.Error("expected net_calls = true")
+	}
+	if sig.Resilience {
+		t.Error("expected resilience = false for plain net/http usage")
+	}
 }
 
-// FuzzParseGoSource verifies that parsing + extraction never panics on arbitrary
-// Go source bytes (valid or not).
-func FuzzParseGoSource(f *testing.F) {
-	// Seed corpus: valid Go snippets
-	f.Add([]byte("package main\nfunc main() {}\n"))
-	f.Add([]byte("package p\nimport \"os\"\nfunc f() { os.Exit(0) }\n"))
-	f.Add([]byte("package p\nvar x chan int\n"))
-	f.Add([]byte("package p\ntype S struct{}\nfunc (s S) M() {}\n"))
-	f.Add([]byte(""))
-	f.Add([]byte("not valid go at all!!!"))
+func TestExtractSignals_ConcurrencyKindsSyncMapField(t *testing.T) {
+	src := `package pkg
+import "sync"
+type Cache struct {
+	mu    sync.RWMutex
+	items sync.Map
+}
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
 
-	f.Fuzz(func(t *testing.T, data []byte) {
-		fset := token.NewFileSet()
-		// Pass data directly as source; ParseFile returns partial AST even on errors
-		file, _ := parser.ParseFile(fset, "fuzz.go", data, 0)
-		if file == nil {
-			return // totally invalid — nothing to extract
-		}
-		// All extraction helpers must not panic
-		meta := extractPackageMeta(file)
-		syms := extractSymbols(file, nil, nil, nullQualifier)
-		calls := extractCalls(file, nil, nil, nullQualifier)
-		sig := extractSignals(meta, calls, file)
+	want := []string{"rwmutex", "sync_map"}
+	if !reflect.DeepEqual(sig.ConcurrencyKinds, want) {
+		t.Errorf("concurrency_kinds = %v, want %v", sig.ConcurrencyKinds, want)
+	}
+	if !sig.Concurrency {
+		t.Error("expected concurrency = true")
+	}
+}
 
-		// Sorting invariants must still hold
-		for i := 1; i < len(meta.Imports); i++ {
-			if meta.Imports[i].Path < meta.Imports[i-1].Path {
-				t.Errorf("imports not sorted at %d", i)
-			}
-		}
-		for i := 1; i < len(syms.Functions); i++ {
-			if syms.Functions[i].Name < syms.Functions[i-1].Name {
-				t.Errorf("functions not sorted at %d", i)
+func TestExtractSignals_ConcurrencyKindsAtomicCall(t *testing.T) {
+	src := `package pkg
+import "sync/atomic"
+var hits int64
+var last atomic.Value
+func inc() { atomic.AddInt64(&hits, 1) }
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
+
+	want := []string{"atomic", "atomic_value"}
+	if !reflect.DeepEqual(sig.ConcurrencyKinds, want) {
+		t.Errorf("concurrency_kinds = %v, want %v", sig.ConcurrencyKinds, want)
+	}
+}
+
+func TestExtractSignals_UnboundedHTTPClient(t *testing.T) {
+	src := `package pkg
+import "net/http"
+var client = &http.Client{}
+func f() { client.Get("x") }
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
+
+	if !sig.UnboundedHTTPClient {
+		t.Error("expected unbounded_http_client = true for a client without Timeout")
+	}
+}
+
+func TestExtractSignals_HTTPClientWithTimeout(t *testing.T) {
+	src := `package pkg
+import (
+	"net/http"
+	"time"
+)
+var client = http.Client{Timeout: 5 * time.Second}
+func f() { client.Get("x") }
+`
+	f := parseSource(t, src)
+	meta := extractPackageMeta(f)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(meta, calls, f)
+
+	if !sig.NetCalls {
+		t.Error("expected net_calls = true")
+	}
+	if sig.UnboundedHTTPClient {
+		t.Error("expected unbounded_http_client = false when Timeout is set")
+	}
+}
+
+func TestExtractSignals_DefaultHTTPClient(t *testing.T) {
+	for name, src := range map[string]string{
+		"http.Get": `package pkg
+import "net/http"
+func f() { http.Get("https://example.com") }
+`,
+		"DefaultClient": `package pkg
+import "net/http"
+func f(req *http.Request) { http.DefaultClient.Do(req) }
+`,
+	} {
+		f := parseSource(t, src)
+		calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+		if !extractSignals(extractPackageMeta(f), calls, f).UsesDefaultHTTPClient {
+			t.Errorf("%s: expected uses_default_http_client = true", name)
+		}
+	}
+}
+
+func TestExtractSignals_ConstructedHTTPClient(t *testing.T) {
+	src := `package pkg
+import (
+	"net/http"
+	"time"
+)
+var client = &http.Client{Timeout: time.Second}
+func f() { client.Get("https://example.com") }
+`
+	f := parseSource(t, src)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	sig := extractSignals(extractPackageMeta(f), calls, f)
+	if !sig.NetCalls || sig.UsesDefaultHTTPClient {
+		t.Errorf("net_calls = %v, uses_default_http_client = %v; want true, false", sig.NetCalls, sig.UsesDefaultHTTPClient)
+	}
+}
+
+func TestExtractSignals_ExecsSubprocess(t *testing.T) {
+	src := `package pkg
+import "os/exec"
+func run() error { return exec.Command("git", "status").Run() }
+`
+	f := parseSource(t, src)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	if !extractSignals(extractPackageMeta(f), calls, f).ExecsSubprocess {
+		t.Error("expected execs_subprocess = true")
+	}
+}
+
+// TestExtractSignals_Termination verifies the panics, recovers, and exits
+// signals.
+func TestExtractSignals_Termination(t *testing.T) {
+	src := `package pkg
+import (
+	"log"
+	"os"
+)
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+func safe() {
+	defer func() { _ = recover() }()
+}
+func fatal() { log.Fatalf("bad") }
+func quit() { os.Exit(1) }
+`
+	f := parseSource(t, src)
+	sig := extractSignals(extractPackageMeta(f), extractCalls(f, noTypeInfo, noTypePkg, nullQualifier), f)
+	if !sig.Panics || !sig.Recovers || !sig.Exits {
+		t.Errorf("panics, recovers, exits = %v, %v, %v; want all true", sig.Panics, sig.Recovers, sig.Exits)
+	}
+
+	f = parseSource(t, "package pkg\nfunc f() error { return nil }\n")
+	sig = extractSignals(extractPackageMeta(f), extractCalls(f, noTypeInfo, noTypePkg, nullQualifier), f)
+	if sig.Panics || sig.Recovers || sig.Exits {
+		t.Errorf("unexpected termination signals: %+v", sig)
+	}
+}
+
+func TestExtractSignals_NoSubprocess(t *testing.T) {
+	src := `package pkg
+import "strings"
+func f(s string) string { return strings.ToUpper(s) }
+`
+	f := parseSource(t, src)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	if extractSignals(extractPackageMeta(f), calls, f).ExecsSubprocess {
+		t.Error("expected execs_subprocess = false")
+	}
+}
+
+func TestHasBlockingChannelOps_BareReceive(t *testing.T) {
+	src := `package pkg
+func wait(ch chan int) int { return <-ch }
+`
+	if !hasBlockingChannelOps(parseSource(t, src)) {
+		t.Error("expected a bare <-ch to be reported as blocking")
+	}
+}
+
+func TestHasBlockingChannelOps_InsideSelect(t *testing.T) {
+	src := `package pkg
+import "context"
+func wait(ctx context.Context, ch chan int, out chan int) int {
+	select {
+	case v := <-ch:
+		return v
+	case out <- 1:
+		return 0
+	case <-ctx.Done():
+		return -1
+	}
+}
+func stop(ctx context.Context) { <-ctx.Done() }
+`
+	if hasBlockingChannelOps(parseSource(t, src)) {
+		t.Error("expected select cases and <-ctx.Done() not to be reported")
+	}
+}
+
+func TestCountLargeSwitches(t *testing.T) {
+	src := `package pkg
+func kind(v any) string {
+	switch v.(type) {
+	case int:
+		return "int"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "float"
+	default:
+		return "other"
+	}
+}
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+`
+	f := parseSource(t, src)
+	if got := countLargeSwitches(f, 3); got != 1 {
+		t.Errorf("countLargeSwitches(threshold 3) = %d, want 1", got)
+	}
+	if got := countLargeSwitches(f, 5); got != 0 {
+		t.Errorf("countLargeSwitches(threshold 5) = %d, want 0", got)
+	}
+}
+
+func TestReflectTagParsing(t *testing.T) {
+	src := `package pkg
+import "reflect"
+func names(v any) []string {
+	var out []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		out = append(out, field.Tag.Get("json"))
+	}
+	return out
+}
+`
+	f := parseSource(t, src)
+	if !hasReflectTagParsing(f) {
+		t.Error("expected field.Tag.Get to be reported")
+	}
+	if !extractSignals(PackageMeta{}, nil, f).DynamicSerialization {
+		t.Error("expected DynamicSerialization signal")
+	}
+}
+
+func TestReflectTagParsing_None(t *testing.T) {
+	src := `package pkg
+import "encoding/json"
+type T struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+func enc(t T) ([]byte, error) { return json.Marshal(t) }
+`
+	if hasReflectTagParsing(parseSource(t, src)) {
+		t.Error("expected static struct tags alone not to be reported")
+	}
+}
+
+func TestCountUncheckedAssertions(t *testing.T) {
+	src := `package pkg
+func name(v any) string {
+	s := v.(string)
+	return s + v.(fmt.Stringer).String()
+}
+`
+	if got := countUncheckedAssertions(parseSource(t, src)); got != 2 {
+		t.Errorf("countUncheckedAssertions = %d, want 2", got)
+	}
+}
+
+func TestCountUncheckedAssertions_CommaOK(t *testing.T) {
+	src := `package pkg
+func name(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	var n, isInt = v.(int)
+	_, _ = n, isInt
+	switch x := v.(type) {
+	case error:
+		return x.Error()
+	}
+	return s
+}
+`
+	if got := countUncheckedAssertions(parseSource(t, src)); got != 0 {
+		t.Errorf("countUncheckedAssertions = %d, want 0", got)
+	}
+}
+
+// TestExtractConfigInputs verifies that env and viper keys given as
+// literals are recorded with their enclosing function, that computed keys
+// are not, and that string constants resolve with type info.
+func TestExtractConfigInputs(t *testing.T) {
+	src := `package pkg
+import (
+	"os"
+	"github.com/spf13/viper"
+)
+var home = os.Getenv("HOME")
+func Load(name string) {
+	os.LookupEnv("DEBUG")
+	os.Getenv(name)
+	func() { os.Getenv("DEBUG") }()
+	viper.GetString("db.url")
+	viper.Set("db.url", "x")
+}
+`
+	f := parseSource(t, src)
+	got := extractConfigInputs(f, noTypeInfo, noTypePkg, nullQualifier)
+	want := []ConfigInput{
+		{Source: ConfigEnv, Key: "DEBUG", From: "Load"},
+		{Source: ConfigEnv, Key: "HOME", From: "<global>"},
+		{Source: ConfigViper, Key: "db.url", From: "Load"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inputs = %+v, want %+v", got, want)
+	}
+
+	f, info, _ := checkSource(t, "package pkg\nconst prefix = \"APP_\"\nvar key = prefix + \"PORT\"\nvar _ = key\n")
+	expr := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	if key, ok := constantString(expr, info); !ok || key != "APP_PORT" {
+		t.Errorf("constantString = %q, %v; want APP_PORT", key, ok)
+	}
+	if _, ok := constantString(expr, nil); ok {
+		t.Error("constantString resolved a constant expression without type info")
+	}
+}
+
+// TestExtractCLIFlags verifies flag and pflag registrations, including
+// cobra's Flags() chain without type info, and that flags with a computed
+// name are skipped.
+func TestExtractCLIFlags(t *testing.T) {
+	src := `package main
+import (
+	"flag"
+	"github.com/spf13/pflag"
+)
+var verbose = flag.Bool("v", false, "verbose output")
+func main() {
+	var addr string
+	flag.StringVar(&addr, "addr", ":8080", "listen address")
+	flag.Duration("timeout", 5*time.Second, "request timeout")
+	pflag.StringSliceP("tag", "t", nil, "tags to apply")
+	rootCmd.PersistentFlags().IntP("workers", "w", 4, "worker count")
+	flag.String(name, "", "computed name")
+	flag.Parse()
+}
+`
+	f := parseSource(t, src)
+	got := extractCLIFlags(f, noTypeInfo, noTypePkg, nullQualifier)
+	want := []CLIFlag{
+		{Name: "addr", Type: "string", Default: ":8080", Usage: "listen address", From: "main"},
+		{Name: "tag", Shorthand: "t", Type: "stringSlice", Default: "nil", Usage: "tags to apply", From: "main"},
+		{Name: "timeout", Type: "duration", Default: "5 * time.Second", Usage: "request timeout", From: "main"},
+		{Name: "v", Type: "bool", Default: "false", Usage: "verbose output", From: "<global>"},
+		{Name: "workers", Shorthand: "w", Type: "int", Default: "4", Usage: "worker count", From: "main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flags =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestExtractQueries verifies that constant SQL passed to Query, QueryRow,
+// and Exec is recorded with the tables it writes and reads, that non-SQL
+// strings and computed queries are skipped, and that long SQL is cut.
+func TestExtractQueries(t *testing.T) {
+	long := "SELECT id FROM big WHERE " + strings.Repeat("x = 1 AND ", 60) + "y = 2"
+	src := `package store
+func (s *Store) Save(ctx context.Context, q string) {
+	s.db.ExecContext(ctx, "INSERT INTO orders (id) SELECT id FROM carts JOIN users ON true")
+	s.db.Exec("UPDATE \"Users\" SET name = ? WHERE id = ?", 1, 2)
+	s.db.QueryRow("DELETE FROM sessions RETURNING id")
+	s.db.Query(q)
+	cmd.Exec("not sql")
+	s.db.Query("` + long + `")
+}
+`
+	f := parseSource(t, src)
+	got := extractQueries(f, noTypeInfo, noTypePkg, nullQualifier)
+	want := []Query{
+		{SQL: "DELETE FROM sessions RETURNING id", Writes: []string{"sessions"}, From: "*Store.Save"},
+		{SQL: "INSERT INTO orders (id) SELECT id FROM carts JOIN users ON true", Writes: []string{"orders"}, Reads: []string{"carts", "users"}, From: "*Store.Save"},
+		{SQL: long[:MaxQueryLen], Truncated: true, Reads: []string{"big"}, From: "*Store.Save"},
+		{SQL: "UPDATE \"Users\" SET name = ? WHERE id = ?", Writes: []string{"users"}, From: "*Store.Save"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("queries =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestExtractRoutes verifies net/http, Go 1.22 method patterns, gin, and
+// chi registrations, and that non-path strings, handlerless calls, and
+// client calls with a content type are skipped.
+func TestExtractRoutes(t *testing.T) {
+	src := `package api
+func Register(mux *http.ServeMux, r *gin.Engine, c chi.Router) {
+	http.HandleFunc("/healthz", health)
+	mux.Handle("POST /orders/{id}", orders)
+	r.GET("/users/:id", getUser)
+	c.Post("/users", createUser)
+	cache.Get("key", &v)
+	c.Get("/no-handler")
+	client.Post("/v1/items", "application/json", body)
+	c.Put("/users", &user)
+}
+`
+	f := parseSource(t, src)
+	got := extractRoutes(f, noTypeInfo, noTypePkg, nullQualifier)
+	want := []Route{
+		{Path: "/healthz", From: "Register"},
+		{Method: "POST", Path: "/orders/{id}", From: "Register"},
+		{Method: "POST", Path: "/users", From: "Register"},
+		{Method: "GET", Path: "/users/:id", From: "Register"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestExtractRoutes_TypeInfo verifies that with type info only calls on a
+// router whose last argument has a handler type register routes, so
+// client-style Post methods are not recorded.
+func TestExtractRoutes_TypeInfo(t *testing.T) {
+	src := `package api
+type Mux struct{}
+func (*Mux) ServeHTTP(w, r any)           {}
+func (*Mux) Post(path string, h func())    {}
+type Client struct{}
+func (*Client) Post(url string, body any) {}
+type API struct{}
+func (API) Post(path string, body []byte) {}
+type Store struct{}
+func (Store) Get(path string, h func())   {}
+func Register(m *Mux, c *Client, a API, s Store, body []byte) {
+	m.Post("/orders", func() {})
+	c.Post("/v1/items", body)
+	a.Post("/users", body)
+	s.Get("/keys", func() {})
+}
+`
+	f, info, pkg := checkSource(t, src)
+	got := extractRoutes(f, info, pkg, makeQualifier(pkg))
+	want := []Route{{Method: "POST", Path: "/orders", From: "Register"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestExtractEndpoints verifies outbound requests to constant URLs, with
+// the method taken from the function or the NewRequest argument.
+func TestExtractEndpoints(t *testing.T) {
+	src := `package client
+func Sync(ctx context.Context, base string) {
+	http.Get("https://api.example.com/v1/status")
+	http.NewRequestWithContext(ctx, http.MethodPut, "https://api.example.com/v1/items", nil)
+	http.NewRequest("delete", "/relative", nil)
+	http.Get(base + "/x")
+}
+`
+	f := parseSource(t, src)
+	got := extractEndpoints(f, noTypeInfo, noTypePkg, nullQualifier)
+	want := []Endpoint{
+		{Method: "DELETE", URL: "/relative", From: "Sync"},
+		{Method: "PUT", URL: "https://api.example.com/v1/items", Host: "api.example.com", From: "Sync"},
+		{Method: "GET", URL: "https://api.example.com/v1/status", Host: "api.example.com", From: "Sync"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("endpoints =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestExtractConcurrency verifies the inventory of goroutine launch sites,
+// sync primitives declared as fields, parameters, and variables, channels
+// with their direction, and atomic calls.
+func TestExtractConcurrency(t *testing.T) {
+	src := `package pool
+type Pool struct {
+	sync.Mutex
+	wg    sync.WaitGroup
+	jobs  chan Job
+	ready atomic.Bool
+}
+var results = make(chan<- Result, 8)
+func (p *Pool) Run(in <-chan Job) {
+	done := make(chan struct{})
+	var once sync.Once
+	for i := 0; i < 4; i++ {
+		go p.work()
+	}
+	go func() { close(done) }()
+	atomic.AddInt64(&n, 1)
+}
+`
+	f := parseSource(t, src)
+	calls := extractCalls(f, noTypeInfo, noTypePkg, nullQualifier)
+	got := extractConcurrency(f, calls, noTypeInfo, noTypePkg, nullQualifier)
+	want := &Concurrency{
+		Goroutines: []GoroutineSite{
+			{From: "*Pool.Run", Target: "<anonymous>", Count: 1},
+			{From: "*Pool.Run", Target: "p.work", Count: 1},
+		},
+		Primitives: []SyncPrimitive{
+			{Owner: "*Pool.Run", Name: "once", Kind: "once"},
+			{Owner: "Pool", Name: "Mutex", Kind: "mutex"},
+			{Owner: "Pool", Name: "ready", Kind: "atomic"},
+			{Owner: "Pool", Name: "wg", Kind: "waitgroup"},
+		},
+		Channels: []Channel{
+			{Owner: "*Pool.Run", Name: "done", Dir: "both", Elem: "struct{}"},
+			{Owner: "*Pool.Run", Name: "in", Dir: "recv", Elem: "Job"},
+			{Owner: "<global>", Name: "results", Dir: "send", Elem: "Result"},
+			{Owner: "Pool", Name: "jobs", Dir: "both", Elem: "Job"},
+		},
+		AtomicCalls: []string{"atomic.AddInt64"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("concurrency =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := extractConcurrency(parseSource(t, "package p\nfunc f() {}\n"), nil, noTypeInfo, noTypePkg, nullQualifier); got != nil {
+		t.Errorf("concurrency of plain file = %+v, want nil", got)
+	}
+}
+
+// TestCountDroppedErrors verifies that a call statement dropping an error
+// result is counted, while deferred calls, assigned errors, and calls
+// without an error result are not.
+func TestCountDroppedErrors(t *testing.T) {
+	src := `package pkg
+type file struct{}
+func (file) Close() error { return nil }
+func size() (int, error) { return 0, nil }
+func count() int { return 0 }
+func use(f file) {
+	f.Close()
+	size()
+	count()
+	defer f.Close()
+	_ = f.Close()
+	if err := f.Close(); err != nil {
+		return
+	}
+}
+`
+	f, info, _ := checkSource(t, src)
+	if got := countDroppedErrors(f, info); got != 2 {
+		t.Errorf("countDroppedErrors = %d, want 2", got)
+	}
+	if got := countDroppedErrors(f, nil); got != 0 {
+		t.Errorf("countDroppedErrors without type info = %d, want 0", got)
+	}
+}
+
+// TestCountIgnoredErrors verifies that an error result assigned to _ is
+// counted while a checked error and a blank non-error result are not.
+func TestCountIgnoredErrors(t *testing.T) {
+	src := `package pkg
+func parse(s string) (int, error) { return 0, nil }
+func pair() (int, int) { return 0, 0 }
+func ignored() int {
+	v, _ := parse("1")
+	return v
+}
+func checked() (int, error) {
+	v, err := parse("1")
+	if err != nil {
+		return 0, err
+	}
+	_, _ = pair()
+	return v, nil
+}
+`
+	f, info, _ := checkSource(t, src)
+	if got := countIgnoredErrors(f, info); got != 1 {
+		t.Errorf("countIgnoredErrors = %d, want 1", got)
+	}
+	if got := countIgnoredErrors(f, nil); got != 0 {
+		t.Errorf("countIgnoredErrors without type info = %d, want 0", got)
+	}
+}
+
+// --------------------------------------------------------------------------
+// Fuzz tests
+// --------------------------------------------------------------------------
+
+// FuzzExprToString verifies exprToString never panics on arbitrary AST exprs.
+// The fuzzer drives node selection by index.
+func FuzzExprToString(f *testing.F) {
+	// Seed with a few representative expressions
+	f.Add(0, "int")
+	f.Add(1, "Server")
+	f.Add(2, "os")
+
+	f.Fuzz(func(t *testing.T, kind int, name string) {
+		var expr ast.Expr
+		switch kind % 6 {
+		case 0:
+			expr = &ast.Ident{Name: name}
+		case 1:
+			expr = &ast.StarExpr{X: &ast.Ident{Name: name}}
+		case 2:
+			expr = &ast.ArrayType{Elt: &ast.Ident{Name: name}}
+		case 3:
+			expr = &ast.ChanType{Dir: ast.SEND, Value: &ast.Ident{Name: name}}
+		case 4:
+			expr = &ast.MapType{
+				Key:   &ast.Ident{Name: name},
+				Value: &ast.Ident{Name: name},
 			}
+		case 5:
+			expr = nil
+		}
+		// Must not panic
+		_ = exprToString(expr)
+	})
+}
+
+// FuzzParseGoSource verifies that parsing + extraction never panics on arbitrary
+// Go source bytes (valid or not).
+func FuzzParseGoSource(f *testing.F) {
+	// Seed corpus: valid Go snippets
+	f.Add([]byte("package main\nfunc main() {}\n"))
+	f.Add([]byte("package p\nimport \"os\"\nfunc f() { os.Exit(0) }\n"))
+	f.Add([]byte("package p\nvar x chan int\n"))
+	f.Add([]byte("package p\ntype S struct{}\nfunc (s S) M() {}\n"))
+	f.Add([]byte(""))
+	f.Add([]byte("not valid go at all!!!"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fset := token.NewFileSet()
+		// Pass data directly as source; ParseFile returns partial AST even on errors
+		file, _ := parser.ParseFile(fset, "fuzz.go", data, 0)
+		if file == nil {
+			return // totally invalid — nothing to extract
+		}
+		// All extraction helpers must not panic
+		meta := extractPackageMeta(file)
+		syms := extractSymbols(file, nil, nil, nullQualifier)
+		calls := extractCalls(file, nil, nil, nullQualifier)
+		sig := extractSignals(meta, calls, file)
+
+		// Sorting invariants must still hold
+		for i := 1; i < len(meta.Imports); i++ {
+			if meta.Imports[i].Path < meta.Imports[i-1].Path {
+				t.Errorf("imports not sorted at %d", i)
+			}
+		}
+		for i := 1; i < len(syms.Functions); i++ {
+			if syms.Functions[i].Name < syms.Functions[i-1].Name {
+				t.Errorf("functions not sorted at %d", i)
+			}
+		}
+		for i := 1; i < len(calls); i++ {
+			prev, curr := calls[i-1], calls[i]
+			if curr.From < prev.From || (curr.From == prev.From && curr.To < prev.To) {
+				t.Errorf("calls not sorted at %d", i)
+			}
+		}
+
+		// Suppress unused variable warnings
+		_ = sig
+	})
+}
+
+// --------------------------------------------------------------------------
+// Helpers
+// --------------------------------------------------------------------------
+
+// containsStr reports whether slice contains s.
+func containsStr(slice []string, s string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// CleanEvidenceBundles
+// ---------------------------------------------------------------------------
+
+// TestCleanEvidenceBundles verifies that CleanEvidenceBundles removes all
+// *.evidence.yaml files and returns the correct count, leaving other files alone.
+func TestCleanEvidenceBundles(t *testing.T) {
+	dir := t.TempDir()
+
+	// Create a mix of evidence files and unrelated files.
+	evFiles := []string{
+		"main.go.evidence.yaml",
+		"sub/store.go.evidence.yaml",
+	}
+	other := []string{
+		"main.go",
+		"system_model.yaml",
+	}
+	for _, name := range append(evFiles, other...) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := CleanEvidenceBundles(dir)
+	if err != nil {
+		t.Fatalf("CleanEvidenceBundles: %v", err)
+	}
+	if removed != len(evFiles) {
+		t.Errorf("removed %d files, want %d", removed, len(evFiles))
+	}
+
+	// Evidence files must be gone.
+	for _, name := range evFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	// Other files must still exist.
+	for _, name := range other {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to still exist: %v", name, err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Skip logic (INV-50)
+// ---------------------------------------------------------------------------
+
+// TestWriteEvidenceBundle_SkipsIfUpToDate verifies INV-50: WriteEvidenceBundle
+// returns skipped=true without overwriting if the existing bundle has the
+// same file.sha256 as the new bundle.
+func TestWriteEvidenceBundle_SkipsIfUpToDate(t *testing.T) {
+	// Use a temp dir so WriteEvidenceBundle never touches the committed evidence yaml.
+	tmpDir := t.TempDir()
+	src, err := os.ReadFile("generate.go")
+	if err != nil {
+		t.Fatalf("read generate.go: %v", err)
+	}
+	tmpFile := filepath.Join(tmpDir, "generate.go")
+	if err := os.WriteFile(tmpFile, src, 0o644); err != nil {
+		t.Fatalf("write temp: %v", err)
+	}
+
+	b, err := CreateEvidenceBundle(tmpFile)
+	if err != nil {
+		t.Fatalf("CreateEvidenceBundle: %v", err)
+	}
+	outputPath := tmpFile + ".evidence.yaml"
+	// No t.Cleanup needed — t.TempDir() removes the directory automatically.
+
+	// First write — must not be skipped.
+	skipped, err := WriteEvidenceBundle(b, false)
+	if err != nil {
+		t.Fatalf("first WriteEvidenceBundle: %v", err)
+	}
+	if skipped {
+		t.Error("first write should not be skipped")
+	}
+
+	// Record the mtime of the written file.
+	stat1, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	// Second write — same SHA256, must be skipped (file not overwritten).
+	skipped, err = WriteEvidenceBundle(b, false)
+	if err != nil {
+		t.Fatalf("second WriteEvidenceBundle: %v", err)
+	}
+	if !skipped {
+		t.Error("second write should be skipped (same SHA256)")
+	}
+
+	// File must not have been modified.
+	stat2, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("stat after second write: %v", err)
+	}
+	if !stat2.ModTime().Equal(stat1.ModTime()) {
+		t.Error("file mtime changed on skipped write")
+	}
+}
+
+// TestWriteEvidenceBundle_ForceOverwrites verifies that --force bypasses the
+// skip check and always writes.
+func TestWriteEvidenceBundle_ForceOverwrites(t *testing.T) {
+	// Use a temp dir so WriteEvidenceBundle never touches the committed evidence yaml.
+	tmpDir := t.TempDir()
+	src, err := os.ReadFile("generate.go")
+	if err != nil {
+		t.Fatalf("read generate.go: %v", err)
+	}
+	tmpFile := filepath.Join(tmpDir, "generate.go")
+	if err := os.WriteFile(tmpFile, src, 0o644); err != nil {
+		t.Fatalf("write temp: %v", err)
+	}
+
+	b, err := CreateEvidenceBundle(tmpFile)
+	if err != nil {
+		t.Fatalf("CreateEvidenceBundle: %v", err)
+	}
+	outputPath := tmpFile + ".evidence.yaml"
+	// No t.Cleanup needed — t.TempDir() removes the directory automatically.
+
+	// First write.
+	if _, err := WriteEvidenceBundle(b, false); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	stat1, _ := os.Stat(outputPath)
+
+	// Force write — must not be skipped even though SHA is identical.
+	skipped, err := WriteEvidenceBundle(b, true)
+	if err != nil {
+		t.Fatalf("force write: %v", err)
+	}
+	if skipped {
+		t.Error("force write must not be skipped")
+	}
+	stat2, _ := os.Stat(outputPath)
+	if !stat2.ModTime().After(stat1.ModTime()) {
+		// ModTime resolution may be low on some filesystems; just confirm no error.
+		t.Log("note: mtime did not advance (filesystem resolution)")
+	}
+}
+
+// TestMarshalBundle_ClassicSchema verifies that the 2-classic profile omits
+// fields added after the original v2 layout while latest keeps them.
+func TestMarshalBundle_ClassicSchema(t *testing.T) {
+	b := &EvidenceBundle{
+		Version:  2,
+		Language: "go",
+		File:     FileMeta{Path: "a.go", SHA256: "abc"},
+		Files:    []FileMeta{{Path: "a.go", SHA256: "abc"}},
+		Package: PackageMeta{
+			Name:       "a",
+			Module:     "example.com/a",
+			Imports:    []Import{{Path: "os", Class: ImportStdlib}},
+			GoGenerate: []string{"stringer -type=Kind"},
+		},
+		Symbols: Symbols{
+			Types: []TypeDecl{
+				{Name: "ID", Kind: "alias", Underlying: "string"},
+				{Name: "List", Kind: "struct", TypeParams: "[E any]"},
+				{Name: "RW", Kind: "interface", Embeds: []string{"io.Reader"}},
+				{Name: "T", Kind: "struct", Implements: []string{"io.Writer"}, Methods: []Method{{Name: "Write", Exported: true}}},
+			},
+			InterfaceAssertions: []string{"T:io.Writer"},
+			ErrorSentinels:      []string{"ErrNotFound"},
+		},
+		ConfigInputs: []ConfigInput{{Source: ConfigEnv, Key: "PORT", From: "main"}},
+		CLIFlags:     []CLIFlag{{Name: "addr", Type: "string", Default: ":8080", From: "main"}},
+		Queries:      []Query{{SQL: "DELETE FROM jobs", Writes: []string{"jobs"}, From: "main"}},
+		Routes:       []Route{{Path: "/healthz", From: "main"}},
+		Endpoints:    []Endpoint{{Method: "GET", URL: "https://example.com/", Host: "example.com", From: "main"}},
+		Concurrency:  &Concurrency{Goroutines: []GoroutineSite{{From: "main", Target: "serve", Count: 1}}},
+		Signals: Signals{
+			FSWrites:            true,
+			Resilience:          true,
+			ConcurrencyKinds:    []string{"mutex"},
+			IgnoredErrors:       2,
+			UnboundedHTTPClient: true,
+			BlockingChannelOps:  true,
+			LargeSwitches:       1,
+
+			DynamicSerialization:  true,
+			UncheckedAssertions:   1,
+			UsesDefaultHTTPClient: true,
+			ExecsSubprocess:       true,
+			Custom:                []string{"kafka"},
+			Panics:                true,
+			Recovers:              true,
+			Exits:                 true,
+			ErrorReturnsIgnored:   1,
+		},
+	}
+	newKeys := []string{
+		"language:", "class:", "embeds:", "go_generate:", "underlying:", "interface_assertions:",
+		"resilience:", "concurrency_kinds:", "ignored_errors:",
+		"unbounded_http_client:", "uses_default_http_client:", "blocking_channel_ops:", "large_switches:",
+		"dynamic_serialization:", "unchecked_assertions:", "execs_subprocess:", "error_sentinels:",
+		"custom:", "implements:", "methods:", "type_params:", "module:",
+		"panics:", "recovers:", "exits:", "error_returns_ignored:", "config_inputs:",
+		"cli_flags:", "queries:", "routes:", "endpoints:",
+		"goroutines:", "files:",
+	}
+
+	latest, err := MarshalBundle(b, SchemaLatest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classic, err := MarshalBundle(b, SchemaClassic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range newKeys {
+		if !strings.Contains(string(latest), key) {
+			t.Errorf("latest bundle missing %q", key)
+		}
+		if strings.Contains(string(classic), key) {
+			t.Errorf("classic bundle contains %q:\n%s", key, classic)
+		}
+	}
+	if !strings.Contains(string(classic), "fs_writes: true") {
+		t.Errorf("classic bundle lost original signals:\n%s", classic)
+	}
+	if b.Signals.IgnoredErrors != 2 || b.Package.Imports[0].Class != ImportStdlib {
+		t.Error("MarshalBundle modified its input")
+	}
+	if _, err := MarshalBundle(b, "1"); err == nil {
+		t.Error("expected an error for an unsupported bundle version")
+	}
+}
+
+// TestWalkAndGenerate_SkipsUnchanged verifies INV-50: a second WalkAndGenerate
+// call on the same directory (no source changes) reports all files as skipped.
+func TestWalkAndGenerate_SkipsUnchanged(t *testing.T) {
+	root := t.TempDir()
+	src := "package main\nfunc Hello() {}\n"
+	goFile := filepath.Join(root, "hello.go")
+	if err := os.WriteFile(goFile, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// First pass — must write.
+	written1, skipped1, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 {
+		t.Fatalf("first pass errors: %v", errs)
+	}
+	if written1 != 1 || skipped1 != 0 {
+		t.Errorf("first pass: written=%d skipped=%d, want 1/0", written1, skipped1)
+	}
+
+	// Second pass — same source, must skip.
+	written2, skipped2, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 {
+		t.Fatalf("second pass errors: %v", errs)
+	}
+	if written2 != 0 || skipped2 != 1 {
+		t.Errorf("second pass: written=%d skipped=%d, want 0/1", written2, skipped2)
+	}
+}
+
+// TestWalkAndGenerate_LoadsOnlyStaleDirs verifies that an incremental run
+// loads packages only for directories with changed files, and that Force
+// loads every directory again.
+func TestWalkAndGenerate_LoadsOnlyStaleDirs(t *testing.T) {
+	root := t.TempDir()
+	for rel, src := range map[string]string{
+		"a/a.go": "package a\nfunc A() {}\n",
+		"b/b.go": "package b\nfunc B() {}\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("first pass errors: %v", errs)
+	}
+
+	var mu sync.Mutex
+	var loaded []string
+	orig := loadDirPackage
+	t.Cleanup(func() { loadDirPackage = orig })
+	loadDirPackage = func(dir string) (*packages.Package, *token.FileSet, error) {
+		mu.Lock()
+		rel, _ := filepath.Rel(root, dir)
+		loaded = append(loaded, filepath.ToSlash(rel))
+		mu.Unlock()
+		return orig(dir)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "b", "b.go"), []byte("package b\nfunc B() { B() }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	written, skipped, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 || written != 1 || skipped != 1 {
+		t.Fatalf("incremental pass: written=%d skipped=%d errs=%v, want 1/1/none", written, skipped, errs)
+	}
+	if !reflect.DeepEqual(loaded, []string{"b"}) {
+		t.Errorf("incremental pass loaded %v, want [b]", loaded)
+	}
+
+	loaded = nil
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{Force: true}); len(errs) != 0 {
+		t.Fatalf("forced pass errors: %v", errs)
+	}
+	if !reflect.DeepEqual(loaded, []string{"a", "b"}) {
+		t.Errorf("forced pass loaded %v, want [a b]", loaded)
+	}
+}
+
+// TestWalkAndGenerate_RegeneratesOnChange verifies that modifying a source
+// file causes WalkAndGenerate to regenerate its bundle (not skip it).
+func TestWalkAndGenerate_RegeneratesOnChange(t *testing.T) {
+	root := t.TempDir()
+	goFile := filepath.Join(root, "hello.go")
+	if err := os.WriteFile(goFile, []byte("package main\nfunc Hello() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// First pass — write.
+	WalkAndGenerate(root, WalkOptions{}) //nolint:errcheck
+
+	// Modify the source file.
+	if err := os.WriteFile(goFile, []byte("package main\nfunc Hello() {}\nfunc World() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Second pass — source changed, must regenerate (written=1, skipped=0).
+	written, skipped, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if written != 1 || skipped != 0 {
+		t.Errorf("after change: written=%d skipped=%d, want 1/0", written, skipped)
+	}
+}
+
+// TestWalkAndGenerate_ForceRegeneratesAll verifies that --force causes all
+// bundles to be regenerated even when source is unchanged.
+func TestWalkAndGenerate_ForceRegeneratesAll(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package main\nfunc A() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.go"), []byte("package main\nfunc B() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// First pass — write both.
+	WalkAndGenerate(root, WalkOptions{}) //nolint:errcheck
+
+	// Force pass — must write both even though nothing changed.
+	written, skipped, errs := WalkAndGenerate(root, WalkOptions{Force: true})
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if written != 2 || skipped != 0 {
+		t.Errorf("force pass: written=%d skipped=%d, want 2/0", written, skipped)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tree checks (CheckFreshness, CheckOrder)
+// ---------------------------------------------------------------------------
+
+// TestCheckFreshness verifies missing and stale bundles are reported and a
+// freshly generated tree reports nothing.
+func TestCheckFreshness(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.go")
+	b := filepath.Join(root, "b.go")
+	for _, f := range []string{a, b} {
+		if err := os.WriteFile(f, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
 		}
-		for i := 1; i < len(calls); i++ {
-			prev, curr := calls[i-1], calls[i]
-			if curr.From < prev.From || (curr.From == prev.From && curr.To < prev.To) {
-				t.Errorf("calls not sorted at %d", i)
-			}
+	}
+
+	problems, err := CheckFreshness(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || !strings.Contains(problems[0], "a.go: missing") {
+		t.Errorf("before analysis: problems = %v, want two missing bundles", problems)
+	}
+
+	WalkAndGenerate(root, WalkOptions{}) //nolint:errcheck
+	if problems, _ := CheckFreshness(root); len(problems) != 0 {
+		t.Errorf("after analysis: problems = %v, want none", problems)
+	}
+
+	if err := os.WriteFile(b, []byte("package main\nvar X int\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	problems, _ = CheckFreshness(root)
+	if len(problems) != 1 || problems[0] != "b.go: evidence bundle is stale" {
+		t.Errorf("after edit: problems = %v, want b.go stale", problems)
+	}
+}
+
+// TestCheckOrphans verifies that a bundle whose source was deleted is
+// reported, and bundles under skipped directories are not.
+func TestCheckOrphans(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"a.go", "pkg/b.go"} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
 		}
+	}
+	WalkAndGenerate(root, WalkOptions{}) //nolint:errcheck
+	if problems, err := CheckOrphans(root); err != nil || len(problems) != 0 {
+		t.Fatalf("after analysis: problems = %v, err = %v; want none", problems, err)
+	}
 
-		// Suppress unused variable warnings
-		_ = sig
-	})
+	if err := os.Remove(filepath.Join(root, "pkg", "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "vendor"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "vendor", "c.go.evidence.yaml"), []byte("version: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := CheckOrphans(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pkg/b.go: orphaned evidence bundle"}; !reflect.DeepEqual(problems, want) {
+		t.Errorf("problems = %v, want %v", problems, want)
+	}
+}
+
+// TestCheckOrder verifies that a hand-edited bundle with unsorted functions
+// is reported (INV-8).
+func TestCheckOrder(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "a.go")
+	if err := os.WriteFile(src, []byte("package main\nfunc A() {}\nfunc B() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	WalkAndGenerate(root, WalkOptions{}) //nolint:errcheck
+	if problems, _ := CheckOrder(root); len(problems) != 0 {
+		t.Fatalf("generated bundle reported unsorted: %v", problems)
+	}
+
+	bnd, err := readBundle(src + ".evidence.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fns := bnd.Symbols.Functions
+	fns[0], fns[1] = fns[1], fns[0]
+	data, _ := yaml.Marshal(bnd)
+	if err := os.WriteFile(src+".evidence.yaml", data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := CheckOrder(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0] != "a.go: symbols.functions not sorted" {
+		t.Errorf("problems = %v, want functions not sorted", problems)
+	}
 }
 
 // --------------------------------------------------------------------------
-// Helpers
+// Diff-base generation
 // --------------------------------------------------------------------------
 
-// containsStr reports whether slice contains s.
-func containsStr(slice []string, s string) bool {
-	for _, v := range slice {
-		if v == s {
-			return true
+// gitRun runs git in dir with a fixed identity, failing the test on error.
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestGenerateChanged verifies that only files changed since the base ref get
+// bundles, deleted files lose theirs, and changes.yaml lists them all.
+func TestGenerateChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	write := func(name, src string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("same.go", "package p\n\nfunc Same() {}\n")
+	write("edit.go", "package p\n\nfunc Edit() {}\n")
+	write("gone.go", "package p\n\nfunc Gone() {}\n")
+	write("gone.go.evidence.yaml", "version: 2\n")
+	gitRun(t, root, "init", "-q")
+	gitRun(t, root, "add", "same.go", "edit.go", "gone.go")
+	gitRun(t, root, "commit", "-q", "-m", "base")
+	gitRun(t, root, "tag", "base")
+
+	write("edit.go", "package p\n\nfunc Edit() int { return 1 }\n")
+	if err := os.Remove(filepath.Join(root, "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, root, "add", "-A", "edit.go", "gone.go")
+	gitRun(t, root, "commit", "-q", "-m", "change")
+
+	manifest, written, errs := GenerateChanged(root, "base", WalkOptions{})
+	if len(errs) > 0 {
+		t.Fatalf("GenerateChanged: %v", errs)
+	}
+	if written != 1 {
+		t.Errorf("written = %d, want 1", written)
+	}
+	want := []FileChange{
+		{Path: "edit.go", Status: "M", Bundle: "edit.go.evidence.yaml"},
+		{Path: "gone.go", Status: "D"},
+	}
+	if !reflect.DeepEqual(manifest.Files, want) {
+		t.Errorf("manifest files = %+v, want %+v", manifest.Files, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "edit.go.evidence.yaml")); err != nil {
+		t.Errorf("expected bundle for changed file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "same.go.evidence.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected no bundle for unchanged file, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "gone.go.evidence.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected deleted file's bundle removed, stat err = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, ChangeManifestName))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	if !strings.Contains(string(data), "base: base") || !strings.Contains(string(data), "path: edit.go") {
+		t.Errorf("unexpected manifest:\n%s", data)
+	}
+}
+
+// TestGenerateChanged_Filter verifies that changed paths are matched
+// literally, intersected with opts.Include, and given a Bundle in the
+// manifest only when one was written.
+func TestGenerateChanged_Filter(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	write := func(name, src string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a1.go", "package p\n\nfunc A1() {}\n")
+	gitRun(t, root, "init", "-q")
+	gitRun(t, root, "add", "a1.go")
+	gitRun(t, root, "commit", "-q", "-m", "base")
+	gitRun(t, root, "tag", "base")
+
+	write("a[1].go", "package p\n\nfunc Bracket() {}\n")
+	write("b.go", "package p\n\nfunc B() {}\n")
+	write("testdata/t.go", "package t\n\nfunc T() {}\n")
+	gitRun(t, root, "add", "-A")
+	gitRun(t, root, "commit", "-q", "-m", "change")
+
+	manifest, written, errs := GenerateChanged(root, "base", WalkOptions{Include: []string{"a*", "testdata/*"}})
+	if len(errs) > 0 {
+		t.Fatalf("GenerateChanged: %v", errs)
+	}
+	if written != 1 {
+		t.Errorf("written = %d, want 1", written)
+	}
+	want := []FileChange{
+		{Path: "a[1].go", Status: "A", Bundle: "a[1].go.evidence.yaml"},
+		{Path: "b.go", Status: "A"},
+		{Path: "testdata/t.go", Status: "A"},
+	}
+	if !reflect.DeepEqual(manifest.Files, want) {
+		t.Errorf("manifest files = %+v, want %+v", manifest.Files, want)
+	}
+	for _, name := range []string{"a1.go", "b.go", "testdata/t.go"} {
+		if _, err := os.Stat(filepath.Join(root, name+".evidence.yaml")); !os.IsNotExist(err) {
+			t.Errorf("expected no bundle for %s, stat err = %v", name, err)
+		}
+	}
+}
+
+// TestGitChanges_OptionBase verifies that a base that looks like an option
+// is rejected as a revision instead of being passed to git diff.
+func TestGitChanges_OptionBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package p\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, root, "init", "-q")
+	gitRun(t, root, "add", "a.go")
+	gitRun(t, root, "commit", "-q", "-m", "first")
+
+	out := filepath.Join(root, "out")
+	if _, err := GitChanges(root, "--output="+out); err == nil {
+		t.Error("expected an error for an option-like base")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("git wrote %s, stat err = %v", out, err)
+	}
+}
+
+// TestVerifyPinnedCommit verifies that a pin matching HEAD passes and one
+// HEAD has moved past fails.
+func TestVerifyPinnedCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package p\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, root, "init", "-q")
+	gitRun(t, root, "add", "a.go")
+	gitRun(t, root, "commit", "-q", "-m", "first")
+	gitRun(t, root, "tag", "v1")
+
+	if err := VerifyPinnedCommit(root, "v1"); err != nil {
+		t.Errorf("matching pin: %v", err)
+	}
+
+	gitRun(t, root, "commit", "-q", "--allow-empty", "-m", "second")
+	if err := VerifyPinnedCommit(root, "v1"); err == nil {
+		t.Error("expected an error after HEAD moved past the pin")
+	}
+	if err := VerifyPinnedCommit(root, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown pin")
+	}
+}
+
+// TestHoverAt verifies that a position inside a function body resolves to
+// that function with its signature, call-derived signals, and corpus callers.
+func TestHoverAt(t *testing.T) {
+	root := t.TempDir()
+	lib := `package lib
+
+import "os"
+
+type Thing struct{}
+
+func Load(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+`
+	user := `package lib
+
+func Run() {
+	Load("config.yaml")
+}
+`
+	libPath := filepath.Join(root, "lib.go")
+	if err := os.WriteFile(libPath, []byte(lib), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "run.go"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("WalkAndGenerate: %v", errs)
+	}
+
+	// Line 8 is the return statement inside Load.
+	h, err := HoverAt(libPath, 8, 3, root)
+	if err != nil {
+		t.Fatalf("HoverAt: %v", err)
+	}
+	if h.Symbol != "Load" || h.Kind != "function" {
+		t.Errorf("symbol = %q (%s), want Load (function)", h.Symbol, h.Kind)
+	}
+	if want := "func Load(string) ([]byte, error)"; h.Signature != want {
+		t.Errorf("Signature = %q, want %q", h.Signature, want)
+	}
+	if !reflect.DeepEqual(h.Signals, []string{"fs_reads"}) {
+		t.Errorf("Signals = %v, want [fs_reads]", h.Signals)
+	}
+	if !reflect.DeepEqual(h.Callers, []string{"run.go:Run"}) {
+		t.Errorf("Callers = %v, want [run.go:Run]", h.Callers)
+	}
+
+	// Line 5 is the Thing type declaration.
+	h, err = HoverAt(libPath, 5, 6, root)
+	if err != nil {
+		t.Fatalf("HoverAt type: %v", err)
+	}
+	if h.Symbol != "Thing" || h.Signature != "type Thing struct" {
+		t.Errorf("type hover = %+v", h)
+	}
+
+	if _, err := HoverAt(libPath, 2, 1, root); err == nil {
+		t.Error("expected an error on a blank line")
+	}
+}
+
+// TestCompactBundles verifies that compaction drops false signals and empty
+// sections, keeps the source hash and decoded content, is idempotent, and
+// refuses stale bundles.
+func TestCompactBundles(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "a.go")
+	if err := os.WriteFile(src, []byte("package a\n\nimport \"os\"\n\nfunc Read() { os.ReadFile(\"x\") }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("WalkAndGenerate: %v", errs)
+	}
+	bundlePath := src + ".evidence.yaml"
+	before, err := readBundle(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewritten, errs := CompactBundles(root)
+	if len(errs) != 0 || rewritten != 1 {
+		t.Fatalf("CompactBundles = %d, %v; want 1, none", rewritten, errs)
+	}
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "false") {
+		t.Errorf("compact bundle still has false signals:\n%s", data)
+	}
+	if !strings.Contains(string(data), "fs_reads: true") || !strings.Contains(string(data), "sha256: "+before.File.SHA256) {
+		t.Errorf("compact bundle lost content:\n%s", data)
+	}
+	after, err := readBundle(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("decoded bundle changed:\nbefore: %+v\nafter:  %+v", before, after)
+	}
+
+	if rewritten, errs := CompactBundles(root); rewritten != 0 || len(errs) != 0 {
+		t.Errorf("second CompactBundles = %d, %v; want 0, none", rewritten, errs)
+	}
+
+	if err := os.WriteFile(src, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := CompactBundles(root); len(errs) != 1 {
+		t.Errorf("expected a stale-bundle error, got %v", errs)
+	}
+}
+
+// --------------------------------------------------------------------------
+// Unit tests — .proto bundles
+// --------------------------------------------------------------------------
+
+// TestBuildProtoBundle verifies that services, RPCs, messages (with nested
+// types, oneof and map fields), enums, and imports are extracted, and that
+// comments and options are ignored.
+func TestBuildProtoBundle(t *testing.T) {
+	src := `syntax = "proto3";
+// Billing API.
+package acme.billing.v1;
+
+import "google/protobuf/timestamp.proto";
+import public "acme/common.proto";
+
+option go_package = "acme/billing/v1;billingv1";
+
+service Billing {
+  option (acme.auth) = true;
+  rpc Charge(ChargeRequest) returns (ChargeResponse);
+  rpc Watch(WatchRequest) returns (stream Event) {
+    option deprecated = true;
+  }
+}
+
+/* A charge against an account. */
+message ChargeRequest {
+  string account_id = 1;
+  repeated Item items = 2 [packed = true];
+  map<string, string> labels = 3;
+  oneof method {
+    string card = 4;
+    string wallet = 5;
+  }
+  message Item {
+    int64 cents = 1;
+  }
+  reserved 6 to 9;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+`
+	b := buildProtoBundle("api/billing.proto", "abc", src)
+
+	if b.Lang() != LanguageProto || b.Package.Name != "acme.billing.v1" {
+		t.Errorf("language, package = %q, %q", b.Lang(), b.Package.Name)
+	}
+	wantImports := []Import{{Path: "acme/common.proto"}, {Path: "google/protobuf/timestamp.proto"}}
+	if !reflect.DeepEqual(b.Package.Imports, wantImports) {
+		t.Errorf("imports = %v, want %v", b.Package.Imports, wantImports)
+	}
+	wantFuncs := []Function{
+		{Name: "Charge", Exported: true, Receiver: "Billing", Params: []string{"ChargeRequest"}, Returns: []string{"ChargeResponse"}},
+		{Name: "Watch", Exported: true, Receiver: "Billing", Params: []string{"WatchRequest"}, Returns: []string{"stream Event"}},
+	}
+	if !reflect.DeepEqual(b.Symbols.Functions, wantFuncs) {
+		t.Errorf("functions = %+v, want %+v", b.Symbols.Functions, wantFuncs)
+	}
+	var kinds []string
+	for _, td := range b.Symbols.Types {
+		kinds = append(kinds, td.Name+":"+td.Kind)
+	}
+	wantKinds := []string{"Billing:service", "ChargeRequest:message", "ChargeRequest.Item:message", "Status:enum"}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("types = %v, want %v", kinds, wantKinds)
+	}
+	wantFields := []FieldDecl{
+		{Name: "account_id", TypeStr: "string"},
+		{Name: "items", TypeStr: "repeated Item"},
+		{Name: "labels", TypeStr: "map<string, string>"},
+		{Name: "card", TypeStr: "string"},
+		{Name: "wallet", TypeStr: "string"},
+	}
+	if !reflect.DeepEqual(b.Symbols.Types[1].Fields, wantFields) {
+		t.Errorf("ChargeRequest fields = %+v, want %+v", b.Symbols.Types[1].Fields, wantFields)
+	}
+	if v := orderViolations(b); len(v) != 0 {
+		t.Errorf("order violations: %v", v)
+	}
+}
+
+// TestWalkAndGenerate_Proto verifies that .proto files get bundles, and
+// that a directory with no Go files is never loaded as a package.
+func TestWalkAndGenerate_Proto(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "svc.proto"), []byte("package svc;\nservice S { rpc Get(Req) returns (Resp); }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := loadDirPackage
+	defer func() { loadDirPackage = orig }()
+	loadDirPackage = func(dir string) (*packages.Package, *token.FileSet, error) {
+		t.Errorf("unexpected package load of %s", dir)
+		return orig(dir)
+	}
+
+	written, _, errs := WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 || written != 1 {
+		t.Fatalf("written = %d, errs = %v; want 1, none", written, errs)
+	}
+	b, err := readBundle(filepath.Join(root, "svc.proto.evidence.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Language != LanguageProto || b.File.Path != "svc.proto" || len(b.Symbols.Functions) != 1 {
+		t.Errorf("unexpected bundle: %+v", b)
+	}
+}
+
+// --------------------------------------------------------------------------
+// Unit tests — external plugins
+// --------------------------------------------------------------------------
+
+// TestWalkAndGenerate_Plugin verifies the exec protocol: a plugin declaring
+// .txt gets the stale .txt files, its bundles are written with iguana's
+// hash and the plugin's language, and an unchanged file is not sent again.
+func TestWalkAndGenerate_Plugin(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	root, pluginDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The plugin logs each analyze request so the test can count them.
+	script := `#!/bin/sh
+read -r req
+case "$req" in
+*configure*) echo '{"name":"text","extensions":[".txt"]}' ;;
+*) echo "$req" >> "` + filepath.Join(pluginDir, "requests.log") + `"
+   echo '{"bundles":[{"version":2,"file":{"path":"notes.txt"},"package":{"name":"notes"},"symbols":{},"signals":{}}]}' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(pluginDir, "text"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := WalkOptions{PluginDir: pluginDir}
+	written, _, errs := WalkAndGenerate(root, opts)
+	if len(errs) != 0 || written != 1 {
+		t.Fatalf("written = %d, errs = %v; want 1, none", written, errs)
+	}
+	b, err := readBundle(filepath.Join(root, "notes.txt.evidence.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("hello\n"))
+	if b.Language != "text" || b.File.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("language, sha256 = %q, %q", b.Language, b.File.SHA256)
+	}
+
+	written, skipped, errs := WalkAndGenerate(root, opts)
+	if len(errs) != 0 || written != 0 || skipped != 1 {
+		t.Errorf("second run: written = %d, skipped = %d, errs = %v; want 0, 1, none", written, skipped, errs)
+	}
+	log, err := os.ReadFile(filepath.Join(pluginDir, "requests.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(log), `"verb":"analyze"`); n != 1 {
+		t.Errorf("analyze requests = %d, want 1:\n%s", n, log)
+	}
+}
+
+// TestBuildTestBundle verifies that each test records the calls it and its
+// same-file helpers make, excluding calls on testing parameters, and that
+// non-test functions get no entry.
+func TestBuildTestBundle(t *testing.T) {
+	src := `package store_test
+
+import (
+	"testing"
+
+	"example.com/m/store"
+)
+
+func newStore(t *testing.T) *store.Store {
+	t.Helper()
+	return store.Open("mem")
+}
+
+func TestSave(t *testing.T) {
+	s := newStore(t)
+	t.Run("ok", func(t *testing.T) {
+		if err := s.Save(nil); err != nil {
+			t.Fatal(err)
 		}
-	}
-	return false
+	})
 }
 
-// ---------------------------------------------------------------------------
-// CleanEvidenceBundles
-// ---------------------------------------------------------------------------
+func BenchmarkLoad(b *testing.B) {
+	for range b.N {
+		store.Load("k")
+	}
+}
 
-// TestCleanEvidenceBundles verifies that CleanEvidenceBundles removes all
-// *.evidence.yaml files and returns the correct count, leaving other files alone.
-func TestCleanEvidenceBundles(t *testing.T) {
-	dir := t.TempDir()
+func Testify() {}
+`
+	tb := buildTestBundle("store/store_test.go", "h", parseSource(t, src))
 
-	// Create a mix of evidence files and unrelated files.
-	evFiles := []string{
-		"main.go.evidence.yaml",
-		"sub/store.go.evidence.yaml",
+	want := []TestCase{
+		{Name: "BenchmarkLoad", Exercises: []string{"store.Load"}},
+		{Name: "TestSave", Exercises: []string{"s.Save", "store.Open"}},
 	}
-	other := []string{
-		"main.go",
-		"system_model.yaml",
+	if !reflect.DeepEqual(tb.Tests, want) {
+		t.Errorf("tests = %+v\nwant %+v", tb.Tests, want)
 	}
-	for _, name := range append(evFiles, other...) {
-		path := filepath.Join(dir, name)
-		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-			t.Fatal(err)
-		}
-		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+	if tb.Package.Name != "store_test" || tb.File.Path != "store/store_test.go" {
+		t.Errorf("package, path = %q, %q", tb.Package.Name, tb.File.Path)
+	}
+}
+
+// TestWalkAndGenerate_IncludeTests verifies that test bundles are written
+// only in test mode, skipped when unchanged, and never reported as orphans.
+func TestWalkAndGenerate_IncludeTests(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, src string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
 			t.Fatal(err)
 		}
 	}
+	writeFile("go.mod", "module example.com/m\n\ngo 1.22\n")
+	writeFile("a.go", "package a\n\nfunc Add(x, y int) int { return x + y }\n")
+	writeFile("a_test.go", "package a\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) { Add(1, 2) }\n")
+	testBundle := filepath.Join(root, "a_test.go"+TestBundleSuffix)
 
-	removed, err := CleanEvidenceBundles(dir)
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if _, err := os.Stat(testBundle); !os.IsNotExist(err) {
+		t.Fatalf("test bundle written without IncludeTests (stat err %v)", err)
+	}
+
+	written, _, errs := WalkAndGenerate(root, WalkOptions{IncludeTests: true})
+	if len(errs) != 0 || written != 1 {
+		t.Fatalf("written = %d, errs = %v; want 1 test bundle", written, errs)
+	}
+	tb, err := ReadTestBundle(testBundle)
 	if err != nil {
-		t.Fatalf("CleanEvidenceBundles: %v", err)
+		t.Fatal(err)
 	}
-	if removed != len(evFiles) {
-		t.Errorf("removed %d files, want %d", removed, len(evFiles))
+	if want := []TestCase{{Name: "TestAdd", Exercises: []string{"Add"}}}; !reflect.DeepEqual(tb.Tests, want) {
+		t.Errorf("tests = %+v, want %+v", tb.Tests, want)
 	}
 
-	// Evidence files must be gone.
-	for _, name := range evFiles {
-		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
-			t.Errorf("expected %s to be removed", name)
-		}
+	written, _, errs = WalkAndGenerate(root, WalkOptions{IncludeTests: true})
+	if len(errs) != 0 || written != 0 {
+		t.Errorf("second run: written = %d, errs = %v; want 0, none", written, errs)
 	}
-	// Other files must still exist.
-	for _, name := range other {
-		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
-			t.Errorf("expected %s to still exist: %v", name, err)
-		}
+	if problems, err := CheckOrphans(root); err != nil || len(problems) != 0 {
+		t.Errorf("CheckOrphans = %v, %v; want none", problems, err)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Skip logic (INV-50)
-// ---------------------------------------------------------------------------
+// TestMergePackageBundle verifies that a package bundle sorts the merged
+// symbols, deduplicates calls and imports, ORs signals, and records every
+// file's hash.
+func TestMergePackageBundle(t *testing.T) {
+	a := &EvidenceBundle{
+		Version: 2,
+		File:    FileMeta{Path: "p/a.go", SHA256: "aa"},
+		Package: PackageMeta{Name: "p", Imports: []Import{{Path: "os"}}},
+		Symbols: Symbols{Functions: []Function{{Name: "Z"}}},
+		Calls:   []Call{{From: "Z", To: "os.Exit"}},
+		Signals: Signals{Exits: true, IgnoredErrors: 1},
+	}
+	b := &EvidenceBundle{
+		Version: 2,
+		File:    FileMeta{Path: "p/b.go", SHA256: "bb"},
+		Package: PackageMeta{Name: "p", Imports: []Import{{Path: "fmt"}, {Path: "os"}}},
+		Symbols: Symbols{Functions: []Function{{Name: "A"}}},
+		Calls:   []Call{{From: "A", To: "fmt.Println"}, {From: "Z", To: "os.Exit"}},
+		Signals: Signals{FSReads: true, IgnoredErrors: 2, ConcurrencyKinds: []string{"mutex"}},
+	}
 
-// TestWriteEvidenceBundle_SkipsIfUpToDate verifies INV-50: WriteEvidenceBundle
-// returns skipped=true without overwriting if the existing bundle has the
-// same file.sha256 as the new bundle.
-func TestWriteEvidenceBundle_SkipsIfUpToDate(t *testing.T) {
-	// Use a temp dir so WriteEvidenceBundle never touches the committed evidence yaml.
-	tmpDir := t.TempDir()
-	src, err := os.ReadFile("generate.go")
-	if err != nil {
-		t.Fatalf("read generate.go: %v", err)
+	m := MergePackageBundle("p", []*EvidenceBundle{a, b})
+	if m.File.Path != "p/package" || m.File.SHA256 != packageHash(m.Files) {
+		t.Errorf("file = %+v", m.File)
 	}
-	tmpFile := filepath.Join(tmpDir, "generate.go")
-	if err := os.WriteFile(tmpFile, src, 0o644); err != nil {
-		t.Fatalf("write temp: %v", err)
+	if !m.IsPackageBundle() || !reflect.DeepEqual(m.Files, []FileMeta{a.File, b.File}) {
+		t.Errorf("files = %+v", m.Files)
+	}
+	if got := []string{m.Package.Imports[0].Path, m.Package.Imports[1].Path}; len(m.Package.Imports) != 2 || got[0] != "fmt" || got[1] != "os" {
+		t.Errorf("imports = %+v", m.Package.Imports)
+	}
+	if len(m.Symbols.Functions) != 2 || m.Symbols.Functions[0].Name != "A" {
+		t.Errorf("functions = %+v", m.Symbols.Functions)
+	}
+	wantCalls := []Call{{From: "A", To: "fmt.Println"}, {From: "Z", To: "os.Exit"}}
+	if !reflect.DeepEqual(m.Calls, wantCalls) {
+		t.Errorf("calls = %+v, want %+v", m.Calls, wantCalls)
+	}
+	if !m.Signals.Exits || !m.Signals.FSReads || m.Signals.IgnoredErrors != 3 || !reflect.DeepEqual(m.Signals.ConcurrencyKinds, []string{"mutex"}) {
+		t.Errorf("signals = %+v", m.Signals)
 	}
+	if v := orderViolations(m); len(v) != 0 {
+		t.Errorf("order violations: %v", v)
+	}
+}
 
-	b, err := CreateEvidenceBundle(tmpFile)
-	if err != nil {
-		t.Fatalf("CreateEvidenceBundle: %v", err)
+// TestWalkAndGenerate_PerPackage verifies that PerPackage writes one bundle
+// per directory, skips it while no file changes, keeps the checks passing,
+// and that switching back to per-file bundles removes it.
+func TestWalkAndGenerate_PerPackage(t *testing.T) {
+	root := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": "package p\nfunc A() { B() }\n",
+		"b.go": "package p\nfunc B() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
 	}
-	outputPath := tmpFile + ".evidence.yaml"
-	// No t.Cleanup needed — t.TempDir() removes the directory automatically.
+	pkgPath := filepath.Join(root, "package.evidence.yaml")
 
-	// First write — must not be skipped.
-	skipped, err := WriteEvidenceBundle(b, false)
+	written, skipped, errs := WalkAndGenerate(root, WalkOptions{PerPackage: true})
+	if len(errs) != 0 || written != 1 || skipped != 0 {
+		t.Fatalf("first pass: written=%d skipped=%d errs=%v, want 1/0/none", written, skipped, errs)
+	}
+	b, err := readBundle(pkgPath)
 	if err != nil {
-		t.Fatalf("first WriteEvidenceBundle: %v", err)
+		t.Fatal(err)
 	}
-	if skipped {
-		t.Error("first write should not be skipped")
+	if len(b.Files) != 2 || b.Files[0].Path != "a.go" || b.Files[1].Path != "b.go" {
+		t.Errorf("files = %+v", b.Files)
+	}
+	if len(b.Symbols.Functions) != 2 {
+		t.Errorf("functions = %+v", b.Symbols.Functions)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.go.evidence.yaml")); !os.IsNotExist(err) {
+		t.Errorf("per-file bundle written in per-package mode: %v", err)
+	}
+	for _, check := range []func(string) ([]string, error){CheckFreshness, CheckOrphans, CheckOrder} {
+		if problems, err := check(root); err != nil || len(problems) != 0 {
+			t.Errorf("check: %v %v", problems, err)
+		}
 	}
 
-	// Record the mtime of the written file.
-	stat1, err := os.Stat(outputPath)
-	if err != nil {
-		t.Fatalf("stat: %v", err)
+	written, skipped, errs = WalkAndGenerate(root, WalkOptions{PerPackage: true})
+	if len(errs) != 0 || written != 0 || skipped != 1 {
+		t.Errorf("second pass: written=%d skipped=%d errs=%v, want 0/1/none", written, skipped, errs)
 	}
 
-	// Second write — same SHA256, must be skipped (file not overwritten).
-	skipped, err = WriteEvidenceBundle(b, false)
-	if err != nil {
-		t.Fatalf("second WriteEvidenceBundle: %v", err)
+	written, _, errs = WalkAndGenerate(root, WalkOptions{})
+	if len(errs) != 0 || written != 2 {
+		t.Errorf("per-file pass: written=%d errs=%v, want 2/none", written, errs)
 	}
-	if !skipped {
-		t.Error("second write should be skipped (same SHA256)")
+	if _, err := os.Stat(pkgPath); !os.IsNotExist(err) {
+		t.Errorf("package bundle not removed in per-file mode: %v", err)
 	}
 
-	// File must not have been modified.
-	stat2, err := os.Stat(outputPath)
-	if err != nil {
-		t.Fatalf("stat after second write: %v", err)
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{PerPackage: true, Schema: SchemaClassic}); len(errs) == 0 {
+		t.Error("PerPackage with the classic schema: want error")
 	}
-	if !stat2.ModTime().Equal(stat1.ModTime()) {
-		t.Error("file mtime changed on skipped write")
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{PerPackage: true, Include: []string{"a.go"}}); len(errs) == 0 {
+		t.Error("PerPackage with Include: want error")
 	}
 }
 
-// TestWriteEvidenceBundle_ForceOverwrites verifies that --force bypasses the
-// skip check and always writes.
-func TestWriteEvidenceBundle_ForceOverwrites(t *testing.T) {
-	// Use a temp dir so WriteEvidenceBundle never touches the committed evidence yaml.
-	tmpDir := t.TempDir()
-	src, err := os.ReadFile("generate.go")
-	if err != nil {
-		t.Fatalf("read generate.go: %v", err)
+// TestCheck_PerPackageDeletedFile verifies that deleting one file of a
+// package bundle makes both the freshness and the orphan check fail.
+func TestCheck_PerPackageDeletedFile(t *testing.T) {
+	root := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": "package p\nfunc A() {}\n",
+		"b.go": "package p\nfunc B() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
 	}
-	tmpFile := filepath.Join(tmpDir, "generate.go")
-	if err := os.WriteFile(tmpFile, src, 0o644); err != nil {
-		t.Fatalf("write temp: %v", err)
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{PerPackage: true}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if err := os.Remove(filepath.Join(root, "b.go")); err != nil {
+		t.Fatal(err)
 	}
 
-	b, err := CreateEvidenceBundle(tmpFile)
+	problems, err := CheckFreshness(root)
 	if err != nil {
-		t.Fatalf("CreateEvidenceBundle: %v", err)
+		t.Fatal(err)
 	}
-	outputPath := tmpFile + ".evidence.yaml"
-	// No t.Cleanup needed — t.TempDir() removes the directory automatically.
-
-	// First write.
-	if _, err := WriteEvidenceBundle(b, false); err != nil {
-		t.Fatalf("first write: %v", err)
+	if want := []string{"package: evidence bundle is stale (b.go no longer analyzed)"}; !slices.Equal(problems, want) {
+		t.Errorf("freshness = %q, want %q", problems, want)
 	}
-	stat1, _ := os.Stat(outputPath)
-
-	// Force write — must not be skipped even though SHA is identical.
-	skipped, err := WriteEvidenceBundle(b, true)
+	problems, err = CheckOrphans(root)
 	if err != nil {
-		t.Fatalf("force write: %v", err)
-	}
-	if skipped {
-		t.Error("force write must not be skipped")
+		t.Fatal(err)
 	}
-	stat2, _ := os.Stat(outputPath)
-	if !stat2.ModTime().After(stat1.ModTime()) {
-		// ModTime resolution may be low on some filesystems; just confirm no error.
-		t.Log("note: mtime did not advance (filesystem resolution)")
+	if want := []string{"b.go: orphaned evidence bundle"}; !slices.Equal(problems, want) {
+		t.Errorf("orphans = %q, want %q", problems, want)
 	}
 }
 
-// TestWalkAndGenerate_SkipsUnchanged verifies INV-50: a second WalkAndGenerate
-// call on the same directory (no source changes) reports all files as skipped.
-func TestWalkAndGenerate_SkipsUnchanged(t *testing.T) {
+// TestWalkAndGenerate_RepoConfig verifies that .iguana.yaml allow rules
+// reach into denied directories, include_generated: false skips generated
+// files, and include_tests writes test bundles.
+func TestWalkAndGenerate_RepoConfig(t *testing.T) {
 	root := t.TempDir()
-	src := "package main\nfunc Hello() {}\n"
-	goFile := filepath.Join(root, "hello.go")
-	if err := os.WriteFile(goFile, []byte(src), 0o644); err != nil {
+	for rel, src := range map[string]string{
+		"main.go":       "package main\nfunc main() {}\n",
+		"main_test.go":  "package main\nimport \"testing\"\nfunc TestMain2(t *testing.T) { main() }\n",
+		"zz_gen.go":     "// Code generated by stringer; DO NOT EDIT.\n\npackage main\n",
+		"gen/drop/d.go": "package drop\n",
+		"gen/keep/k.go": "package keep\n",
+		".iguana.yaml":  "deny: [\"gen/**\"]\nallow: [\"gen/keep/**\"]\ninclude_tests: true\ninclude_generated: false\n",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	for rel, want := range map[string]bool{
+		"main.go.evidence.yaml":           true,
+		"main_test.go" + TestBundleSuffix: true,
+		"zz_gen.go.evidence.yaml":         false,
+		"gen/drop/d.go.evidence.yaml":     false,
+		"gen/keep/k.go.evidence.yaml":     true,
+	} {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+// TestDecode verifies that Decode reads current bundles, migrates version 1
+// bundles forward, and rejects missing, malformed, and future versions with
+// ErrUnsupportedVersion.
+func TestDecode(t *testing.T) {
+	b, err := Decode([]byte("version: 2\nfile:\n  path: a.go\n  sha256: abc\npackage:\n  name: a\n"))
+	if err != nil {
 		t.Fatal(err)
 	}
+	if b.Version != CurrentVersion || b.File.Path != "a.go" || b.Package.Name != "a" {
+		t.Errorf("v2 = %+v", b)
+	}
 
-	// First pass — must write.
-	written1, skipped1, errs := WalkAndGenerate(root, false)
-	if len(errs) != 0 {
-		t.Fatalf("first pass errors: %v", errs)
+	b, err = Decode([]byte("version: 1\nfile:\n  path: a.go\n  sha256: abc\npackage:\n  name: a\nsignals:\n  fs_reads: true\n"))
+	if err != nil {
+		t.Fatal(err)
 	}
-	if written1 != 1 || skipped1 != 0 {
-		t.Errorf("first pass: written=%d skipped=%d, want 1/0", written1, skipped1)
+	if b.Version != CurrentVersion || b.File.SHA256 != "abc" || !b.Signals.FSReads {
+		t.Errorf("migrated v1 = %+v", b)
+	}
+
+	// A complete version 1 document, as iguana wrote before the version 2
+	// sections existed, keeps every section through the migration.
+	b, err = Decode([]byte(`version: 1
+file:
+  path: store/store.go
+  sha256: 9f2c
+package:
+  name: store
+  imports:
+    - path: database/sql
+    - path: os
+symbols:
+  functions:
+    - name: Open
+      exported: true
+      params: [string]
+      returns: ["*Store", error]
+    - name: Save
+      exported: true
+      receiver: "*Store"
+      params: [Record]
+      returns: [error]
+  types:
+    - name: Record
+      kind: struct
+      exported: true
+      fields:
+        - name: ID
+          type: string
+  variables:
+    - name: ErrClosed
+      exported: true
+  constants:
+    - name: maxRows
+      exported: false
+  constructors: [Open]
+calls:
+  - from: Open
+    to: sql.Open
+  - from: Save
+    to: os.WriteFile
+signals:
+  fs_writes: true
+  db_calls: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &EvidenceBundle{
+		Version: CurrentVersion,
+		File:    FileMeta{Path: "store/store.go", SHA256: "9f2c"},
+		Package: PackageMeta{Name: "store", Imports: []Import{{Path: "database/sql"}, {Path: "os"}}},
+		Symbols: Symbols{
+			Functions: []Function{
+				{Name: "Open", Exported: true, Params: []string{"string"}, Returns: []string{"*Store", "error"}},
+				{Name: "Save", Exported: true, Receiver: "*Store", Params: []string{"Record"}, Returns: []string{"error"}},
+			},
+			Types:        []TypeDecl{{Name: "Record", Kind: "struct", Exported: true, Fields: []FieldDecl{{Name: "ID", TypeStr: "string"}}}},
+			Variables:    []VarDecl{{Name: "ErrClosed", Exported: true}},
+			Constants:    []VarDecl{{Name: "maxRows"}},
+			Constructors: []string{"Open"},
+		},
+		Calls:   []Call{{From: "Open", To: "sql.Open"}, {From: "Save", To: "os.WriteFile"}},
+		Signals: Signals{FSWrites: true, DBCalls: true},
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("migrated v1 document =\n%+v\nwant\n%+v", b, want)
 	}
 
-	// Second pass — same source, must skip.
-	written2, skipped2, errs := WalkAndGenerate(root, false)
-	if len(errs) != 0 {
-		t.Fatalf("second pass errors: %v", errs)
+	for _, doc := range []string{
+		"file:\n  path: a.go\n",
+		"version: two\n",
+		"version: 0\n",
+		"version: 3\nfile:\n  path: a.go\n",
+	} {
+		if _, err := Decode([]byte(doc)); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("Decode(%q) = %v, want ErrUnsupportedVersion", doc, err)
+		}
 	}
-	if written2 != 0 || skipped2 != 1 {
-		t.Errorf("second pass: written=%d skipped=%d, want 0/1", written2, skipped2)
+	if _, err := Decode([]byte("version: 3\n")); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("future version error = %v, want it to say newer", err)
 	}
 }
 
-// TestWalkAndGenerate_RegeneratesOnChange verifies that modifying a source
-// file causes WalkAndGenerate to regenerate its bundle (not skip it).
-func TestWalkAndGenerate_RegeneratesOnChange(t *testing.T) {
-	root := t.TempDir()
-	goFile := filepath.Join(root, "hello.go")
-	if err := os.WriteFile(goFile, []byte("package main\nfunc Hello() {}\n"), 0o644); err != nil {
-		t.Fatal(err)
+// --------------------------------------------------------------------------
+// Unit tests — bundle diff
+// --------------------------------------------------------------------------
+
+// TestDiffBundles verifies that added, removed, and changed entries are
+// reported per section, signal changes by name, and hashes are ignored.
+func TestDiffBundles(t *testing.T) {
+	old := &EvidenceBundle{
+		File:    FileMeta{Path: "store/db.go", SHA256: "a"},
+		Package: PackageMeta{Name: "store", Imports: []Import{{Path: "fmt"}, {Path: "os"}}},
+		Symbols: Symbols{Functions: []Function{
+			{Name: "Save", Receiver: "*Store", Params: []string{"string"}},
+			{Name: "load"},
+		}},
+		Calls:   []Call{{From: "load", To: "os.ReadFile"}},
+		Signals: Signals{FSReads: true},
+	}
+	new := &EvidenceBundle{
+		File:    FileMeta{Path: "store/db.go", SHA256: "b"},
+		Package: PackageMeta{Name: "store", Imports: []Import{{Path: "database/sql"}, {Path: "fmt"}}},
+		Symbols: Symbols{Functions: []Function{
+			{Name: "Open"},
+			{Name: "Save", Receiver: "*Store", Params: []string{"context.Context", "string"}},
+		}},
+		Signals: Signals{DBCalls: true, IgnoredErrors: 1},
+	}
+
+	d := DiffBundles(old, new)
+	if d == nil {
+		t.Fatal("expected a diff")
+	}
+	want := &BundleDiff{
+		Path:      "store/db.go",
+		Status:    DiffChanged,
+		Imports:   SetDiff{Added: []string{"database/sql"}, Removed: []string{"os"}},
+		Functions: SetDiff{Added: []string{"Open"}, Removed: []string{"load"}, Changed: []string{"Store.Save"}},
+		Calls:     SetDiff{Removed: []string{"load -> os.ReadFile"}},
+		Signals: []SignalChange{
+			{Name: "fs_reads", From: "true", To: "false"},
+			{Name: "db_calls", From: "false", To: "true"},
+			{Name: "ignored_errors", From: "0", To: "1"},
+		},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("diff = %+v\nwant %+v", d, want)
 	}
 
-	// First pass — write.
-	WalkAndGenerate(root, false) //nolint:errcheck
+	same := *old
+	same.File.SHA256 = "c"
+	if d := DiffBundles(old, &same); d != nil {
+		t.Errorf("hash-only change: diff = %+v, want nil", d)
+	}
+	if d := DiffBundles(nil, new); d == nil || d.Status != DiffAdded {
+		t.Errorf("added file: diff = %+v", d)
+	}
+}
 
-	// Modify the source file.
-	if err := os.WriteFile(goFile, []byte("package main\nfunc Hello() {}\nfunc World() {}\n"), 0o644); err != nil {
+// TestDiffTrees verifies that bundles are paired by source path across two
+// trees and that identical files are omitted.
+func TestDiffTrees(t *testing.T) {
+	oldRoot, newRoot := t.TempDir(), t.TempDir()
+	write := func(root, name, src string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(oldRoot, "a.go", "package main\n\nfunc A() {}\n")
+	write(oldRoot, "b.go", "package main\n\nfunc B() {}\n")
+	write(newRoot, "a.go", "package main\n\nfunc A() {}\n\nfunc A2() {}\n")
+	write(newRoot, "c.go", "package main\n\nfunc C() {}\n")
+	for _, root := range []string{oldRoot, newRoot} {
+		if _, _, errs := WalkAndGenerate(root, WalkOptions{}); len(errs) != 0 {
+			t.Fatal(errs)
+		}
+	}
+
+	diffs, err := DiffTrees(oldRoot, newRoot)
+	if err != nil {
 		t.Fatal(err)
 	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path+":"+d.Status)
+	}
+	if want := []string{"a.go:changed", "b.go:removed", "c.go:added"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffs = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(diffs[0].Functions.Added, []string{"A2"}) {
+		t.Errorf("a.go functions = %+v, want A2 added", diffs[0].Functions)
+	}
+}
 
-	// Second pass — source changed, must regenerate (written=1, skipped=0).
-	written, skipped, errs := WalkAndGenerate(root, false)
-	if len(errs) != 0 {
-		t.Fatalf("errors: %v", errs)
+// TestWorkspace verifies module discovery in a go.work layout: each bundle
+// records its own module, and a module the workspace does not use is
+// loaded with GOWORK=off.
+func TestWorkspace(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"go.work":      "go 1.22\n\nuse (\n\t./api // service\n)\n",
+		"api/go.mod":   "module example.com/api\n",
+		"api/api.go":   "package api\n\nimport \"example.com/api/internal/db\"\n\nvar _ = db.X\n",
+		"tools/go.mod": "module example.com/tools\n",
+		"tools/gen.go": "package tools\n",
+	} {
+		abs := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
 	}
-	if written != 1 || skipped != 0 {
-		t.Errorf("after change: written=%d skipped=%d, want 1/0", written, skipped)
+
+	if uses := workspaceUses(filepath.Join(root, "go.work")); len(uses) != 1 || uses[0] != filepath.Join(root, "api") {
+		t.Errorf("workspaceUses = %v, want [api]", uses)
+	}
+	if env := loadEnv(filepath.Join(root, "api")); env != nil {
+		t.Errorf("loadEnv(api) = %v, want inherited environment", env)
+	}
+	if env := loadEnv(filepath.Join(root, "tools")); !slices.Contains(env, "GOWORK=off") {
+		t.Error("loadEnv(tools) does not set GOWORK=off")
+	}
+
+	WalkAndGenerate(root, WalkOptions{}) // load errors fall back to AST-only bundles
+	for path, want := range map[string]string{"api/api.go": "example.com/api", "tools/gen.go": "example.com/tools"} {
+		b, err := readBundle(filepath.Join(root, filepath.FromSlash(path)) + ".evidence.yaml")
+		if err != nil {
+			t.Fatalf("readBundle %s: %v", path, err)
+		}
+		if b.Package.Module != want {
+			t.Errorf("%s: package.module = %q, want %q", path, b.Package.Module, want)
+		}
 	}
 }
 
-// TestWalkAndGenerate_ForceRegeneratesAll verifies that --force causes all
-// bundles to be regenerated even when source is unchanged.
-func TestWalkAndGenerate_ForceRegeneratesAll(t *testing.T) {
+// TestWatchTree verifies that the watcher analyzes the tree at startup,
+// again after a source edit, rewriting only the changed file's bundle, and
+// after a deletion, removing the deleted file's bundle.
+func TestWatchTree(t *testing.T) {
 	root := t.TempDir()
-	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package main\nfunc A() {}\n"), 0o644); err != nil {
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package p\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs := make(chan [3]int, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchTree(ctx, root, WalkOptions{}, 10*time.Millisecond, func(written, skipped, removed int, errs []error) {
+			if len(errs) != 0 {
+				t.Errorf("run errors: %v", errs)
+			}
+			runs <- [3]int{written, skipped, removed}
+		})
+	}()
+	wait := func() [3]int {
+		t.Helper()
+		select {
+		case r := <-runs:
+			return r
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a run")
+			return [3]int{}
+		}
+	}
+
+	if r := wait(); r != [3]int{2, 0, 0} {
+		t.Errorf("initial run = %v, want 2 written", r)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.go"), []byte("package p\n\nfunc B() {}\n"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(root, "b.go"), []byte("package main\nfunc B() {}\n"), 0o644); err != nil {
+	if r := wait(); r != [3]int{1, 1, 0} {
+		t.Errorf("run after edit = %v, want 1 written, 1 up to date", r)
+	}
+	if err := os.Remove(filepath.Join(root, "a.go")); err != nil {
 		t.Fatal(err)
 	}
-
-	// First pass — write both.
-	WalkAndGenerate(root, false) //nolint:errcheck
-
-	// Force pass — must write both even though nothing changed.
-	written, skipped, errs := WalkAndGenerate(root, true)
-	if len(errs) != 0 {
-		t.Fatalf("errors: %v", errs)
+	if r := wait(); r != [3]int{0, 1, 1} {
+		t.Errorf("run after delete = %v, want 1 removed, 1 up to date", r)
 	}
-	if written != 2 || skipped != 0 {
-		t.Errorf("force pass: written=%d skipped=%d, want 2/0", written, skipped)
+	if _, err := os.Stat(filepath.Join(root, "a.go.evidence.yaml")); !os.IsNotExist(err) {
+		t.Errorf("deleted file's bundle still present (err=%v)", err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchTree: %v", err)
 	}
 }
@@ -4,8 +4,10 @@ package evidence
 // directory walking.
 
 import (
+	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -13,9 +15,14 @@ import (
 	"go/types"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/tools/go/packages"
 
@@ -56,29 +63,51 @@ func CreateEvidenceBundle(filePath string) (*EvidenceBundle, error) {
 		typesPkg = nil
 	}
 
-	return buildBundle(normalizedPath, hash, file, typesInfo, typesPkg), nil
+	module := findModulePath(filepath.Dir(filePath))
+	return buildBundle(normalizedPath, hash, module, file, typesInfo, typesPkg), nil
 }
 
 // buildBundle assembles an EvidenceBundle from pre-loaded AST and type data.
 // normalizedPath is already slash-normalized; hash is the hex-encoded SHA256.
+// module is the enclosing module path used to classify imports ("" if unknown).
 // typesInfo and typesPkg may be nil (AST-only fallback).
-func buildBundle(normalizedPath, hash string, file *ast.File, typesInfo *types.Info, typesPkg *types.Package) *EvidenceBundle {
+func buildBundle(normalizedPath, hash, module string, file *ast.File, typesInfo *types.Info, typesPkg *types.Package) *EvidenceBundle {
 	qualifier := makeQualifier(typesPkg)
 	pkgMeta := extractPackageMeta(file)
+	pkgMeta.Module = module
+	for i := range pkgMeta.Imports {
+		pkgMeta.Imports[i].Class = ClassifyImport(pkgMeta.Imports[i].Path, module)
+	}
 	syms := extractSymbols(file, typesInfo, typesPkg, qualifier)
 	calls := extractCalls(file, typesInfo, typesPkg, qualifier)
 	sigs := extractSignals(pkgMeta, calls, file)
+	sigs.IgnoredErrors = countIgnoredErrors(file, typesInfo)
+	sigs.ErrorReturnsIgnored = countDroppedErrors(file, typesInfo)
+	concurrency := extractConcurrency(file, calls, typesInfo, typesPkg, qualifier)
+	if concurrency != nil {
+		sigs.Concurrency = true
+	}
+	var queries []Query
+	if sigs.DBCalls {
+		queries = extractQueries(file, typesInfo, typesPkg, qualifier)
+	}
 
 	return &EvidenceBundle{
-		Version: 2,
+		Version: CurrentVersion,
 		File: FileMeta{
 			Path:   normalizedPath,
 			SHA256: hash,
 		},
-		Package: pkgMeta,
-		Symbols: syms,
-		Calls:   calls,
-		Signals: sigs,
+		Package:      pkgMeta,
+		Symbols:      syms,
+		Calls:        calls,
+		ConfigInputs: extractConfigInputs(file, typesInfo, typesPkg, qualifier),
+		CLIFlags:     extractCLIFlags(file, typesInfo, typesPkg, qualifier),
+		Queries:      queries,
+		Routes:       extractRoutes(file, typesInfo, typesPkg, qualifier),
+		Endpoints:    extractEndpoints(file, typesInfo, typesPkg, qualifier),
+		Concurrency:  concurrency,
+		Signals:      sigs,
 	}
 }
 
@@ -86,62 +115,186 @@ func buildBundle(normalizedPath, hash string, file *ast.File, typesInfo *types.I
 // Directory Walking
 // ---------------------------------------------------------------------------
 
+// WalkOptions controls which files WalkAndGenerate analyzes and whether
+// up-to-date bundles are rewritten.
+type WalkOptions struct {
+	// Force rewrites every bundle even when its source is unchanged (INV-52).
+	Force bool
+	// Include is an allow-list of root-relative globs. When non-empty, only
+	// files matching at least one glob are analyzed; built-in and settings
+	// skips still apply. An empty list includes every file.
+	Include []string
+	// Clean removes every existing *.evidence.yaml under root before
+	// analyzing, so bundles for deleted or renamed files do not linger.
+	// It cannot be combined with Include.
+	Clean bool
+	// Schema is the bundle profile to emit (SchemaLatest or SchemaClassic);
+	// empty means SchemaLatest.
+	Schema string
+	// Stream processes each directory as the walk reaches it instead of
+	// collecting every file first, bounding memory on very large trees.
+	// Bundles are identical; only the directory processing order (and so
+	// the order of errs) may differ from the default sorted order.
+	Stream bool
+	// ConcurrencyBudget is the most directories whose packages are loaded
+	// with full type info at once; directories up to the budget are
+	// processed in parallel. Values below 1 mean 1 (one at a time). Output
+	// and the order of errs do not depend on the budget.
+	ConcurrencyBudget int
+	// PluginDir holds external evidence producers (see plugin.go), run
+	// after the Go files are analyzed; empty means no plugins.
+	PluginDir string
+	// IncludeTests also writes a test bundle for every _test.go file (see
+	// testbundle.go). Test files are still never analyzed as sources.
+	// The settings include_tests key turns it on as well.
+	IncludeTests bool
+	// Progress, if non-nil, is called after each step of the run (see
+	// progress.go).
+	Progress func(Progress)
+	// PerPackage writes one package bundle per directory, merging its Go
+	// files, instead of one bundle per file (see pkgbundle.go). Proto
+	// files still get per-file bundles. Not supported with SchemaClassic
+	// or Include (and so not by GenerateChanged).
+	PerPackage bool
+	// Store, if non-nil, receives every Go, proto, and plugin bundle
+	// instead of its companion file, and decides which are up to date.
+	// The on_bundle hook, which names a bundle file, does not run. Not
+	// supported with PerPackage or IncludeTests, whose bundles are
+	// always files.
+	Store BundleStore
+
+	// paths, when non-nil, further limits the walk to these exact
+	// root-relative paths (see fileFilter); set by GenerateChanged.
+	paths map[string]bool
+	// onWrite, if non-nil, is called with the root-relative path of each
+	// bundle written, possibly from several goroutines.
+	onWrite func(rel string)
+	// signalsSHA256 is the settings' SignalsSHA256, set by WalkAndGenerate.
+	signalsSHA256 string
+}
+
+// filter returns the include filter of a walk with opts.
+func (opts WalkOptions) filter() fileFilter {
+	return fileFilter{globs: opts.Include, paths: opts.paths}
+}
+
 // WalkAndGenerate walks root recursively, generating an evidence bundle for
-// every .go file found. Directories named vendor, testdata, or starting with
+// every .go and .proto file found. Directories named vendor, testdata, or starting with
 // "." are skipped entirely (INV-24). Directories and files are processed in
 // sorted order (INV-25). Each directory's package is loaded once (INV-26).
 //
-// If force is false, files whose existing bundle SHA256 matches the current
-// source are skipped (INV-50). Returns counts of written and skipped files.
-func WalkAndGenerate(root string, force bool) (written, skipped int, errs []error) {
+// If opts.Force is false, files whose existing bundle SHA256 matches the
+// current source are skipped (INV-50). If opts.Clean is true, all existing
+// bundles under root are removed first. The settings on_bundle hook runs
+// after each written bundle; its failures are collected in errs. With
+// opts.Stream, directories are processed as the walk reaches them; with
+// opts.ConcurrencyBudget above 1, that many directories may be loaded and
+// processed at once. Plugins in opts.PluginDir then analyze the files with
+// their extensions, and with opts.IncludeTests test bundles are written.
+// opts.Progress is told about each step. Returns counts of written and
+// skipped files.
+func WalkAndGenerate(root string, opts WalkOptions) (written, skipped int, errs []error) {
+	if err := ValidateSchema(opts.Schema); err != nil {
+		errs = append(errs, err)
+		return
+	}
+	if opts.PerPackage && opts.Schema == SchemaClassic {
+		errs = append(errs, fmt.Errorf("per-package bundles cannot be written in the %s schema", SchemaClassic))
+		return
+	}
+	if opts.Clean && len(opts.Include) > 0 {
+		errs = append(errs, fmt.Errorf("clean removes every bundle and cannot be combined with include globs"))
+		return
+	}
+	if opts.PerPackage && (len(opts.Include) > 0 || opts.paths != nil) {
+		errs = append(errs, fmt.Errorf("per-package bundles cover whole directories and cannot be combined with include globs"))
+		return
+	}
 	s, err := settings.LoadSettings(root)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("load settings: %w", err))
 		return
 	}
+	if s.TestsIncluded() {
+		opts.IncludeTests = true
+	}
+	opts.signalsSHA256 = s.SignalsSHA256()
+	if opts.Store != nil && (opts.PerPackage || opts.IncludeTests) {
+		errs = append(errs, fmt.Errorf("package and test bundles cannot be written to a store"))
+		return
+	}
 
-	// Collect .go files grouped by directory.
-	filesByDir := make(map[string][]string)
-	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	if opts.Clean {
+		if _, err := CleanEvidenceBundles(root); err != nil {
+			errs = append(errs, fmt.Errorf("clean %s: %w", root, err))
+			return
 		}
-		name := d.Name()
+	}
 
-		// Compute the forward-slash relative path for settings checks.
-		rel, _ := filepath.Rel(root, path)
-		rel = filepath.ToSlash(rel)
+	plugins, pluginErrs := DiscoverPlugins(opts.PluginDir)
+	errs = append(errs, pluginErrs...)
+	progress := newProgressReporter(root, opts.Progress)
 
-		if d.IsDir() {
-			// Always descend into the root itself.
-			if path == root {
-				return nil
-			}
-			// Skip vendor, testdata, examples, docs, and hidden directories (INV-24).
-			if name == "vendor" || name == "testdata" || name == "examples" || name == "docs" || strings.HasPrefix(name, ".") {
-				return filepath.SkipDir
-			}
-			// Skip directories denied by settings (INV-39).
-			if s.IsDenied(rel) {
-				return filepath.SkipDir
-			}
-			return nil
+	// Directories are handed to generateDir as they are found, at most
+	// ConcurrencyBudget at a time; results are kept in submission order.
+	type dirResult struct {
+		written, skipped int
+		errs             []error
+	}
+	var results []*dirResult
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(opts.ConcurrencyBudget, 1))
+	process := func(files []string) {
+		r := &dirResult{}
+		results = append(results, r)
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			start := time.Now()
+			r.written, r.skipped, r.errs = generateDir(root, files, s, opts)
+			progress.report(PhaseAnalyze, filepath.Dir(files[0]), len(files), start)
+		}()
+	}
+	collect := func() {
+		wg.Wait()
+		for _, r := range results {
+			written += r.written
+			skipped += r.skipped
+			errs = append(errs, r.errs...)
 		}
-		if filepath.Ext(name) != ".go" {
-			return nil
+		for _, p := range plugins {
+			start := time.Now()
+			w, sk, e := runPlugin(root, p, s, opts)
+			written += w
+			skipped += sk
+			errs = append(errs, e...)
+			progress.report(PhasePlugin, p.Name, 0, start)
 		}
-		// Skip test files (INV-24).
-		if strings.HasSuffix(name, "_test.go") {
-			return nil
+		if opts.IncludeTests {
+			start := time.Now()
+			w, sk, e := generateTestBundles(root, s, opts)
+			written += w
+			skipped += sk
+			errs = append(errs, e...)
+			progress.report(PhaseTests, "", 0, start)
 		}
-		// Skip files denied by settings (INV-39).
-		if s.IsDenied(rel) {
-			return nil
+	}
+
+	if opts.Stream {
+		err := streamGoDirs(root, s, opts.filter(), func(dir string, files []string) {
+			progress.found(len(files))
+			process(files)
+		})
+		collect()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("walk %s: %w", root, err))
 		}
-		dir := filepath.Dir(path)
-		filesByDir[dir] = append(filesByDir[dir], path)
-		return nil
-	})
+		return
+	}
+
+	start := time.Now()
+	filesByDir, err := collectGoFiles(root, s, opts.filter())
 	if err != nil {
 		errs = append(errs, fmt.Errorf("walk %s: %w", root, err))
 		return
@@ -149,46 +302,320 @@ func WalkAndGenerate(root string, force bool) (written, skipped int, errs []erro
 
 	// Sort directories for deterministic processing (INV-25).
 	dirs := make([]string, 0, len(filesByDir))
-	for dir := range filesByDir {
+	for dir, files := range filesByDir {
 		dirs = append(dirs, dir)
+		progress.found(len(files))
 	}
 	sort.Strings(dirs)
+	progress.report(PhaseWalk, "", 0, start)
 
 	for _, dir := range dirs {
-		files := filesByDir[dir]
-		sort.Strings(files) // sort files within each dir (INV-25)
+		process(filesByDir[dir])
+	}
+	collect()
+	return
+}
 
-		// Load the package once per directory (INV-26).
-		// pkg may be nil if loading fails; buildBundleForFile falls back to go/parser.
-		pkg, fset, _ := loadPackageForDir(dir)
+// generateDir writes bundles for files, which all live in one directory.
+// The directory's package is loaded once (INV-26) and files are processed
+// in sorted order (INV-25).
+func generateDir(root string, files []string, s *settings.Settings, opts WalkOptions) (written, skipped int, errs []error) {
+	if len(files) == 0 {
+		return
+	}
+	sort.Strings(files) // sort files within each dir (INV-25)
+
+	// A directory holds either a package bundle or per-file Go bundles;
+	// switching modes replaces the other kind.
+	pkgBundle := filepath.Join(filepath.Dir(files[0]), PackageBundleName+".evidence.yaml")
+	if opts.PerPackage {
+		goFiles := slices.DeleteFunc(slices.Clone(files), func(f string) bool { return filepath.Ext(f) != ".go" })
+		if len(goFiles) > 0 {
+			written, skipped, errs = generatePackageBundle(root, goFiles, s, opts)
+		}
+		files = slices.DeleteFunc(files, func(f string) bool { return filepath.Ext(f) == ".go" })
+		if len(files) == 0 {
+			return
+		}
+	} else if err := os.Remove(pkgBundle); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
 
+	// Up-to-date files are skipped before loading (INV-50), so a directory
+	// with no changes never pays for packages.Load.
+	if !opts.Force {
+		stale := files[:0:0]
 		for _, absPath := range files {
-			relPath, err := filepath.Rel(root, absPath)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("rel path %s: %w", absPath, err))
-				continue
+			if opts.sourceUpToDate(root, absPath) {
+				skipped++
+			} else {
+				stale = append(stale, absPath)
 			}
-			relPath = filepath.ToSlash(relPath)
+		}
+		files = stale
+		if len(files) == 0 {
+			return
+		}
+	}
 
-			bundle, err := buildBundleForFile(absPath, relPath, pkg, fset)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("build bundle %s: %w", relPath, err))
-				continue
+	// pkg may be nil if loading fails; buildBundleForFile falls back to go/parser.
+	// A directory of only .proto files has no package to load.
+	var pkg *packages.Package
+	var fset *token.FileSet
+	if slices.ContainsFunc(files, func(f string) bool { return filepath.Ext(f) == ".go" }) {
+		pkg, fset, _ = loadDirPackage(filepath.Dir(files[0]))
+	}
+
+	for _, absPath := range files {
+		relPath, err := filepath.Rel(root, absPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rel path %s: %w", absPath, err))
+			continue
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		var bundle *EvidenceBundle
+		if filepath.Ext(absPath) == ".proto" {
+			bundle, err = CreateProtoBundle(absPath, relPath)
+		} else {
+			bundle, err = buildBundleForFile(absPath, relPath, pkg, fset)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("build bundle %s: %w", relPath, err))
+			continue
+		}
+		SetCustomSignals(bundle, s)
+
+		sk, err := opts.saveBundle(bundle, absPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("write bundle %s: %w", relPath, err))
+			continue
+		}
+		if sk {
+			skipped++
+			continue
+		}
+		written++
+		if opts.onWrite != nil {
+			opts.onWrite(relPath)
+		}
+		if err := runBundleHook(root, opts.bundleHook(s, relPath)); err != nil {
+			errs = append(errs, fmt.Errorf("on_bundle %s: %w", relPath, err))
+		}
+	}
+	return
+}
+
+// SetCustomSignals records in b.Signals.Custom the settings-defined signals
+// its imports and calls match, and in b.File.SignalsSHA256 the definitions
+// they were matched against, as WalkAndGenerate does for every bundle it
+// writes; single-file analyze calls it directly.
+func SetCustomSignals(b *EvidenceBundle, s *settings.Settings) {
+	imports := make([]string, len(b.Package.Imports))
+	for i, imp := range b.Package.Imports {
+		imports[i] = imp.Path
+	}
+	calls := make([]string, len(b.Calls))
+	for i, c := range b.Calls {
+		calls[i] = c.To
+	}
+	b.Signals.Custom = s.CustomSignals(imports, calls)
+	b.File.SignalsSHA256 = s.SignalsSHA256()
+}
+
+// loadDirPackage is the package loader used by generateDir; tests replace
+// it to observe loads.
+var loadDirPackage = loadPackageForDir
+
+// sourceUpToDate reports whether the bundle of absPath, beneath root, was
+// generated from its current content and signal definitions (INV-50).
+func (opts WalkOptions) sourceUpToDate(root, absPath string) bool {
+	raw, err := os.ReadFile(absPath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(raw)
+	return opts.bundleCurrent(absPath, FileMeta{Path: filepath.ToSlash(rel), SHA256: hex.EncodeToString(sum[:]), SignalsSHA256: opts.signalsSHA256})
+}
+
+// bundleHook returns the on_bundle argv for the bundle just written for
+// rel, or nil when it went to opts.Store and there is no file to name.
+func (opts WalkOptions) bundleHook(s *settings.Settings, rel string) []string {
+	if opts.Store != nil {
+		return nil
+	}
+	return s.BundleHook(rel, rel+".evidence.yaml")
+}
+
+// runBundleHook executes the settings on_bundle command in root. argv is run
+// directly, never through a shell, so substituted paths cannot inject
+// commands. A nil argv is a no-op.
+func runBundleHook(root string, argv []string) error {
+	if len(argv) == 0 {
+		return nil
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// collectGoFiles walks root and returns the .go files to analyze, grouped by
+// directory. Built-in skips (INV-24) and settings deny rules (INV-39) are
+// applied first; then only files whose root-relative path passes filter are
+// kept.
+func collectGoFiles(root string, s *settings.Settings, filter fileFilter) (map[string][]string, error) {
+	filesByDir := make(map[string][]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Compute the forward-slash relative path for settings checks.
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if path != root && skipWalkDir(d.Name(), rel, s) {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+		if !keepGoFile(path, rel, s, filter) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		filesByDir[dir] = append(filesByDir[dir], path)
+		return nil
+	})
+	return filesByDir, err
+}
 
-			sk, err := writeBundleAt(bundle, absPath, force)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("write bundle %s: %w", relPath, err))
+// streamGoDirs walks root like collectGoFiles but calls visit with each
+// directory's kept files as soon as the directory is reached, so only one
+// directory's file list is held at a time. Directories are visited in walk
+// (lexical, depth-first) order; visit is not called for directories with
+// no kept files.
+func streamGoDirs(root string, s *settings.Settings, filter fileFilter, visit func(dir string, files []string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if path != root && skipWalkDir(d.Name(), rel, s) {
+			return filepath.SkipDir
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		var files []string
+		for _, e := range entries {
+			if e.IsDir() {
 				continue
 			}
-			if sk {
-				skipped++
-			} else {
-				written++
+			fileRel := e.Name()
+			if rel != "." {
+				fileRel = rel + "/" + e.Name()
+			}
+			if keepGoFile(filepath.Join(path, e.Name()), fileRel, s, filter) {
+				files = append(files, filepath.Join(path, e.Name()))
 			}
 		}
+		if len(files) > 0 {
+			visit(path, files)
+		}
+		return nil
+	})
+}
+
+// skipWalkDir reports whether a directory below root is excluded: vendor,
+// testdata, examples, docs, and hidden directories (INV-24), or denied by
+// settings (INV-39) with no allow rule reaching beneath it.
+func skipWalkDir(name, rel string, s *settings.Settings) bool {
+	if name == "vendor" || name == "testdata" || name == "examples" || name == "docs" || strings.HasPrefix(name, ".") {
+		return true
 	}
-	return
+	return s.IsDirDenied(rel)
+}
+
+// keepGoFile reports whether the file at absPath is analyzed: a non-test
+// .go file (INV-24) or a .proto file (see proto.go), not denied by settings
+// (INV-39), not generated when settings exclude generated files, and
+// passing filter.
+func keepGoFile(absPath, rel string, s *settings.Settings, filter fileFilter) bool {
+	name := filepath.Base(absPath)
+	switch filepath.Ext(name) {
+	case ".go":
+		if strings.HasSuffix(name, "_test.go") {
+			return false
+		}
+	case ".proto":
+	default:
+		return false
+	}
+	if s.IsDenied(rel) || !filter.match(rel) {
+		return false
+	}
+	return filepath.Ext(name) != ".go" || !s.SkipsGenerated() || !IsGenerated(absPath)
+}
+
+// generatedRe matches the standard generated-code marker
+// (https://go.dev/s/generatedcode).
+var generatedRe = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGenerated reports whether the Go file at path carries the generated-code
+// marker before its package clause. Unreadable files are not generated.
+func IsGenerated(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if generatedRe.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
+
+// fileFilter selects the root-relative paths a walk analyzes: those matching
+// one of globs, when there are any, and in paths, when it is non-nil. paths
+// holds literal names, so "[", "*", and "?" in them match only themselves.
+type fileFilter struct {
+	globs []string
+	paths map[string]bool
+}
+
+// match reports whether rel passes f.
+func (f fileFilter) match(rel string) bool {
+	if f.paths != nil && !f.paths[rel] {
+		return false
+	}
+	if len(f.globs) == 0 {
+		return true
+	}
+	for _, pattern := range f.globs {
+		if settings.MatchGlob(strings.TrimPrefix(pattern, "./"), rel) {
+			return true
+		}
+	}
+	return false
 }
 
 // buildBundleForFile creates an EvidenceBundle for a single file.
@@ -203,13 +630,14 @@ func buildBundleForFile(absPath, relPath string, pkg *packages.Package, fset *to
 	}
 	sum := sha256.Sum256(fileBytes)
 	hash := hex.EncodeToString(sum[:])
+	module := findModulePath(filepath.Dir(absPath))
 
 	// Try to find the file in the pre-loaded package syntax.
 	if pkg != nil && fset != nil && pkg.TypesInfo != nil && pkg.Types != nil {
 		for _, f := range pkg.Syntax {
 			pos := fset.Position(f.Pos())
 			if pos.Filename == absPath {
-				return buildBundle(relPath, hash, f, pkg.TypesInfo, pkg.Types), nil
+				return buildBundle(relPath, hash, module, f, pkg.TypesInfo, pkg.Types), nil
 			}
 		}
 	}
@@ -220,5 +648,12 @@ func buildBundleForFile(absPath, relPath string, pkg *packages.Package, fset *to
 	if err != nil {
 		return nil, fmt.Errorf("parse: %w", err)
 	}
-	return buildBundle(relPath, hash, file, nil, nil), nil
+	return buildBundle(relPath, hash, module, file, nil, nil), nil
+}
+
+// findModulePath returns the module path declared by the nearest go.mod at
+// or above dir, or "" if none is found.
+func findModulePath(dir string) string {
+	_, modPath := findModule(dir)
+	return modPath
 }
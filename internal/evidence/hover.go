@@ -0,0 +1,181 @@
+package evidence
+
+// hover.go — editor hover: describe the symbol at a source position.
+//
+// Bundles carry no positions (INV-5), so the file is re-parsed at request
+// time to find the declaration under the cursor, which is then matched by
+// name against the file's bundle and the bundles of the surrounding corpus.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Hover describes the top-level declaration at a source position.
+type Hover struct {
+	File      string   `json:"file"`
+	Package   string   `json:"package"`
+	Symbol    string   `json:"symbol"` // "Name" or "Recv.Name" for methods
+	Kind      string   `json:"kind"`   // "function", "method", or "type"
+	Signature string   `json:"signature"`
+	Signals   []string `json:"signals,omitempty"` // signals raised by the function's own calls
+	Callers   []string `json:"callers,omitempty"` // "<file>:<caller>", sorted
+}
+
+// HoverAt returns the hover for the declaration enclosing line:col (both
+// 1-based) in filePath. The file's bundle is read from its companion file
+// when fresh and generated in memory otherwise; nothing is written.
+// Callers are collected from the bundles of the files WalkAndGenerate would
+// analyze under corpusRoot. They are matched by name, so methods of
+// different types with the same name are not told apart.
+func HoverAt(filePath string, line, col int, corpusRoot string) (*Hover, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	tf := fset.File(file.Pos())
+	if line < 1 || line > tf.LineCount() {
+		return nil, fmt.Errorf("line %d out of range (file has %d lines)", line, tf.LineCount())
+	}
+	pos := tf.LineStart(line) + token.Pos(col-1)
+
+	bundle, err := hoverBundle(filePath)
+	if err != nil {
+		return nil, err
+	}
+	h := &Hover{File: bundle.File.Path, Package: bundle.Package.Name}
+
+	for _, decl := range file.Decls {
+		if pos < decl.Pos() || pos > decl.End() {
+			continue
+		}
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			from := funcDeclName(d, nil, nil)
+			fn, ok := findFunction(bundle, d)
+			if !ok {
+				return nil, fmt.Errorf("%s not found in bundle", from)
+			}
+			h.Symbol = from
+			h.Kind = "function"
+			if fn.Receiver != "" {
+				h.Kind = "method"
+			}
+			h.Signature = functionSignature(fn)
+			var calls []Call
+			for _, c := range bundle.Calls {
+				if c.From == from || strings.HasPrefix(c.From, from+".<anonymous>") {
+					calls = append(calls, c)
+				}
+			}
+			h.Signals = extractSignals(PackageMeta{}, calls, &ast.File{Name: file.Name}).Names()
+			h.Callers, err = findCallers(corpusRoot, bundle.Package.Name, fn.Name)
+			if err != nil {
+				return nil, err
+			}
+			return h, nil
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || pos < ts.Pos() || pos > ts.End() {
+					continue
+				}
+				for _, td := range bundle.Symbols.Types {
+					if td.Name == ts.Name.Name {
+						h.Symbol = td.Name
+						h.Kind = "type"
+						h.Signature = "type " + td.Name + td.TypeParams + " " + td.Kind
+						return h, nil
+					}
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("no function or type declaration at %s:%d:%d", filePath, line, col)
+}
+
+// hoverBundle returns the companion bundle of filePath when it matches the
+// current source, or a freshly generated one.
+func hoverBundle(filePath string) (*EvidenceBundle, error) {
+	fresh, err := CreateEvidenceBundle(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if b, err := readBundle(filePath + ".evidence.yaml"); err == nil && b.File.SHA256 == fresh.File.SHA256 {
+		return b, nil
+	}
+	return fresh, nil
+}
+
+// findFunction returns the bundle function declared by d, matching name and
+// receiver base type.
+func findFunction(b *EvidenceBundle, d *ast.FuncDecl) (Function, bool) {
+	recv := ""
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv = Function{Receiver: exprToString(d.Recv.List[0].Type)}.ReceiverType()
+	}
+	for _, fn := range b.Symbols.Functions {
+		if fn.Name == d.Name.Name && fn.ReceiverType() == recv {
+			return fn, true
+		}
+	}
+	return Function{}, false
+}
+
+// functionSignature renders fn as Go source, e.g. "func (s *S) Get(string) (int, error)".
+func functionSignature(fn Function) string {
+	var b strings.Builder
+	b.WriteString("func ")
+	if fn.Receiver != "" {
+		b.WriteString("(" + fn.Receiver + ") ")
+	}
+	b.WriteString(fn.Name + fn.TypeParams + "(" + strings.Join(fn.Params, ", ") + ")")
+	switch len(fn.Returns) {
+	case 0:
+	case 1:
+		b.WriteString(" " + fn.Returns[0])
+	default:
+		b.WriteString(" (" + strings.Join(fn.Returns, ", ") + ")")
+	}
+	return b.String()
+}
+
+// findCallers scans the companion bundles under root for calls to name in
+// package pkg: the bare name from within pkg, "pkg.name" from elsewhere.
+// Files without a bundle are skipped.
+func findCallers(root, pkg, name string) ([]string, error) {
+	files, err := walkedFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var callers []string
+	for _, f := range files {
+		b, err := readBundle(filepath.FromSlash(f.abs) + ".evidence.yaml")
+		if err != nil {
+			continue
+		}
+		want := pkg + "." + name
+		if b.Package.Name == pkg {
+			want = name
+		}
+		for _, c := range b.Calls {
+			if c.To != want {
+				continue
+			}
+			entry := f.rel + ":" + c.From
+			if !seen[entry] {
+				seen[entry] = true
+				callers = append(callers, entry)
+			}
+		}
+	}
+	sort.Strings(callers)
+	return callers, nil
+}
@@ -0,0 +1,243 @@
+package evidence
+
+// http.go — HTTP route and outbound endpoint extraction.
+//
+// Routes are calls that register a handler under a constant path:
+// Handle/HandleFunc (net/http, gorilla/mux; Go 1.22 "GET /path" patterns
+// included) and verb-named methods such as gin's r.GET or chi's r.Get
+// whose first argument starts with "/" and whose last argument is a
+// handler (see registersHandler), so client calls such as
+// (*http.Client).Post or a user API's api.Post("/users", body) are not
+// mistaken for routes.
+// Endpoints are net/http requests to a constant URL: http.Get, Head,
+// Post, PostForm (package-level or on a Client) and NewRequest[WithContext].
+
+import (
+	"go/ast"
+	"go/types"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// routeVerbs maps route-registering method names to their HTTP method.
+var routeVerbs = map[string]string{
+	"GET": http.MethodGet, "Get": http.MethodGet,
+	"HEAD": http.MethodHead, "Head": http.MethodHead,
+	"POST": http.MethodPost, "Post": http.MethodPost,
+	"PUT": http.MethodPut, "Put": http.MethodPut,
+	"PATCH": http.MethodPatch, "Patch": http.MethodPatch,
+	"DELETE": http.MethodDelete, "Delete": http.MethodDelete,
+	"OPTIONS": http.MethodOptions, "Options": http.MethodOptions,
+	"Any": "", "Handle": "", "HandleFunc": "",
+}
+
+// extractRoutes records the HTTP routes file registers with a constant
+// path. From is the enclosing function as in extractConfigInputs. Sorted
+// by (path, method, from) and deduplicated.
+func extractRoutes(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) []Route {
+	seen := make(map[Route]bool)
+	var routes []Route
+	inspectCalls(file, typesInfo, qualifier, func(from string, call *ast.CallExpr) {
+		target := resolveCallTarget(call.Fun, typesInfo, pkg, qualifier)
+		method, ok := routeVerbs[target[strings.LastIndexByte(target, '.')+1:]]
+		if !ok || len(call.Args) < 2 || !registersHandler(call, typesInfo) {
+			return
+		}
+		pattern, ok := constantString(call.Args[0], typesInfo)
+		if !ok {
+			return
+		}
+		// Go 1.22 ServeMux patterns: "[METHOD ][HOST]/[PATH]".
+		if m, rest, found := strings.Cut(pattern, " "); found && method == "" {
+			method, pattern = m, strings.TrimSpace(rest)
+		}
+		if !strings.HasPrefix(pattern, "/") {
+			return // not a path (host-qualified patterns are skipped too)
+		}
+		r := Route{Method: method, Path: pattern, From: from}
+		if !seen[r] {
+			seen[r] = true
+			routes = append(routes, r)
+		}
+	})
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.From < b.From
+	})
+	return routes
+}
+
+// routerPkgs lists the import path prefixes of router packages whose
+// types register routes even when they are not themselves http.Handlers
+// (gin's RouterGroup, echo's Group, ...).
+var routerPkgs = []string{
+	"net/http",
+	"github.com/gin-gonic/gin",
+	"github.com/go-chi/chi",
+	"github.com/gorilla/mux",
+	"github.com/labstack/echo",
+	"github.com/gofiber/fiber",
+	"github.com/julienschmidt/httprouter",
+}
+
+// registersHandler reports whether call passes a handler as its last
+// argument, with no string constant between the path and the handler
+// (client calls pass a content type there). With type info the handler
+// must have a function or http.Handler type and the call must be made on
+// a router: a type with a ServeHTTP method or one from routerPkgs, or a
+// package-level function of a routerPkgs package. Without it, the handler
+// must be a function literal, a name, or a call (a conversion or wrapper
+// such as http.HandlerFunc(f)).
+func registersHandler(call *ast.CallExpr, typesInfo *types.Info) bool {
+	last := len(call.Args) - 1
+	for _, arg := range call.Args[1:last] {
+		if _, ok := constantString(arg, typesInfo); ok {
+			return false
+		}
+	}
+	handler := ast.Unparen(call.Args[last])
+	if typesInfo != nil {
+		if t := typesInfo.TypeOf(handler); t != nil {
+			if _, ok := t.Underlying().(*types.Signature); !ok && !hasServeHTTP(t) {
+				return false
+			}
+			return onRouter(call.Fun, typesInfo)
+		}
+	}
+	switch handler.(type) {
+	case *ast.FuncLit, *ast.Ident, *ast.SelectorExpr, *ast.CallExpr:
+		return true
+	}
+	return false
+}
+
+// onRouter reports whether fun, a route-registering call's function, is a
+// method of a router type or a package-level function of a router
+// package. It returns true when type info does not resolve fun.
+func onRouter(fun ast.Expr, typesInfo *types.Info) bool {
+	sel, ok := ast.Unparen(fun).(*ast.SelectorExpr)
+	if !ok {
+		return true
+	}
+	obj, ok := typesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok {
+		return true
+	}
+	if sig, _ := obj.Type().(*types.Signature); sig == nil || sig.Recv() == nil {
+		return obj.Pkg() != nil && isRouterPkg(obj.Pkg().Path())
+	}
+	recv := typesInfo.TypeOf(sel.X)
+	if recv == nil {
+		return true
+	}
+	if hasServeHTTP(recv) {
+		return true
+	}
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if named, ok := recv.(*types.Named); ok && named.Obj().Pkg() != nil {
+		// net/http's own types (Client, Request, ...) are not routers
+		// unless they serve HTTP, which hasServeHTTP has ruled out.
+		path := named.Obj().Pkg().Path()
+		return path != "net/http" && isRouterPkg(path)
+	}
+	return false
+}
+
+// isRouterPkg reports whether path is, or is beneath, a routerPkgs entry.
+func isRouterPkg(path string) bool {
+	for _, p := range routerPkgs {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasServeHTTP reports whether t or *t has a ServeHTTP method, the mark of
+// an http.Handler.
+func hasServeHTTP(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "ServeHTTP")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// endpointCalls maps net/http request functions to the index of their URL
+// argument and their HTTP method ("" when an argument gives it).
+var endpointCalls = map[string]struct {
+	url    int
+	method string
+}{
+	"http.Get":                   {0, http.MethodGet},
+	"http.Head":                  {0, http.MethodHead},
+	"http.Post":                  {0, http.MethodPost},
+	"http.PostForm":              {0, http.MethodPost},
+	"http.NewRequest":            {1, ""},
+	"http.NewRequestWithContext": {2, ""},
+}
+
+// extractEndpoints records the outbound HTTP requests file makes to a
+// constant URL. The method of NewRequest comes from its constant method
+// argument (or an http.Method* name without type info). Sorted by (url,
+// method, from) and deduplicated.
+func extractEndpoints(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) []Endpoint {
+	seen := make(map[Endpoint]bool)
+	var endpoints []Endpoint
+	inspectCalls(file, typesInfo, qualifier, func(from string, call *ast.CallExpr) {
+		ec, ok := endpointCalls[resolveCallTarget(call.Fun, typesInfo, pkg, qualifier)]
+		if !ok || len(call.Args) <= ec.url {
+			return
+		}
+		raw, ok := constantString(call.Args[ec.url], typesInfo)
+		if !ok {
+			return
+		}
+		e := Endpoint{Method: ec.method, URL: raw, From: from}
+		if e.Method == "" {
+			e.Method = requestMethod(call.Args[ec.url-1], typesInfo)
+		}
+		if u, err := url.Parse(raw); err == nil {
+			e.Host = u.Host
+		}
+		if !seen[e] {
+			seen[e] = true
+			endpoints = append(endpoints, e)
+		}
+	})
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.From < b.From
+	})
+	return endpoints
+}
+
+// requestMethod returns the HTTP method named by expr: a string constant,
+// or http.MethodX without type info. It returns "" when unknown.
+func requestMethod(expr ast.Expr, typesInfo *types.Info) string {
+	if m, ok := constantString(expr, typesInfo); ok {
+		return strings.ToUpper(m)
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == "http" {
+			if m, ok := strings.CutPrefix(sel.Sel.Name, "Method"); ok {
+				return strings.ToUpper(m)
+			}
+		}
+	}
+	return ""
+}
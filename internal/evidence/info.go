@@ -0,0 +1,88 @@
+package evidence
+
+// info.go — Single-bundle inspection: summary counts and source validation
+// for one companion .evidence.yaml file. Read-only (INV-22).
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Bundle validation states reported in BundleInfo.Validation.
+const (
+	ValidationFresh         = "fresh"
+	ValidationStale         = "stale"
+	ValidationMissingSource = "source not found"
+)
+
+// BundleInfo is a compact summary of one evidence bundle.
+type BundleInfo struct {
+	Version      int      `json:"version"`
+	Path         string   `json:"path"`
+	SHA256       string   `json:"sha256"`
+	Package      string   `json:"package"`
+	Imports      int      `json:"imports"`
+	Functions    int      `json:"functions"`
+	Types        int      `json:"types"`
+	Variables    int      `json:"variables"`
+	Constants    int      `json:"constants"`
+	Constructors int      `json:"constructors"`
+	Calls        int      `json:"calls"`
+	Signals      []string `json:"signals"`    // names of signals that are true
+	Validation   string   `json:"validation"` // one of the Validation* constants
+}
+
+// InspectBundle reads the bundle at bundlePath and summarizes it. The source
+// file is located next to the bundle (bundlePath without ".evidence.yaml",
+// INV-14) and re-hashed to fill Validation. Bundles of any version are
+// accepted; sections absent from older versions count as zero.
+func InspectBundle(bundlePath string) (*BundleInfo, error) {
+	b, err := readBundle(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	info := &BundleInfo{
+		Version:      b.Version,
+		Path:         b.File.Path,
+		SHA256:       b.File.SHA256,
+		Package:      b.Package.Name,
+		Imports:      len(b.Package.Imports),
+		Functions:    len(b.Symbols.Functions),
+		Types:        len(b.Symbols.Types),
+		Variables:    len(b.Symbols.Variables),
+		Constants:    len(b.Symbols.Constants),
+		Constructors: len(b.Symbols.Constructors),
+		Calls:        len(b.Calls),
+		Signals:      b.Signals.Names(),
+	}
+
+	raw, err := os.ReadFile(strings.TrimSuffix(bundlePath, ".evidence.yaml"))
+	if err != nil {
+		info.Validation = ValidationMissingSource
+		return info, nil
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) == b.File.SHA256 {
+		info.Validation = ValidationFresh
+	} else {
+		info.Validation = ValidationStale
+	}
+	return info, nil
+}
+
+// Names returns the YAML names of all signals that are true, in field order,
+// followed by the custom signal names.
+func (s Signals) Names() []string {
+	var names []string
+	v := reflect.ValueOf(s)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Bool && v.Field(i).Bool() {
+			names = append(names, strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0])
+		}
+	}
+	return append(names, s.Custom...)
+}
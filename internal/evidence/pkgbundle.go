@@ -0,0 +1,260 @@
+package evidence
+
+// pkgbundle.go — Package-level evidence bundles.
+//
+// With WalkOptions.PerPackage, the Go files of each directory are written
+// as one bundle, <dir>/package.evidence.yaml, instead of one per file. The
+// package bundle merges the per-file bundles: symbols are combined and
+// kept sorted, other sections are concatenated in file order, calls and
+// imports are deduplicated, and signals are OR'd (counts summed). Files records each
+// source file's hash; File.Path is "<dir>/package", so the bundle still
+// sits at <File.Path>.evidence.yaml, and File.SHA256 hashes the Files
+// list, so a package bundle is up to date exactly when none of its files
+// changed. Readers that find a package bundle in a directory ignore any
+// per-file Go bundles next to it.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"iguana/internal/settings"
+)
+
+// PackageBundleName is the base name of a package bundle's File.Path; the
+// bundle is written to <dir>/package.evidence.yaml.
+const PackageBundleName = "package"
+
+// IsPackageBundle reports whether b merges the Go files of a directory.
+func (b *EvidenceBundle) IsPackageBundle() bool {
+	return len(b.Files) > 0
+}
+
+// packageHash returns the File.SHA256 of a package bundle over files.
+func packageHash(files []FileMeta) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s %s\n", f.Path, f.SHA256)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MergePackageBundle merges the per-file bundles of the Go files in the
+// root-relative directory dir into one package bundle. bundles must be
+// sorted by File.Path.
+func MergePackageBundle(dir string, bundles []*EvidenceBundle) *EvidenceBundle {
+	m := &EvidenceBundle{Version: CurrentVersion}
+	if len(bundles) == 0 {
+		return m
+	}
+	m.Package = PackageMeta{Name: bundles[0].Package.Name, Module: bundles[0].Package.Module}
+	imports := make(map[string]Import)
+	goGenerate := make(map[string]bool)
+	calls := make(map[Call]bool)
+	goroutines := make(map[[2]string]int)
+	var conc Concurrency
+
+	for _, b := range bundles {
+		m.Files = append(m.Files, b.File)
+		for _, imp := range b.Package.Imports {
+			if _, ok := imports[imp.Path]; !ok {
+				imports[imp.Path] = imp
+			}
+		}
+		for _, g := range b.Package.GoGenerate {
+			goGenerate[g] = true
+		}
+
+		m.Symbols.Functions = append(m.Symbols.Functions, b.Symbols.Functions...)
+		m.Symbols.Types = append(m.Symbols.Types, b.Symbols.Types...)
+		m.Symbols.Variables = append(m.Symbols.Variables, b.Symbols.Variables...)
+		m.Symbols.Constants = append(m.Symbols.Constants, b.Symbols.Constants...)
+		m.Symbols.Constructors = append(m.Symbols.Constructors, b.Symbols.Constructors...)
+		m.Symbols.InterfaceAssertions = append(m.Symbols.InterfaceAssertions, b.Symbols.InterfaceAssertions...)
+		m.Symbols.ErrorSentinels = append(m.Symbols.ErrorSentinels, b.Symbols.ErrorSentinels...)
+
+		for _, c := range b.Calls {
+			calls[c] = true
+		}
+		m.ConfigInputs = appendNew(m.ConfigInputs, b.ConfigInputs...)
+		m.CLIFlags = appendNew(m.CLIFlags, b.CLIFlags...)
+		m.Queries = append(m.Queries, b.Queries...)
+		m.Routes = appendNew(m.Routes, b.Routes...)
+		m.Endpoints = appendNew(m.Endpoints, b.Endpoints...)
+		if c := b.Concurrency; c != nil {
+			for _, g := range c.Goroutines {
+				goroutines[[2]string{g.From, g.Target}] += g.Count
+			}
+			conc.Primitives = append(conc.Primitives, c.Primitives...)
+			conc.Channels = append(conc.Channels, c.Channels...)
+			conc.AtomicCalls = appendNew(conc.AtomicCalls, c.AtomicCalls...)
+		}
+		m.Signals = m.Signals.or(b.Signals)
+	}
+
+	m.File = FileMeta{Path: path.Join(dir, PackageBundleName), SHA256: packageHash(m.Files)}
+	for _, imp := range imports {
+		m.Package.Imports = append(m.Package.Imports, imp)
+	}
+	sort.Slice(m.Package.Imports, func(i, j int) bool { return m.Package.Imports[i].Path < m.Package.Imports[j].Path })
+	for g := range goGenerate {
+		m.Package.GoGenerate = append(m.Package.GoGenerate, g)
+	}
+	sort.Strings(m.Package.GoGenerate)
+	// Symbols are kept sorted by name (INV-7..10); the stable sort keeps
+	// file order among same-named methods.
+	sort.SliceStable(m.Symbols.Functions, func(i, j int) bool { return m.Symbols.Functions[i].Name < m.Symbols.Functions[j].Name })
+	sort.SliceStable(m.Symbols.Types, func(i, j int) bool { return m.Symbols.Types[i].Name < m.Symbols.Types[j].Name })
+	sort.SliceStable(m.Symbols.Variables, func(i, j int) bool { return m.Symbols.Variables[i].Name < m.Symbols.Variables[j].Name })
+	sort.SliceStable(m.Symbols.Constants, func(i, j int) bool { return m.Symbols.Constants[i].Name < m.Symbols.Constants[j].Name })
+	for _, list := range []*[]string{&m.Symbols.Constructors, &m.Symbols.InterfaceAssertions, &m.Symbols.ErrorSentinels} {
+		sort.Strings(*list)
+	}
+	for c := range calls {
+		m.Calls = append(m.Calls, c)
+	}
+	sort.Slice(m.Calls, func(i, j int) bool {
+		if m.Calls[i].From != m.Calls[j].From {
+			return m.Calls[i].From < m.Calls[j].From
+		}
+		return m.Calls[i].To < m.Calls[j].To
+	})
+	for k, n := range goroutines {
+		conc.Goroutines = append(conc.Goroutines, GoroutineSite{From: k[0], Target: k[1], Count: n})
+	}
+	sort.Slice(conc.Goroutines, func(i, j int) bool {
+		a, b := conc.Goroutines[i], conc.Goroutines[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.Target < b.Target
+	})
+	sort.Strings(conc.AtomicCalls)
+	if len(conc.Goroutines)+len(conc.Primitives)+len(conc.Channels)+len(conc.AtomicCalls) > 0 {
+		m.Concurrency = &conc
+	}
+	return m
+}
+
+// packageFileMeta returns the FileMeta recorded for the source file rel
+// (root-relative) by the package bundle in its directory absDir, if any.
+func packageFileMeta(absDir, rel string) (FileMeta, bool) {
+	b, err := readBundle(filepath.Join(absDir, PackageBundleName+".evidence.yaml"))
+	if err != nil {
+		return FileMeta{}, false
+	}
+	for _, f := range b.Files {
+		if f.Path == rel {
+			return f, true
+		}
+	}
+	return FileMeta{}, false
+}
+
+// appendNew appends the elements of add not already in list.
+func appendNew[T comparable](list []T, add ...T) []T {
+	for _, v := range add {
+		found := false
+		for _, w := range list {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// or merges the signals of two files of one package: booleans are OR'd,
+// counts summed, and name lists unioned.
+func (s Signals) or(o Signals) Signals {
+	s.FSReads = s.FSReads || o.FSReads
+	s.FSWrites = s.FSWrites || o.FSWrites
+	s.DBCalls = s.DBCalls || o.DBCalls
+	s.NetCalls = s.NetCalls || o.NetCalls
+	s.Concurrency = s.Concurrency || o.Concurrency
+	s.YAMLio = s.YAMLio || o.YAMLio
+	s.JSONio = s.JSONio || o.JSONio
+	s.Resilience = s.Resilience || o.Resilience
+	s.ConcurrencyKinds = appendNew(s.ConcurrencyKinds, o.ConcurrencyKinds...)
+	sort.Strings(s.ConcurrencyKinds)
+	s.Custom = appendNew(s.Custom, o.Custom...)
+	sort.Strings(s.Custom)
+	s.ExecsSubprocess = s.ExecsSubprocess || o.ExecsSubprocess
+	s.IgnoredErrors += o.IgnoredErrors
+	s.UnboundedHTTPClient = s.UnboundedHTTPClient || o.UnboundedHTTPClient
+	s.UsesDefaultHTTPClient = s.UsesDefaultHTTPClient || o.UsesDefaultHTTPClient
+	s.BlockingChannelOps = s.BlockingChannelOps || o.BlockingChannelOps
+	s.LargeSwitches += o.LargeSwitches
+	s.DynamicSerialization = s.DynamicSerialization || o.DynamicSerialization
+	s.UncheckedAssertions += o.UncheckedAssertions
+	s.Panics = s.Panics || o.Panics
+	s.Recovers = s.Recovers || o.Recovers
+	s.Exits = s.Exits || o.Exits
+	s.ErrorReturnsIgnored += o.ErrorReturnsIgnored
+	return s
+}
+
+// generatePackageBundle writes the package bundle of goFiles, which all
+// live in one directory, and removes their per-file bundles. It is skipped
+// when the existing package bundle's hash matches (INV-50).
+func generatePackageBundle(root string, goFiles []string, s *settings.Settings, opts WalkOptions) (written, skipped int, errs []error) {
+	absDir := filepath.Dir(goFiles[0])
+	relDir, err := filepath.Rel(root, absDir)
+	if err != nil {
+		return 0, 0, []error{fmt.Errorf("rel path %s: %w", absDir, err)}
+	}
+	relDir = filepath.ToSlash(relDir)
+	bundlePath := filepath.Join(absDir, PackageBundleName)
+
+	metas := make([]FileMeta, 0, len(goFiles))
+	for _, absPath := range goFiles {
+		raw, err := os.ReadFile(absPath)
+		if err != nil {
+			return 0, 0, []error{fmt.Errorf("read %s: %w", absPath, err)}
+		}
+		sum := sha256.Sum256(raw)
+		metas = append(metas, FileMeta{Path: path.Join(relDir, filepath.Base(absPath)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	if !opts.Force && bundleUpToDate(bundlePath+".evidence.yaml", FileMeta{SHA256: packageHash(metas), SignalsSHA256: s.SignalsSHA256()}) {
+		return 0, 1, nil
+	}
+
+	pkg, fset, _ := loadDirPackage(absDir)
+	var bundles []*EvidenceBundle
+	for i, absPath := range goFiles {
+		bundle, err := buildBundleForFile(absPath, metas[i].Path, pkg, fset)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("build bundle %s: %w", metas[i].Path, err))
+			continue
+		}
+		SetCustomSignals(bundle, s)
+		bundles = append(bundles, bundle)
+	}
+	if len(bundles) == 0 {
+		return 0, 0, errs
+	}
+	merged := MergePackageBundle(relDir, bundles)
+	merged.File.SignalsSHA256 = s.SignalsSHA256()
+	if _, err := writeBundleAt(merged, bundlePath, true, opts.Schema); err != nil {
+		return 0, 0, append(errs, fmt.Errorf("write bundle %s: %w", merged.File.Path, err))
+	}
+	for _, absPath := range goFiles {
+		if err := os.Remove(absPath + ".evidence.yaml"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if err := runBundleHook(root, s.BundleHook(relDir, merged.File.Path+".evidence.yaml")); err != nil {
+		errs = append(errs, fmt.Errorf("on_bundle %s: %w", relDir, err))
+	}
+	return 1, 0, errs
+}
@@ -0,0 +1,241 @@
+package evidence
+
+// plugin.go — External evidence producers over an exec protocol.
+//
+// Any executable in the plugin directory (~/.iguana/plugins by default) is a
+// plugin. iguana runs it once per verb, writes one JSON request to its stdin,
+// and reads one JSON response from its stdout; stderr is reported on failure.
+//
+//	{"verb": "configure"}
+//	  → {"name": "python", "extensions": [".py"]}
+//	{"verb": "analyze", "root": "/abs/root", "files": ["pkg/a.py", ...]}
+//	  → {"bundles": [<EvidenceBundle as JSON>, ...]}
+//
+// configure declares the file extensions the plugin analyzes; .go and
+// .proto belong to iguana. analyze receives the root-relative paths of the
+// stale files with those extensions (the same walk rules as Go files) and
+// must return one bundle per file, with file.path set to the requested path.
+// iguana fills in file.sha256 itself so freshness checks (INV-50) hold, and
+// sets language to the plugin name when the plugin leaves it empty.
+// version, when set, must be CurrentVersion; omitted, it defaults to it.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"iguana/internal/settings"
+)
+
+// Plugin is an external evidence producer discovered by DiscoverPlugins.
+type Plugin struct {
+	Name       string   // from configure; defaults to the executable's name
+	Path       string   // executable path
+	Extensions []string // file extensions it analyzes, e.g. ".py"
+}
+
+// pluginRequest is the JSON written to a plugin's stdin.
+type pluginRequest struct {
+	Verb  string   `json:"verb"`
+	Root  string   `json:"root,omitempty"`
+	Files []string `json:"files,omitempty"`
+}
+
+// pluginConfig is the configure response.
+type pluginConfig struct {
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`
+}
+
+// pluginAnalysis is the analyze response.
+type pluginAnalysis struct {
+	Bundles []*EvidenceBundle `json:"bundles"`
+}
+
+// DefaultPluginDir returns ~/.iguana/plugins, or "" when the home
+// directory is unknown.
+func DefaultPluginDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".iguana", "plugins")
+}
+
+// DiscoverPlugins configures every executable regular file in dir, in name
+// order. A missing dir means no plugins. A plugin that fails to configure,
+// or claims .go or .proto files, is reported in errs and left out.
+func DiscoverPlugins(dir string) (plugins []Plugin, errs []error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{fmt.Errorf("read plugin dir: %w", err)}
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0o111 == 0 {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		var cfg pluginConfig
+		if err := callPlugin(path, pluginRequest{Verb: "configure"}, &cfg); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: configure: %w", e.Name(), err))
+			continue
+		}
+		if slices.Contains(cfg.Extensions, ".go") || slices.Contains(cfg.Extensions, ".proto") {
+			errs = append(errs, fmt.Errorf("plugin %s: .go and .proto files are analyzed by iguana", e.Name()))
+			continue
+		}
+		if cfg.Name == "" {
+			cfg.Name = e.Name()
+		}
+		plugins = append(plugins, Plugin{Name: cfg.Name, Path: path, Extensions: cfg.Extensions})
+	}
+	return plugins, errs
+}
+
+// callPlugin runs the plugin at path with req on stdin and decodes its
+// stdout into resp.
+func callPlugin(path string, req pluginRequest, resp any) error {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(append(in, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// runPlugin writes bundles for the files under root that p analyzes,
+// asking p only for stale ones unless opts.Force is set.
+func runPlugin(root string, p Plugin, s *settings.Settings, opts WalkOptions) (written, skipped int, errs []error) {
+	files, err := collectPluginFiles(root, p.Extensions, s, opts.filter())
+	if err != nil {
+		return 0, 0, []error{fmt.Errorf("plugin %s: walk %s: %w", p.Name, root, err)}
+	}
+	hashes := make(map[string]string) // rel path → source hash of requested files
+	var rels []string
+	for _, rel := range files {
+		raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: read %s: %w", p.Name, rel, err))
+			continue
+		}
+		sum := sha256.Sum256(raw)
+		hash := hex.EncodeToString(sum[:])
+		if !opts.Force && opts.bundleCurrent(filepath.Join(root, filepath.FromSlash(rel)), FileMeta{Path: rel, SHA256: hash, SignalsSHA256: opts.signalsSHA256}) {
+			skipped++
+			continue
+		}
+		hashes[rel] = hash
+		rels = append(rels, rel)
+	}
+	if len(rels) == 0 {
+		return
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return written, skipped, append(errs, fmt.Errorf("plugin %s: %w", p.Name, err))
+	}
+	var resp pluginAnalysis
+	if err := callPlugin(p.Path, pluginRequest{Verb: "analyze", Root: absRoot, Files: rels}, &resp); err != nil {
+		return written, skipped, append(errs, fmt.Errorf("plugin %s: analyze: %w", p.Name, err))
+	}
+
+	for _, bundle := range resp.Bundles {
+		rel := bundle.File.Path
+		hash, ok := hashes[rel]
+		if !ok {
+			errs = append(errs, fmt.Errorf("plugin %s: bundle for unrequested file %q", p.Name, rel))
+			continue
+		}
+		delete(hashes, rel)
+		if bundle.Version == 0 {
+			bundle.Version = CurrentVersion
+		}
+		if bundle.Version != CurrentVersion {
+			errs = append(errs, fmt.Errorf("plugin %s: bundle for %s: %w: version %d, want %d", p.Name, rel, ErrUnsupportedVersion, bundle.Version, CurrentVersion))
+			continue
+		}
+		bundle.File.SHA256 = hash
+		if bundle.Language == "" {
+			bundle.Language = p.Name
+		}
+		SetCustomSignals(bundle, s)
+		sk, err := opts.saveBundle(bundle, filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("write bundle %s: %w", rel, err))
+			continue
+		}
+		if sk {
+			skipped++
+			continue
+		}
+		written++
+		if opts.onWrite != nil {
+			opts.onWrite(rel)
+		}
+		if err := runBundleHook(root, opts.bundleHook(s, rel)); err != nil {
+			errs = append(errs, fmt.Errorf("on_bundle %s: %w", rel, err))
+		}
+	}
+	missing := make([]string, 0, len(hashes))
+	for rel := range hashes {
+		missing = append(missing, rel)
+	}
+	sort.Strings(missing)
+	for _, rel := range missing {
+		errs = append(errs, fmt.Errorf("plugin %s: no bundle for %s", p.Name, rel))
+	}
+	return
+}
+
+// collectPluginFiles returns the sorted root-relative paths of the files
+// with one of exts, walked with the same skips, deny rules, and include
+// filter as Go files.
+func collectPluginFiles(root string, exts []string, s *settings.Settings, filter fileFilter) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if path != root && skipWalkDir(d.Name(), rel, s) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if slices.Contains(exts, filepath.Ext(d.Name())) && !s.IsDenied(rel) && filter.match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
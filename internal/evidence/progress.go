@@ -0,0 +1,74 @@
+package evidence
+
+// progress.go — Progress events for long WalkAndGenerate runs.
+//
+// With WalkOptions.Progress set, WalkAndGenerate reports each step as it
+// finishes: the file listing, every directory analyzed, every plugin, and
+// the test bundles. Events are delivered one at a time even when
+// directories are processed concurrently, so a callback needs no locking.
+// Rendering (progress bar, JSON log lines) is left to the caller.
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Progress phases.
+const (
+	PhaseWalk    = "walk"    // the files to analyze were listed
+	PhaseAnalyze = "analyze" // one directory's bundles were written
+	PhasePlugin  = "plugin"  // one external plugin finished
+	PhaseTests   = "tests"   // test bundles were written
+)
+
+// Progress is one step of a WalkAndGenerate run.
+type Progress struct {
+	Phase string
+	// Dir is the root-relative directory (PhaseAnalyze) or the plugin name
+	// (PhasePlugin); empty otherwise.
+	Dir string
+	// Files is how many Go and .proto files have been handled so far,
+	// written or up to date, and Total how many there are. With
+	// WalkOptions.Stream, Total counts only the files found so far.
+	Files, Total int
+	// Elapsed is the time the step took.
+	Elapsed time.Duration
+}
+
+// progressReporter serializes Progress events and keeps the running file
+// counts. A nil callback makes every method a no-op.
+type progressReporter struct {
+	mu           sync.Mutex
+	fn           func(Progress)
+	root         string
+	files, total int
+}
+
+func newProgressReporter(root string, fn func(Progress)) *progressReporter {
+	return &progressReporter{fn: fn, root: root}
+}
+
+// found adds n files to the total.
+func (r *progressReporter) found(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total += n
+}
+
+// report emits an event for phase. n files were handled by the step; for
+// PhaseAnalyze, dir is an absolute directory made root-relative.
+func (r *progressReporter) report(phase, dir string, n int, start time.Time) {
+	if r.fn == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files += n
+	if phase == PhaseAnalyze {
+		if rel, err := filepath.Rel(r.root, dir); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+	r.fn(Progress{Phase: phase, Dir: dir, Files: r.files, Total: r.total, Elapsed: time.Since(start)})
+}
@@ -0,0 +1,296 @@
+package evidence
+
+// proto.go — Evidence bundles for Protocol Buffers (.proto) files.
+//
+// A .proto file declares a gRPC API surface rather than behavior, so its
+// bundle maps declarations onto the Go bundle schema:
+//
+//	package.name       — the proto package ("acme.billing.v1")
+//	package.imports    — imported .proto files
+//	symbols.types      — messages (kind "message", with fields), enums
+//	                     (kind "enum"), and services (kind "service")
+//	symbols.functions  — RPC methods, receiver = service, one param and
+//	                     one return, prefixed "stream " when streamed
+//
+// Calls and signals are always empty. The parser is a small tokenizer that
+// understands the declaration structure of proto2 and proto3 and skips
+// everything else (options, reserved ranges, extensions).
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LanguageProto is the language of bundles produced from .proto files.
+const LanguageProto = "proto"
+
+// CreateProtoBundle analyzes the .proto file at filePath and returns its
+// evidence bundle, with relPath as the bundle's file path. It does not
+// write any files (INV-20).
+func CreateProtoBundle(filePath, relPath string) (*EvidenceBundle, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(src)
+	return buildProtoBundle(relPath, hex.EncodeToString(sum[:]), string(src)), nil
+}
+
+// buildProtoBundle parses src and assembles its bundle. A file without a
+// package statement is named after its base name.
+func buildProtoBundle(relPath, hash, src string) *EvidenceBundle {
+	p := &protoParser{toks: protoTokens(src)}
+	p.parse()
+
+	name := p.pkg
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(relPath), ".proto")
+	}
+	sort.Slice(p.imports, func(i, j int) bool { return p.imports[i].Path < p.imports[j].Path })
+	sort.Slice(p.types, func(i, j int) bool { return p.types[i].Name < p.types[j].Name })
+	sort.Slice(p.funcs, func(i, j int) bool {
+		if p.funcs[i].Name != p.funcs[j].Name {
+			return p.funcs[i].Name < p.funcs[j].Name
+		}
+		return p.funcs[i].Receiver < p.funcs[j].Receiver
+	})
+
+	return &EvidenceBundle{
+		Version:  CurrentVersion,
+		Language: LanguageProto,
+		File:     FileMeta{Path: relPath, SHA256: hash},
+		Package:  PackageMeta{Name: name, Imports: p.imports},
+		Symbols:  Symbols{Functions: p.funcs, Types: p.types},
+	}
+}
+
+// protoTokens splits proto source into tokens: identifiers and numbers
+// (dots included, so qualified names stay whole), quoted strings (quotes
+// kept), and single punctuation characters. Comments are dropped.
+func protoTokens(src string) []string {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return toks
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(src))
+			toks = append(toks, src[i:j])
+			i = j
+		case isProtoIdent(c):
+			j := i
+			for j < len(src) && isProtoIdent(src[j]) {
+				j++
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks
+}
+
+func isProtoIdent(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// protoParser collects the declarations of one .proto file.
+type protoParser struct {
+	toks []string
+	pos  int
+
+	pkg     string
+	imports []Import
+	types   []TypeDecl
+	funcs   []Function
+}
+
+// next consumes and returns the next token, or "" at the end of input.
+func (p *protoParser) next() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+// skipStatement consumes tokens through the end of the current statement:
+// a ";" or a balanced "{...}" block.
+func (p *protoParser) skipStatement() {
+	for {
+		switch p.next() {
+		case "", ";":
+			return
+		case "{":
+			for depth := 1; depth > 0; {
+				switch p.next() {
+				case "":
+					return
+				case "{":
+					depth++
+				case "}":
+					depth--
+				}
+			}
+			return
+		}
+	}
+}
+
+// parse reads top-level statements.
+func (p *protoParser) parse() {
+	for p.pos < len(p.toks) {
+		switch p.next() {
+		case ";":
+		case "package":
+			p.pkg = p.next()
+			p.skipStatement()
+		case "import":
+			path := p.next()
+			if path == "public" || path == "weak" {
+				path = p.next()
+			}
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				p.imports = append(p.imports, Import{Path: unquoted})
+			}
+			p.skipStatement()
+		case "message":
+			p.message(p.next())
+		case "enum":
+			p.types = append(p.types, TypeDecl{Name: p.next(), Kind: "enum", Exported: true})
+			p.skipStatement()
+		case "service":
+			p.service(p.next())
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// message parses a message body; nested messages and enums are recorded
+// with the dotted name of their parent ("Outer.Inner").
+func (p *protoParser) message(name string) {
+	if p.next() != "{" {
+		return
+	}
+	td := TypeDecl{Name: name, Kind: "message", Exported: true}
+	p.fields(&td, name)
+	p.types = append(p.types, td)
+}
+
+// fields parses message (or oneof) members through the closing brace,
+// appending fields to td in declaration order (INV-48).
+func (p *protoParser) fields(td *TypeDecl, scope string) {
+	for {
+		switch t := p.next(); t {
+		case "", "}":
+			return
+		case ";":
+		case "message":
+			p.message(scope + "." + p.next())
+		case "enum":
+			p.types = append(p.types, TypeDecl{Name: scope + "." + p.next(), Kind: "enum", Exported: true})
+			p.skipStatement()
+		case "oneof":
+			p.next()
+			if p.next() == "{" {
+				p.fields(td, scope)
+			}
+		case "option", "reserved", "extensions", "extend":
+			p.skipStatement()
+		default:
+			typ := p.fieldType(t)
+			td.Fields = append(td.Fields, FieldDecl{Name: p.next(), TypeStr: typ})
+			p.skipStatement()
+		}
+	}
+}
+
+// fieldType reads a field's type starting at t: "Foo", "repeated Foo",
+// or "map<string, Foo>".
+func (p *protoParser) fieldType(t string) string {
+	switch t {
+	case "repeated", "optional", "required":
+		return t + " " + p.fieldType(p.next())
+	case "map":
+		var b strings.Builder
+		b.WriteString("map")
+		for tok := p.next(); tok != "" && tok != ">"; tok = p.next() {
+			b.WriteString(tok)
+			if tok == "," {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(">")
+		return b.String()
+	}
+	return t
+}
+
+// service parses a service body, recording one function per rpc.
+func (p *protoParser) service(name string) {
+	if p.next() != "{" {
+		return
+	}
+	p.types = append(p.types, TypeDecl{Name: name, Kind: "service", Exported: true})
+	for {
+		switch p.next() {
+		case "", "}":
+			return
+		case ";":
+		case "rpc":
+			method := p.next()
+			req := p.rpcType()
+			p.next() // returns
+			resp := p.rpcType()
+			p.funcs = append(p.funcs, Function{
+				Name:     method,
+				Exported: true,
+				Receiver: name,
+				Params:   []string{req},
+				Returns:  []string{resp},
+			})
+			p.skipStatement()
+		default:
+			p.skipStatement()
+		}
+	}
+}
+
+// rpcType reads a parenthesized rpc message type, "stream "-prefixed when
+// the message is streamed.
+func (p *protoParser) rpcType() string {
+	p.next() // (
+	t := p.next()
+	if t == "stream" {
+		t = "stream " + p.next()
+	}
+	p.next() // )
+	return t
+}
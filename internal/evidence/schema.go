@@ -0,0 +1,200 @@
+package evidence
+
+// schema.go — Bundle schema profiles for older consumers.
+//
+// EvidenceBundle.Version stays 2 (INV-3, CurrentVersion) while optional
+// fields are added to it; see decode.go for version changes.
+// A consumer written against the original v2 layout may reject unknown
+// keys, so writers can emit the "2-classic" profile, which drops every
+// field added since. The "compact" profile keeps every field but omits
+// false booleans and empty lists, which readers decode as zero values.
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"iguana/internal/canonical"
+)
+
+// Bundle schema profiles accepted by MarshalBundle and --bundle-version.
+const (
+	SchemaLatest  = "latest"
+	SchemaClassic = "2-classic"
+	SchemaCompact = "compact"
+)
+
+// ValidateSchema returns an error unless schema is a supported profile.
+// The empty string means SchemaLatest.
+func ValidateSchema(schema string) error {
+	switch schema {
+	case "", SchemaLatest, SchemaClassic, SchemaCompact:
+		return nil
+	}
+	return fmt.Errorf("unsupported bundle version %q (want %s, %s, or %s)", schema, SchemaClassic, SchemaLatest, SchemaCompact)
+}
+
+// MarshalBundle encodes b as canonical YAML in the given schema profile.
+// b itself is never modified.
+func MarshalBundle(b *EvidenceBundle, schema string) ([]byte, error) {
+	if err := ValidateSchema(schema); err != nil {
+		return nil, err
+	}
+	if schema == SchemaCompact {
+		var node yaml.Node
+		if err := node.Encode(b); err != nil {
+			return nil, err
+		}
+		doc := &node
+		if doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 {
+			doc = doc.Content[0]
+		}
+		// Top-level sections stay so the bundle keeps its recognizable shape.
+		for i := 1; i < len(doc.Content); i += 2 {
+			pruneEmpty(doc.Content[i])
+		}
+		return canonical.MarshalYAML(&node)
+	}
+	if schema != SchemaClassic {
+		return canonical.MarshalYAML(b)
+	}
+
+	classic := classicBundle(b)
+	var node yaml.Node
+	if err := node.Encode(classic); err != nil {
+		return nil, err
+	}
+	// signals.resilience is always emitted (no omitempty), so it cannot be
+	// dropped by zeroing; remove the key from the encoded mapping instead.
+	if sig := mappingValue(&node, "signals"); sig != nil {
+		deleteMappingKey(sig, "resilience")
+	}
+	return canonical.MarshalYAML(&node)
+}
+
+// classicBundle returns a deep-enough copy of b with every post-v2 field
+// zeroed, so omitempty drops it from the output. file.signals_sha256 is
+// kept: freshness checks depend on it, and it is only set when settings
+// define signals.
+func classicBundle(b *EvidenceBundle) *EvidenceBundle {
+	c := *b
+	c.Language = ""
+	c.Package.GoGenerate = nil
+	c.Package.Module = ""
+	c.Package.Imports = make([]Import, len(b.Package.Imports))
+	for i, imp := range b.Package.Imports {
+		imp.Class = ""
+		c.Package.Imports[i] = imp
+	}
+	c.Symbols.InterfaceAssertions = nil
+	c.Symbols.ErrorSentinels = nil
+	c.Symbols.Functions = make([]Function, len(b.Symbols.Functions))
+	for i, fn := range b.Symbols.Functions {
+		fn.TypeParams = ""
+		c.Symbols.Functions[i] = fn
+	}
+	c.Symbols.Variables = make([]VarDecl, len(b.Symbols.Variables))
+	for i, v := range b.Symbols.Variables {
+		v.TypeStr = ""
+		c.Symbols.Variables[i] = v
+	}
+	c.Symbols.Constants = make([]VarDecl, len(b.Symbols.Constants))
+	for i, v := range b.Symbols.Constants {
+		v.TypeStr = ""
+		c.Symbols.Constants[i] = v
+	}
+	c.Symbols.Types = make([]TypeDecl, len(b.Symbols.Types))
+	for i, td := range b.Symbols.Types {
+		td.Underlying = ""
+		td.Embeds = nil
+		td.Implements = nil
+		td.Methods = nil
+		td.TypeParams = ""
+		c.Symbols.Types[i] = td
+	}
+	c.Files = nil
+	c.ConfigInputs = nil
+	c.CLIFlags = nil
+	c.Queries = nil
+	c.Routes = nil
+	c.Endpoints = nil
+	c.Concurrency = nil
+	c.Signals.Resilience = false
+	c.Signals.ConcurrencyKinds = nil
+	c.Signals.ExecsSubprocess = false
+	c.Signals.Custom = nil
+	c.Signals.IgnoredErrors = 0
+	c.Signals.UnboundedHTTPClient = false
+	c.Signals.UsesDefaultHTTPClient = false
+	c.Signals.BlockingChannelOps = false
+	c.Signals.LargeSwitches = 0
+	c.Signals.DynamicSerialization = false
+	c.Signals.UncheckedAssertions = 0
+	c.Signals.Panics = false
+	c.Signals.Recovers = false
+	c.Signals.Exits = false
+	c.Signals.ErrorReturnsIgnored = 0
+	return &c
+}
+
+// pruneEmpty removes, recursively, mapping entries whose value is a false
+// boolean or an empty sequence or mapping (including ones emptied by the
+// pruning itself).
+func pruneEmpty(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			pruneEmpty(c)
+		}
+	case yaml.MappingNode:
+		kept := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v := n.Content[i+1]
+			pruneEmpty(v)
+			if isEmptyValue(v) {
+				continue
+			}
+			kept = append(kept, n.Content[i], v)
+		}
+		n.Content = kept
+	}
+}
+
+// isEmptyValue reports whether n is a false boolean or an empty collection.
+func isEmptyValue(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Tag == "!!bool" && n.Value == "false"
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(n.Content) == 0
+	}
+	return false
+}
+
+// mappingValue returns the value node for key in the top-level mapping of a
+// document node, or nil.
+func mappingValue(doc *yaml.Node, key string) *yaml.Node {
+	m := doc
+	if m.Kind == yaml.DocumentNode && len(m.Content) == 1 {
+		m = m.Content[0]
+	}
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteMappingKey removes key and its value from mapping node m.
+func deleteMappingKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
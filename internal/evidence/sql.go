@@ -0,0 +1,116 @@
+package evidence
+
+// sql.go — SQL statement extraction for files with db_calls.
+//
+// Query, QueryRow, and Exec calls (and their Context variants) whose SQL
+// argument is a string constant are recorded with the tables they touch.
+// Table names come from a keyword scan, not a SQL parser: "INSERT INTO t",
+// "UPDATE t", "DELETE FROM t", and DDL name written tables; "FROM t" and
+// "JOIN t" name read tables. Quoted or schema-qualified names are kept
+// without their quotes.
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxQueryLen is the longest SQL text kept in a Query; the rest is
+// redacted so large generated statements do not bloat bundles.
+const MaxQueryLen = 512
+
+// queryMethods maps SQL-executing method names to the index of their SQL
+// argument.
+var queryMethods = map[string]int{
+	"Query": 0, "QueryRow": 0, "Exec": 0,
+	"QueryContext": 1, "QueryRowContext": 1, "ExecContext": 1,
+}
+
+var (
+	sqlStartRe = regexp.MustCompile(`(?i)^\s*(select|insert|update|delete|with|replace|merge|upsert|create|alter|drop|truncate)\b`)
+	sqlWriteRe = regexp.MustCompile("(?i)\\b(?:insert\\s+(?:or\\s+\\w+\\s+)?into|update|delete\\s+from|replace\\s+into|merge\\s+into|upsert\\s+into|truncate(?:\\s+table)?|(?:create|alter|drop)\\s+table(?:\\s+if\\s+(?:not\\s+)?exists)?)\\s+([\\w.\"`]+)")
+	sqlReadRe  = regexp.MustCompile("(?i)\\b(?:from|join)\\s+([\\w.\"`]+)")
+)
+
+// sqlNotTables are keywords the scan can capture in place of a table name,
+// as in "ON CONFLICT DO UPDATE SET".
+var sqlNotTables = map[string]bool{"set": true, "select": true, "only": true, "lateral": true}
+
+// extractQueries records the constant SQL statements passed to Query,
+// QueryRow, and Exec methods in file. Only string constants that start
+// with a SQL keyword are kept. From is the enclosing function as in
+// extractConfigInputs. Sorted by (from, sql) and deduplicated.
+func extractQueries(file *ast.File, typesInfo *types.Info, pkg *types.Package, qualifier types.Qualifier) []Query {
+	seen := make(map[string]bool)
+	var queries []Query
+	inspectCalls(file, typesInfo, qualifier, func(from string, call *ast.CallExpr) {
+		target := resolveCallTarget(call.Fun, typesInfo, pkg, qualifier)
+		i, ok := queryMethods[target[strings.LastIndexByte(target, '.')+1:]]
+		if !ok || len(call.Args) <= i {
+			return
+		}
+		sql, ok := constantString(call.Args[i], typesInfo)
+		if !ok || !sqlStartRe.MatchString(sql) {
+			return
+		}
+		if key := from + "\x00" + sql; !seen[key] {
+			seen[key] = true
+			queries = append(queries, newQuery(sql, from))
+		}
+	})
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].From != queries[j].From {
+			return queries[i].From < queries[j].From
+		}
+		return queries[i].SQL < queries[j].SQL
+	})
+	return queries
+}
+
+// newQuery returns the Query for statement sql, scanning its tables before
+// the text is cut to MaxQueryLen.
+func newQuery(sql, from string) Query {
+	writes := sqlTables(sqlWriteRe, sql)
+	reads := sqlTables(sqlReadRe, sql)
+	for t := range writes {
+		delete(reads, t) // "DELETE FROM t" matches both scans
+	}
+	q := Query{SQL: strings.TrimSpace(sql), Writes: setList(writes), Reads: setList(reads), From: from}
+	if len(q.SQL) > MaxQueryLen {
+		n := MaxQueryLen
+		for n > 0 && !utf8.RuneStart(q.SQL[n]) {
+			n--
+		}
+		q.SQL = q.SQL[:n]
+		q.Truncated = true
+	}
+	return q
+}
+
+// sqlTables returns the lowercased table names captured by re in sql.
+func sqlTables(re *regexp.Regexp, sql string) map[string]bool {
+	tables := make(map[string]bool)
+	for _, m := range re.FindAllStringSubmatch(sql, -1) {
+		name := strings.ToLower(strings.NewReplacer(`"`, "", "`", "").Replace(m[1]))
+		if name != "" && !sqlNotTables[name] {
+			tables[name] = true
+		}
+	}
+	return tables
+}
+
+// setList returns the members of set sorted, or nil when it is empty.
+func setList(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(set))
+	for k := range set {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,254 @@
+package evidence
+
+// testbundle.go — Test-file evidence (analyze --include-tests).
+//
+// Test files are not analyzed as ordinary sources (INV-24). In test mode
+// each _test.go file instead gets a test bundle, <file>.test.evidence.yaml,
+// recording which call targets each Test, Benchmark, Fuzz, and Example
+// function exercises:
+//
+//	version: 1
+//	file: {path: store/store_test.go, sha256: ...}
+//	package: {name: store_test, imports: [...]}
+//	tests:
+//	  - name: TestSave
+//	    exercises: [NewMem, store.Open]
+//
+// Extraction is AST-only: test packages are not type-checked, so method
+// calls keep their variable name ("s.Save"). Calls made through helper
+// functions declared in the same file are attributed to the test, and calls
+// on the test's *testing.T (or B, F, TB) are dropped. The system model
+// resolves the targets against the analyzed module (see buildTestedSymbols).
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"iguana/internal/canonical"
+	"iguana/internal/settings"
+)
+
+// TestBundleSuffix is appended to a _test.go file's path to name its test
+// bundle.
+const TestBundleSuffix = ".test.evidence.yaml"
+
+// TestBundleVersion is the TestBundle.Version writers emit; test bundles
+// have their own schema, versioned separately from evidence bundles.
+const TestBundleVersion = 1
+
+// IsTestBundle reports whether name is a test bundle file name.
+func IsTestBundle(name string) bool {
+	return strings.HasSuffix(name, TestBundleSuffix)
+}
+
+// TestBundle is the test evidence of one _test.go file.
+type TestBundle struct {
+	Version int         `yaml:"version" json:"version"`
+	File    FileMeta    `yaml:"file" json:"file"`
+	Package PackageMeta `yaml:"package" json:"package"`
+	Tests   []TestCase  `yaml:"tests,omitempty" json:"tests,omitempty"` // sorted by name
+}
+
+// TestCase is one test function and the call targets it exercises.
+type TestCase struct {
+	Name      string   `yaml:"name" json:"name"`
+	Exercises []string `yaml:"exercises,omitempty" json:"exercises,omitempty"` // sorted
+}
+
+// CreateTestBundle analyzes the _test.go file at filePath and returns its
+// test bundle, with relPath as the bundle's file path. It does not write
+// any files.
+func CreateTestBundle(filePath, relPath string) (*TestBundle, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	sum := sha256.Sum256(src)
+	return buildTestBundle(relPath, hex.EncodeToString(sum[:]), file), nil
+}
+
+// buildTestBundle assembles the test bundle of a parsed test file.
+func buildTestBundle(relPath, hash string, file *ast.File) *TestBundle {
+	helpers := make(map[string]*ast.FuncDecl)
+	var tests []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil {
+			continue
+		}
+		if isTestFunc(fd.Name.Name) {
+			tests = append(tests, fd)
+		} else {
+			helpers[fd.Name.Name] = fd
+		}
+	}
+
+	direct := make(map[string][]string) // function name → its own call targets
+	for _, c := range extractCalls(file, nil, nil, nil) {
+		from, _, _ := strings.Cut(c.From, ".")
+		direct[from] = append(direct[from], c.To)
+	}
+
+	tb := &TestBundle{
+		Version: TestBundleVersion,
+		File:    FileMeta{Path: relPath, SHA256: hash},
+		Package: extractPackageMeta(file),
+	}
+	helperVars := make(map[string]bool)
+	for _, h := range helpers {
+		for name := range testingParams(h) {
+			helperVars[name] = true
+		}
+	}
+	for _, fd := range tests {
+		testingVars := testingParams(fd)
+		for name := range helperVars {
+			testingVars[name] = true
+		}
+		exercised := make(map[string]bool)
+		visited := map[string]bool{fd.Name.Name: true}
+		var visit func(name string)
+		visit = func(name string) {
+			for _, to := range direct[name] {
+				if helpers[to] != nil {
+					if !visited[to] {
+						visited[to] = true
+						visit(to)
+					}
+					continue
+				}
+				if recv, _, ok := strings.Cut(to, "."); ok && (testingVars[recv] || recv == "testing") {
+					continue
+				}
+				exercised[to] = true
+			}
+		}
+		visit(fd.Name.Name)
+		tc := TestCase{Name: fd.Name.Name}
+		for to := range exercised {
+			tc.Exercises = append(tc.Exercises, to)
+		}
+		sort.Strings(tc.Exercises)
+		tb.Tests = append(tb.Tests, tc)
+	}
+	sort.Slice(tb.Tests, func(i, j int) bool { return tb.Tests[i].Name < tb.Tests[j].Name })
+	return tb
+}
+
+// isTestFunc reports whether name is run by go test: Test, Benchmark,
+// Fuzz, or Example, alone or followed by a non-lowercase character.
+func isTestFunc(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return true
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); !unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// testingParams returns the names of the parameters of type *testing.T,
+// *testing.B, *testing.F, or testing.TB declared anywhere in fd, including
+// the subtest closures passed to t.Run.
+func testingParams(fd *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(fd, func(n ast.Node) bool {
+		var ft *ast.FuncType
+		switch f := n.(type) {
+		case *ast.FuncDecl:
+			ft = f.Type
+		case *ast.FuncLit:
+			ft = f.Type
+		default:
+			return true
+		}
+		for _, field := range ft.Params.List {
+			switch exprToString(field.Type) {
+			case "*testing.T", "*testing.B", "*testing.F", "testing.TB":
+				for _, name := range field.Names {
+					names[name.Name] = true
+				}
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// ReadTestBundle reads the test bundle at path, rejecting any version but
+// TestBundleVersion.
+func ReadTestBundle(path string) (*TestBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tb TestBundle
+	if err := yaml.Unmarshal(data, &tb); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	if tb.Version != TestBundleVersion {
+		return nil, fmt.Errorf("decode %s: %w: test bundle version %d, want %d", path, ErrUnsupportedVersion, tb.Version, TestBundleVersion)
+	}
+	return &tb, nil
+}
+
+// generateTestBundles writes a test bundle next to every _test.go file under
+// root, walked with the same skips, deny rules, and include filter as Go
+// files. Up-to-date test bundles are skipped unless opts.Force is set.
+func generateTestBundles(root string, s *settings.Settings, opts WalkOptions) (written, skipped int, errs []error) {
+	files, err := collectPluginFiles(root, []string{".go"}, s, opts.filter())
+	if err != nil {
+		return 0, 0, []error{fmt.Errorf("walk %s: %w", root, err)}
+	}
+	for _, rel := range files {
+		if !strings.HasSuffix(rel, "_test.go") {
+			continue
+		}
+		absPath := filepath.Join(root, filepath.FromSlash(rel))
+		tb, err := CreateTestBundle(absPath, rel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("build test bundle %s: %w", rel, err))
+			continue
+		}
+		outputPath := absPath + TestBundleSuffix
+		if !opts.Force {
+			if existing, err := ReadTestBundle(outputPath); err == nil && existing.File.SHA256 == tb.File.SHA256 {
+				skipped++
+				continue
+			}
+		}
+		data, err := canonical.MarshalYAML(tb)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("marshal test bundle %s: %w", rel, err))
+			continue
+		}
+		if err := os.WriteFile(outputPath, data, 0o644); err != nil {
+			errs = append(errs, fmt.Errorf("write test bundle %s: %w", rel, err))
+			continue
+		}
+		written++
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package evidence
+
+// watch.go — Continuous bundle regeneration (iguana watch).
+//
+// Polls the size and modification time of the files WalkAndGenerate would
+// analyze (no external file-watching dependency). A change is acted on
+// once the tree has been stable for one full interval, which debounces
+// editors and branch switches that write many files in quick succession.
+// Each run is an ordinary WalkAndGenerate, so only bundles whose source
+// hash changed are rewritten (INV-50). Bundles of files that left the
+// watched set, deleted or renamed, are removed first, as GenerateChanged
+// does for deletions, so they do not linger in the system model.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"iguana/internal/settings"
+)
+
+// fileStamp is the cheap change signal polled for each source file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// WatchTree runs WalkAndGenerate on root once and again whenever the set
+// of analyzed files or any of their contents changes, until ctx is
+// cancelled. A run first removes the bundles of files that were watched
+// before and are gone now. onRun, if non-nil, receives the result of each
+// run. Bundle errors are reported through onRun; a failure to walk root
+// stops the watch and is returned.
+func WatchTree(ctx context.Context, root string, opts WalkOptions, interval time.Duration, onRun func(written, skipped, removed int, errs []error)) error {
+	s, err := settings.LoadSettings(root)
+	if err != nil {
+		return err
+	}
+	run := func(gone []string) {
+		var removed int
+		var errs []error
+		for _, path := range gone {
+			bundle := path + ".evidence.yaml"
+			if err := os.Remove(bundle); err == nil {
+				removed++
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("remove %s: %w", bundle, err))
+			}
+		}
+		written, skipped, walkErrs := WalkAndGenerate(root, opts)
+		if onRun != nil {
+			onRun(written, skipped, removed, append(errs, walkErrs...))
+		}
+	}
+
+	synced, err := treeStamps(root, s, opts.filter())
+	if err != nil {
+		return err
+	}
+	run(nil)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pending := synced
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		stamps, err := treeStamps(root, s, opts.filter())
+		if err != nil {
+			return err
+		}
+		switch {
+		case !maps.Equal(stamps, pending):
+			pending = stamps // changed: wait one more interval for it to settle
+		case !maps.Equal(stamps, synced):
+			var gone []string
+			for path := range synced {
+				if _, ok := stamps[path]; !ok {
+					gone = append(gone, path)
+				}
+			}
+			slices.Sort(gone)
+			run(gone)
+			synced = stamps
+		}
+	}
+}
+
+// treeStamps returns the stamp of every file under root that
+// WalkAndGenerate would analyze, keyed by path. Files removed between the
+// walk and the stat are left out.
+func treeStamps(root string, s *settings.Settings, filter fileFilter) (map[string]fileStamp, error) {
+	filesByDir, err := collectGoFiles(root, s, filter)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp)
+	for _, files := range filesByDir {
+		for _, path := range files {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			stamps[path] = fileStamp{info.Size(), info.ModTime()}
+		}
+	}
+	return stamps, nil
+}
@@ -0,0 +1,107 @@
+package evidence
+
+// workspace.go — Module and go.work awareness.
+//
+// A repository may hold several Go modules, tied together by a go.work
+// file or not. Each analyzed file belongs to the module of its nearest
+// go.mod: that module path classifies its imports and is recorded in the
+// bundle as package.module. Packages are loaded from their own directory,
+// so go/packages resolves them within their module; when a go.work above
+// the module does not use it, loading runs with GOWORK=off instead of
+// failing with "directory is outside modules listed in go.work".
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// findModule returns the directory and module path of the nearest go.mod
+// at or above dir, or "" for both if there is none.
+func findModule(dir string) (modDir, modPath string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return dir, strings.Trim(strings.TrimSpace(rest), `"`)
+				}
+			}
+			return dir, ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// findWorkspace returns the path of the nearest go.work at or above dir,
+// or "" if there is none.
+func findWorkspace(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// workspaceUses returns the absolute module directories named by the use
+// directives of the go.work file at path, in both the single-line and
+// block forms.
+func workspaceUses(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	base := filepath.Dir(path)
+	var dirs []string
+	add := func(s string) {
+		if s = strings.Trim(strings.TrimSpace(s), `"`); s != "" {
+			dirs = append(dirs, filepath.Join(base, filepath.FromSlash(s)))
+		}
+	}
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			add(line)
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			add(strings.TrimPrefix(line, "use "))
+		}
+	}
+	return dirs
+}
+
+// loadEnv returns the environment for loading the package in dir with
+// go/packages: nil (inherit) normally, or the current environment with
+// GOWORK=off when dir's module sits under a go.work that does not use it.
+func loadEnv(dir string) []string {
+	modDir, _ := findModule(dir)
+	work := findWorkspace(dir)
+	if modDir == "" || work == "" || slices.Contains(workspaceUses(work), modDir) {
+		return nil
+	}
+	return append(os.Environ(), "GOWORK=off")
+}
@@ -9,20 +9,25 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 // WriteEvidenceBundle marshals the bundle to YAML and writes it to the
 // companion file `<bundle.File.Path>.evidence.yaml` (INV-14, INV-21).
-// If force is false and an existing bundle has the same file.sha256, the file
-// is not overwritten and skipped=true is returned (INV-50).
+// If force is false and an existing bundle has the same file.sha256 and
+// file.signals_sha256, the file is not overwritten and skipped=true is
+// returned (INV-50).
 func WriteEvidenceBundle(bundle *EvidenceBundle, force bool) (skipped bool, err error) {
+	return WriteEvidenceBundleAs(bundle, force, SchemaLatest)
+}
+
+// WriteEvidenceBundleAs is WriteEvidenceBundle emitting the given schema
+// profile (see MarshalBundle).
+func WriteEvidenceBundleAs(bundle *EvidenceBundle, force bool, schema string) (skipped bool, err error) {
 	outputPath := filepath.FromSlash(bundle.File.Path + ".evidence.yaml")
-	if !force && bundleUpToDate(outputPath, bundle.File.SHA256) {
+	if !force && bundleUpToDate(outputPath, bundle.File) {
 		return true, nil
 	}
-	data, err := yaml.Marshal(bundle)
+	data, err := MarshalBundle(bundle, schema)
 	if err != nil {
 		return false, fmt.Errorf("marshal: %w", err)
 	}
@@ -33,25 +38,29 @@ func WriteEvidenceBundle(bundle *EvidenceBundle, force bool) (skipped bool, err
 }
 
 // bundleUpToDate returns true if the existing evidence bundle at outputPath
-// was generated from a source file with the same SHA256 as newSHA256.
-// Returns false if the file does not exist, cannot be read, or has a
-// different hash (INV-50).
-func bundleUpToDate(outputPath, newSHA256 string) bool {
-	data, err := os.ReadFile(outputPath)
+// was generated from a source file with the same SHA256 as want, under the
+// same signal definitions. Returns false if the file does not exist, cannot
+// be read, or has a different hash (INV-50).
+func bundleUpToDate(outputPath string, want FileMeta) bool {
+	existing, err := readBundle(outputPath)
 	if err != nil {
 		return false
 	}
-	var existing EvidenceBundle
-	if err := yaml.Unmarshal(data, &existing); err != nil {
-		return false
-	}
-	return existing.File.SHA256 == newSHA256
+	return existing.File.SHA256 == want.SHA256 && existing.File.SignalsSHA256 == want.SignalsSHA256
 }
 
 // validateEvidenceBundle re-hashes the source file and returns an error if
 // the current hash differs from the stored hash (INV-2, INV-22).
-// It does not modify any files.
+// It does not modify any files. A package bundle is checked file by file.
 func validateEvidenceBundle(bundle *EvidenceBundle) error {
+	if bundle.IsPackageBundle() {
+		for _, f := range bundle.Files {
+			if err := validateEvidenceBundle(&EvidenceBundle{File: f}); err != nil {
+				return fmt.Errorf("%s: %w", f.Path, err)
+			}
+		}
+		return nil
+	}
 	filePath := filepath.FromSlash(bundle.File.Path)
 	raw, err := os.ReadFile(filePath)
 	if err != nil {
@@ -69,14 +78,15 @@ func validateEvidenceBundle(bundle *EvidenceBundle) error {
 // writeBundleAt marshals bundle to YAML and writes it to absFilePath+".evidence.yaml".
 // The companion file is written using the absolute path so it lands next to the
 // source regardless of the caller's working directory (INV-14).
-// If force is false and the existing bundle has the same SHA256, writing is
-// skipped and skipped=true is returned (INV-50).
-func writeBundleAt(bundle *EvidenceBundle, absFilePath string, force bool) (skipped bool, err error) {
+// If force is false and the existing bundle has the same hashes, writing is
+// skipped and skipped=true is returned (INV-50). schema selects the output
+// profile (see MarshalBundle).
+func writeBundleAt(bundle *EvidenceBundle, absFilePath string, force bool, schema string) (skipped bool, err error) {
 	outputPath := absFilePath + ".evidence.yaml"
-	if !force && bundleUpToDate(outputPath, bundle.File.SHA256) {
+	if !force && bundleUpToDate(outputPath, bundle.File) {
 		return true, nil
 	}
-	data, err := yaml.Marshal(bundle)
+	data, err := MarshalBundle(bundle, schema)
 	if err != nil {
 		return false, fmt.Errorf("marshal: %w", err)
 	}
@@ -86,6 +96,57 @@ func writeBundleAt(bundle *EvidenceBundle, absFilePath string, force bool) (skip
 	return false, nil
 }
 
+// BundleStore keeps evidence bundles in one place instead of companion
+// files, keyed by root-relative source path and source hash; see
+// WalkOptions.Store. internal/store implements it over SQLite. When signals
+// are defined the hash passed is the source hash, "+", and the signal
+// definitions hash (see storeHash), so editing signals makes stored bundles
+// stale.
+type BundleStore interface {
+	// Has reports whether the store holds the bundle of path generated
+	// from source hash sha256.
+	Has(path, sha256 string) (bool, error)
+	// Put stores the marshaled bundle of path, generated from source hash
+	// sha256, replacing any earlier bundle of path.
+	Put(path, sha256 string, data []byte) error
+}
+
+// bundleCurrent reports whether the bundle of the source at absFilePath,
+// want.Path from the walk root, was generated with want's hashes: in
+// opts.Store when set, in its companion file otherwise (INV-50).
+func (opts WalkOptions) bundleCurrent(absFilePath string, want FileMeta) bool {
+	if opts.Store == nil {
+		return bundleUpToDate(absFilePath+".evidence.yaml", want)
+	}
+	ok, err := opts.Store.Has(want.Path, storeHash(want))
+	return err == nil && ok
+}
+
+// storeHash returns the hash a BundleStore keys the bundle of m by.
+func storeHash(m FileMeta) string {
+	if m.SignalsSHA256 == "" {
+		return m.SHA256
+	}
+	return m.SHA256 + "+" + m.SignalsSHA256
+}
+
+// saveBundle writes bundle, whose File.Path is relative to the walk root,
+// to opts.Store when set and beside absFilePath as writeBundleAt does
+// otherwise, skipping up-to-date bundles unless opts.Force is set.
+func (opts WalkOptions) saveBundle(bundle *EvidenceBundle, absFilePath string) (skipped bool, err error) {
+	if opts.Store == nil {
+		return writeBundleAt(bundle, absFilePath, opts.Force, opts.Schema)
+	}
+	if !opts.Force && opts.bundleCurrent(absFilePath, bundle.File) {
+		return true, nil
+	}
+	data, err := MarshalBundle(bundle, opts.Schema)
+	if err != nil {
+		return false, fmt.Errorf("marshal: %w", err)
+	}
+	return false, opts.Store.Put(bundle.File.Path, storeHash(bundle.File), data)
+}
+
 // CleanEvidenceBundles removes all *.evidence.yaml files under root.
 // Returns the number of files removed.
 func CleanEvidenceBundles(root string) (int, error) {
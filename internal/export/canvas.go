@@ -0,0 +1,228 @@
+package export
+
+// canvas.go — Obsidian canvas (architecture.canvas) of the system model.
+//
+// The canvas lays the architecture out as a diagram in JSON Canvas 1.0:
+// state domains in the center column (as file nodes linking to their
+// domain pages), packages that write a domain on the left, packages that
+// only read one on the right, and the persistence, network, process, and
+// API boundaries in a row below. Edges run writer → domain → reader, and
+// from packages to the persistence and network boundaries they touch.
+// Node IDs and order are deterministic so regeneration is idempotent.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"iguana/internal/model"
+)
+
+type canvas struct {
+	Nodes []canvasNode `json:"nodes"`
+	Edges []canvasEdge `json:"edges"`
+}
+
+type canvasNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"` // "text" | "file"
+	Text   string `json:"text,omitempty"`
+	File   string `json:"file,omitempty"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Color  string `json:"color,omitempty"`
+}
+
+type canvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	FromSide string `json:"fromSide"`
+	ToNode   string `json:"toNode"`
+	ToSide   string `json:"toSide"`
+	Label    string `json:"label,omitempty"`
+}
+
+// Canvas layout, in canvas pixels.
+const (
+	canvasNodeWidth  = 260
+	canvasNodeHeight = 60
+	canvasRowStep    = 100 // vertical distance between nodes in a column
+	canvasColumnGap  = 200 // horizontal gap between columns
+)
+
+// buildArchitectureCanvas builds architecture.canvas for sys.
+func buildArchitectureCanvas(sys *model.SystemModel) (string, error) {
+	pkgOf := make(map[string]string) // file → package
+	for _, p := range sys.Inventory.Packages {
+		for _, f := range p.Files {
+			pkgOf[f] = p.Name
+		}
+	}
+	pkgFor := func(file string) string {
+		file, _, _ = strings.Cut(file, "#") // SymbolRef fragments
+		if p := pkgOf[file]; p != "" {
+			return p
+		}
+		return file
+	}
+
+	// Effect kinds per (package, domain), split into writes and reads.
+	type link struct{ pkg, domain string }
+	writes := make(map[link]map[string]bool)
+	reads := make(map[link]map[string]bool)
+	writers := make(map[string]bool)
+	readers := make(map[string]bool)
+	for _, e := range sys.Effects {
+		if e.Domain == "" {
+			continue
+		}
+		l := link{pkgFor(e.Via), e.Domain}
+		set, who := reads, readers
+		if strings.HasSuffix(e.Kind, "_write") {
+			set, who = writes, writers
+		}
+		if set[l] == nil {
+			set[l] = make(map[string]bool)
+		}
+		set[l][e.Kind] = true
+		who[l.pkg] = true
+	}
+	for p := range writers {
+		delete(readers, p) // a package that writes is drawn once, on the left
+	}
+
+	var c canvas
+	column := func(x int, ids, texts, files []string, color string) {
+		top := -len(ids) * canvasRowStep / 2
+		for i, id := range ids {
+			n := canvasNode{ID: id, Type: "text", X: x, Y: top + i*canvasRowStep,
+				Width: canvasNodeWidth, Height: canvasNodeHeight, Color: color}
+			if files != nil {
+				n.Type, n.File = "file", files[i]
+			} else {
+				n.Text = texts[i]
+			}
+			c.Nodes = append(c.Nodes, n)
+		}
+	}
+
+	domainIDs := make([]string, len(sys.StateDomains))
+	var domainFiles []string
+	for i, d := range sys.StateDomains {
+		domainIDs[i] = "domain-" + sanitizeFilename(d.ID)
+		domainFiles = append(domainFiles, "domains/"+sanitizeFilename(d.ID)+".md")
+	}
+	domainNode := make(map[string]string, len(sys.StateDomains))
+	for i, d := range sys.StateDomains {
+		domainNode[d.ID] = domainIDs[i]
+	}
+
+	writerNames, readerNames := sortedKeys(writers), sortedKeys(readers)
+	pkgIDs := func(names []string) []string {
+		ids := make([]string, len(names))
+		for i, n := range names {
+			ids[i] = "package-" + sanitizeFilename(n)
+		}
+		return ids
+	}
+	step := canvasNodeWidth + canvasColumnGap
+	column(-step, pkgIDs(writerNames), writerNames, nil, "")
+	column(0, domainIDs, nil, domainFiles, "4")
+	column(step, pkgIDs(readerNames), readerNames, nil, "")
+
+	// Boundaries: one node per kind in a row below the tallest column.
+	var boundaries, boundaryIDs []string
+	for _, p := range sys.Boundaries.Persistence {
+		boundaries = append(boundaries, "persistence: "+p.Kind)
+		boundaryIDs = append(boundaryIDs, "boundary-persistence-"+sanitizeFilename(p.Kind))
+	}
+	if sys.Boundaries.Network != nil {
+		boundaries = append(boundaries, "network: outbound")
+		boundaryIDs = append(boundaryIDs, "boundary-network")
+	}
+	for _, p := range sys.Boundaries.Process {
+		boundaries = append(boundaries, "process: "+p.Kind)
+		boundaryIDs = append(boundaryIDs, "boundary-process-"+sanitizeFilename(p.Kind))
+	}
+	for _, a := range sys.Boundaries.API {
+		boundaries = append(boundaries, a.Kind+": "+a.Service)
+		boundaryIDs = append(boundaryIDs, "boundary-api-"+sanitizeFilename(a.Service))
+	}
+	rows := max(len(writerNames), len(sys.StateDomains), len(readerNames))
+	y := rows*canvasRowStep/2 + canvasRowStep
+	left := -len(boundaries) * step / 2
+	for i, text := range boundaries {
+		c.Nodes = append(c.Nodes, canvasNode{ID: boundaryIDs[i], Type: "text", Text: text,
+			X: left + i*step, Y: y, Width: canvasNodeWidth, Height: canvasNodeHeight, Color: "6"})
+	}
+
+	// Edges, sorted by ID.
+	exists := make(map[string]bool, len(c.Nodes))
+	for _, n := range c.Nodes {
+		exists[n.ID] = true
+	}
+	edge := func(from, fromSide, to, toSide, label string) {
+		if exists[from] && exists[to] {
+			c.Edges = append(c.Edges, canvasEdge{ID: from + "--" + to, FromNode: from, FromSide: fromSide,
+				ToNode: to, ToSide: toSide, Label: label})
+		}
+	}
+	for l, kinds := range writes {
+		edge("package-"+sanitizeFilename(l.pkg), "right", domainNode[l.domain], "left", strings.Join(sortedKeys(kinds), ", "))
+	}
+	for l, kinds := range reads {
+		from, side := "package-"+sanitizeFilename(l.pkg), "left"
+		if writers[l.pkg] {
+			side = "right" // readers that also write sit on the left
+		}
+		edge(domainNode[l.domain], "right", from, side, strings.Join(sortedKeys(kinds), ", "))
+	}
+	for _, p := range sys.Boundaries.Persistence {
+		for _, w := range p.Writers {
+			edge("package-"+sanitizeFilename(pkgFor(w.File)), "bottom", "boundary-persistence-"+sanitizeFilename(p.Kind), "top", "")
+		}
+	}
+	if sys.Boundaries.Network != nil {
+		for _, o := range sys.Boundaries.Network.Outbound {
+			edge("package-"+sanitizeFilename(pkgFor(o.File)), "bottom", "boundary-network", "top", "")
+		}
+	}
+	sort.Slice(c.Edges, func(i, j int) bool { return c.Edges[i].ID < c.Edges[j].ID })
+	c.Edges = dedupEdges(c.Edges)
+	if c.Nodes == nil {
+		c.Nodes = []canvasNode{}
+	}
+	if c.Edges == nil {
+		c.Edges = []canvasEdge{}
+	}
+
+	out, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return "", fmt.Errorf("render canvas: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+// dedupEdges drops edges whose ID repeats the previous one in a sorted slice.
+func dedupEdges(edges []canvasEdge) []canvasEdge {
+	out := edges[:0]
+	for i, e := range edges {
+		if i == 0 || e.ID != edges[i-1].ID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
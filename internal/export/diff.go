@@ -0,0 +1,244 @@
+package export
+
+// diff.go — dry-run preview of a vault write.
+//
+// DiffKnowledgeBundle reports what SyncKnowledgeBundle would change as
+// unified diffs, one per page, without touching outputDir. Lines are
+// diffed with Myers' algorithm in its linear-space form, so large uncapped
+// pages such as graphs/transitions.md cost memory in proportion to their
+// length, not its square.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// DiffKnowledgeBundle returns the unified diffs between the notes in
+// outputDir and bundle, in sorted path order: rewritten and new pages, and
+// stale iguana-managed notes that would be removed (INV-57). It returns ""
+// when writing bundle would change nothing. Nothing is written.
+func DiffKnowledgeBundle(bundle *KnowledgeBundle, outputDir string) (string, error) {
+	stale, err := staleNotes(bundle, outputDir)
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(bundle.pages)+len(stale))
+	for p := range bundle.pages {
+		paths = append(paths, p)
+	}
+	paths = append(paths, stale...)
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		existing, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(p)))
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("read %s: %w", p, err)
+		}
+		content, keep := bundle.pages[p]
+		if exists && keep && string(existing) == content {
+			continue
+		}
+		oldName, newName := "a/"+p, "b/"+p
+		if !exists {
+			oldName = "/dev/null"
+		}
+		if !keep {
+			newName = "/dev/null"
+		}
+		b.WriteString(unifiedDiff(oldName, newName, string(existing), content))
+	}
+	return b.String(), nil
+}
+
+// diffLine is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff renders the line diff from a to b in unified format with
+// diffContext lines of context, or "" when they are equal.
+func unifiedDiff(oldName, newName, a, b string) string {
+	script := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for start := 0; start < len(script); {
+		// Find the next change and extend the hunk while changes are at most
+		// 2*diffContext kept lines apart.
+		first := start
+		for first < len(script) && script[first].op == ' ' {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+		last := first
+		for i := first; i < len(script); i++ {
+			if script[i].op != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		lo := max(first-diffContext, start)
+		hi := min(last+diffContext+1, len(script))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		oldLine, newLine := 1, 1
+		for _, l := range script[:lo] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		var oldCount, newCount int
+		for _, l := range script[lo:hi] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, l := range script[lo:hi] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// hunkRange formats a unified-diff range; an empty range names the line
+// before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits s into lines without their terminators.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns a shortest edit script from a to b.
+func diffLines(a, b []string) []diffLine {
+	n := len(a) + len(b)
+	v := make([]int, 4*n+4) // forward and backward V arrays, see middleSnake
+	return myersDiff(make([]diffLine, 0, n), a, b, v[:2*n+2], v[2*n+2:])
+}
+
+// myersDiff appends the edit script from a to b to script. After trimming
+// the common prefix and suffix it splits the problem at a middle snake and
+// recurses on both halves, so it needs no more than the vf and vb arrays
+// of len(a)+len(b) entries each way.
+func myersDiff(script []diffLine, a, b []string, vf, vb []int) []diffLine {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		script = append(script, diffLine{' ', a[pre]})
+		pre++
+	}
+	a, b = a[pre:], b[pre:]
+	suf := 0
+	for suf < len(a) && suf < len(b) && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	common := a[len(a)-suf:]
+	a, b = a[:len(a)-suf], b[:len(b)-suf]
+
+	switch {
+	case len(a) == 0:
+		for _, l := range b {
+			script = append(script, diffLine{'+', l})
+		}
+	case len(b) == 0:
+		for _, l := range a {
+			script = append(script, diffLine{'-', l})
+		}
+	default:
+		// With both sides non-empty and differing at each end, the edit
+		// distance is at least 2, so both halves are strictly smaller.
+		x, y, u, w := middleSnake(a, b, vf, vb)
+		script = myersDiff(script, a[:x], b[:y], vf, vb)
+		for _, l := range a[x:u] {
+			script = append(script, diffLine{' ', l})
+		}
+		script = myersDiff(script, a[u:], b[w:], vf, vb)
+	}
+	for _, l := range common {
+		script = append(script, diffLine{' ', l})
+	}
+	return script
+}
+
+// middleSnake returns the start (x, y) and end (u, w) of a snake on a
+// shortest edit path from a to b, found by running Myers' search forward
+// from the start and backward from the end until the paths overlap. vf and
+// vb hold the furthest x reached on each diagonal, offset by len(vf)/2;
+// the backward search works on the reversed sequences, where forward
+// diagonal k is diagonal len(a)-len(b)-k.
+func middleSnake(a, b []string, vf, vb []int) (x, y, u, w int) {
+	n, m := len(a), len(b)
+	off := len(vf) / 2
+	delta := n - m
+	odd := delta%2 != 0
+	vf[off+1], vb[off+1] = 0, 0
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || k != d && vf[off+k-1] < vf[off+k+1] {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y = x - k
+			u, w = x, y
+			for u < n && w < m && a[u] == b[w] {
+				u, w = u+1, w+1
+			}
+			vf[off+k] = u
+			if r := delta - k; odd && r >= -(d-1) && r <= d-1 && u+vb[off+r] >= n {
+				return x, y, u, w
+			}
+		}
+		for r := -d; r <= d; r += 2 {
+			var xr int
+			if r == -d || r != d && vb[off+r-1] < vb[off+r+1] {
+				xr = vb[off+r+1]
+			} else {
+				xr = vb[off+r-1] + 1
+			}
+			yr := xr - r
+			ur, wr := xr, yr
+			for ur < n && wr < m && a[n-1-ur] == b[m-1-wr] {
+				ur, wr = ur+1, wr+1
+			}
+			vb[off+r] = ur
+			if k := delta - r; !odd && k >= -d && k <= d && ur+vf[off+k] >= n {
+				return n - ur, m - wr, n - xr, m - yr
+			}
+		}
+	}
+	panic("middleSnake: no overlap") // unreachable: the paths always meet
+}
@@ -0,0 +1,129 @@
+package export
+
+// dot.go — GraphViz DOT export of the system model.
+//
+// The Mermaid graphs in the vault become unreadable past a few hundred
+// nodes; a DOT file can be laid out by graphviz (dot, sfdp) instead. The
+// graph holds:
+//
+//   - one box per inventory package, with its import edges;
+//   - one cluster per state domain around its owner packages and a
+//     cylinder node for the domain itself (a package owned by several
+//     domains is drawn in the first, by domain ID);
+//   - one dashed edge per effect from the package of its file to its
+//     domain, or to a node for the effect kind when it has no domain.
+//
+// Statements are emitted in sorted order so the output is deterministic.
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"iguana/internal/model"
+)
+
+// Model export formats accepted by RenderModel.
+const (
+	ModelFormatDOT = "dot"
+)
+
+// RenderModel renders sys as a standalone graph file in format.
+func RenderModel(sys *model.SystemModel, format string) (string, error) {
+	switch format {
+	case ModelFormatDOT:
+		return BuildDOT(sys), nil
+	default:
+		return "", fmt.Errorf("unknown model format %q (want %s)", format, ModelFormatDOT)
+	}
+}
+
+// BuildDOT renders the package import graph, effect edges, and state domain
+// clusters of sys as a GraphViz digraph.
+func BuildDOT(sys *model.SystemModel) string {
+	var b strings.Builder
+	b.WriteString("digraph system_model {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	pkgOf := make(map[string]string) // file → package
+	pkgs := make(map[string]bool)
+	for _, p := range sys.Inventory.Packages {
+		pkgs[p.Name] = true
+		for _, f := range p.Files {
+			pkgOf[f] = p.Name
+		}
+	}
+
+	domains := append([]model.StateDomain(nil), sys.StateDomains...)
+	sort.Slice(domains, func(i, j int) bool { return domains[i].ID < domains[j].ID })
+	clustered := make(map[string]bool)
+	for _, d := range domains {
+		b.WriteString(fmt.Sprintf("  subgraph %s {\n", dotID("cluster_"+d.ID)))
+		b.WriteString(fmt.Sprintf("    label=%s;\n", dotID(d.ID)))
+		b.WriteString("    style=rounded;\n")
+		b.WriteString(fmt.Sprintf("    %s [label=%s, shape=cylinder];\n", dotID("domain:"+d.ID), dotID(d.ID)))
+		owners := append([]string(nil), d.Owners...)
+		sort.Strings(owners)
+		for _, o := range owners {
+			if !clustered[o] {
+				clustered[o] = true
+				b.WriteString(fmt.Sprintf("    %s;\n", dotID(o)))
+			}
+		}
+		b.WriteString("  }\n")
+	}
+
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		if !clustered[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %s;\n", dotID(name)))
+	}
+
+	var edges []string
+	for _, p := range sys.Inventory.Packages {
+		for _, imp := range p.Imports {
+			edges = append(edges, fmt.Sprintf("  %s -> %s;\n", dotID(p.Name), dotID(imp)))
+		}
+	}
+	kinds := make(map[string]bool)
+	for _, e := range sys.Effects {
+		from := pkgOf[e.Via]
+		if from == "" {
+			from = e.Via
+		}
+		to := "domain:" + e.Domain
+		if e.Domain == "" {
+			to = "effect:" + e.Kind
+			kinds[e.Kind] = true
+		}
+		edges = append(edges, fmt.Sprintf("  %s -> %s [label=%s, style=dashed];\n", dotID(from), dotID(to), dotID(e.Kind)))
+	}
+	kindNames := make([]string, 0, len(kinds))
+	for k := range kinds {
+		kindNames = append(kindNames, k)
+	}
+	sort.Strings(kindNames)
+	for _, k := range kindNames {
+		b.WriteString(fmt.Sprintf("  %s [label=%s, shape=ellipse];\n", dotID("effect:"+k), dotID(k)))
+	}
+
+	sort.Strings(edges)
+	edges = slices.Compact(edges)
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -9,9 +9,11 @@ package export
 //   index.md                 — lists all state domains
 //   domains/<id>.md          — one per state domain
 //   boundaries.md            — persistence + network
-//   risk.md                  — in-degree, write domains, import cycles
+//   risk.md                  — in-degree, write domains, resilience, import cycles
 //   open-questions.md        — grouped by domain
 //   graphs/dependencies.md   — Mermaid LR import graph
+//   graphs/transitions.md    — Mermaid LR symbol transition graph
+//   architecture.canvas      — Obsidian canvas: writers → domains → readers
 //
 // See INVARIANT.md INV-42..46, INV-53..55.
 
@@ -25,7 +27,8 @@ import (
 	"iguana/internal/model"
 )
 
-// KnowledgeBundle holds pre-generated page content (path → markdown).
+// KnowledgeBundle holds pre-generated page content (path → markdown, or
+// JSON for the canvas).
 // Paths are relative to the output directory, using forward slashes.
 type KnowledgeBundle struct {
 	pages map[string]string
@@ -40,13 +43,20 @@ func GenerateKnowledgeBundle(sys *model.SystemModel) (*KnowledgeBundle, error) {
 
 	for _, d := range sys.StateDomains {
 		id := sanitizeFilename(d.ID)
-		pages["domains/"+id+".md"] = buildDomainPage(d, sys.Effects)
+		pages["domains/"+id+".md"] = buildDomainPage(d, sys.Effects, sys.SharedState)
 	}
 
 	pages["boundaries.md"] = buildBoundaryMap(sys)
 	pages["risk.md"] = buildRiskReport(sys)
 	pages["open-questions.md"] = buildOpenQuestionsIndex(sys)
 	pages["graphs/dependencies.md"] = buildDependencyGraph(sys)
+	pages["graphs/transitions.md"] = buildTransitionGraph(sys)
+
+	canvas, err := buildArchitectureCanvas(sys)
+	if err != nil {
+		return nil, err
+	}
+	pages["architecture.canvas"] = canvas
 
 	return &KnowledgeBundle{pages: pages}, nil
 }
@@ -54,27 +64,46 @@ func GenerateKnowledgeBundle(sys *model.SystemModel) (*KnowledgeBundle, error) {
 // WriteKnowledgeBundle writes all pages in bundle to outputDir.
 // Pages are written in sorted path order for idempotency (INV-44).
 // Always creates domains/ and graphs/ subdirectories (INV-42).
+// iguana-managed notes left over from a previous generation that are not part
+// of bundle are removed first (INV-57).
 func WriteKnowledgeBundle(bundle *KnowledgeBundle, outputDir string) error {
+	_, err := SyncKnowledgeBundle(bundle, outputDir)
+	return err
+}
+
+// SyncKnowledgeBundle writes bundle like WriteKnowledgeBundle but leaves
+// notes whose content is already up to date untouched, and returns the
+// sorted vault-relative paths of notes it created or rewrote.
+func SyncKnowledgeBundle(bundle *KnowledgeBundle, outputDir string) ([]string, error) {
 	// INV-42: always create these subdirectories.
 	for _, sub := range []string{"domains", "graphs"} {
 		if err := os.MkdirAll(filepath.Join(outputDir, sub), 0o755); err != nil {
-			return fmt.Errorf("mkdir %s: %w", sub, err)
+			return nil, fmt.Errorf("mkdir %s: %w", sub, err)
 		}
 	}
 
+	if err := removeStaleNotes(bundle, outputDir); err != nil {
+		return nil, err
+	}
+
 	paths := make([]string, 0, len(bundle.pages))
 	for p := range bundle.pages {
 		paths = append(paths, p)
 	}
 	sort.Strings(paths)
 
+	var changed []string
 	for _, p := range paths {
 		abs := filepath.Join(outputDir, filepath.FromSlash(p))
-		if err := writeNote(abs, bundle.pages[p]); err != nil {
-			return err
+		wrote, err := writeNote(abs, bundle.pages[p])
+		if err != nil {
+			return nil, err
+		}
+		if wrote {
+			changed = append(changed, p)
 		}
 	}
-	return nil
+	return changed, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -97,9 +126,10 @@ func buildOverviewPage(sys *model.SystemModel) string {
 }
 
 // buildDomainPage builds domains/<id>.md for one state domain.
-// Symbols are plain text (no wiki links). Evidence section included when
+// Symbols are plain text (no wiki links). A Warnings section lists the
+// domain's shared mutable state risks. Evidence section included when
 // EvidenceRefs is non-empty (INV-55).
-func buildDomainPage(d model.StateDomain, effects []model.Effect) string {
+func buildDomainPage(d model.StateDomain, effects []model.Effect, risks []model.SharedStateRisk) string {
 	var b strings.Builder
 
 	tags := []string{"state-domain", confidenceTag(d.Confidence)}
@@ -147,6 +177,19 @@ func buildDomainPage(d model.StateDomain, effects []model.Effect) string {
 		}
 	}
 
+	var warned bool
+	for _, r := range risks {
+		if r.Domain != d.ID {
+			continue
+		}
+		if !warned {
+			b.WriteString("\n## Warnings\n\n")
+			warned = true
+		}
+		b.WriteString(fmt.Sprintf("- `%s` is exported mutable state; goroutines are launched in %s with no synchronization declared for it.\n",
+			r.Variable, strings.Join(r.Launchers, ", ")))
+	}
+
 	// INV-55: Evidence section when EvidenceRefs non-empty.
 	if len(d.EvidenceRefs) > 0 {
 		b.WriteString("\n## Evidence\n\n")
@@ -176,84 +219,225 @@ func buildBoundaryMap(sys *model.SystemModel) string {
 		b.WriteString("\n")
 	}
 
-	if sys.Boundaries.Network != nil && len(sys.Boundaries.Network.Outbound) > 0 {
+	for _, pb := range sys.Boundaries.Persistence {
+		if len(pb.Tables) == 0 {
+			continue
+		}
+		b.WriteString("### Tables\n\n")
+		b.WriteString("| Package | Writes | Reads |\n")
+		b.WriteString("|---------|--------|-------|\n")
+		for _, tu := range pb.Tables {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", tu.Package, strings.Join(tu.Writes, ", "), strings.Join(tu.Reads, ", ")))
+		}
+		b.WriteString("\n")
+	}
+
+	if n := sys.Boundaries.Network; n != nil {
 		b.WriteString("## Network\n\n")
-		b.WriteString("| File |\n")
-		b.WriteString("|------|\n")
-		for _, ob := range sys.Boundaries.Network.Outbound {
-			b.WriteString(fmt.Sprintf("| `%s` |\n", ob.File))
+		if len(n.Outbound) > 0 {
+			b.WriteString("| File |\n")
+			b.WriteString("|------|\n")
+			for _, ob := range n.Outbound {
+				b.WriteString(fmt.Sprintf("| `%s` |\n", ob.File))
+			}
+			b.WriteString("\n")
+		}
+		if len(n.Routes) > 0 {
+			b.WriteString("### Inbound Routes\n\n")
+			b.WriteString("| Method | Path | Package |\n")
+			b.WriteString("|--------|------|---------|\n")
+			for _, r := range n.Routes {
+				b.WriteString(fmt.Sprintf("| %s | `%s` | %s |\n", orAny(r.Method), r.Path, r.Package))
+			}
+			b.WriteString("\n")
+		}
+		if len(n.Endpoints) > 0 {
+			b.WriteString("### Outbound Endpoints\n\n")
+			b.WriteString("| Method | URL | Package |\n")
+			b.WriteString("|--------|-----|---------|\n")
+			for _, e := range n.Endpoints {
+				b.WriteString(fmt.Sprintf("| %s | `%s` | %s |\n", orDash(e.Method), e.URL, e.Package))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(sys.Boundaries.Config) > 0 {
+		b.WriteString("## Configuration Surface\n\n")
+		b.WriteString("| Source | Key | Packages |\n")
+		b.WriteString("|--------|-----|----------|\n")
+		for _, cb := range sys.Boundaries.Config {
+			b.WriteString(fmt.Sprintf("| %s | `%s` | %s |\n", cb.Source, cb.Key, strings.Join(cb.Packages, ", ")))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(sys.CLISurface) > 0 {
+		b.WriteString("## CLI Surface\n\n")
+		for _, cs := range sys.CLISurface {
+			b.WriteString(fmt.Sprintf("### %s.%s (`%s`)\n\n", cs.Package, cs.Symbol, cs.Dir))
+			b.WriteString("| Flag | Type | Default | Usage |\n")
+			b.WriteString("|------|------|---------|-------|\n")
+			for _, f := range cs.Flags {
+				name := "--" + f.Name
+				if f.Shorthand != "" {
+					name = "-" + f.Shorthand + ", " + name
+				}
+				b.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", name, f.Type, f.Default, f.Usage))
+			}
+			b.WriteString("\n")
 		}
 	}
 
 	return b.String()
 }
 
-// buildRiskReport builds risk.md — in-degree, write domains, import cycles.
+// orAny returns method, or "ANY" for a route registered for every method.
+func orAny(method string) string {
+	if method == "" {
+		return "ANY"
+	}
+	return method
+}
+
+// orDash returns method, or "-" for a request whose method is unknown.
+func orDash(method string) string {
+	if method == "" {
+		return "-"
+	}
+	return method
+}
+
+// buildRiskReport builds risk.md — in-degree, write domains, network
+// resilience, test coverage, custom signals, unreferenced exported symbols,
+// shared mutable state, import cycles.
 func buildRiskReport(sys *model.SystemModel) string {
 	var b strings.Builder
 	b.WriteString(frontmatter([]string{"iguana/risk"}))
 	b.WriteString("# Risk Report\n\n")
 
 	// --- Top packages by in-degree ---
-	inDegree := make(map[string]int)
-	for _, pkg := range sys.Inventory.Packages {
-		for _, imp := range pkg.Imports {
-			inDegree[imp]++
+	b.WriteString("## Top Packages by In-Degree\n\n")
+	if counts := topInDegree(sys, 10); len(counts) > 0 {
+		b.WriteString("| Package | Dependents |\n")
+		b.WriteString("|---------|------------|\n")
+		for _, pc := range counts {
+			b.WriteString(fmt.Sprintf("| %s | %d |\n", pc.Name, pc.Count))
 		}
 	}
+	b.WriteString("\n")
 
-	type pkgCount struct {
-		name  string
-		count int
+	// --- Domains with write effects ---
+	b.WriteString("## Domains with Write Effects\n\n")
+	if rows := writeDomains(sys); len(rows) > 0 {
+		b.WriteString("| Domain | Writers |\n")
+		b.WriteString("|--------|----------|\n")
+		for _, r := range rows {
+			san := sanitizeFilename(r.Domain)
+			writers := strings.Join(r.Writers, ", ")
+			b.WriteString(fmt.Sprintf("| [[domains/%s|%s]] | %s |\n", san, r.Domain, writers))
+		}
 	}
-	counts := make([]pkgCount, 0, len(inDegree))
-	for name, count := range inDegree {
-		counts = append(counts, pkgCount{name, count})
+	b.WriteString("\n")
+
+	// --- Network resilience ---
+	b.WriteString("## Network Resilience\n\n")
+	if rows := networkResilience(sys); len(rows) > 0 {
+		b.WriteString("| Package | Retry / Circuit Breaker |\n")
+		b.WriteString("|---------|-------------------------|\n")
+		for _, r := range rows {
+			mark := "no"
+			if r.resilient {
+				mark = "yes"
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", r.pkg, mark))
+		}
+	} else {
+		b.WriteString("_No network-calling packages._\n")
 	}
-	// Sort descending by count, then ascending by name for determinism.
-	sort.Slice(counts, func(i, j int) bool {
-		if counts[i].count != counts[j].count {
-			return counts[i].count > counts[j].count
+	b.WriteString("\n")
+
+	// --- Confidence calibration ---
+	b.WriteString("## Confidence Calibration\n\n")
+	if rows := confidenceCalibration(sys); len(rows) > 0 {
+		b.WriteString("| Domain | LLM Confidence | Evidence Strength | Flag |\n")
+		b.WriteString("|--------|----------------|-------------------|------|\n")
+		for _, r := range rows {
+			san := sanitizeFilename(r.id)
+			b.WriteString(fmt.Sprintf("| [[domains/%s|%s]] | %.2f | %.2f | %s |\n",
+				san, r.id, r.confidence, r.strength, r.flag))
 		}
-		return counts[i].name < counts[j].name
-	})
-	if len(counts) > 10 {
-		counts = counts[:10]
+	} else {
+		b.WriteString("_No state domains._\n")
 	}
+	b.WriteString("\n")
 
-	b.WriteString("## Top Packages by In-Degree\n\n")
-	if len(counts) > 0 {
-		b.WriteString("| Package | Dependents |\n")
+	// --- Ownership conflicts ---
+	b.WriteString("## Ownership Conflicts\n\n")
+	if conflicts := model.OwnershipConflicts(sys.StateDomains); len(conflicts) > 0 {
+		b.WriteString("| Package | Claimed By |\n")
 		b.WriteString("|---------|------------|\n")
-		for _, pc := range counts {
-			b.WriteString(fmt.Sprintf("| %s | %d |\n", pc.name, pc.count))
+		for _, c := range conflicts {
+			links := make([]string, len(c.Domains))
+			for i, id := range c.Domains {
+				links[i] = fmt.Sprintf("[[domains/%s|%s]]", sanitizeFilename(id), id)
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", c.Package, strings.Join(links, ", ")))
 		}
+	} else {
+		b.WriteString("_None found._\n")
 	}
 	b.WriteString("\n")
 
-	// --- Domains with write effects ---
-	writeDomains := make(map[string][]string) // domainID → []Via
-	for _, e := range sys.Effects {
-		if (e.Kind == "fs_write" || e.Kind == "db_write") && e.Domain != "" {
-			writeDomains[e.Domain] = append(writeDomains[e.Domain], e.Via)
+	// --- Test coverage (only when test bundles were analyzed) ---
+	if len(sys.TestedSymbols) > 0 {
+		b.WriteString("## Test Coverage\n\n")
+		b.WriteString("| Package | Tested Symbols |\n")
+		b.WriteString("|---------|----------------|\n")
+		for _, r := range testCoverage(sys) {
+			b.WriteString(fmt.Sprintf("| %s | %d |\n", r.pkg, r.tested))
 		}
+		b.WriteString("\n")
 	}
 
-	b.WriteString("## Domains with Write Effects\n\n")
-	if len(writeDomains) > 0 {
-		domainIDs := make([]string, 0, len(writeDomains))
-		for id := range writeDomains {
-			domainIDs = append(domainIDs, id)
+	// --- Custom signals (only when settings define signals that matched) ---
+	if rows := customSignals(sys); len(rows) > 0 {
+		b.WriteString("## Custom Signals\n\n")
+		b.WriteString("| Package | Signals |\n")
+		b.WriteString("|---------|---------|\n")
+		for _, p := range rows {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", p.Name, strings.Join(p.CustomSignals, ", ")))
 		}
-		sort.Strings(domainIDs)
+		b.WriteString("\n")
+	}
 
-		b.WriteString("| Domain | Writers |\n")
-		b.WriteString("|--------|----------|\n")
-		for _, id := range domainIDs {
-			san := sanitizeFilename(id)
-			writers := strings.Join(writeDomains[id], ", ")
-			b.WriteString(fmt.Sprintf("| [[domains/%s|%s]] | %s |\n", san, id, writers))
+	// --- Unreferenced exported symbols ---
+	b.WriteString("## Unreferenced Exported Symbols\n\n")
+	if len(sys.Unreferenced) > 0 {
+		b.WriteString("| Symbol | Kind |\n")
+		b.WriteString("|--------|------|\n")
+		for _, u := range sys.Unreferenced {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", u.Symbol, u.Kind))
 		}
+	} else {
+		b.WriteString("_None found._\n")
+	}
+	b.WriteString("\n")
+
+	// --- Shared mutable state ---
+	b.WriteString("## Shared Mutable State\n\n")
+	if len(sys.SharedState) > 0 {
+		b.WriteString("| Variable | Goroutine Launchers | Domain |\n")
+		b.WriteString("|----------|---------------------|--------|\n")
+		for _, r := range sys.SharedState {
+			domain := "-"
+			if r.Domain != "" {
+				domain = fmt.Sprintf("[[domains/%s|%s]]", sanitizeFilename(r.Domain), r.Domain)
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", r.Variable, strings.Join(r.Launchers, ", "), domain))
+		}
+	} else {
+		b.WriteString("_None found._\n")
 	}
 	b.WriteString("\n")
 
@@ -356,6 +540,44 @@ func buildDependencyGraph(sys *model.SystemModel) string {
 	return b.String()
 }
 
+// buildTransitionGraph builds graphs/transitions.md — Mermaid LR graph of
+// the model's transitions, one edge per Transition.From → To.
+func buildTransitionGraph(sys *model.SystemModel) string {
+	var b strings.Builder
+	b.WriteString(frontmatter([]string{"iguana/graph"}))
+	b.WriteString("# Transition Graph\n\n")
+
+	if len(sys.Transitions) == 0 {
+		b.WriteString("_No transitions._\n")
+		return b.String()
+	}
+
+	// Deduplicate and sort edges for determinism (INV-44).
+	seen := make(map[[2]string]bool)
+	var edges [][2]string
+	for _, tr := range sys.Transitions {
+		e := [2]string{tr.From, tr.To}
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	b.WriteString("```mermaid\ngraph LR\n")
+	for _, e := range edges {
+		b.WriteString(fmt.Sprintf("  %s --> %s\n", e[0], e[1]))
+	}
+	b.WriteString("```\n")
+
+	return b.String()
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -411,16 +633,301 @@ func sanitizeFilename(s string) string {
 }
 
 // writeNote writes content to path, creating parent directories as needed.
-func writeNote(path, content string) error {
+// It skips the write when the file already holds content, reporting whether
+// the note was written.
+func writeNote(path, content string) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == content {
+		return false, nil
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("mkdir %s: %w", filepath.Dir(path), err)
+		return false, fmt.Errorf("mkdir %s: %w", filepath.Dir(path), err)
 	}
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
-		return fmt.Errorf("write %s: %w", path, err)
+		return false, fmt.Errorf("write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// removeStaleNotes deletes the notes staleNotes reports (INV-57).
+func removeStaleNotes(bundle *KnowledgeBundle, outputDir string) error {
+	stale, err := staleNotes(bundle, outputDir)
+	if err != nil {
+		return err
+	}
+	for _, rel := range stale {
+		path := filepath.Join(outputDir, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove %s: %w", path, err)
+		}
 	}
 	return nil
 }
 
+// staleNotes returns the vault-relative paths of Markdown notes under
+// outputDir that are not pages of bundle but carry an iguana-managed tag in
+// their frontmatter, in walk order. Notes without such a tag (e.g.
+// hand-written notes) are never reported (INV-57). A missing outputDir has
+// none.
+func staleNotes(bundle *KnowledgeBundle, outputDir string) ([]string, error) {
+	var stale []string
+	err := filepath.WalkDir(outputDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == outputDir && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := bundle.pages[rel]; ok {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		if isManagedNote(string(data)) {
+			stale = append(stale, rel)
+		}
+		return nil
+	})
+	return stale, err
+}
+
+// isManagedNote reports whether content starts with a frontmatter block whose
+// tags mark it as generated by iguana: "state-domain" or any "iguana/*" tag.
+func isManagedNote(content string) bool {
+	if !strings.HasPrefix(content, "---\n") {
+		return false
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return false
+	}
+	for _, line := range strings.Split(content[4:4+end], "\n") {
+		tag, ok := strings.CutPrefix(strings.TrimSpace(line), "- ")
+		if !ok {
+			continue
+		}
+		if tag == "state-domain" || strings.HasPrefix(tag, "iguana/") {
+			return true
+		}
+	}
+	return false
+}
+
+// inDegreeRow is one package and the number of inventory packages that
+// import it.
+type inDegreeRow struct {
+	Name  string
+	Count int
+}
+
+// topInDegree returns the n packages imported by the most other packages,
+// sorted by count descending, then name.
+func topInDegree(sys *model.SystemModel, n int) []inDegreeRow {
+	inDegree := make(map[string]int)
+	for _, pkg := range sys.Inventory.Packages {
+		for _, imp := range pkg.Imports {
+			inDegree[imp]++
+		}
+	}
+	counts := make([]inDegreeRow, 0, len(inDegree))
+	for name, count := range inDegree {
+		counts = append(counts, inDegreeRow{name, count})
+	}
+	// Sort descending by count, then ascending by name for determinism.
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// writeDomainRow lists the files that write state owned by one domain.
+type writeDomainRow struct {
+	Domain  string
+	Writers []string
+}
+
+// writeDomains returns the domains with linked fs_write or db_write effects,
+// sorted by domain ID; writers keep effect order.
+func writeDomains(sys *model.SystemModel) []writeDomainRow {
+	writers := make(map[string][]string) // domainID → []Via
+	for _, e := range sys.Effects {
+		if (e.Kind == "fs_write" || e.Kind == "db_write") && e.Domain != "" {
+			writers[e.Domain] = append(writers[e.Domain], e.Via)
+		}
+	}
+	rows := make([]writeDomainRow, 0, len(writers))
+	for id, via := range writers {
+		rows = append(rows, writeDomainRow{Domain: id, Writers: via})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Domain < rows[j].Domain })
+	return rows
+}
+
+// customSignals returns the inventory packages that raised a user-defined
+// signal, in inventory (name) order.
+func customSignals(sys *model.SystemModel) []model.PackageEntry {
+	var rows []model.PackageEntry
+	for _, p := range sys.Inventory.Packages {
+		if len(p.CustomSignals) > 0 {
+			rows = append(rows, p)
+		}
+	}
+	return rows
+}
+
+// coverageRow is the number of tested symbols in one package.
+type coverageRow struct {
+	pkg    string
+	tested int
+}
+
+// testCoverage counts tested symbols per inventory package, listing
+// packages without any so untested code stands out. Rows are sorted by
+// package name.
+func testCoverage(sys *model.SystemModel) []coverageRow {
+	counts := make(map[string]int)
+	for _, p := range sys.Inventory.Packages {
+		if p.Language == "" {
+			counts[p.Name] = 0
+		}
+	}
+	for _, ts := range sys.TestedSymbols {
+		pkg, _, _ := strings.Cut(ts.Symbol, ".")
+		counts[pkg]++
+	}
+	rows := make([]coverageRow, 0, len(counts))
+	for pkg, n := range counts {
+		rows = append(rows, coverageRow{pkg, n})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].pkg < rows[j].pkg })
+	return rows
+}
+
+// resilienceRow reports whether one network-calling package uses a retry or
+// circuit-breaker library.
+type resilienceRow struct {
+	pkg       string
+	resilient bool
+}
+
+// networkResilience maps outbound network files to their packages (via the
+// inventory) and reports, per package, whether any of its files imports a
+// resilience library. Files missing from the inventory are reported under
+// their own path. Rows are sorted by package name.
+func networkResilience(sys *model.SystemModel) []resilienceRow {
+	if sys.Boundaries.Network == nil {
+		return nil
+	}
+	fileToPkg := make(map[string]string)
+	for _, p := range sys.Inventory.Packages {
+		for _, f := range p.Files {
+			fileToPkg[f] = p.Name
+		}
+	}
+	pkgOf := func(file string) string {
+		if pkg, ok := fileToPkg[file]; ok {
+			return pkg
+		}
+		return file
+	}
+
+	resilient := make(map[string]bool)
+	for _, r := range sys.Boundaries.Network.Resilient {
+		resilient[pkgOf(r.File)] = true
+	}
+	seen := make(map[string]bool)
+	var rows []resilienceRow
+	for _, ob := range sys.Boundaries.Network.Outbound {
+		pkg := pkgOf(ob.File)
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		rows = append(rows, resilienceRow{pkg: pkg, resilient: resilient[pkg]})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].pkg < rows[j].pkg })
+	return rows
+}
+
+// calibrationDivergence is the gap between LLM confidence and evidence
+// strength at which confidenceCalibration flags a domain.
+const calibrationDivergence = 0.4
+
+// calibrationRow compares one state domain's LLM confidence with its
+// deterministic evidence strength. flag is "overconfident", "underconfident",
+// or "-" when the two agree.
+type calibrationRow struct {
+	id         string
+	confidence float64
+	strength   float64
+	flag       string
+}
+
+// confidenceCalibration scores every state domain's evidence strength and
+// flags domains whose LLM confidence diverges from it by at least
+// calibrationDivergence. Rows follow the (sorted) domain order.
+func confidenceCalibration(sys *model.SystemModel) []calibrationRow {
+	var rows []calibrationRow
+	for _, d := range sys.StateDomains {
+		strength := evidenceStrength(d, domainEffects(d.ID, sys.Effects))
+		flag := "-"
+		switch {
+		case d.Confidence-strength >= calibrationDivergence:
+			flag = "overconfident"
+		case strength-d.Confidence >= calibrationDivergence:
+			flag = "underconfident"
+		}
+		rows = append(rows, calibrationRow{id: d.ID, confidence: d.Confidence, strength: strength, flag: flag})
+	}
+	return rows
+}
+
+// evidenceStrength scores, in [0, 1], how well static evidence supports a
+// domain: up to 0.4 for supporting bundles (saturating at four), 0.2 for a
+// persistence signal, and 0.2 each when named mutators and readers are
+// backed by write and read effects linked to the domain.
+func evidenceStrength(d model.StateDomain, effects []model.Effect) float64 {
+	bundles := len(d.EvidenceRefs)
+	if bundles > 4 {
+		bundles = 4
+	}
+	score := 0.4 * float64(bundles) / 4
+	if d.Persistence != nil {
+		score += 0.2
+	}
+	var writes, reads bool
+	for _, e := range effects {
+		switch e.Kind {
+		case "db_write", "fs_write":
+			writes = true
+		case "fs_read":
+			reads = true
+		}
+	}
+	if len(d.PrimaryMutators) > 0 && writes {
+		score += 0.2
+	}
+	if len(d.PrimaryReaders) > 0 && reads {
+		score += 0.2
+	}
+	return score
+}
+
 // findCycles performs DFS cycle detection on the package import graph.
 // Returns one string per cycle in "pkgA → pkgB → pkgA" format.
 // Results are deterministic because nodes and neighbors are sorted.
@@ -13,10 +13,15 @@ package export
 //   INV-53: one domains/<id>.md per domain; no symbols/
 //   INV-54: tag requirements per note type
 //   INV-55: DomainPage ## Evidence section when EvidenceRefs non-empty
+//   INV-57: stale iguana-managed notes removed on regeneration
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -411,6 +416,107 @@ func TestGenerateKnowledgeBundle_BoundaryMap(t *testing.T) {
 	}
 }
 
+// TestGenerateKnowledgeBundle_SharedState verifies that shared mutable
+// state risks appear in risk.md and as warnings on their domain's note.
+func TestGenerateKnowledgeBundle_SharedState(t *testing.T) {
+	dir := t.TempDir()
+	sys := minimalModel()
+	sys.SharedState = []model.SharedStateRisk{
+		{Variable: "store.Cache", Package: "store", Launchers: []string{"store/worker.go"}, Domain: "evidence_store"},
+	}
+	writeBundle(t, sys, dir)
+
+	risk := readFile(t, filepath.Join(dir, "risk.md"))
+	if !strings.Contains(risk, "| store.Cache | store/worker.go | [[domains/evidence_store|evidence_store]] |") {
+		t.Errorf("missing shared state row;\ngot:\n%s", risk)
+	}
+	note := readFile(t, filepath.Join(dir, "domains", "evidence_store.md"))
+	if !strings.Contains(note, "## Warnings") || !strings.Contains(note, "`store.Cache`") {
+		t.Errorf("missing shared state warning;\ngot:\n%s", note)
+	}
+}
+
+// TestGenerateKnowledgeBundle_BoundaryMap_Config verifies boundaries.md
+// lists the configuration surface when the model has one.
+func TestGenerateKnowledgeBundle_BoundaryMap_Config(t *testing.T) {
+	dir := t.TempDir()
+	sys := minimalModel()
+	sys.Boundaries.Config = []model.ConfigBoundary{
+		{Source: "env", Key: "DATABASE_URL", Packages: []string{"main", "store"}},
+	}
+	writeBundle(t, sys, dir)
+
+	content := readFile(t, filepath.Join(dir, "boundaries.md"))
+	if !strings.Contains(content, "## Configuration Surface") {
+		t.Errorf("missing ## Configuration Surface section;\ngot:\n%s", content)
+	}
+	if !strings.Contains(content, "| env | `DATABASE_URL` | main, store |") {
+		t.Errorf("missing DATABASE_URL row;\ngot:\n%s", content)
+	}
+}
+
+// TestGenerateKnowledgeBundle_BoundaryMap_Tables verifies boundaries.md
+// lists the tables each package writes and reads.
+func TestGenerateKnowledgeBundle_BoundaryMap_Tables(t *testing.T) {
+	dir := t.TempDir()
+	sys := minimalModel()
+	sys.Boundaries.Persistence = append(sys.Boundaries.Persistence, model.PersistenceBoundary{
+		Kind:    "db",
+		Writers: []model.SymbolRef{{File: "store/query.go"}},
+		Tables:  []model.TableUse{{Package: "store", Writes: []string{"orders", "users"}, Reads: []string{"carts"}}},
+	})
+	writeBundle(t, sys, dir)
+
+	content := readFile(t, filepath.Join(dir, "boundaries.md"))
+	for _, want := range []string{"### Tables", "| store | orders, users | carts |"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+}
+
+// TestGenerateKnowledgeBundle_BoundaryMap_HTTP verifies boundaries.md
+// separates inbound routes from outbound endpoints.
+func TestGenerateKnowledgeBundle_BoundaryMap_HTTP(t *testing.T) {
+	dir := t.TempDir()
+	sys := minimalModel()
+	sys.Boundaries.Network.Routes = []model.HTTPRoute{{Path: "/healthz", Package: "api"}}
+	sys.Boundaries.Network.Endpoints = []model.HTTPEndpoint{
+		{Method: "GET", URL: "https://example.com/v1", Host: "example.com", Package: "client"},
+		{URL: "https://example.com/v2", Host: "example.com", Package: "client"},
+	}
+	writeBundle(t, sys, dir)
+
+	content := readFile(t, filepath.Join(dir, "boundaries.md"))
+	for _, want := range []string{
+		"### Inbound Routes", "| ANY | `/healthz` | api |",
+		"### Outbound Endpoints", "| GET | `https://example.com/v1` | client |",
+		"| - | `https://example.com/v2` | client |",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+}
+
+// TestGenerateKnowledgeBundle_BoundaryMap_CLI verifies boundaries.md lists
+// each entrypoint's command-line flags.
+func TestGenerateKnowledgeBundle_BoundaryMap_CLI(t *testing.T) {
+	dir := t.TempDir()
+	sys := minimalModel()
+	sys.CLISurface = []model.CLISurface{{Package: "main", Symbol: "main", Dir: "cmd/api", Flags: []model.CLIFlag{
+		{Name: "port", Shorthand: "p", Type: "int", Default: "80", Usage: "listen port"},
+	}}}
+	writeBundle(t, sys, dir)
+
+	content := readFile(t, filepath.Join(dir, "boundaries.md"))
+	for _, want := range []string{"## CLI Surface", "### main.main (`cmd/api`)", "| `-p, --port` | int | `80` | listen port |"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Risk report
 // ---------------------------------------------------------------------------
@@ -468,6 +574,349 @@ func TestGenerateKnowledgeBundle_RiskReport_Cycles(t *testing.T) {
 	}
 }
 
+// TestGenerateKnowledgeBundle_RiskReport_TestCoverage verifies risk.md counts
+// tested symbols per package, including packages with none, and omits the
+// section when no tests were analyzed.
+func TestGenerateKnowledgeBundle_RiskReport_TestCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeBundle(t, minimalModel(), dir)
+	if content := readFile(t, filepath.Join(dir, "risk.md")); strings.Contains(content, "## Test Coverage") {
+		t.Errorf("unexpected ## Test Coverage without tested symbols;\ngot:\n%s", content)
+	}
+
+	dir = t.TempDir()
+	m := minimalModel()
+	m.Inventory.Packages = append(m.Inventory.Packages, model.PackageEntry{Name: "api"})
+	m.TestedSymbols = []model.TestedSymbol{
+		{Symbol: "store.Open", Tests: []string{"store.TestOpen"}},
+		{Symbol: "store.Store.Save", Tests: []string{"store.TestSave"}},
+	}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+	for _, want := range []string{"## Test Coverage", "| api | 0 |", "| store | 2 |"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+}
+
+// TestGenerateKnowledgeBundle_RiskReport_Unreferenced verifies risk.md lists
+// the model's unreferenced exported symbols.
+func TestGenerateKnowledgeBundle_RiskReport_Unreferenced(t *testing.T) {
+	dir := t.TempDir()
+	m := minimalModel()
+	m.Unreferenced = []model.UnreferencedSymbol{{Symbol: "store.LegacyOpen", Kind: model.UnreferencedFunc}}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+	for _, want := range []string{"## Unreferenced Exported Symbols", "| store.LegacyOpen | func |"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+}
+
+// TestGenerateKnowledgeBundle_RiskReport_CustomSignals verifies risk.md and
+// the dashboard list the packages that raised user-defined signals, and
+// omit the section when none did.
+func TestGenerateKnowledgeBundle_RiskReport_CustomSignals(t *testing.T) {
+	m := minimalModel()
+	if report := buildRiskReport(m); strings.Contains(report, "## Custom Signals") {
+		t.Errorf("custom signals section without custom signals:\n%s", report)
+	}
+
+	dir := t.TempDir()
+	m.Inventory.Packages = []model.PackageEntry{
+		{Name: "api"},
+		{Name: "billing", CustomSignals: []string{"pii", "stripe"}},
+	}
+	writeBundle(t, m, dir)
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+	for _, want := range []string{"## Custom Signals", "| billing | pii, stripe |"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "| api |") {
+		t.Errorf("package without custom signals listed;\ngot:\n%s", content)
+	}
+	page, err := BuildRiskDashboard(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "<tr><td>billing</td><td>pii, stripe</td></tr>") {
+		t.Errorf("dashboard missing custom signals;\ngot:\n%s", page)
+	}
+}
+
+// TestGenerateKnowledgeBundle_RiskReport_Resilience verifies risk.md marks
+// network-calling packages as resilient only when one of their files imports
+// a retry or circuit-breaker library.
+func TestGenerateKnowledgeBundle_RiskReport_Resilience(t *testing.T) {
+	dir := t.TempDir()
+	m := minimalModel()
+	m.Inventory.Packages = append(m.Inventory.Packages,
+		model.PackageEntry{Name: "api", Files: []string{"api/client.go", "api/retry.go"}},
+		model.PackageEntry{Name: "hook", Files: []string{"hook/send.go"}},
+	)
+	m.Boundaries.Network = &model.NetworkBoundary{
+		Outbound:  []model.SymbolRef{{File: "api/client.go"}, {File: "hook/send.go"}},
+		Resilient: []model.SymbolRef{{File: "api/retry.go"}},
+	}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+
+	if !strings.Contains(content, "## Network Resilience") {
+		t.Errorf("missing ## Network Resilience;\ngot:\n%s", content)
+	}
+	if !strings.Contains(content, "| api | yes |") {
+		t.Errorf("expected api marked resilient;\ngot:\n%s", content)
+	}
+	if !strings.Contains(content, "| hook | no |") {
+		t.Errorf("expected hook marked non-resilient;\ngot:\n%s", content)
+	}
+}
+
+// TestGenerateKnowledgeBundle_RiskReport_Calibration verifies that a
+// high-confidence domain with little supporting evidence is flagged as
+// overconfident while a well-evidenced domain is not.
+func TestGenerateKnowledgeBundle_RiskReport_Calibration(t *testing.T) {
+	dir := t.TempDir()
+	m := minimalModel()
+	m.StateDomains = []model.StateDomain{
+		{ID: "ghost", Aggregate: "Ghost", Confidence: 0.95},
+		{
+			ID:              "orders",
+			Aggregate:       "Order",
+			PrimaryMutators: []string{"Save"},
+			PrimaryReaders:  []string{"Load"},
+			Persistence:     &model.Persistence{Kind: "fs"},
+			EvidenceRefs:    []string{"bundle:a.go@v2", "bundle:b.go@v2", "bundle:c.go@v2", "bundle:d.go@v2"},
+			Confidence:      0.9,
+		},
+	}
+	m.Effects = []model.Effect{
+		{Kind: "fs_write", Domain: "orders", Via: "a.go"},
+		{Kind: "fs_read", Domain: "orders", Via: "b.go"},
+	}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+
+	if !strings.Contains(content, "## Confidence Calibration") {
+		t.Errorf("missing ## Confidence Calibration;\ngot:\n%s", content)
+	}
+	if !strings.Contains(content, "| [[domains/ghost|ghost]] | 0.95 | 0.00 | overconfident |") {
+		t.Errorf("expected ghost flagged overconfident;\ngot:\n%s", content)
+	}
+	if !strings.Contains(content, "| [[domains/orders|orders]] | 0.90 | 1.00 | - |") {
+		t.Errorf("expected orders unflagged;\ngot:\n%s", content)
+	}
+}
+
+// TestGenerateKnowledgeBundle_RiskReport_OwnershipConflicts verifies that a
+// package claimed by two domains is listed with links to both.
+func TestGenerateKnowledgeBundle_RiskReport_OwnershipConflicts(t *testing.T) {
+	dir := t.TempDir()
+	m := minimalModel()
+	m.StateDomains = []model.StateDomain{
+		{ID: "carts", Owners: []string{"shared"}, Confidence: 0.8},
+		{ID: "orders", Owners: []string{"shared"}, Confidence: 0.8},
+	}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "risk.md"))
+
+	if !strings.Contains(content, "| shared | [[domains/carts|carts]], [[domains/orders|orders]] |") {
+		t.Errorf("expected shared ownership conflict;\ngot:\n%s", content)
+	}
+}
+
+// TestBuildRiskDashboard verifies that the HTML dashboard carries the
+// in-degree table in deterministic order and escapes model-supplied names.
+func TestBuildRiskDashboard(t *testing.T) {
+	m := minimalModel()
+	m.Inventory.Packages = []model.PackageEntry{
+		{Name: "api", Imports: []string{"<script>alert(1)</script>", "store"}},
+		{Name: "worker", Imports: []string{"store"}},
+	}
+
+	page, err := BuildRiskDashboard(m)
+	if err != nil {
+		t.Fatalf("BuildRiskDashboard: %v", err)
+	}
+
+	if !strings.Contains(page, "<th>Package</th><th>Dependents</th>") {
+		t.Errorf("missing in-degree table;\ngot:\n%s", page)
+	}
+	store := strings.Index(page, "<tr><td>store</td><td class=\"num\">2</td></tr>")
+	evil := strings.Index(page, "<tr><td>&lt;script&gt;alert(1)&lt;/script&gt;</td><td class=\"num\">1</td></tr>")
+	if store < 0 || evil < 0 || store > evil {
+		t.Errorf("expected escaped rows sorted by dependents;\ngot:\n%s", page)
+	}
+	if strings.Contains(page, "<script>alert(1)") {
+		t.Error("package name was not escaped")
+	}
+	for _, want := range []string{`<h2 id="unreferenced">`, `<h2 id="shared-state">`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("missing section %s", want)
+		}
+	}
+	if strings.Contains(page, `<h2 id="coverage">`) {
+		t.Error("test coverage shown without test bundles")
+	}
+
+	m.Unreferenced = []model.UnreferencedSymbol{{Symbol: "store.LegacyOpen", Kind: model.UnreferencedFunc}}
+	m.SharedState = []model.SharedStateRisk{{Variable: "config.Current", Package: "config", Launchers: []string{"api.Serve", "worker.Run"}}}
+	m.TestedSymbols = []model.TestedSymbol{{Symbol: "store.Open"}}
+	page, err = BuildRiskDashboard(m)
+	if err != nil {
+		t.Fatalf("BuildRiskDashboard: %v", err)
+	}
+	for _, want := range []string{
+		"<tr><td>store.LegacyOpen</td><td>func</td></tr>",
+		"<tr><td>config.Current</td><td>api.Serve, worker.Run</td><td>-</td></tr>",
+		`<h2 id="coverage">`, "<tr><td>store</td><td class=\"num\">1</td></tr>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, page)
+		}
+	}
+
+	again, err := BuildRiskDashboard(m)
+	if err != nil || again != page {
+		t.Error("dashboard output is not deterministic")
+	}
+}
+
+// TestBuildRiskSARIF verifies that import cycles, in-degree, and writes from
+// concurrent files become SARIF results located at source files.
+func TestBuildRiskSARIF(t *testing.T) {
+	m := minimalModel()
+	m.Inventory.Packages = []model.PackageEntry{
+		{Name: "api", Files: []string{"api/handler.go"}, Imports: []string{"store"}},
+		{Name: "store", Files: []string{"store/db.go"}, Imports: []string{"api"}},
+	}
+	m.Effects = []model.Effect{
+		{Kind: "db_write", Via: "store/db.go", Domain: "users"},
+		{Kind: "fs_write", Via: "api/handler.go", Domain: "users"},
+	}
+	m.ConcurrencyDomains = []model.ConcurrencyDomain{{ID: "store", Files: []string{"store/db.go"}}}
+
+	out, err := RenderRiskReport(m, RiskReportSARIF)
+	if err != nil {
+		t.Fatalf("RenderRiskReport: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %q, %d runs", log.Version, len(log.Runs))
+	}
+	var got []string
+	for _, r := range log.Runs[0].Results {
+		got = append(got, r.RuleID+" "+r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	want := []string{
+		"iguana/import-cycle api/handler.go",
+		"iguana/high-in-degree api/handler.go",
+		"iguana/high-in-degree store/db.go",
+		"iguana/concurrent-write-domain store/db.go",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestBuildDOT verifies the DOT graph: owner packages clustered with their
+// domain, import edges, effect edges to domains or kind nodes, and quoting.
+func TestBuildDOT(t *testing.T) {
+	m := multiDomainModel()
+	m.Inventory.Packages = append(m.Inventory.Packages, model.PackageEntry{Name: `we"ird`})
+	m.Effects = append(m.Effects, model.Effect{Kind: "net_call", Via: "api/handler.go"})
+
+	out, err := RenderModel(m, ModelFormatDOT)
+	if err != nil {
+		t.Fatalf("RenderModel: %v", err)
+	}
+	for _, want := range []string{
+		"digraph system_model {\n",
+		`subgraph "cluster_user_state" {`,
+		`"domain:user_state" [label="user_state", shape=cylinder];`,
+		`"api" -> "store";`,
+		`"effect:net_call" [label="net_call", shape=ellipse];`,
+		`-> "domain:user_state" [label="db_write", style=dashed];`,
+		`"we\"ird";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q;\ngot:\n%s", want, out)
+		}
+	}
+	if again := BuildDOT(m); again != out {
+		t.Error("DOT output is not deterministic")
+	}
+	if _, err := RenderModel(m, "svg"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// TestGenerateKnowledgeBundle_Canvas verifies architecture.canvas: writer
+// packages left of their domain, readers right, boundaries below, and
+// labeled edges between them.
+func TestGenerateKnowledgeBundle_Canvas(t *testing.T) {
+	bundle, err := GenerateKnowledgeBundle(minimalModel())
+	if err != nil {
+		t.Fatalf("GenerateKnowledgeBundle: %v", err)
+	}
+	raw, ok := bundle.pages["architecture.canvas"]
+	if !ok {
+		t.Fatal("architecture.canvas not generated")
+	}
+	var c canvas
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatalf("canvas is not JSON: %v", err)
+	}
+	nodes := make(map[string]canvasNode)
+	for _, n := range c.Nodes {
+		nodes[n.ID] = n
+	}
+	domain, writer, reader := nodes["domain-evidence_store"], nodes["package-store"], nodes["package-main"]
+	if domain.Type != "file" || domain.File != "domains/evidence_store.md" {
+		t.Errorf("domain node = %+v, want file node for domains/evidence_store.md", domain)
+	}
+	if writer.Text != "store" || writer.X >= domain.X {
+		t.Errorf("writer node = %+v, want left of domain", writer)
+	}
+	if reader.Text != "main" || reader.X <= domain.X {
+		t.Errorf("reader node = %+v, want right of domain", reader)
+	}
+	fs, network := nodes["boundary-persistence-fs"], nodes["boundary-network"]
+	if fs.Text != "persistence: fs" || fs.Y <= domain.Y || network.Text != "network: outbound" {
+		t.Errorf("boundary nodes = %+v, %+v, want a row below the domains", fs, network)
+	}
+
+	edges := make(map[string]string)
+	for _, e := range c.Edges {
+		edges[e.FromNode+" -> "+e.ToNode] = e.Label
+	}
+	want := map[string]string{
+		"package-store -> domain-evidence_store":   "fs_write",
+		"domain-evidence_store -> package-main":    "fs_read",
+		"package-store -> boundary-persistence-fs": "",
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+
+	again, _ := GenerateKnowledgeBundle(minimalModel())
+	if again.pages["architecture.canvas"] != raw {
+		t.Error("canvas output is not deterministic")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Open questions
 // ---------------------------------------------------------------------------
@@ -647,3 +1096,237 @@ func TestGenerateKnowledgeBundle_DomainPage_CorrespondenceWithINV53(t *testing.T
 		}
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Transition graph
+// ---------------------------------------------------------------------------
+
+// TestGenerateKnowledgeBundle_TransitionGraph verifies graphs/transitions.md
+// renders one Mermaid edge per domain transition.
+func TestGenerateKnowledgeBundle_TransitionGraph(t *testing.T) {
+	dir := t.TempDir()
+	m := multiDomainModel()
+	m.Transitions = []model.Transition{
+		{From: "user_state", To: "job_queue"},
+		{From: "job_queue", To: "user_state"},
+	}
+	writeBundle(t, m, dir)
+
+	content := readFile(t, filepath.Join(dir, "graphs", "transitions.md"))
+	if !strings.Contains(content, "```mermaid") {
+		t.Errorf("transitions.md missing mermaid block:\n%s", content)
+	}
+	for _, edge := range []string{"job_queue --> user_state", "user_state --> job_queue"} {
+		if !strings.Contains(content, edge) {
+			t.Errorf("transitions.md missing edge %q:\n%s", edge, content)
+		}
+	}
+	// Edges are sorted for determinism (INV-44).
+	if strings.Index(content, "job_queue -->") > strings.Index(content, "user_state -->") {
+		t.Errorf("edges not sorted:\n%s", content)
+	}
+}
+
+// TestGenerateKnowledgeBundle_TransitionGraph_Empty verifies the placeholder
+// shown when the model has no transitions.
+func TestGenerateKnowledgeBundle_TransitionGraph_Empty(t *testing.T) {
+	dir := t.TempDir()
+	writeBundle(t, minimalModel(), dir)
+
+	content := readFile(t, filepath.Join(dir, "graphs", "transitions.md"))
+	if !strings.Contains(content, "_No transitions._") {
+		t.Errorf("expected placeholder in empty transition graph:\n%s", content)
+	}
+	if strings.Contains(content, "```mermaid") {
+		t.Errorf("empty transition graph should not contain a mermaid block:\n%s", content)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// INV-57: stale note removal
+// ---------------------------------------------------------------------------
+
+// TestWriteKnowledgeBundle_RemovesStaleNotes verifies INV-57: regenerating
+// after a domain disappears from the model removes its note, while notes that
+// iguana did not generate are left alone.
+func TestWriteKnowledgeBundle_RemovesStaleNotes(t *testing.T) {
+	dir := t.TempDir()
+	writeBundle(t, multiDomainModel(), dir)
+
+	userNote := filepath.Join(dir, "domains", "my-notes.md")
+	if err := os.WriteFile(userNote, []byte("# My notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := multiDomainModel()
+	m.StateDomains = m.StateDomains[1:] // drop job_queue
+	writeBundle(t, m, dir)
+
+	if _, err := os.Stat(filepath.Join(dir, "domains", "job_queue.md")); !os.IsNotExist(err) {
+		t.Errorf("stale domains/job_queue.md should have been removed (err=%v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "domains", "user_state.md")); err != nil {
+		t.Errorf("domains/user_state.md should still exist: %v", err)
+	}
+	if _, err := os.Stat(userNote); err != nil {
+		t.Errorf("unmanaged note should be untouched: %v", err)
+	}
+}
+
+// TestDiffKnowledgeBundle verifies that changing one domain in the model
+// yields a diff for that domain's page only, that nothing is written, and
+// that an up-to-date vault has no diff.
+func TestDiffKnowledgeBundle(t *testing.T) {
+	dir := t.TempDir()
+	writeBundle(t, minimalModel(), dir)
+
+	unchanged, err := GenerateKnowledgeBundle(minimalModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, err := DiffKnowledgeBundle(unchanged, dir); err != nil || d != "" {
+		t.Fatalf("up-to-date vault: diff = %q, err = %v; want none", d, err)
+	}
+
+	m := minimalModel()
+	m.StateDomains[0].PrimaryReaders = []string{"FindBundle"}
+	changed, err := GenerateKnowledgeBundle(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(filepath.Join(dir, "domains", "evidence_store.md"))
+	d, err := DiffKnowledgeBundle(changed, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- a/domains/evidence_store.md\n+++ b/domains/evidence_store.md\n@@ ",
+		"\n-- LoadBundle\n",
+		"\n+- FindBundle\n",
+	} {
+		if !strings.Contains(d, want) {
+			t.Errorf("diff missing %q:\n%s", want, d)
+		}
+	}
+	if strings.Count(d, "+++ ") != 1 {
+		t.Errorf("expected a diff for one page only:\n%s", d)
+	}
+	after, _ := os.ReadFile(filepath.Join(dir, "domains", "evidence_store.md"))
+	if string(before) != string(after) {
+		t.Error("DiffKnowledgeBundle modified the vault")
+	}
+}
+
+// TestUnifiedDiff verifies hunk ranges for a change, an addition at the end,
+// and a new file.
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	cases := []struct {
+		name, oldName, a, b, want string
+	}{
+		{"change", "a/p", old, "a\nb\nc\nd\nE\nf\ng\nh\n",
+			"--- a/p\n+++ b/p\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n"},
+		{"append", "a/p", "x\n", "x\ny\n",
+			"--- a/p\n+++ b/p\n@@ -1 +1,2 @@\n x\n+y\n"},
+		{"new file", "/dev/null", "", "x\n",
+			"--- /dev/null\n+++ b/p\n@@ -0,0 +1 @@\n+x\n"},
+		{"equal", "a/p", old, old, ""},
+	}
+	for _, c := range cases {
+		if got := unifiedDiff(c.oldName, "b/p", c.a, c.b); got != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, got, c.want)
+		}
+	}
+}
+
+// TestDiffLines verifies that the edit script rebuilds both sides and has
+// the minimal number of edits, checked against an LCS table on every pair
+// of short sequences over a two-letter alphabet, and that a large page
+// with one change diffs to a single edit pair.
+func TestDiffLines(t *testing.T) {
+	var seqs [][]string
+	for n := 0; n <= 6; n++ {
+		for bits := 0; bits < 1<<n; bits++ {
+			seq := make([]string, n)
+			for i := range seq {
+				seq[i] = string(rune('a' + bits>>i&1))
+			}
+			seqs = append(seqs, seq)
+		}
+	}
+	for _, a := range seqs {
+		for _, b := range seqs {
+			var gotA, gotB []string
+			edits := 0
+			for _, l := range diffLines(a, b) {
+				if l.op != '+' {
+					gotA = append(gotA, l.text)
+				}
+				if l.op != '-' {
+					gotB = append(gotB, l.text)
+				}
+				if l.op != ' ' {
+					edits++
+				}
+			}
+			if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+				t.Fatalf("diffLines(%v, %v) rebuilds %v, %v", a, b, gotA, gotB)
+			}
+			if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+				t.Fatalf("diffLines(%v, %v) has %d edits, want %d", a, b, edits, want)
+			}
+		}
+	}
+
+	big := make([]string, 20000)
+	for i := range big {
+		big[i] = fmt.Sprint("line ", i)
+	}
+	changed := slices.Clone(big)
+	changed[10000] = "changed"
+	edits := 0
+	for _, l := range diffLines(big, changed) {
+		if l.op != ' ' {
+			edits++
+		}
+	}
+	if edits != 2 {
+		t.Errorf("large page: %d edits, want 2", edits)
+	}
+}
+
+// lcsLen returns the length of the longest common subsequence of a and b.
+func lcsLen(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// TestIsManagedNote verifies frontmatter tag detection for INV-57.
+func TestIsManagedNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"domain note", frontmatter([]string{"state-domain", "confidence-high"}) + "# x\n", true},
+		{"index page", frontmatter([]string{"iguana/index"}) + "# x\n", true},
+		{"no frontmatter", "# x\n", false},
+		{"foreign tags", frontmatter([]string{"personal"}) + "# x\n", false},
+		{"unterminated", "---\ntags:\n  - state-domain\n", false},
+	}
+	for _, tc := range tests {
+		if got := isManagedNote(tc.content); got != tc.want {
+			t.Errorf("%s: isManagedNote = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
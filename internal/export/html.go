@@ -0,0 +1,239 @@
+package export
+
+// html.go — standalone HTML risk dashboard.
+//
+// The dashboard carries the same sections as risk.md (plus open questions)
+// in a single self-contained page for readers without Obsidian. Tables are
+// sortable by clicking a header; the page renders fine with JS disabled.
+// Rows are emitted in the same deterministic order as the Markdown report.
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"iguana/internal/model"
+)
+
+// Risk report formats accepted by RenderRiskReport.
+const (
+	RiskReportHTML     = "html"
+	RiskReportMarkdown = "markdown"
+	RiskReportSARIF    = "sarif"
+)
+
+// RenderRiskReport renders the risk report for sys in format: the risk.md
+// vault page (RiskReportMarkdown), the standalone dashboard
+// (RiskReportHTML, see BuildRiskDashboard), or a SARIF log of its findings
+// (RiskReportSARIF, see BuildRiskSARIF).
+func RenderRiskReport(sys *model.SystemModel, format string) (string, error) {
+	switch format {
+	case RiskReportHTML:
+		return BuildRiskDashboard(sys)
+	case RiskReportMarkdown:
+		return buildRiskReport(sys), nil
+	case RiskReportSARIF:
+		return BuildRiskSARIF(sys)
+	default:
+		return "", fmt.Errorf("unknown report format %q (want %s, %s, or %s)", format, RiskReportHTML, RiskReportMarkdown, RiskReportSARIF)
+	}
+}
+
+// riskDashboard is the template data for BuildRiskDashboard.
+type riskDashboard struct {
+	BundleSetSHA256 string
+	InDegree        []inDegreeRow
+	WriteDomains    []writeDomainRow
+	Resilience      []dashboardResilience
+	Calibration     []dashboardCalibration
+	Conflicts       []model.OwnershipConflict
+	Coverage        []dashboardCoverage
+	CustomSignals   []model.PackageEntry
+	Unreferenced    []model.UnreferencedSymbol
+	SharedState     []model.SharedStateRisk
+	Cycles          []string
+	Questions       []model.OpenQuestion
+}
+
+type dashboardCoverage struct {
+	Package string
+	Tested  int
+}
+
+type dashboardResilience struct {
+	Package   string
+	Resilient bool
+}
+
+type dashboardCalibration struct {
+	Domain     string
+	Confidence string
+	Strength   string
+	Flag       string
+}
+
+// BuildRiskDashboard renders the risk report for sys as a standalone HTML
+// page. All model-derived text is escaped by html/template.
+func BuildRiskDashboard(sys *model.SystemModel) (string, error) {
+	data := riskDashboard{
+		BundleSetSHA256: sys.Inputs.BundleSetSHA256,
+		InDegree:        topInDegree(sys, 10),
+		WriteDomains:    writeDomains(sys),
+		Conflicts:       model.OwnershipConflicts(sys.StateDomains),
+		CustomSignals:   customSignals(sys),
+		Unreferenced:    sys.Unreferenced,
+		SharedState:     sys.SharedState,
+		Cycles:          findCycles(sys.Inventory.Packages),
+		Questions:       sys.OpenQuestions,
+	}
+	// Test coverage is only meaningful when test bundles were analyzed.
+	if len(sys.TestedSymbols) > 0 {
+		for _, r := range testCoverage(sys) {
+			data.Coverage = append(data.Coverage, dashboardCoverage{Package: r.pkg, Tested: r.tested})
+		}
+	}
+	for _, r := range networkResilience(sys) {
+		data.Resilience = append(data.Resilience, dashboardResilience{Package: r.pkg, Resilient: r.resilient})
+	}
+	for _, r := range confidenceCalibration(sys) {
+		data.Calibration = append(data.Calibration, dashboardCalibration{
+			Domain:     r.id,
+			Confidence: fmt.Sprintf("%.2f", r.confidence),
+			Strength:   fmt.Sprintf("%.2f", r.strength),
+			Flag:       r.flag,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := riskDashboardTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render risk dashboard: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var riskDashboardTemplate = template.Must(template.New("risk").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Risk Report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #ccc; padding: 0.3rem 0.6rem; text-align: left; }
+th { background: #f3f3f3; cursor: pointer; user-select: none; }
+td.num { text-align: right; }
+.empty { color: #777; font-style: italic; }
+.flag { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Risk Report</h1>
+<p>Bundle set <code>{{.BundleSetSHA256}}</code></p>
+
+<h2 id="in-degree">Top Packages by In-Degree</h2>
+{{if .InDegree}}<table class="sortable">
+<thead><tr><th>Package</th><th>Dependents</th></tr></thead>
+<tbody>
+{{range .InDegree}}<tr><td>{{.Name}}</td><td class="num">{{.Count}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">No internal imports.</p>{{end}}
+
+<h2 id="write-domains">Domains with Write Effects</h2>
+{{if .WriteDomains}}<table class="sortable">
+<thead><tr><th>Domain</th><th>Writers</th></tr></thead>
+<tbody>
+{{range .WriteDomains}}<tr><td>{{.Domain}}</td><td>{{range $i, $w := .Writers}}{{if $i}}, {{end}}{{$w}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">None found.</p>{{end}}
+
+<h2 id="resilience">Network Resilience</h2>
+{{if .Resilience}}<table class="sortable">
+<thead><tr><th>Package</th><th>Retry / Circuit Breaker</th></tr></thead>
+<tbody>
+{{range .Resilience}}<tr><td>{{.Package}}</td><td>{{if .Resilient}}yes{{else}}<span class="flag">no</span>{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">No network-calling packages.</p>{{end}}
+
+<h2 id="calibration">Confidence Calibration</h2>
+{{if .Calibration}}<table class="sortable">
+<thead><tr><th>Domain</th><th>LLM Confidence</th><th>Evidence Strength</th><th>Flag</th></tr></thead>
+<tbody>
+{{range .Calibration}}<tr><td>{{.Domain}}</td><td class="num">{{.Confidence}}</td><td class="num">{{.Strength}}</td><td>{{if eq .Flag "-"}}-{{else}}<span class="flag">{{.Flag}}</span>{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">No state domains.</p>{{end}}
+
+<h2 id="ownership">Ownership Conflicts</h2>
+{{if .Conflicts}}<table class="sortable">
+<thead><tr><th>Package</th><th>Claimed By</th></tr></thead>
+<tbody>
+{{range .Conflicts}}<tr><td>{{.Package}}</td><td>{{range $i, $d := .Domains}}{{if $i}}, {{end}}{{$d}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">None found.</p>{{end}}
+
+{{if .Coverage}}<h2 id="coverage">Test Coverage</h2>
+<table class="sortable">
+<thead><tr><th>Package</th><th>Tested Symbols</th></tr></thead>
+<tbody>
+{{range .Coverage}}<tr><td>{{.Package}}</td><td class="num">{{.Tested}}</td></tr>
+{{end}}</tbody>
+</table>
+
+{{end}}{{if .CustomSignals}}<h2 id="custom-signals">Custom Signals</h2>
+<table class="sortable">
+<thead><tr><th>Package</th><th>Signals</th></tr></thead>
+<tbody>
+{{range .CustomSignals}}<tr><td>{{.Name}}</td><td>{{range $i, $s := .CustomSignals}}{{if $i}}, {{end}}{{$s}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+
+{{end}}<h2 id="unreferenced">Unreferenced Exported Symbols</h2>
+{{if .Unreferenced}}<table class="sortable">
+<thead><tr><th>Symbol</th><th>Kind</th></tr></thead>
+<tbody>
+{{range .Unreferenced}}<tr><td>{{.Symbol}}</td><td>{{.Kind}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">None found.</p>{{end}}
+
+<h2 id="shared-state">Shared Mutable State</h2>
+{{if .SharedState}}<table class="sortable">
+<thead><tr><th>Variable</th><th>Goroutine Launchers</th><th>Domain</th></tr></thead>
+<tbody>
+{{range .SharedState}}<tr><td>{{.Variable}}</td><td>{{range $i, $l := .Launchers}}{{if $i}}, {{end}}{{$l}}{{end}}</td><td>{{if .Domain}}{{.Domain}}{{else}}-{{end}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">None found.</p>{{end}}
+
+<h2 id="cycles">Import Cycles</h2>
+{{if .Cycles}}<ul>
+{{range .Cycles}}<li>{{.}}</li>
+{{end}}</ul>{{else}}<p class="empty">None found.</p>{{end}}
+
+<h2 id="questions">Open Questions</h2>
+{{if .Questions}}<table class="sortable">
+<thead><tr><th>Question</th><th>Domain</th></tr></thead>
+<tbody>
+{{range .Questions}}<tr><td>{{.Question}}</td><td>{{.RelatedDomain}}</td></tr>
+{{end}}</tbody>
+</table>{{else}}<p class="empty">None.</p>{{end}}
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var col = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var asc = th.dataset.order !== "asc";
+    th.parentNode.querySelectorAll("th").forEach(function (h) { delete h.dataset.order; });
+    th.dataset.order = asc ? "asc" : "desc";
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[col].textContent, y = b.cells[col].textContent;
+      var nx = parseFloat(x), ny = parseFloat(y);
+      var c = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+      return asc ? c : -c;
+    });
+    rows.forEach(function (r) { body.appendChild(r); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,172 @@
+package export
+
+// sarif.go — SARIF 2.1.0 export of risk findings.
+//
+// The findings are the actionable rows of the risk report: import cycles,
+// the most depended-on packages, and state domains written from files with
+// concurrency. Each result points at a source file (the first inventory
+// file of a package) so GitHub Code Scanning and other SARIF consumers can
+// place it. Results are emitted in the report's deterministic order.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"iguana/internal/model"
+)
+
+// SARIF rule IDs.
+const (
+	RuleImportCycle           = "iguana/import-cycle"
+	RuleHighInDegree          = "iguana/high-in-degree"
+	RuleConcurrentWriteDomain = "iguana/concurrent-write-domain"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+var sarifRules = []sarifRule{
+	{RuleImportCycle, sarifMessage{"Packages import each other in a cycle."}, sarifConfiguration{"warning"}},
+	{RuleHighInDegree, sarifMessage{"Package is among the most imported; changes ripple widely."}, sarifConfiguration{"note"}},
+	{RuleConcurrentWriteDomain, sarifMessage{"State domain is written from files that use concurrency."}, sarifConfiguration{"warning"}},
+}
+
+// BuildRiskSARIF renders the risk findings for sys as a SARIF 2.1.0 log.
+func BuildRiskSARIF(sys *model.SystemModel) (string, error) {
+	files := make(map[string]string) // package → first inventory file
+	for _, p := range sys.Inventory.Packages {
+		if len(p.Files) > 0 && files[p.Name] == "" {
+			files[p.Name] = p.Files[0]
+		}
+	}
+	locate := func(paths ...string) []sarifLocation {
+		var locs []sarifLocation
+		for _, p := range paths {
+			if p != "" {
+				locs = append(locs, sarifLocation{sarifPhysicalLocation{sarifArtifactLocation{URI: p}}})
+			}
+		}
+		return locs
+	}
+
+	results := []sarifResult{}
+	for _, cycle := range findCycles(sys.Inventory.Packages) {
+		first, _, _ := strings.Cut(cycle, " → ")
+		results = append(results, sarifResult{
+			RuleID:    RuleImportCycle,
+			Level:     "warning",
+			Message:   sarifMessage{"Import cycle: " + cycle},
+			Locations: locate(files[first]),
+		})
+	}
+	for _, r := range topInDegree(sys, 10) {
+		results = append(results, sarifResult{
+			RuleID:    RuleHighInDegree,
+			Level:     "note",
+			Message:   sarifMessage{fmt.Sprintf("Package %s is imported by %d packages.", r.Name, r.Count)},
+			Locations: locate(files[r.Name]),
+		})
+	}
+	for _, r := range concurrentWriteDomains(sys) {
+		results = append(results, sarifResult{
+			RuleID:    RuleConcurrentWriteDomain,
+			Level:     "warning",
+			Message:   sarifMessage{fmt.Sprintf("State domain %s is written from concurrent code in %s.", r.Domain, strings.Join(r.Writers, ", "))},
+			Locations: locate(r.Writers...),
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{sarifDriver{Name: "iguana", Rules: sarifRules}},
+			Results: results,
+		}},
+	}
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("render SARIF: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+// concurrentWriteDomains narrows writeDomains to the writer files that are
+// also in a concurrency domain, dropping domains with none. Writers are
+// deduplicated and sorted.
+func concurrentWriteDomains(sys *model.SystemModel) []writeDomainRow {
+	concurrent := make(map[string]bool)
+	for _, cd := range sys.ConcurrencyDomains {
+		for _, f := range cd.Files {
+			concurrent[f] = true
+		}
+	}
+	var rows []writeDomainRow
+	for _, r := range writeDomains(sys) {
+		seen := make(map[string]bool)
+		var writers []string
+		for _, w := range r.Writers {
+			if concurrent[w] && !seen[w] {
+				seen[w] = true
+				writers = append(writers, w)
+			}
+		}
+		if len(writers) > 0 {
+			sort.Strings(writers)
+			rows = append(rows, writeDomainRow{Domain: r.Domain, Writers: writers})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,82 @@
+package llm
+
+// llm.go — LLM provider selection for BAML function calls.
+//
+// baml_client calls the client named in the .baml sources. Call instead
+// runs a BAML function against the providers configured in the "llm"
+// section of .iguana/config.yaml (settings.LLMConfig): each provider is
+// registered as the primary client of a BAML client registry, and the
+// providers are tried in order, each under its own timeout, until one
+// succeeds.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	baml "github.com/boundaryml/baml/engine/language_client_go/pkg"
+
+	b "iguana/baml_client"
+	"iguana/internal/settings"
+)
+
+// Call runs call once per provider of chain, in order, and returns the
+// first successful result. Each attempt gets the provider's timeout and a
+// client registry selecting it. An empty chain runs call once with the
+// compiled-in client. When every provider fails, the errors are joined.
+func Call[T any](ctx context.Context, chain []settings.LLMProvider, call func(ctx context.Context, opts ...b.CallOptionFunc) (T, error)) (T, error) {
+	if len(chain) == 0 {
+		return call(ctx)
+	}
+	var zero T
+	timeouts := make([]time.Duration, len(chain))
+	for i, p := range chain {
+		var err error
+		if timeouts[i], err = p.TimeoutDuration(); err != nil {
+			return zero, err // a config error, not a failed call: no fallback
+		}
+	}
+	var errs []error
+	for i, p := range chain {
+		res, err := attempt(ctx, timeouts[i], func(ctx context.Context) (T, error) {
+			return call(ctx, b.WithClientRegistry(Registry(p)))
+		})
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, fmt.Errorf("llm provider %s: %w", p.Name, err))
+		if ctx.Err() != nil {
+			break // the caller gave up; later providers would fail too
+		}
+	}
+	return zero, errors.Join(errs...)
+}
+
+// attempt runs fn under timeout (none when zero).
+func attempt[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+// Registry returns a BAML client registry whose primary client is p. The
+// API key is read from p.APIKeyEnv at call time so it never appears in
+// config files.
+func Registry(p settings.LLMProvider) *baml.ClientRegistry {
+	options := map[string]interface{}{"model": p.Model}
+	if p.BaseURL != "" {
+		options["base_url"] = p.BaseURL
+	}
+	if p.APIKeyEnv != "" {
+		options["api_key"] = os.Getenv(p.APIKeyEnv)
+	}
+	cr := baml.NewClientRegistry()
+	cr.AddLlmClient(p.Name, p.Provider, options)
+	cr.SetPrimaryClient(p.Name)
+	return cr
+}
@@ -0,0 +1,133 @@
+package model
+
+// aggregate.go — single-file corpus of evidence bundles.
+//
+// Some tools prefer one document over a companion bundle per source file.
+// An aggregate holds the same bundles loadEvidenceBundles would return,
+// sorted by path, with their bundle-set hash (INV-31) so readers can tell
+// whether it is stale. GenerateSystemModel accepts an aggregate file, or
+// an SQLite evidence store (internal/store), in place of a directory.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"iguana/internal/canonical"
+	"iguana/internal/evidence"
+	"iguana/internal/store"
+)
+
+// AggregateVersion is the envelope version written by WriteAggregate.
+const AggregateVersion = 1
+
+// Aggregate is the envelope of a single-file bundle corpus.
+type Aggregate struct {
+	Version         int                        `yaml:"version"`
+	BundleSetSHA256 string                     `yaml:"bundle_set_sha256"`
+	Bundles         []*evidence.EvidenceBundle `yaml:"bundles"`
+}
+
+// BuildAggregate collects the evidence bundles under root into an Aggregate.
+func BuildAggregate(root string) (*Aggregate, error) {
+	bundles, err := loadEvidenceBundles(root)
+	if err != nil {
+		return nil, fmt.Errorf("load bundles: %w", err)
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no evidence bundles found in %s (run iguana on the directory first)", root)
+	}
+	return &Aggregate{
+		Version:         AggregateVersion,
+		BundleSetSHA256: computeBundleSetHash(bundles),
+		Bundles:         bundles,
+	}, nil
+}
+
+// WriteAggregate marshals agg as canonical YAML and writes it to path.
+func WriteAggregate(agg *Aggregate, path string) error {
+	data, err := canonical.MarshalYAML(agg)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadAggregate reads an aggregate file, rejecting unknown versions and
+// envelopes whose hash does not match their bundles. Each embedded bundle
+// goes through evidence.Decode, so older bundle versions are migrated and
+// unsupported ones rejected as for companion bundles.
+func ReadAggregate(path string) (*Aggregate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var env struct {
+		Version         int         `yaml:"version"`
+		BundleSetSHA256 string      `yaml:"bundle_set_sha256"`
+		Bundles         []yaml.Node `yaml:"bundles"`
+	}
+	if err := yaml.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	if env.Version != AggregateVersion {
+		return nil, fmt.Errorf("%s: unsupported aggregate version %d", path, env.Version)
+	}
+	agg := Aggregate{Version: env.Version, BundleSetSHA256: env.BundleSetSHA256}
+	for i := range env.Bundles {
+		raw, err := yaml.Marshal(&env.Bundles[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: bundle %d: %w", path, i, err)
+		}
+		b, err := evidence.Decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bundle %d: %w", path, i, err)
+		}
+		agg.Bundles = append(agg.Bundles, b)
+	}
+	if got := computeBundleSetHash(agg.Bundles); got != agg.BundleSetSHA256 {
+		return nil, fmt.Errorf("%s: bundle set hash mismatch (file was edited after aggregation)", path)
+	}
+	return &agg, nil
+}
+
+// LoadBundles returns the bundles system-model reads from root: the
+// companion bundles under a directory, or the contents of an aggregate
+// file or evidence store.
+func LoadBundles(root string) ([]*evidence.EvidenceBundle, error) {
+	bundles, _, err := loadBundles(root)
+	return bundles, err
+}
+
+// loadBundles returns the bundles for root — the contents of an evidence
+// store or aggregate file when root is a file, the companion bundles under
+// it otherwise — and the directory whose settings and go.mod apply to them.
+func loadBundles(root string) (bundles []*evidence.EvidenceBundle, dir string, err error) {
+	info, err := os.Stat(root)
+	if err != nil || info.IsDir() {
+		bundles, err = loadEvidenceBundles(root)
+		return bundles, root, err
+	}
+	if store.IsStore(root) {
+		st, err := store.Open(root)
+		if err != nil {
+			return nil, "", err
+		}
+		defer st.Close()
+		bundles, err = st.Bundles()
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", root, err)
+		}
+		return bundles, filepath.Dir(root), nil
+	}
+	agg, err := ReadAggregate(root)
+	if err != nil {
+		return nil, "", err
+	}
+	return agg.Bundles, filepath.Dir(root), nil
+}
@@ -0,0 +1,99 @@
+package model
+
+// cache.go — LLM inference cache.
+//
+// InferSystemModel is slow and billed, yet its input only changes when the
+// evidence does. GenerateSystemModel with GenerateOptions.CacheDir stores
+// each inference result as <cache dir>/<bundle set hash>.json and reuses it
+// while the bundle set hash matches. The entry also records a hash of the
+// package summaries sent to the LLM, so a run whose summaries differ (e.g.
+// --merge-summaries or --summary-fields) misses instead of reusing an
+// inference made from another prompt, and a hash of the provider chain
+// (name, provider, model, base URL of each), so switching providers or
+// models misses instead of reusing another model's answer.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	b "iguana/baml_client"
+	"iguana/baml_client/types"
+	"iguana/internal/llm"
+	"iguana/internal/settings"
+)
+
+// DefaultCacheDir returns ~/.iguana/cache, or "" when the home directory is
+// unknown.
+func DefaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".iguana", "cache")
+}
+
+// cachedInference is the JSON document stored per bundle set.
+type cachedInference struct {
+	BundleSetSHA256 string                     `json:"bundle_set_sha256"`
+	SummariesSHA256 string                     `json:"summaries_sha256"`
+	ChainSHA256     string                     `json:"chain_sha256"`
+	Inference       types.SystemModelInference `json:"inference"`
+}
+
+// summariesHash hashes the JSON encoding of the summaries sent to the LLM.
+func summariesHash(summaries []types.PackageSummary) string {
+	data, _ := json.Marshal(summaries) // plain data; cannot fail
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainHash hashes the identity of each provider in chain, in order. An
+// empty chain (the compiled-in client) has its own hash.
+func chainHash(chain []settings.LLMProvider) string {
+	h := sha256.New()
+	for _, p := range chain {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\n", p.Name, p.Provider, p.Model, p.BaseURL)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedInferSystemModel calls inferSystemModel, through the providers of
+// opts.LLM, behind the cache in opts.CacheDir. An empty CacheDir disables
+// the cache; RefreshCache skips the lookup but still stores the new
+// result. The cache is best effort: unreadable entries are misses and
+// failed writes are ignored.
+func cachedInferSystemModel(ctx context.Context, opts GenerateOptions, bundleSetHash string, summaries []types.PackageSummary) (types.SystemModelInference, error) {
+	infer := func(ctx context.Context) (types.SystemModelInference, error) {
+		return llm.Call(ctx, opts.LLM, func(ctx context.Context, callOpts ...b.CallOptionFunc) (types.SystemModelInference, error) {
+			return inferSystemModel(ctx, summaries, callOpts...)
+		})
+	}
+	dir := opts.CacheDir
+	if dir == "" {
+		return infer(ctx)
+	}
+	path := filepath.Join(dir, bundleSetHash+".json")
+	key, chain := summariesHash(summaries), chainHash(opts.LLM)
+	if !opts.RefreshCache {
+		if data, err := os.ReadFile(path); err == nil {
+			var entry cachedInference
+			if json.Unmarshal(data, &entry) == nil && entry.BundleSetSHA256 == bundleSetHash && entry.SummariesSHA256 == key && entry.ChainSHA256 == chain {
+				return entry.Inference, nil
+			}
+		}
+	}
+	inference, err := infer(ctx)
+	if err != nil {
+		return inference, err
+	}
+	data, err := json.MarshalIndent(cachedInference{bundleSetHash, key, chain, inference}, "", "  ")
+	if err == nil && os.MkdirAll(dir, 0o755) == nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+	return inference, nil
+}
@@ -0,0 +1,172 @@
+package model
+
+// calls.go — Call-path queries over the stitched call graph.
+//
+// The per-file calls of every bundle are joined into one graph: calls
+// between the module's own functions are the resolved edges of
+// buildTransitions, and calls that leave the module ("os.WriteFile",
+// "sql.DB.Exec") are kept as edges to their qualified target. "iguana
+// calls" then answers "how does main.main reach os.WriteFile?" with the
+// shortest call paths, or lists everything a symbol can reach.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"iguana/internal/evidence"
+)
+
+// DefaultCallPathLimit is the number of paths CallPaths returns when its
+// limit is zero.
+const DefaultCallPathLimit = 20
+
+// CallPaths returns the shortest call paths from the symbol from to the
+// symbol or call target to in the bundles under root (a directory or an
+// aggregate file). Each path lists the symbols from from to to. At most
+// limit paths are returned (zero means DefaultCallPathLimit), in
+// lexicographic order. No path is not an error; an unknown from is.
+func CallPaths(root, from, to string, limit int) ([][]string, error) {
+	graph, err := loadCallGraph(root, from)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = DefaultCallPathLimit
+	}
+	dist := graph.distances(from)
+	if _, ok := dist[to]; !ok {
+		return nil, nil
+	}
+
+	// Walk forward along edges that stay on some shortest path: v is one
+	// step further from from, and to is reachable from v in the remaining
+	// steps.
+	toDist := graph.reverse().distances(to)
+	var paths [][]string
+	var walk func(path []string)
+	walk = func(path []string) {
+		if len(paths) >= limit {
+			return
+		}
+		u := path[len(path)-1]
+		if u == to {
+			paths = append(paths, append([]string(nil), path...))
+			return
+		}
+		for _, v := range graph[u] {
+			if d, ok := toDist[v]; ok && dist[v] == dist[u]+1 && dist[v]+d == dist[to] {
+				walk(append(path, v))
+			}
+		}
+	}
+	walk([]string{from})
+	return paths, nil
+}
+
+// Reachable returns every symbol and call target reachable from the symbol
+// from in the bundles under root, sorted.
+func Reachable(root, from string) ([]string, error) {
+	graph, err := loadCallGraph(root, from)
+	if err != nil {
+		return nil, err
+	}
+	var reached []string
+	for s := range graph.distances(from) {
+		if s != from {
+			reached = append(reached, s)
+		}
+	}
+	sort.Strings(reached)
+	return reached, nil
+}
+
+// callGraph maps each caller to its sorted, distinct callees.
+type callGraph map[string][]string
+
+// loadCallGraph builds the call graph of the bundles under root and checks
+// that from calls something in it.
+func loadCallGraph(root, from string) (callGraph, error) {
+	bundles, _, err := loadBundles(root)
+	if err != nil {
+		return nil, err
+	}
+	graph := buildCallIndex(bundles)
+	if _, ok := graph[from]; !ok {
+		return nil, fmt.Errorf("%s makes no calls in %s (symbols are <pkg>.<func> or <pkg>.<type>.<method>)", from, root)
+	}
+	return graph, nil
+}
+
+// buildCallIndex stitches the bundles' calls into a callGraph: the
+// module-internal edges of buildTransitions plus, for each caller, its
+// package-qualified calls to functions the bundles do not define.
+func buildCallIndex(bundles []*evidence.EvidenceBundle) callGraph {
+	edges := make(map[string]map[string]bool)
+	add := func(from, to string) {
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+	for _, t := range buildTransitions(bundles) {
+		add(t.From, t.To)
+	}
+
+	defined := make(map[string]bool)
+	for _, bnd := range bundles {
+		for _, fn := range bnd.Symbols.Functions {
+			defined[bnd.Package.Name+"."+fn.Name] = true
+		}
+	}
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		for _, c := range bnd.Calls {
+			from, _, ok := callerSymbol(bnd.Package.Name, c.From)
+			if !ok || !strings.Contains(c.To, ".") || defined[c.To] {
+				continue
+			}
+			add(from, c.To)
+		}
+	}
+
+	graph := make(callGraph, len(edges))
+	for from, set := range edges {
+		graph[from] = setKeys(set)
+	}
+	return graph
+}
+
+// distances returns the number of calls from start to every symbol it
+// reaches, start included at zero.
+func (g callGraph) distances(start string) map[string]int {
+	dist := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range g[u] {
+			if _, seen := dist[v]; !seen {
+				dist[v] = dist[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+	return dist
+}
+
+// reverse returns g with every edge flipped.
+func (g callGraph) reverse() callGraph {
+	r := make(callGraph)
+	for from, tos := range g {
+		for _, to := range tos {
+			r[to] = append(r[to], from)
+		}
+	}
+	for to := range r {
+		sort.Strings(r[to])
+	}
+	return r
+}
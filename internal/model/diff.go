@@ -0,0 +1,145 @@
+package model
+
+// diff.go — Structured delta between two system models.
+//
+// DiffSystemModels answers "how did the architecture drift?" across two
+// generations: which state domains, effects, and open questions appeared
+// or went away, and how trust zone membership moved. Provenance
+// (generated_at, bundle hash) and evidence refs are not compared.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"iguana/internal/evidence"
+)
+
+// ModelDiff is the change from one system model to another. Sections list
+// keys: state domains and trust zones by ID, effects as "<kind> <via>",
+// open questions by their text.
+type ModelDiff struct {
+	StateDomains  evidence.SetDiff `yaml:"state_domains,omitempty" json:"state_domains,omitzero"`
+	Effects       evidence.SetDiff `yaml:"effects,omitempty" json:"effects,omitzero"`
+	TrustZones    evidence.SetDiff `yaml:"trust_zones,omitempty" json:"trust_zones,omitzero"`
+	ZoneMembers   []ZoneMembers    `yaml:"zone_members,omitempty" json:"zone_members,omitempty"`
+	OpenQuestions evidence.SetDiff `yaml:"open_questions,omitempty" json:"open_questions,omitzero"`
+}
+
+// ZoneMembers is the package membership change of a trust zone present in
+// both models.
+type ZoneMembers struct {
+	ID      string   `yaml:"id" json:"id"`
+	Added   []string `yaml:"added,omitempty" json:"added,omitempty"`
+	Removed []string `yaml:"removed,omitempty" json:"removed,omitempty"`
+}
+
+// IsZero reports whether the models did not differ.
+func (d *ModelDiff) IsZero() bool {
+	return d.StateDomains.IsZero() && d.Effects.IsZero() && d.TrustZones.IsZero() &&
+		len(d.ZoneMembers) == 0 && d.OpenQuestions.IsZero()
+}
+
+// DiffSystemModels compares old with new. A state domain changes when any
+// of its inferred fields other than evidence refs and confidence differ;
+// an effect changes when its linked domain does.
+func DiffSystemModels(old, new *SystemModel) *ModelDiff {
+	var d ModelDiff
+
+	domainKey := func(sd StateDomain) string { return sd.ID }
+	domainValue := func(sd StateDomain) any {
+		sd.EvidenceRefs, sd.Confidence = nil, 0
+		if sd.Persistence != nil {
+			p := *sd.Persistence
+			p.EvidenceRefs = nil
+			sd.Persistence = &p
+		}
+		return sd
+	}
+	d.StateDomains = diffSections(old.StateDomains, new.StateDomains, domainKey, domainValue)
+
+	effectKey := func(e Effect) string { return e.Kind + " " + e.Via }
+	d.Effects = diffSections(old.Effects, new.Effects, effectKey, func(e Effect) any { return e.Domain })
+
+	zoneKey := func(z TrustZone) string { return z.ID }
+	d.TrustZones = diffSections(old.TrustZones, new.TrustZones, zoneKey, func(TrustZone) any { return nil })
+	before := make(map[string][]string, len(old.TrustZones))
+	for _, z := range old.TrustZones {
+		before[z.ID] = z.Packages
+	}
+	for _, z := range new.TrustZones {
+		prev, ok := before[z.ID]
+		if !ok {
+			continue
+		}
+		m := ZoneMembers{ID: z.ID, Added: missingFrom(z.Packages, prev), Removed: missingFrom(prev, z.Packages)}
+		if len(m.Added) > 0 || len(m.Removed) > 0 {
+			d.ZoneMembers = append(d.ZoneMembers, m)
+		}
+	}
+	sort.Slice(d.ZoneMembers, func(i, j int) bool { return d.ZoneMembers[i].ID < d.ZoneMembers[j].ID })
+
+	questionKey := func(q OpenQuestion) string { return q.Question }
+	d.OpenQuestions = diffSections(old.OpenQuestions, new.OpenQuestions, questionKey, func(OpenQuestion) any { return nil })
+
+	return &d
+}
+
+// DiffSystemModelFiles reads two system model files and diffs them.
+func DiffSystemModelFiles(oldPath, newPath string) (*ModelDiff, error) {
+	old, err := ReadSystemModel(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("old model: %w", err)
+	}
+	new, err := ReadSystemModel(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("new model: %w", err)
+	}
+	return DiffSystemModels(old, new), nil
+}
+
+// diffSections compares two sections by key: keys only in new are added,
+// only in old removed, and in both with unequal values changed. Each list
+// is sorted.
+func diffSections[T any](old, new []T, key func(T) string, value func(T) any) evidence.SetDiff {
+	before := make(map[string]any, len(old))
+	for _, v := range old {
+		before[key(v)] = value(v)
+	}
+	var d evidence.SetDiff
+	seen := make(map[string]bool, len(new))
+	for _, v := range new {
+		k := key(v)
+		seen[k] = true
+		prev, ok := before[k]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, k)
+		case !reflect.DeepEqual(prev, value(v)):
+			d.Changed = append(d.Changed, k)
+		}
+	}
+	for k := range before {
+		if !seen[k] {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// missingFrom returns the sorted values of a that are not in b.
+func missingFrom(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	addAll(in, b)
+	var out []string
+	for _, v := range a {
+		if !in[v] {
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -16,9 +16,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	gotypes "go/types"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -27,8 +31,6 @@ import (
 	"iguana/baml_client/types"
 	"iguana/internal/evidence"
 	"iguana/internal/settings"
-
-	"gopkg.in/yaml.v3"
 )
 
 // ---------------------------------------------------------------------------
@@ -52,6 +54,8 @@ func evidenceRef(path string, version int, fragment string) string {
 
 // loadEvidenceBundles walks root for *.evidence.yaml files, unmarshals each,
 // and returns them sorted by File.Path (INV-31 requires deterministic hash).
+// Per-file and package bundles (see evidence.MergePackageBundle) may be
+// mixed; a directory with a package bundle contributes only that bundle.
 func loadEvidenceBundles(root string) ([]*evidence.EvidenceBundle, error) {
 	settings, err := settings.LoadSettings(root)
 	if err != nil {
@@ -72,7 +76,7 @@ func loadEvidenceBundles(root string) ([]*evidence.EvidenceBundle, error) {
 			// Skip directories denied by settings (INV-39).
 			if path != root {
 				rel, _ := filepath.Rel(root, path)
-				if settings.IsDenied(filepath.ToSlash(rel)) {
+				if settings.IsDirDenied(filepath.ToSlash(rel)) {
 					return filepath.SkipDir
 				}
 			}
@@ -81,32 +85,52 @@ func loadEvidenceBundles(root string) ([]*evidence.EvidenceBundle, error) {
 		if !strings.HasSuffix(d.Name(), ".evidence.yaml") {
 			return nil
 		}
-		// Skip test evidence bundles (INV-24: test files are not analyzed).
-		if strings.HasSuffix(d.Name(), "_test.go.evidence.yaml") {
+		// Skip test evidence bundles (INV-24: test files are not analyzed);
+		// test bundles are loaded by loadTestBundles.
+		if strings.HasSuffix(d.Name(), "_test.go.evidence.yaml") || evidence.IsTestBundle(d.Name()) {
 			return nil
 		}
-		// Skip evidence bundles whose source file is denied by settings (INV-39).
+		// Skip evidence bundles whose source file is denied by settings
+		// (INV-39), or generated when settings exclude generated files.
 		// Bundle File.Path is relative with forward slashes (INV-23).
 		rel, _ := filepath.Rel(root, path)
 		rel = filepath.ToSlash(rel)
-		if settings.IsDenied(rel) {
+		src := strings.TrimSuffix(rel, ".evidence.yaml")
+		if settings.IsDenied(rel) || settings.IsDenied(src) {
+			return nil
+		}
+		if settings.SkipsGenerated() && strings.HasSuffix(src, ".go") && evidence.IsGenerated(strings.TrimSuffix(path, ".evidence.yaml")) {
 			return nil
 		}
 		data, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("read %s: %w", path, err)
 		}
-		var bundle evidence.EvidenceBundle
-		if err := yaml.Unmarshal(data, &bundle); err != nil {
-			return fmt.Errorf("unmarshal %s: %w", path, err)
+		bundle, err := evidence.Decode(data)
+		if err != nil {
+			return fmt.Errorf("decode %s: %w", path, err)
 		}
-		bundles = append(bundles, &bundle)
+		bundles = append(bundles, bundle)
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("walk %s: %w", root, err)
 	}
 
+	// A package bundle replaces the per-file Go bundles of its directory;
+	// any left over from an earlier per-file run are stale duplicates.
+	pkgDirs := make(map[string]bool)
+	for _, b := range bundles {
+		if b.IsPackageBundle() {
+			pkgDirs[path.Dir(b.File.Path)] = true
+		}
+	}
+	if len(pkgDirs) > 0 {
+		bundles = slices.DeleteFunc(bundles, func(b *evidence.EvidenceBundle) bool {
+			return !b.IsPackageBundle() && b.Lang() == evidence.LanguageGo && pkgDirs[path.Dir(b.File.Path)]
+		})
+	}
+
 	// Sort by File.Path for determinism (INV-31).
 	sort.Slice(bundles, func(i, j int) bool {
 		return bundles[i].File.Path < bundles[j].File.Path
@@ -114,6 +138,44 @@ func loadEvidenceBundles(root string) ([]*evidence.EvidenceBundle, error) {
 	return bundles, nil
 }
 
+// loadTestBundles walks root like loadEvidenceBundles for test bundles
+// (written by analyze --include-tests) and returns them sorted by File.Path.
+func loadTestBundles(root string) ([]*evidence.TestBundle, error) {
+	settings, err := settings.LoadSettings(root)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	var tests []*evidence.TestBundle
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || name == "examples" || name == "docs" || strings.HasPrefix(name, ".") || settings.IsDirDenied(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !evidence.IsTestBundle(d.Name()) || settings.IsDenied(strings.TrimSuffix(rel, evidence.TestBundleSuffix)) {
+			return nil
+		}
+		tb, err := evidence.ReadTestBundle(path)
+		if err != nil {
+			return err
+		}
+		tests = append(tests, tb)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].File.Path < tests[j].File.Path })
+	return tests, nil
+}
+
 // ---------------------------------------------------------------------------
 // Bundle set hash
 // ---------------------------------------------------------------------------
@@ -131,34 +193,129 @@ func computeBundleSetHash(bundles []*evidence.EvidenceBundle) string {
 	return hex.EncodeToString(sum[:])
 }
 
-// ---------------------------------------------------------------------------
-// hasSymbol checks if a bundle contains a symbol with the given name.
-// ---------------------------------------------------------------------------
+// unitNamer returns the function naming the inventory unit a bundle of
+// bundles belongs to: the package name for Go bundles, "<language>:<package>"
+// for others so that same-named units in different languages stay separate.
+// When the Go bundles span more than one module, a Go unit is named by its
+// root-relative directory instead (e.g. "svc/internal/config"), so the
+// same package path in two modules stays two units.
+func unitNamer(bundles []*evidence.EvidenceBundle) func(*evidence.EvidenceBundle) string {
+	multiModule := len(goModules(bundles)) > 1
+	return func(bnd *evidence.EvidenceBundle) string {
+		if lang := bnd.Lang(); lang != evidence.LanguageGo {
+			return lang + ":" + bnd.Package.Name
+		}
+		if multiModule {
+			return path.Dir(bnd.File.Path)
+		}
+		return bnd.Package.Name
+	}
+}
 
-func hasSymbol(bundle *evidence.EvidenceBundle, name string) bool {
-	for _, fn := range bundle.Symbols.Functions {
-		if fn.Name == name {
-			return true
+// goModules returns the set of modules the Go bundles belong to.
+func goModules(bundles []*evidence.EvidenceBundle) map[string]bool {
+	modules := make(map[string]bool)
+	for _, bnd := range bundles {
+		if mod := bnd.Package.Module; mod != "" && bnd.Lang() == evidence.LanguageGo {
+			modules[mod] = true
 		}
 	}
-	return false
+	return modules
+}
+
+// importUnit returns the unit named by the import path imp in a
+// multi-module root, given each Go unit's module: a unit of the longest
+// module that prefixes imp whose directory ends with the rest of imp (the
+// shortest such directory, for the module's root package when imp is the
+// module path itself), or "" if imp names no analyzed package.
+func importUnit(imp string, unitModules map[string]string) string {
+	var mod string
+	for _, m := range unitModules {
+		if (imp == m || strings.HasPrefix(imp, m+"/")) && len(m) > len(mod) {
+			mod = m
+		}
+	}
+	if mod == "" {
+		return ""
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(imp, mod), "/")
+	best := ""
+	for unit, m := range unitModules {
+		if m != mod || (rest != "" && unit != rest && !strings.HasSuffix(unit, "/"+rest)) {
+			continue
+		}
+		if best == "" || len(unit) < len(best) || len(unit) == len(best) && unit < best {
+			best = unit
+		}
+	}
+	return best
 }
 
 // ---------------------------------------------------------------------------
 // Deterministic builders
 // ---------------------------------------------------------------------------
 
-// buildInventory groups bundles by package name, assembles PackageEntry slices,
-// and identifies entrypoints (package main + main function).
-func buildInventory(bundles []*evidence.EvidenceBundle) Inventory {
-	// Group bundles by package name.
+// buildInventory groups bundles by unit (see unitNamer), assembles
+// PackageEntry slices, and identifies entrypoints (see buildEntrypoints).
+// When the bundles span several Go modules (a go.work or nested go.mod
+// layout), the module paths are listed and each package records its own.
+func buildInventory(bundles []*evidence.EvidenceBundle, entrypointFuncs []string) Inventory {
+	unitName := unitNamer(bundles)
+	// Group bundles by unit name.
 	pkgFiles := make(map[string][]string)
 	pkgRefs := make(map[string][]string)
+	pkgExported := make(map[string]map[string]bool)
+	pkgGenerate := make(map[string]map[string]bool)
+	pkgSentinels := make(map[string]map[string]bool)
+	pkgCustom := make(map[string]map[string]bool)
+	pkgLang := make(map[string]string)
+	pkgModules := make(map[string]map[string]bool)
+	modules := make(map[string]bool)
 
 	for _, bnd := range bundles {
-		pkg := bnd.Package.Name
-		pkgFiles[pkg] = append(pkgFiles[pkg], bnd.File.Path)
+		pkg := unitName(bnd)
+		if lang := bnd.Lang(); lang != evidence.LanguageGo {
+			pkgLang[pkg] = lang
+		}
+		if mod := bnd.Package.Module; mod != "" {
+			if pkgModules[pkg] == nil {
+				pkgModules[pkg] = make(map[string]bool)
+			}
+			pkgModules[pkg][mod] = true
+			modules[mod] = true
+		}
+		if bnd.IsPackageBundle() {
+			for _, f := range bnd.Files {
+				pkgFiles[pkg] = append(pkgFiles[pkg], f.Path)
+			}
+		} else {
+			pkgFiles[pkg] = append(pkgFiles[pkg], bnd.File.Path)
+		}
 		pkgRefs[pkg] = append(pkgRefs[pkg], evidenceRef(bnd.File.Path, bnd.Version, ""))
+		if pkgExported[pkg] == nil {
+			pkgExported[pkg] = make(map[string]bool)
+		}
+		for _, key := range exportedSymbolKeys(bnd.Symbols) {
+			pkgExported[pkg][key] = true
+		}
+		for _, cmd := range bnd.Package.GoGenerate {
+			if pkgGenerate[pkg] == nil {
+				pkgGenerate[pkg] = make(map[string]bool)
+			}
+			pkgGenerate[pkg][cmd] = true
+		}
+		if len(bnd.Symbols.ErrorSentinels) > 0 {
+			if pkgSentinels[pkg] == nil {
+				pkgSentinels[pkg] = make(map[string]bool)
+			}
+			addAll(pkgSentinels[pkg], bnd.Symbols.ErrorSentinels)
+		}
+		if len(bnd.Signals.Custom) > 0 {
+			if pkgCustom[pkg] == nil {
+				pkgCustom[pkg] = make(map[string]bool)
+			}
+			addAll(pkgCustom[pkg], bnd.Signals.Custom)
+		}
 	}
 
 	// Sort package names (INV-28).
@@ -176,13 +333,31 @@ func buildInventory(bundles []*evidence.EvidenceBundle) Inventory {
 
 	// Collect internal imports per package: for each import path, the last
 	// path segment is matched against known package names. This identifies
-	// intra-codebase dependencies (e.g. "iguana/store" → "store").
+	// intra-codebase dependencies (e.g. "iguana/store" → "store"). With
+	// several modules, units are directories and an import path is resolved
+	// within its module instead (see importUnit). Import paths only have
+	// this shape in Go, so other languages are skipped.
+	var unitModules map[string]string
+	if len(modules) > 1 {
+		unitModules = make(map[string]string)
+		for _, bnd := range bundles {
+			if bnd.Lang() == evidence.LanguageGo && bnd.Package.Module != "" {
+				unitModules[unitName(bnd)] = bnd.Package.Module
+			}
+		}
+	}
 	pkgImports := make(map[string]map[string]bool)
 	for _, bnd := range bundles {
-		name := bnd.Package.Name
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		name := unitName(bnd)
 		for _, imp := range bnd.Package.Imports {
 			parts := strings.Split(imp.Path, "/")
 			dep := parts[len(parts)-1]
+			if unitModules != nil {
+				dep = importUnit(imp.Path, unitModules)
+			}
 			if pkgNameSet[dep] && dep != name {
 				if pkgImports[name] == nil {
 					pkgImports[name] = make(map[string]bool)
@@ -193,7 +368,6 @@ func buildInventory(bundles []*evidence.EvidenceBundle) Inventory {
 	}
 
 	var entries []PackageEntry
-	var entrypoints []Entrypoint
 
 	for _, name := range pkgNames {
 		files := pkgFiles[name]
@@ -207,42 +381,144 @@ func buildInventory(bundles []*evidence.EvidenceBundle) Inventory {
 		}
 		sort.Strings(imports)
 
+		var generate []string
+		for cmd := range pkgGenerate[name] {
+			generate = append(generate, cmd)
+		}
+		sort.Strings(generate)
+
+		var sentinels, custom []string
+		if pkgSentinels[name] != nil {
+			sentinels = setKeys(pkgSentinels[name])
+		}
+		if pkgCustom[name] != nil {
+			custom = setKeys(pkgCustom[name])
+		}
+
+		// Module membership is only recorded for multi-module roots.
+		var mods []string
+		if len(modules) > 1 && pkgModules[name] != nil {
+			mods = setKeys(pkgModules[name])
+		}
+
 		entries = append(entries, PackageEntry{
 			Name:         name,
 			Files:        files,
 			Imports:      imports,
 			EvidenceRefs: refs,
+
+			ExportedSymbolCount: len(pkgExported[name]),
+			ErrorSentinels:      sentinels,
+			GoGenerate:          generate,
+			CustomSignals:       custom,
+			Language:            pkgLang[name],
+			Modules:             mods,
 		})
+	}
 
-		// Entrypoints: package main with a main function.
-		if name == "main" {
-			for _, bnd := range bundles {
-				if bnd.Package.Name == "main" && hasSymbol(bnd, "main") {
-					entrypoints = append(entrypoints, Entrypoint{
-						Package: bnd.Package.Name,
-						Symbol:  "main",
-						EvidenceRefs: []string{
-							evidenceRef(bnd.File.Path, bnd.Version, "symbol:main"),
-						},
-					})
-				}
+	inv := Inventory{
+		Packages:    entries,
+		Entrypoints: buildEntrypoints(bundles, entrypointFuncs),
+	}
+	if len(modules) > 1 {
+		inv.Modules = setKeys(modules)
+	}
+	return inv
+}
+
+// buildEntrypoints finds the top-level functions that start a program: main
+// in a Go package main, TestMain, and any function named in extra. Each
+// main package is its own entrypoint, keyed by directory. Sorted by
+// directory, then package, then symbol (INV-28).
+func buildEntrypoints(bundles []*evidence.EvidenceBundle, extra []string) []Entrypoint {
+	unitName := unitNamer(bundles)
+	var entrypoints []Entrypoint
+	for _, bnd := range bundles {
+		isGo := bnd.Lang() == evidence.LanguageGo
+		for _, fn := range bnd.Symbols.Functions {
+			if fn.Receiver != "" {
+				continue
+			}
+			switch {
+			case isGo && fn.Name == "main" && bnd.Package.Name == "main":
+			case isGo && fn.Name == "TestMain":
+			case slices.Contains(extra, fn.Name):
+			default:
+				continue
 			}
+			entrypoints = append(entrypoints, Entrypoint{
+				Package: unitName(bnd),
+				Symbol:  fn.Name,
+				Dir:     path.Dir(bnd.File.Path),
+				EvidenceRefs: []string{
+					evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+fn.Name),
+				},
+			})
 		}
 	}
+	sort.SliceStable(entrypoints, func(i, j int) bool {
+		a, b := entrypoints[i], entrypoints[j]
+		if a.Dir != b.Dir {
+			return a.Dir < b.Dir
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Symbol < b.Symbol
+	})
+	return entrypoints
+}
 
-	return Inventory{
-		Packages:    entries,
-		Entrypoints: entrypoints,
+// exportedSymbolKeys returns one key per exported function, method, type,
+// variable, and constant in a bundle. Keys are qualified by kind (and
+// receiver type for methods) so buildInventory can count each symbol of a
+// package once, even when several files declare it — e.g. per-platform
+// variants selected by build tags.
+func exportedSymbolKeys(syms evidence.Symbols) []string {
+	var keys []string
+	for _, fn := range syms.Functions {
+		if fn.Exported {
+			keys = append(keys, "func:"+fn.ReceiverType()+"."+fn.Name)
+		}
+	}
+	for _, td := range syms.Types {
+		if td.Exported {
+			keys = append(keys, "type:"+td.Name)
+		}
+	}
+	for _, v := range syms.Variables {
+		if v.Exported {
+			keys = append(keys, "var:"+v.Name)
+		}
 	}
+	for _, c := range syms.Constants {
+		if c.Exported {
+			keys = append(keys, "const:"+c.Name)
+		}
+	}
+	return keys
 }
 
-// buildBoundaries derives persistence and network boundaries from signals.
+// buildBoundaries derives persistence and network boundaries from signals,
+// HTTP routes and endpoints, gRPC API boundaries from the services in
+// .proto bundles, and the configuration surface from the bundles' config
+// inputs.
 func buildBoundaries(bundles []*evidence.EvidenceBundle) Boundaries {
 	var dbWriters []SymbolRef
 	var fsWriters []SymbolRef
 	var outbound []SymbolRef
+	var resilient []SymbolRef
+	var subprocessRefs []string
+	var apis []APIBoundary
 
 	for _, bnd := range bundles {
+		if bnd.Lang() == evidence.LanguageProto {
+			apis = append(apis, protoServices(bnd)...)
+		}
+		if bnd.Signals.ExecsSubprocess {
+			subprocessRefs = append(subprocessRefs,
+				evidenceRef(bnd.File.Path, bnd.Version, "signal:execs_subprocess"))
+		}
 		if bnd.Signals.DBCalls {
 			dbWriters = append(dbWriters, SymbolRef{
 				File: bnd.File.Path,
@@ -267,14 +543,29 @@ func buildBoundaries(bundles []*evidence.EvidenceBundle) Boundaries {
 				},
 			})
 		}
+		if bnd.Signals.Resilience {
+			resilient = append(resilient, SymbolRef{
+				File: bnd.File.Path,
+				EvidenceRefs: []string{
+					evidenceRef(bnd.File.Path, bnd.Version, "signal:resilience"),
+				},
+			})
+		}
 	}
 
 	var bnd Boundaries
 
+	if len(subprocessRefs) > 0 {
+		bnd.Process = append(bnd.Process, ProcessBoundary{
+			Kind:         "subprocess",
+			EvidenceRefs: subprocessRefs,
+		})
+	}
 	if len(dbWriters) > 0 {
 		bnd.Persistence = append(bnd.Persistence, PersistenceBoundary{
 			Kind:    "db",
 			Writers: dbWriters,
+			Tables:  buildTableUses(bundles),
 		})
 	}
 	if len(fsWriters) > 0 {
@@ -283,16 +574,205 @@ func buildBoundaries(bundles []*evidence.EvidenceBundle) Boundaries {
 			Writers: fsWriters,
 		})
 	}
-	if len(outbound) > 0 {
-		bnd.Network = &NetworkBoundary{Outbound: outbound}
+	routes, endpoints := buildHTTPBoundaries(bundles)
+	if len(outbound) > 0 || len(routes) > 0 || len(endpoints) > 0 {
+		bnd.Network = &NetworkBoundary{Outbound: outbound, Resilient: resilient, Routes: routes, Endpoints: endpoints}
 	}
+	sort.Slice(apis, func(i, j int) bool { return apis[i].Service < apis[j].Service })
+	bnd.API = apis
+	bnd.Config = buildConfigBoundaries(bundles)
 
 	return bnd
 }
 
-// buildEffects produces one Effect per signal kind per file.
-// Effects are sorted by kind then from_file (INV-28).
-func buildEffects(bundles []*evidence.EvidenceBundle) []Effect {
+// buildHTTPBoundaries collects the bundles' HTTP routes and endpoints, one
+// entry per (method, path or URL, package) citing each registering or
+// requesting function. Routes are sorted by (path, method, package) and
+// endpoints by (url, method, package).
+func buildHTTPBoundaries(bundles []*evidence.EvidenceBundle) ([]HTTPRoute, []HTTPEndpoint) {
+	unitName := unitNamer(bundles)
+	type routeKey struct{ method, path, pkg string }
+	type endpointKey struct{ method, url, host, pkg string }
+	routeRefs := make(map[routeKey]map[string]bool)
+	endpointRefs := make(map[endpointKey]map[string]bool)
+	cite := func(refs map[string]bool, bnd *evidence.EvidenceBundle, from string) map[string]bool {
+		if refs == nil {
+			refs = make(map[string]bool)
+		}
+		fragment := "signal:net_calls"
+		if _, name, ok := callerSymbol(bnd.Package.Name, from); ok {
+			fragment = "symbol:" + name
+		}
+		refs[evidenceRef(bnd.File.Path, bnd.Version, fragment)] = true
+		return refs
+	}
+	for _, bnd := range bundles {
+		pkg := unitName(bnd)
+		for _, r := range bnd.Routes {
+			k := routeKey{r.Method, r.Path, pkg}
+			routeRefs[k] = cite(routeRefs[k], bnd, r.From)
+		}
+		for _, e := range bnd.Endpoints {
+			k := endpointKey{e.Method, e.URL, e.Host, pkg}
+			endpointRefs[k] = cite(endpointRefs[k], bnd, e.From)
+		}
+	}
+
+	var routes []HTTPRoute
+	for k, refs := range routeRefs {
+		routes = append(routes, HTTPRoute{Method: k.method, Path: k.path, Package: k.pkg, EvidenceRefs: setKeys(refs)})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Package < b.Package
+	})
+	var endpoints []HTTPEndpoint
+	for k, refs := range endpointRefs {
+		endpoints = append(endpoints, HTTPEndpoint{Method: k.method, URL: k.url, Host: k.host, Package: k.pkg, EvidenceRefs: setKeys(refs)})
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Package < b.Package
+	})
+	return routes, endpoints
+}
+
+// buildTableUses aggregates the tables written and read by the bundles'
+// SQL queries per package, citing each querying function. Sorted by
+// package.
+func buildTableUses(bundles []*evidence.EvidenceBundle) []TableUse {
+	unitName := unitNamer(bundles)
+	type entry struct{ writes, reads, refs map[string]bool }
+	entries := make(map[string]*entry)
+	for _, bnd := range bundles {
+		pkg := unitName(bnd)
+		for _, q := range bnd.Queries {
+			if len(q.Writes) == 0 && len(q.Reads) == 0 {
+				continue
+			}
+			e := entries[pkg]
+			if e == nil {
+				e = &entry{make(map[string]bool), make(map[string]bool), make(map[string]bool)}
+				entries[pkg] = e
+			}
+			for _, t := range q.Writes {
+				e.writes[t] = true
+			}
+			for _, t := range q.Reads {
+				e.reads[t] = true
+			}
+			fragment := "signal:db_calls"
+			if _, name, ok := callerSymbol(bnd.Package.Name, q.From); ok {
+				fragment = "symbol:" + name
+			}
+			e.refs[evidenceRef(bnd.File.Path, bnd.Version, fragment)] = true
+		}
+	}
+
+	var uses []TableUse
+	for pkg, e := range entries {
+		use := TableUse{Package: pkg, EvidenceRefs: setKeys(e.refs)}
+		if len(e.writes) > 0 {
+			use.Writes = setKeys(e.writes)
+		}
+		if len(e.reads) > 0 {
+			use.Reads = setKeys(e.reads)
+		}
+		uses = append(uses, use)
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].Package < uses[j].Package })
+	return uses
+}
+
+// buildConfigBoundaries merges the config inputs of every bundle into one
+// ConfigBoundary per (source, key), citing each reading function (or the
+// file, for package-level reads). Entries are sorted by (source, key).
+func buildConfigBoundaries(bundles []*evidence.EvidenceBundle) []ConfigBoundary {
+	type entry struct {
+		pkgs, refs map[string]bool
+	}
+	entries := make(map[[2]string]*entry)
+	for _, bnd := range bundles {
+		for _, in := range bnd.ConfigInputs {
+			k := [2]string{in.Source, in.Key}
+			e := entries[k]
+			if e == nil {
+				e = &entry{pkgs: make(map[string]bool), refs: make(map[string]bool)}
+				entries[k] = e
+			}
+			e.pkgs[bnd.Package.Name] = true
+			fragment := ""
+			if _, name, ok := callerSymbol(bnd.Package.Name, in.From); ok {
+				fragment = "symbol:" + name
+			}
+			e.refs[evidenceRef(bnd.File.Path, bnd.Version, fragment)] = true
+		}
+	}
+
+	var out []ConfigBoundary
+	for k, e := range entries {
+		out = append(out, ConfigBoundary{
+			Source:       k[0],
+			Key:          k[1],
+			Packages:     setKeys(e.pkgs),
+			EvidenceRefs: setKeys(e.refs),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Source != out[j].Source {
+			return out[i].Source < out[j].Source
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// protoServices returns one gRPC API boundary per service in a .proto
+// bundle, listing its RPC methods.
+func protoServices(bnd *evidence.EvidenceBundle) []APIBoundary {
+	var apis []APIBoundary
+	for _, td := range bnd.Symbols.Types {
+		if td.Kind != "service" {
+			continue
+		}
+		var methods []string
+		for _, fn := range bnd.Symbols.Functions {
+			if fn.Receiver == td.Name {
+				methods = append(methods, fn.Name)
+			}
+		}
+		apis = append(apis, APIBoundary{
+			Kind:         "grpc",
+			Service:      bnd.Package.Name + "." + td.Name,
+			Methods:      methods,
+			EvidenceRefs: []string{evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+td.Name)},
+		})
+	}
+	return apis
+}
+
+// Effect orderings accepted by buildEffects (GenerateOptions.EffectSort).
+const (
+	EffectSortByKind = "by-kind" // kind, then via (default)
+	EffectSortByFile = "by-file" // via, then kind
+)
+
+// buildEffects produces one Effect per signal kind per file, sorted per
+// order: by kind then via (EffectSortByKind or empty), or by via then kind
+// (EffectSortByFile). Either ordering is total (INV-28).
+func buildEffects(bundles []*evidence.EvidenceBundle, order string) []Effect {
 	var effects []Effect
 
 	for _, bnd := range bundles {
@@ -334,74 +814,671 @@ func buildEffects(bundles []*evidence.EvidenceBundle) []Effect {
 		}
 	}
 
-	// Sort by kind then via (INV-28).
+	// Sort by kind then via, or via then kind (INV-28).
 	sort.Slice(effects, func(i, j int) bool {
-		if effects[i].Kind != effects[j].Kind {
-			return effects[i].Kind < effects[j].Kind
+		a, b := effects[i], effects[j]
+		if order == EffectSortByFile {
+			if a.Via != b.Via {
+				return a.Via < b.Via
+			}
+			return a.Kind < b.Kind
 		}
-		return effects[i].Via < effects[j].Via
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Via < b.Via
 	})
 	return effects
 }
 
-// buildConcurrencyDomains collects one domain per file with concurrency signals.
-func buildConcurrencyDomains(bundles []*evidence.EvidenceBundle) []ConcurrencyDomain {
-	var domains []ConcurrencyDomain
+// buildCallGraph unions every bundle's calls into package-qualified edges:
+// callers get their package name as prefix, as do callees resolved within
+// the same package (no "." in the target). Calls to builtins such as len or
+// append are dropped. Edges are deduped and sorted by (from, to); when more
+// than limit remain (see GenerateOptions.CallGraphLimit) the tail is cut and
+// truncated is true.
+func buildCallGraph(bundles []*evidence.EvidenceBundle, limit int) (edges []CallEdge, truncated bool) {
+	seen := make(map[CallEdge]bool)
+	for _, bnd := range bundles {
+		pkg := bnd.Package.Name
+		for _, c := range bnd.Calls {
+			to := c.To
+			if !strings.Contains(to, ".") {
+				if _, builtin := gotypes.Universe.Lookup(to).(*gotypes.Builtin); builtin {
+					continue
+				}
+				to = pkg + "." + to
+			}
+			e := CallEdge{From: pkg + "." + c.From, To: to}
+			if !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	if limit == 0 {
+		limit = DefaultCallGraphLimit
+	}
+	if limit > 0 && len(edges) > limit {
+		return edges[:limit], true
+	}
+	return edges, false
+}
 
+// buildTransitions resolves every bundle's calls against the functions and
+// methods the bundles define, giving the module's own symbol-to-symbol call
+// edges so effects can be traced from entrypoints. Symbols are named
+// "<pkg>.<func>" or "<pkg>.<receiver type>.<method>". A same-package target
+// resolves within the caller's directory, a package-qualified one within
+// every package of that name. Targets carry no receiver type, so a method
+// call links to each same-named method in the package. Closures count as
+// their enclosing function; package-level initializers are skipped. Each
+// edge cites the caller's and callee's bundles; edges are sorted by (from,
+// to) (INV-28).
+func buildTransitions(bundles []*evidence.EvidenceBundle) []Transition {
+	type def struct {
+		symbol, dir, ref string
+	}
+	defs := make(map[string][]def) // "<pkg>.<name>" → definitions
 	for _, bnd := range bundles {
-		if !bnd.Signals.Concurrency {
+		if bnd.Lang() != evidence.LanguageGo {
 			continue
 		}
-		id := bnd.File.Path
-		domains = append(domains, ConcurrencyDomain{
-			ID:    id,
-			Files: []string{bnd.File.Path},
-			EvidenceRefs: []string{
-				evidenceRef(bnd.File.Path, bnd.Version, "signal:concurrency"),
-			},
-		})
+		pkg := bnd.Package.Name
+		for _, fn := range bnd.Symbols.Functions {
+			symbol := pkg + "." + fn.Name
+			if recv := fn.ReceiverType(); recv != "" {
+				symbol = pkg + "." + recv + "." + fn.Name
+			}
+			defs[pkg+"."+fn.Name] = append(defs[pkg+"."+fn.Name], def{
+				symbol: symbol,
+				dir:    path.Dir(bnd.File.Path),
+				ref:    evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+fn.Name),
+			})
+		}
 	}
 
-	// Sort by id (INV-28).
-	sort.Slice(domains, func(i, j int) bool {
-		return domains[i].ID < domains[j].ID
-	})
-	return domains
-}
+	refs := make(map[CallEdge]map[string]bool)
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		pkg, dir := bnd.Package.Name, path.Dir(bnd.File.Path)
+		for _, c := range bnd.Calls {
+			from, fromName, ok := callerSymbol(pkg, c.From)
+			if !ok {
+				continue
+			}
 
-// ---------------------------------------------------------------------------
-// Package summaries for LLM
-// ---------------------------------------------------------------------------
+			key, local := c.To, !strings.Contains(c.To, ".")
+			if local {
+				key = pkg + "." + c.To
+			}
+			for _, d := range defs[key] {
+				if local && d.dir != dir {
+					continue
+				}
+				e := CallEdge{From: from, To: d.symbol}
+				if refs[e] == nil {
+					refs[e] = make(map[string]bool)
+				}
+				refs[e][evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+fromName)] = true
+				refs[e][d.ref] = true
+			}
+		}
+	}
 
-// readModuleName reads the module name from go.mod in root.
-// Returns "" if go.mod is absent or unparseable.
-func readModuleName(root string) string {
-	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
-	if err != nil {
-		return ""
+	transitions := make([]Transition, 0, len(refs))
+	for e, set := range refs {
+		transitions = append(transitions, Transition{From: e.From, To: e.To, EvidenceRefs: setKeys(set)})
 	}
-	for _, line := range strings.SplitN(string(data), "\n", 10) {
-		if strings.HasPrefix(line, "module ") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "module "))
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].From != transitions[j].From {
+			return transitions[i].From < transitions[j].From
 		}
-	}
-	return ""
+		return transitions[i].To < transitions[j].To
+	})
+	return transitions
 }
 
-// formatStructDesc returns a compact description of a struct type for the LLM:
-// "TypeName{Field1:Type1, Field2:Type2}"
-func formatStructDesc(td evidence.TypeDecl) string {
-	if td.Kind != "struct" || len(td.Fields) == 0 {
-		return ""
+// callerSymbol returns the symbol ("<pkg>.<func>" or "<pkg>.<receiver
+// type>.<method>") and bare function name of a bundle call's From in
+// package pkg. Closures count as their enclosing function; ok is false for
+// package-level initializers.
+func callerSymbol(pkg, from string) (symbol, name string, ok bool) {
+	for strings.HasSuffix(from, ".<anonymous>") {
+		from = strings.TrimSuffix(from, ".<anonymous>")
 	}
-	var sb strings.Builder
-	sb.WriteString(td.Name)
-	sb.WriteString("{")
-	for i, f := range td.Fields {
-		if i > 0 {
-			sb.WriteString(", ")
-		}
-		sb.WriteString(f.Name)
+	if from == "<global>" {
+		return "", "", false
+	}
+	name = from
+	if i := strings.LastIndexByte(from, '.'); i >= 0 {
+		name = from[i+1:]
+		recv := evidence.Function{Receiver: from[:i]}.ReceiverType()
+		from = recv + "." + name
+	}
+	return pkg + "." + from, name, true
+}
+
+// buildImplementations collects the interface-satisfaction graph from the
+// implements lists of Go bundles' types. Types are named "<pkg>.<type>";
+// interfaces declared in the type's own package are qualified the same way.
+// Each entry cites the type's bundle; entries are sorted by (type,
+// interface) (INV-28).
+func buildImplementations(bundles []*evidence.EvidenceBundle) []Implementation {
+	var impls []Implementation
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		pkg := bnd.Package.Name
+		for _, td := range bnd.Symbols.Types {
+			for _, iface := range td.Implements {
+				if !strings.Contains(iface, ".") {
+					iface = pkg + "." + iface
+				}
+				impls = append(impls, Implementation{
+					Type:         pkg + "." + td.Name,
+					Interface:    iface,
+					EvidenceRefs: []string{evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+td.Name)},
+				})
+			}
+		}
+	}
+	sort.Slice(impls, func(i, j int) bool {
+		if impls[i].Type != impls[j].Type {
+			return impls[i].Type < impls[j].Type
+		}
+		return impls[i].Interface < impls[j].Interface
+	})
+	return impls
+}
+
+// buildTestedSymbols resolves the call targets each test exercises against
+// the functions and methods the bundles define, naming symbols as
+// buildTransitions does. A test package "x_test" tests package x. Bare
+// targets resolve within the test's directory; "p.Name" resolves within
+// every package p when p is an imported package name, and otherwise
+// (a method call on a variable, "s.Save") as a same-directory method.
+// Tests are named "<test package>.<test>"; each symbol cites its own
+// bundle and the test bundles that exercise it. Symbols are sorted (INV-28).
+func buildTestedSymbols(bundles []*evidence.EvidenceBundle, tests []*evidence.TestBundle) []TestedSymbol {
+	type def struct {
+		symbol, dir, ref string
+	}
+	defs := make(map[string][]def) // "<pkg>.<name>" → definitions
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		pkg := bnd.Package.Name
+		for _, fn := range bnd.Symbols.Functions {
+			symbol := pkg + "." + fn.Name
+			if recv := fn.ReceiverType(); recv != "" {
+				symbol = pkg + "." + recv + "." + fn.Name
+			}
+			defs[pkg+"."+fn.Name] = append(defs[pkg+"."+fn.Name], def{
+				symbol: symbol,
+				dir:    path.Dir(bnd.File.Path),
+				ref:    evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+fn.Name),
+			})
+		}
+	}
+
+	testsOf := make(map[string]map[string]bool)
+	refsOf := make(map[string]map[string]bool)
+	for _, tb := range tests {
+		pkg := strings.TrimSuffix(tb.Package.Name, "_test")
+		dir := path.Dir(tb.File.Path)
+		imported := make(map[string]bool)
+		for _, imp := range tb.Package.Imports {
+			name := imp.Alias
+			if name == "" {
+				name = path.Base(imp.Path)
+			}
+			imported[name] = true
+		}
+		for _, tc := range tb.Tests {
+			for _, target := range tc.Exercises {
+				key, local := pkg+"."+target, true
+				if qual, name, ok := strings.Cut(target, "."); ok {
+					if imported[qual] {
+						key, local = target, false
+					} else {
+						key = pkg + "." + name
+					}
+				}
+				for _, d := range defs[key] {
+					if local && d.dir != dir {
+						continue
+					}
+					if testsOf[d.symbol] == nil {
+						testsOf[d.symbol] = make(map[string]bool)
+						refsOf[d.symbol] = make(map[string]bool)
+					}
+					testsOf[d.symbol][tb.Package.Name+"."+tc.Name] = true
+					refsOf[d.symbol][d.ref] = true
+					refsOf[d.symbol][evidenceRef(tb.File.Path, tb.Version, "symbol:"+tc.Name)] = true
+				}
+			}
+		}
+	}
+
+	var tested []TestedSymbol
+	for symbol, set := range testsOf {
+		tested = append(tested, TestedSymbol{Symbol: symbol, Tests: setKeys(set), EvidenceRefs: setKeys(refsOf[symbol])})
+	}
+	sort.Slice(tested, func(i, j int) bool { return tested[i].Symbol < tested[j].Symbol })
+	return tested
+}
+
+// buildCLISurface assigns the bundles' command-line flags to entrypoints.
+// A flag belongs to an entrypoint when it is registered in a file of the
+// entrypoint's own package and directory, by a function the entrypoint
+// reaches in the call graph, or at package level or in init by a package
+// whose functions the entrypoint reaches (cobra commands register their
+// flags in init). Package main call graphs are keyed by package name, so
+// reachability does not tell several main packages apart; only their own
+// directories do. Entrypoints without flags are omitted.
+func buildCLISurface(bundles []*evidence.EvidenceBundle, entrypoints []Entrypoint) []CLISurface {
+	if !slices.ContainsFunc(bundles, func(b *evidence.EvidenceBundle) bool { return len(b.CLIFlags) > 0 }) {
+		return nil
+	}
+	graph := buildCallIndex(bundles)
+	var surfaces []CLISurface
+	for _, ep := range entrypoints {
+		type flagKey struct{ name, shorthand, typ, def, usage string }
+		refs := make(map[flagKey]map[string]bool)
+		reach := graph.distances(ep.Package + "." + ep.Symbol)
+		for _, bnd := range bundles {
+			pkg := bnd.Package.Name
+			for _, f := range bnd.CLIFlags {
+				if !flagReachable(reach, ep, bnd, f.From) {
+					continue
+				}
+				k := flagKey{f.Name, f.Shorthand, f.Type, f.Default, f.Usage}
+				if refs[k] == nil {
+					refs[k] = make(map[string]bool)
+				}
+				fragment := ""
+				if _, name, ok := callerSymbol(pkg, f.From); ok {
+					fragment = "symbol:" + name
+				}
+				refs[k][evidenceRef(bnd.File.Path, bnd.Version, fragment)] = true
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		var flags []CLIFlag
+		for k, set := range refs {
+			flags = append(flags, CLIFlag{
+				Name:         k.name,
+				Shorthand:    k.shorthand,
+				Type:         k.typ,
+				Default:      k.def,
+				Usage:        k.usage,
+				EvidenceRefs: setKeys(set),
+			})
+		}
+		sort.Slice(flags, func(i, j int) bool {
+			if flags[i].Name != flags[j].Name {
+				return flags[i].Name < flags[j].Name
+			}
+			return flags[i].EvidenceRefs[0] < flags[j].EvidenceRefs[0]
+		})
+		surfaces = append(surfaces, CLISurface{Package: ep.Package, Symbol: ep.Symbol, Dir: ep.Dir, Flags: flags})
+	}
+	return surfaces
+}
+
+// flagReachable reports whether a flag registered by from in bnd belongs
+// to entrypoint ep, which reaches the symbols in reach (see
+// buildCLISurface).
+func flagReachable(reach map[string]int, ep Entrypoint, bnd *evidence.EvidenceBundle, from string) bool {
+	pkg := bnd.Package.Name
+	if pkg == ep.Package {
+		return path.Dir(bnd.File.Path) == ep.Dir
+	}
+	if sym, name, ok := callerSymbol(pkg, from); ok && name != "init" {
+		_, reached := reach[sym]
+		return reached
+	}
+	for sym := range reach {
+		if strings.HasPrefix(sym, pkg+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// buildUnreferencedSymbols reports the exported functions and types of the
+// module's Go packages that nothing in the bundles refers to. A function is
+// referenced by a call target naming it ("<pkg>.<name>", or the bare name
+// within its package); a type by any parameter, result, field, underlying,
+// or embedded type string mentioning it, other than in its own
+// declaration. Methods (reachable through interfaces), package main, and
+// entrypoints are skipped. Matching is by package name, so same-named
+// packages share references. Functions used only as values, and symbols
+// used only by tests or other modules, are reported too; the list is a
+// starting point for review, not a deletion plan. Sorted by symbol
+// (INV-28).
+func buildUnreferencedSymbols(bundles []*evidence.EvidenceBundle, entrypoints []Entrypoint) []UnreferencedSymbol {
+	referenced := make(map[string]bool)
+	mention := func(pkg, self, typeStr string) {
+		for _, ident := range typeIdentRe.FindAllString(typeStr, -1) {
+			if !strings.Contains(ident, ".") {
+				if ident == self {
+					continue
+				}
+				ident = pkg + "." + ident
+			}
+			referenced[ident] = true
+		}
+	}
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		pkg := bnd.Package.Name
+		for _, c := range bnd.Calls {
+			if strings.Contains(c.To, ".") {
+				referenced[c.To] = true
+			} else {
+				referenced[pkg+"."+c.To] = true
+			}
+		}
+		for _, fn := range bnd.Symbols.Functions {
+			for _, t := range slices.Concat(fn.Params, fn.Returns) {
+				mention(pkg, fn.ReceiverType(), t)
+			}
+		}
+		for _, v := range slices.Concat(bnd.Symbols.Variables, bnd.Symbols.Constants) {
+			mention(pkg, "", v.TypeStr)
+		}
+		for _, td := range bnd.Symbols.Types {
+			mention(pkg, td.Name, td.Underlying)
+			for _, f := range td.Fields {
+				mention(pkg, td.Name, f.TypeStr)
+			}
+			for _, e := range td.Embeds {
+				mention(pkg, td.Name, e)
+			}
+			for _, m := range td.Methods {
+				for _, t := range slices.Concat(m.Params, m.Returns) {
+					mention(pkg, td.Name, t)
+				}
+			}
+		}
+	}
+	for _, ep := range entrypoints {
+		referenced[ep.Package+"."+ep.Symbol] = true
+	}
+
+	var unreferenced []UnreferencedSymbol
+	add := func(bnd *evidence.EvidenceBundle, name, kind string) {
+		symbol := bnd.Package.Name + "." + name
+		if referenced[symbol] {
+			return
+		}
+		referenced[symbol] = true // report each symbol once
+		unreferenced = append(unreferenced, UnreferencedSymbol{
+			Symbol:       symbol,
+			Kind:         kind,
+			EvidenceRefs: []string{evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+name)},
+		})
+	}
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo || bnd.Package.Name == "main" {
+			continue
+		}
+		for _, fn := range bnd.Symbols.Functions {
+			if fn.Exported && fn.Receiver == "" {
+				add(bnd, fn.Name, UnreferencedFunc)
+			}
+		}
+		for _, td := range bnd.Symbols.Types {
+			if td.Exported {
+				add(bnd, td.Name, UnreferencedType)
+			}
+		}
+	}
+	sort.Slice(unreferenced, func(i, j int) bool { return unreferenced[i].Symbol < unreferenced[j].Symbol })
+	return unreferenced
+}
+
+// typeIdentRe matches the possibly package-qualified identifiers in a type
+// string, e.g. "map[string]*store.Item" → "map", "string", "store.Item".
+var typeIdentRe = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?`)
+
+// buildSharedStateRisks flags exported package-level variables that
+// goroutines may share: those of a package where some file, in the package
+// itself or in a package importing it, launches goroutines. Variables that
+// are themselves sync or atomic values or channels, and error sentinels,
+// are skipped. Each risk names the launching files and the first state
+// domain (by ID) owning the package. Sorted by variable.
+func buildSharedStateRisks(bundles []*evidence.EvidenceBundle, packages []PackageEntry, domains []StateDomain) []SharedStateRisk {
+	unitName := unitNamer(bundles)
+	launchers := make(map[string]map[string]bool) // package → launching files
+	for _, bnd := range bundles {
+		if bnd.Concurrency != nil && len(bnd.Concurrency.Goroutines) > 0 {
+			pkg := unitName(bnd)
+			if launchers[pkg] == nil {
+				launchers[pkg] = make(map[string]bool)
+			}
+			launchers[pkg][bnd.File.Path] = true
+		}
+	}
+	if len(launchers) == 0 {
+		return nil
+	}
+	importers := make(map[string][]string)
+	for _, p := range packages {
+		for _, dep := range p.Imports {
+			importers[dep] = append(importers[dep], p.Name)
+		}
+	}
+	owner := make(map[string]string)
+	for _, d := range domains { // sorted by ID, so the first owner wins
+		for _, o := range d.Owners {
+			if _, ok := owner[o]; !ok {
+				owner[o] = d.ID
+			}
+		}
+	}
+
+	var risks []SharedStateRisk
+	for _, bnd := range bundles {
+		pkg := unitName(bnd)
+		files := make(map[string]bool)
+		for _, p := range append([]string{pkg}, importers[pkg]...) {
+			for f := range launchers[p] {
+				files[f] = true
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+		synced := make(map[string]bool)
+		if c := bnd.Concurrency; c != nil {
+			for _, p := range c.Primitives {
+				synced[p.Owner+"."+p.Name] = true
+			}
+			for _, ch := range c.Channels {
+				synced[ch.Owner+"."+ch.Name] = true
+			}
+		}
+		for _, v := range bnd.Symbols.Variables {
+			if !v.Exported || synced["<global>."+v.Name] || slices.Contains(bnd.Symbols.ErrorSentinels, v.Name) {
+				continue
+			}
+			risks = append(risks, SharedStateRisk{
+				Variable:     bnd.Package.Name + "." + v.Name,
+				Package:      pkg,
+				Launchers:    setKeys(files),
+				Domain:       owner[pkg],
+				EvidenceRefs: []string{evidenceRef(bnd.File.Path, bnd.Version, "symbol:"+v.Name)},
+			})
+		}
+	}
+	sort.Slice(risks, func(i, j int) bool { return risks[i].Variable < risks[j].Variable })
+	return risks
+}
+
+// buildConcurrencyDomains collects one domain per file with concurrency
+// signals, with the goroutine count, primitives, and channels of its
+// concurrency section.
+func buildConcurrencyDomains(bundles []*evidence.EvidenceBundle) []ConcurrencyDomain {
+	var domains []ConcurrencyDomain
+
+	for _, bnd := range bundles {
+		if !bnd.Signals.Concurrency {
+			continue
+		}
+		id := bnd.File.Path
+		d := ConcurrencyDomain{
+			ID:    id,
+			Files: []string{bnd.File.Path},
+			EvidenceRefs: []string{
+				evidenceRef(bnd.File.Path, bnd.Version, "signal:concurrency"),
+			},
+		}
+		if c := bnd.Concurrency; c != nil {
+			for _, g := range c.Goroutines {
+				d.Goroutines += g.Count
+			}
+			for _, p := range c.Primitives {
+				d.Primitives = append(d.Primitives, concurrencyName(bnd.Package.Name, p.Owner, p.Name)+" ("+p.Kind+")")
+			}
+			for _, ch := range c.Channels {
+				d.Channels = append(d.Channels, concurrencyName(bnd.Package.Name, ch.Owner, ch.Name)+" ("+chanTypeString(ch)+")")
+			}
+			sort.Strings(d.Primitives)
+			sort.Strings(d.Channels)
+		}
+		domains = append(domains, d)
+	}
+
+	// Sort by id (INV-28).
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].ID < domains[j].ID
+	})
+	return domains
+}
+
+// concurrencyName qualifies a concurrency value by package and owner:
+// "pkg.Type.field", "pkg.Func.local", or "pkg.var" for package-level vars.
+func concurrencyName(pkg, owner, name string) string {
+	if owner == "<global>" {
+		return pkg + "." + name
+	}
+	return pkg + "." + strings.TrimPrefix(owner, "*") + "." + name
+}
+
+// chanTypeString renders a channel's type, e.g. "<-chan Job".
+func chanTypeString(ch evidence.Channel) string {
+	switch ch.Dir {
+	case "send":
+		return "chan<- " + ch.Elem
+	case "recv":
+		return "<-chan " + ch.Elem
+	}
+	return "chan " + ch.Elem
+}
+
+// groupConcurrencyDomains merges the per-file concurrency domains whose
+// files have effects linked to the same state domain into one domain with
+// that state domain's ID, so concurrency sharing state shows up as a
+// cluster. Files whose effects are unlinked, or that are alone in their
+// state domain, keep their per-file domain. A file's first linked effect
+// decides its state domain. Output is sorted by ID (INV-28).
+func groupConcurrencyDomains(domains []ConcurrencyDomain, effects []Effect) []ConcurrencyDomain {
+	fileDomain := make(map[string]string)
+	for _, e := range effects {
+		if _, seen := fileDomain[e.Via]; !seen && e.Domain != "" {
+			fileDomain[e.Via] = e.Domain
+		}
+	}
+
+	groups := make(map[string][]ConcurrencyDomain)
+	for _, d := range domains {
+		if len(d.Files) == 1 && fileDomain[d.Files[0]] != "" {
+			id := fileDomain[d.Files[0]]
+			groups[id] = append(groups[id], d)
+		}
+	}
+
+	var out []ConcurrencyDomain
+	for _, d := range domains {
+		if len(d.Files) != 1 || len(groups[fileDomain[d.Files[0]]]) < 2 {
+			out = append(out, d)
+		}
+	}
+	for id, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		combined := ConcurrencyDomain{ID: id}
+		for _, m := range members {
+			combined.Files = append(combined.Files, m.Files...)
+			combined.Goroutines += m.Goroutines
+			combined.Primitives = append(combined.Primitives, m.Primitives...)
+			combined.Channels = append(combined.Channels, m.Channels...)
+			combined.EvidenceRefs = append(combined.EvidenceRefs, m.EvidenceRefs...)
+		}
+		sort.Strings(combined.Files)
+		sort.Strings(combined.Primitives)
+		sort.Strings(combined.Channels)
+		sort.Strings(combined.EvidenceRefs)
+		out = append(out, combined)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Package summaries for LLM
+// ---------------------------------------------------------------------------
+
+// readModuleName reads the module name from go.mod in root.
+// Returns "" if go.mod is absent or unparseable.
+func readModuleName(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.SplitN(string(data), "\n", 10) {
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module "))
+		}
+	}
+	return ""
+}
+
+// formatStructDesc returns a compact description of a struct type for the LLM:
+// "TypeName{Field1:Type1, Field2:Type2}"
+func formatStructDesc(td evidence.TypeDecl) string {
+	if td.Kind != "struct" || len(td.Fields) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(td.Name)
+	sb.WriteString("{")
+	for i, f := range td.Fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(f.Name)
 		sb.WriteString(":")
 		sb.WriteString(f.TypeStr)
 	}
@@ -415,49 +1492,114 @@ func formatFuncDesc(fn evidence.Function) string {
 	if !fn.Exported || fn.Receiver != "" {
 		return "" // skip unexported and methods; focus on top-level functions
 	}
+	return formatSignature(fn.Name, fn.Params, fn.Returns)
+}
+
+// formatSignature returns "Name(Type1, Type2) ReturnType", parenthesizing
+// multiple return types.
+func formatSignature(name string, params, returns []string) string {
 	var sb strings.Builder
-	sb.WriteString(fn.Name)
+	sb.WriteString(name)
 	sb.WriteString("(")
-	for i, p := range fn.Params {
-		if i > 0 {
-			sb.WriteString(", ")
-		}
-		sb.WriteString(p)
-	}
+	sb.WriteString(strings.Join(params, ", "))
 	sb.WriteString(")")
-	if len(fn.Returns) == 1 {
+	if len(returns) == 1 {
 		sb.WriteString(" ")
-		sb.WriteString(fn.Returns[0])
-	} else if len(fn.Returns) > 1 {
+		sb.WriteString(returns[0])
+	} else if len(returns) > 1 {
 		sb.WriteString(" (")
-		for i, r := range fn.Returns {
-			if i > 0 {
-				sb.WriteString(", ")
-			}
-			sb.WriteString(r)
-		}
+		sb.WriteString(strings.Join(returns, ", "))
 		sb.WriteString(")")
 	}
 	return sb.String()
 }
 
-// buildPackageSummaries groups bundles by package, ORs signals, collects
+// formatInterfaceDesc returns a compact description of an interface's
+// exported methods for the LLM:
+// "TypeName interface{M1(Type1) ReturnType; M2()}"
+func formatInterfaceDesc(td evidence.TypeDecl) string {
+	if td.Kind != "interface" {
+		return ""
+	}
+	var sigs []string
+	for _, m := range td.Methods {
+		if m.Exported {
+			sigs = append(sigs, formatSignature(m.Name, m.Params, m.Returns))
+		}
+	}
+	if len(sigs) == 0 {
+		return ""
+	}
+	return td.Name + " interface{" + strings.Join(sigs, "; ") + "}"
+}
+
+// groupMethodsByType groups exported methods by their normalized receiver
+// type, so methods on *T and T land under the same key "T". Method names are
+// deduplicated and sorted. Plain functions are ignored.
+func groupMethodsByType(fns []evidence.Function) map[string][]string {
+	sets := make(map[string]map[string]bool)
+	for _, fn := range fns {
+		recv := fn.ReceiverType()
+		if recv == "" || !fn.Exported {
+			continue
+		}
+		if sets[recv] == nil {
+			sets[recv] = make(map[string]bool)
+		}
+		sets[recv][fn.Name] = true
+	}
+	groups := make(map[string][]string, len(sets))
+	for recv, names := range sets {
+		for name := range names {
+			groups[recv] = append(groups[recv], name)
+		}
+		sort.Strings(groups[recv])
+	}
+	return groups
+}
+
+// formatMethodDesc returns a compact method-set description for the LLM:
+// "TypeName methods: M1, M2"
+func formatMethodDesc(typeName string, methods []string) string {
+	return typeName + " methods: " + strings.Join(methods, ", ")
+}
+
+// buildPackageSummaries groups bundles by unit (see unitNamer), ORs signals, collects
 // types/funcs/imports (capped at 10), and filters to packages with ≥1 signal.
 // At most 60 packages are sent to the LLM.
 func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Settings, moduleName string) []types.PackageSummary {
+	return capSummaries(collectPackageSummaries(bundles, s, moduleName))
+}
+
+// maxSummaries is the number of package summaries sent to the LLM.
+const maxSummaries = 60
+
+// capSummaries keeps the first maxSummaries summaries (INV: keep LLM prompt
+// manageable).
+func capSummaries(summaries []types.PackageSummary) []types.PackageSummary {
+	if len(summaries) > maxSummaries {
+		summaries = summaries[:maxSummaries]
+	}
+	return summaries
+}
+
+// collectPackageSummaries is buildPackageSummaries without the cap.
+func collectPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Settings, moduleName string) []types.PackageSummary {
+	unitName := unitNamer(bundles)
 	type pkgAccum struct {
 		files     []string
 		types     map[string]bool
 		typeDescs map[string]bool // formatted struct descriptions
 		functions map[string]bool
 		funcDescs map[string]bool // formatted function signatures
+		methods   []evidence.Function
 		imports   map[string]bool
 		signals   types.PackageSignals
 	}
 
 	accum := make(map[string]*pkgAccum)
 	for _, bnd := range bundles {
-		name := bnd.Package.Name
+		name := unitName(bnd)
 		a, ok := accum[name]
 		if !ok {
 			a = &pkgAccum{
@@ -487,14 +1629,23 @@ func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Setti
 		if bnd.Signals.Concurrency {
 			a.signals.Concurrency = true
 		}
+		for _, c := range bnd.Signals.Custom {
+			if !slices.Contains(a.signals.Custom, c) {
+				a.signals.Custom = append(a.signals.Custom, c)
+			}
+		}
 
-		// Collect exported types and their struct field descriptions.
+		// Collect exported types, their struct field descriptions, and
+		// interface method sets.
 		for _, td := range bnd.Symbols.Types {
 			if td.Exported {
 				a.types[td.Name] = true
 				if desc := formatStructDesc(td); desc != "" {
 					a.typeDescs[desc] = true
 				}
+				if desc := formatInterfaceDesc(td); desc != "" {
+					a.typeDescs[desc] = true
+				}
 			}
 		}
 		// Collect exported top-level functions and their signatures.
@@ -505,14 +1656,26 @@ func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Setti
 			if desc := formatFuncDesc(fn); desc != "" {
 				a.funcDescs[desc] = true
 			}
+			if fn.Receiver != "" {
+				a.methods = append(a.methods, fn)
+			}
 		}
 		// Collect imports, skipping any that resolve to a denied local path.
 		// Import paths like "iguana/baml_client" are stripped of the module
 		// prefix to get "baml_client", then checked against the deny list.
+		// Non-Go import paths are not module-relative and are kept as is.
 		for _, imp := range bnd.Package.Imports {
 			rel := imp.Path
-			if moduleName != "" {
-				rel = strings.TrimPrefix(imp.Path, moduleName+"/")
+			if bnd.Lang() != evidence.LanguageGo {
+				a.imports[imp.Path] = true
+				continue
+			}
+			mod := moduleName
+			if bnd.Package.Module != "" {
+				mod = bnd.Package.Module // the file's own module in a workspace
+			}
+			if mod != "" {
+				rel = strings.TrimPrefix(imp.Path, mod+"/")
 			}
 			if s.IsDenied(rel) {
 				continue
@@ -542,7 +1705,7 @@ func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Setti
 	}
 
 	hasAnySignal := func(s types.PackageSignals) bool {
-		return s.Fs_reads || s.Fs_writes || s.Db_calls || s.Net_calls || s.Concurrency
+		return s.Fs_reads || s.Fs_writes || s.Db_calls || s.Net_calls || s.Concurrency || len(s.Custom) > 0
 	}
 
 	var summaries []types.PackageSummary
@@ -551,11 +1714,20 @@ func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Setti
 		if !hasAnySignal(a.signals) {
 			continue
 		}
+		sort.Strings(a.signals.Custom)
 		files := append([]string(nil), a.files...)
 		sort.Strings(files)
 
-		// Merge struct descriptions and function signatures into one sorted slice.
+		// Method sets grouped by receiver type (pointer and value together).
+		methodDescs := make(map[string]bool)
+		for recv, names := range groupMethodsByType(a.methods) {
+			methodDescs[formatMethodDesc(recv, names)] = true
+		}
+
+		// Merge struct descriptions, function signatures, and method sets
+		// into one sorted slice.
 		allDescs := append(topN(a.typeDescs, 30), topN(a.funcDescs, 20)...)
+		allDescs = append(allDescs, topN(methodDescs, 20)...)
 		sort.Strings(allDescs)
 
 		summaries = append(summaries, types.PackageSummary{
@@ -569,27 +1741,215 @@ func buildPackageSummaries(bundles []*evidence.EvidenceBundle, s *settings.Setti
 		})
 	}
 
-	// Cap at 60 (INV: keep LLM prompt manageable).
-	if len(summaries) > 60 {
-		summaries = summaries[:60]
-	}
 	return summaries
 }
 
+// Package summary fields selectable with GenerateOptions.SummaryFields.
+const (
+	SummaryTypes            = "types"
+	SummaryTypeDescriptions = "type_descriptions"
+	SummaryFunctions        = "functions"
+	SummaryImports          = "imports"
+)
+
+// summaryFields is every selectable summary field, in summary order.
+var summaryFields = []string{SummaryTypes, SummaryTypeDescriptions, SummaryFunctions, SummaryImports}
+
+func isSummaryField(name string) bool {
+	for _, f := range summaryFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSummaryFields parses a comma-separated field list into a value for
+// GenerateOptions.SummaryFields. Plain names select exactly those fields
+// ("types,functions"); names prefixed with "-" drop fields from the full
+// set ("-type_descriptions"). The two forms cannot be mixed.
+func ParseSummaryFields(spec string) ([]string, error) {
+	var include, exclude []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		drop := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if !isSummaryField(name) {
+			return nil, fmt.Errorf("unknown summary field %q (want one of %s)", name, strings.Join(summaryFields, ", "))
+		}
+		if drop {
+			exclude = append(exclude, name)
+		} else {
+			include = append(include, name)
+		}
+	}
+	if len(include) > 0 && len(exclude) > 0 {
+		return nil, fmt.Errorf("summary fields %q mix included and excluded fields", spec)
+	}
+	if len(exclude) == 0 {
+		return append([]string{}, include...), nil
+	}
+	dropped := make(map[string]bool)
+	addAll(dropped, exclude)
+	fields := []string{}
+	for _, f := range summaryFields {
+		if !dropped[f] {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// selectSummaryFields clears, in place, every selectable field of summaries
+// that is not named in fields.
+func selectSummaryFields(summaries []types.PackageSummary, fields []string) {
+	keep := make(map[string]bool)
+	addAll(keep, fields)
+	for i := range summaries {
+		if !keep[SummaryTypes] {
+			summaries[i].Types = nil
+		}
+		if !keep[SummaryTypeDescriptions] {
+			summaries[i].Type_descriptions = nil
+		}
+		if !keep[SummaryFunctions] {
+			summaries[i].Functions = nil
+		}
+		if !keep[SummaryImports] {
+			summaries[i].Imports = nil
+		}
+	}
+}
+
+// smallPackageFiles is the file count at or below which a package summary
+// is merged with its siblings under GenerateOptions.MergeSummaries.
+const smallPackageFiles = 2
+
+// mergeSmallSummaries combines the summaries of small packages (at most
+// smallPackageFiles files) whose directories share a parent, so that many
+// tiny packages use one LLM summary slot. A merged summary is named by its
+// members joined with "+" (e.g. "a+b"); signals are ORed and the other lists
+// unioned and sorted. Returns the summaries sorted by name and, for each
+// merged name, its member package names (sorted).
+func mergeSmallSummaries(summaries []types.PackageSummary) ([]types.PackageSummary, map[string][]string) {
+	groups := make(map[string][]types.PackageSummary)
+	var out []types.PackageSummary
+	for _, sum := range summaries {
+		if len(sum.Files) == 0 || len(sum.Files) > smallPackageFiles {
+			out = append(out, sum)
+			continue
+		}
+		parent := path.Dir(path.Dir(sum.Files[0]))
+		groups[parent] = append(groups[parent], sum)
+	}
+
+	merged := make(map[string][]string)
+	for _, group := range groups {
+		if len(group) == 1 {
+			out = append(out, group[0])
+			continue
+		}
+		var names []string
+		files, typeSet, descs, funcs, imports, custom := map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+		var sig types.PackageSignals
+		for _, sum := range group {
+			names = append(names, sum.Name)
+			addAll(files, sum.Files)
+			addAll(typeSet, sum.Types)
+			addAll(descs, sum.Type_descriptions)
+			addAll(funcs, sum.Functions)
+			addAll(imports, sum.Imports)
+			sig.Fs_reads = sig.Fs_reads || sum.Signals.Fs_reads
+			sig.Fs_writes = sig.Fs_writes || sum.Signals.Fs_writes
+			sig.Db_calls = sig.Db_calls || sum.Signals.Db_calls
+			sig.Net_calls = sig.Net_calls || sum.Signals.Net_calls
+			sig.Concurrency = sig.Concurrency || sum.Signals.Concurrency
+			addAll(custom, sum.Signals.Custom)
+		}
+		if len(custom) > 0 {
+			sig.Custom = setKeys(custom)
+		}
+		sort.Strings(names)
+		name := strings.Join(names, "+")
+		merged[name] = names
+		out = append(out, types.PackageSummary{
+			Name:              name,
+			Files:             setKeys(files),
+			Types:             setKeys(typeSet),
+			Type_descriptions: setKeys(descs),
+			Functions:         setKeys(funcs),
+			Signals:           sig,
+			Imports:           setKeys(imports),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, merged
+}
+
+// splitMergedOwners rewrites state-domain owners and trust-zone packages
+// that name a merged summary (see mergeSmallSummaries) back to its member
+// packages.
+func splitMergedOwners(inference *types.SystemModelInference, merged map[string][]string) {
+	expand := func(names []string) []string {
+		var out []string
+		seen := make(map[string]bool)
+		for _, n := range names {
+			members, ok := merged[n]
+			if !ok {
+				members = []string{n}
+			}
+			for _, m := range members {
+				if !seen[m] {
+					seen[m] = true
+					out = append(out, m)
+				}
+			}
+		}
+		return out
+	}
+	for i := range inference.State_domains {
+		inference.State_domains[i].Owners = expand(inference.State_domains[i].Owners)
+	}
+	for i := range inference.Trust_zones {
+		inference.Trust_zones[i].Packages = expand(inference.Trust_zones[i].Packages)
+	}
+}
+
+// addAll adds every value to set.
+func addAll(set map[string]bool, values []string) {
+	for _, v := range values {
+		set[v] = true
+	}
+}
+
+// setKeys returns the keys of set, sorted.
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ---------------------------------------------------------------------------
 // LLM output mapping
 // ---------------------------------------------------------------------------
 
 // pkgBundleRefs returns evidence refs for all bundles belonging to the given
-// package names.
+// unit names (see unitNamer).
 func pkgBundleRefs(bundles []*evidence.EvidenceBundle, pkgNames []string) []string {
+	unitName := unitNamer(bundles)
 	pkgSet := make(map[string]bool, len(pkgNames))
 	for _, p := range pkgNames {
 		pkgSet[p] = true
 	}
 	var refs []string
 	for _, bnd := range bundles {
-		if pkgSet[bnd.Package.Name] {
+		if pkgSet[unitName(bnd)] {
 			refs = append(refs, evidenceRef(bnd.File.Path, bnd.Version, ""))
 		}
 	}
@@ -621,15 +1981,98 @@ func mapStateDomains(specs []types.StateDomainSpec, bundles []*evidence.Evidence
 	return domains
 }
 
+// OwnershipConflict is a package claimed as owner by more than one state
+// domain. Domains is sorted.
+type OwnershipConflict struct {
+	Package string
+	Domains []string
+}
+
+// OwnershipConflicts returns every package listed in the Owners of two or
+// more domains, sorted by package. linkEffectsToDomains attributes such a
+// package's effects to the first domain only, so these are ambiguous.
+func OwnershipConflicts(domains []StateDomain) []OwnershipConflict {
+	owners := make(map[string][]string)
+	for _, d := range domains {
+		for _, pkg := range d.Owners {
+			owners[pkg] = append(owners[pkg], d.ID)
+		}
+	}
+	var conflicts []OwnershipConflict
+	for pkg, ids := range owners {
+		if len(ids) > 1 {
+			conflicts = append(conflicts, OwnershipConflict{Package: pkg, Domains: sortedCopy(ids)})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Package < conflicts[j].Package })
+	return conflicts
+}
+
+// ownershipConflictQuestions raises one open question per package claimed
+// by multiple domains.
+func ownershipConflictQuestions(domains []StateDomain) []OpenQuestion {
+	var questions []OpenQuestion
+	for _, c := range OwnershipConflicts(domains) {
+		questions = append(questions, OpenQuestion{
+			Question: fmt.Sprintf("Package %s is claimed by domains %s; which one owns its state?",
+				c.Package, strings.Join(c.Domains, " and ")),
+		})
+	}
+	return questions
+}
+
+// fileUnits maps each bundle's file path to its unit name (see unitNamer).
+func fileUnits(bundles []*evidence.EvidenceBundle) map[string]string {
+	unitName := unitNamer(bundles)
+	units := make(map[string]string, len(bundles))
+	for _, b := range bundles {
+		units[b.File.Path] = unitName(b)
+	}
+	return units
+}
+
+// unownedEffectQuestions raises one open question per package that produces
+// effects but is listed in no domain's Owners: behavior the model cannot
+// attribute to any state.
+func unownedEffectQuestions(effects []Effect, domains []StateDomain, bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	owned := make(map[string]bool)
+	for _, d := range domains {
+		for _, pkg := range d.Owners {
+			owned[pkg] = true
+		}
+	}
+	fileToPkg := fileUnits(bundles)
+	kinds := make(map[string]map[string]bool)
+	for _, e := range effects {
+		pkg := fileToPkg[e.Via]
+		if pkg == "" || owned[pkg] {
+			continue
+		}
+		if kinds[pkg] == nil {
+			kinds[pkg] = make(map[string]bool)
+		}
+		kinds[pkg][e.Kind] = true
+	}
+	var questions []OpenQuestion
+	for pkg, set := range kinds {
+		var list []string
+		for k := range set {
+			list = append(list, k)
+		}
+		sort.Strings(list)
+		questions = append(questions, OpenQuestion{
+			Question: fmt.Sprintf("Package %s has effects (%s) but no owning state domain; which domain does it belong to?",
+				pkg, strings.Join(list, ", ")),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
 // linkEffectsToDomains annotates each effect's Domain field by resolving
 // file → package → domain owner. Effects with no matching domain are left
 // with an empty Domain field.
 func linkEffectsToDomains(effects []Effect, domains []StateDomain, bundles []*evidence.EvidenceBundle) {
-	// Build file path → package name.
-	fileToPkg := make(map[string]string, len(bundles))
-	for _, b := range bundles {
-		fileToPkg[b.File.Path] = b.Package.Name
-	}
+	fileToPkg := fileUnits(bundles)
 	// Build package name → domain ID (first owner wins).
 	pkgToDomain := make(map[string]string)
 	for _, d := range domains {
@@ -681,6 +2124,270 @@ func mapOpenQuestions(specs []types.OpenQuestionSpec) []OpenQuestion {
 	return questions
 }
 
+// ignoredErrorThreshold is the per-package count of discarded error results
+// at which ignoredErrorQuestions raises an open question.
+const ignoredErrorThreshold = 3
+
+// ignoredErrorQuestions seeds one open question per package whose bundles
+// together assign at least ignoredErrorThreshold error results to _.
+// MissingEvidence lists the offending files, sorted.
+func ignoredErrorQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	counts := make(map[string]int)
+	files := make(map[string][]string)
+	for _, bnd := range bundles {
+		if n := bnd.Signals.IgnoredErrors; n > 0 {
+			pkg := bnd.Package.Name
+			counts[pkg] += n
+			files[pkg] = append(files[pkg], bnd.File.Path)
+		}
+	}
+	var questions []OpenQuestion
+	for pkg, n := range counts {
+		if n < ignoredErrorThreshold {
+			continue
+		}
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Package %s discards %d error results to _; are these failures safe to ignore?", pkg, n),
+			MissingEvidence: sortedCopy(files[pkg]),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// droppedErrorQuestions seeds one open question per package whose bundles
+// together drop at least ignoredErrorThreshold error results in call
+// statements (error_returns_ignored). MissingEvidence lists the offending
+// files, sorted.
+func droppedErrorQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	counts := make(map[string]int)
+	files := make(map[string][]string)
+	for _, bnd := range bundles {
+		if n := bnd.Signals.ErrorReturnsIgnored; n > 0 {
+			pkg := bnd.Package.Name
+			counts[pkg] += n
+			files[pkg] = append(files[pkg], bnd.File.Path)
+		}
+	}
+	var questions []OpenQuestion
+	for pkg, n := range counts {
+		if n < ignoredErrorThreshold {
+			continue
+		}
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Package %s drops the error result of %d call(s) without checking it; are these failures safe to ignore?", pkg, n),
+			MissingEvidence: sortedCopy(files[pkg]),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// terminationQuestions seeds open questions for abrupt termination: one per
+// package other than main that exits the process (os.Exit, log.Fatal),
+// skipping its callers' deferred cleanup, and one per package that panics
+// with no file of the package recovering. MissingEvidence lists the files
+// that exit or panic, sorted.
+func terminationQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	exits := make(map[string][]string)
+	panics := make(map[string][]string)
+	recovers := make(map[string]bool)
+	for _, bnd := range bundles {
+		pkg := bnd.Package.Name
+		if bnd.Signals.Exits && pkg != "main" {
+			exits[pkg] = append(exits[pkg], bnd.File.Path)
+		}
+		if bnd.Signals.Panics {
+			panics[pkg] = append(panics[pkg], bnd.File.Path)
+		}
+		if bnd.Signals.Recovers {
+			recovers[pkg] = true
+		}
+	}
+	var questions []OpenQuestion
+	for pkg, files := range exits {
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Package %s exits the process outside main, skipping deferred cleanup; should it return an error instead?", pkg),
+			MissingEvidence: sortedCopy(files),
+		})
+	}
+	for pkg, files := range panics {
+		if recovers[pkg] {
+			continue
+		}
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Package %s panics and never recovers; can any of these panics reach a caller at runtime?", pkg),
+			MissingEvidence: sortedCopy(files),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// uncheckedAssertionQuestions seeds one open question per package with
+// single-value type assertions, which panic on a type mismatch.
+// MissingEvidence lists the offending files, sorted.
+func uncheckedAssertionQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	counts := make(map[string]int)
+	files := make(map[string][]string)
+	for _, bnd := range bundles {
+		if n := bnd.Signals.UncheckedAssertions; n > 0 {
+			pkg := bnd.Package.Name
+			counts[pkg] += n
+			files[pkg] = append(files[pkg], bnd.File.Path)
+		}
+	}
+	var questions []OpenQuestion
+	for pkg, n := range counts {
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Package %s has %d unchecked type assertion(s); can any of them panic on unexpected input?", pkg, n),
+			MissingEvidence: sortedCopy(files[pkg]),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// mixedReceiverQuestions seeds one open question per type whose methods,
+// across all files of its package, mix pointer and value receivers.
+// MissingEvidence lists the files declaring the type's methods, sorted.
+func mixedReceiverQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	type receivers struct {
+		pointer, value bool
+		files          map[string]bool
+	}
+	byType := make(map[string]*receivers)
+	for _, bnd := range bundles {
+		if bnd.Lang() != evidence.LanguageGo {
+			continue
+		}
+		for _, fn := range bnd.Symbols.Functions {
+			if fn.Receiver == "" {
+				continue
+			}
+			key := bnd.Package.Name + "." + fn.ReceiverType()
+			r := byType[key]
+			if r == nil {
+				r = &receivers{files: make(map[string]bool)}
+				byType[key] = r
+			}
+			if strings.HasPrefix(fn.Receiver, "*") {
+				r.pointer = true
+			} else {
+				r.value = true
+			}
+			r.files[bnd.File.Path] = true
+		}
+	}
+	var questions []OpenQuestion
+	for typ, r := range byType {
+		if !r.pointer || !r.value {
+			continue
+		}
+		questions = append(questions, OpenQuestion{
+			Question:        fmt.Sprintf("Type %s has both pointer and value receiver methods; should its receivers be made consistent?", typ),
+			MissingEvidence: setKeys(r.files),
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// unboundedClientQuestions seeds a single reliability question when any
+// bundle builds an http.Client without a Timeout. MissingEvidence lists the
+// offending files, sorted.
+func unboundedClientQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	var files []string
+	for _, bnd := range bundles {
+		if bnd.Signals.UnboundedHTTPClient {
+			files = append(files, bnd.File.Path)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return []OpenQuestion{{
+		Question:        "Which outbound HTTP calls can hang indefinitely? Some http.Client values set no Timeout.",
+		MissingEvidence: sortedCopy(files),
+	}}
+}
+
+// defaultClientQuestions seeds a single reliability question when any bundle
+// sends requests through http.DefaultClient. MissingEvidence lists the
+// offending files, sorted.
+func defaultClientQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	var files []string
+	for _, bnd := range bundles {
+		if bnd.Signals.UsesDefaultHTTPClient {
+			files = append(files, bnd.File.Path)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return []OpenQuestion{{
+		Question:        "Should outbound HTTP go through an injected client? Some files use http.Get/Post or http.DefaultClient, which has no timeout.",
+		MissingEvidence: sortedCopy(files),
+	}}
+}
+
+// blockingChannelQuestions seeds a single concurrency question when any
+// bundle performs channel operations outside a select. MissingEvidence
+// lists the offending files, sorted.
+func blockingChannelQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	var files []string
+	for _, bnd := range bundles {
+		if bnd.Signals.BlockingChannelOps {
+			files = append(files, bnd.File.Path)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return []OpenQuestion{{
+		Question:        "Can any channel send or receive outside a select block forever? Some have no cancellation path.",
+		MissingEvidence: sortedCopy(files),
+	}}
+}
+
+// maxLargeSwitchQuestions caps how many files largeSwitchQuestions reports.
+const maxLargeSwitchQuestions = 3
+
+// largeSwitchQuestions seeds one question for each of the files with the
+// most large switch statements (ties broken by path), up to
+// maxLargeSwitchQuestions.
+func largeSwitchQuestions(bundles []*evidence.EvidenceBundle) []OpenQuestion {
+	var hot []*evidence.EvidenceBundle
+	for _, bnd := range bundles {
+		if bnd.Signals.LargeSwitches > 0 {
+			hot = append(hot, bnd)
+		}
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Signals.LargeSwitches != hot[j].Signals.LargeSwitches {
+			return hot[i].Signals.LargeSwitches > hot[j].Signals.LargeSwitches
+		}
+		return hot[i].File.Path < hot[j].File.Path
+	})
+	if len(hot) > maxLargeSwitchQuestions {
+		hot = hot[:maxLargeSwitchQuestions]
+	}
+	var questions []OpenQuestion
+	for _, bnd := range hot {
+		questions = append(questions, OpenQuestion{
+			Question: fmt.Sprintf("%s has %d large switch statement(s); should these cases be methods on an interface?",
+				bnd.File.Path, bnd.Signals.LargeSwitches),
+			MissingEvidence: []string{bnd.File.Path},
+		})
+	}
+	return mergeOpenQuestions(nil, questions)
+}
+
+// mergeOpenQuestions appends extra to questions and re-sorts by question
+// text (INV-28).
+func mergeOpenQuestions(questions, extra []OpenQuestion) []OpenQuestion {
+	questions = append(questions, extra...)
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Question < questions[j].Question
+	})
+	return questions
+}
+
 // sortedCopy returns a sorted copy of a string slice (nil-safe).
 func sortedCopy(s []string) []string {
 	if len(s) == 0 {
@@ -696,11 +2403,92 @@ func sortedCopy(s []string) []string {
 // Main orchestration
 // ---------------------------------------------------------------------------
 
+// GenerateOptions controls how GenerateSystemModel schedules its work.
+type GenerateOptions struct {
+	// ParallelLLM starts LLM inference as soon as the package summaries are
+	// built, overlapping it with the deterministic sections. The assembled
+	// model is identical to the serial one.
+	ParallelLLM bool
+
+	// CallGraphLimit caps the number of call-graph edges kept in the model.
+	// Zero means DefaultCallGraphLimit; a negative value disables the cap.
+	CallGraphLimit int
+
+	// EffectSort orders the effects section: EffectSortByKind (the default
+	// when empty) or EffectSortByFile.
+	EffectSort string
+
+	// MergeSummaries combines small sibling packages into one LLM summary
+	// before inference (see mergeSmallSummaries); inferred owners are split
+	// back to the member packages.
+	MergeSummaries bool
+
+	// SummaryFields lists the package summary fields sent to the LLM (see
+	// ParseSummaryFields). Nil sends all of them. Name, files, and signals
+	// are always sent.
+	SummaryFields []string
+
+	// GroupConcurrency combines concurrency domains whose files have effects
+	// in the same state domain (see groupConcurrencyDomains). The default
+	// is one concurrency domain per file.
+	GroupConcurrency bool
+
+	// NoLLM skips LLM inference: only the deterministic sections are built,
+	// the inferred sections (state domains, trust zones, LLM open questions)
+	// are left empty, and the model is marked with Inputs.InferenceSkipped.
+	// No LLM client needs to be configured.
+	NoLLM bool
+
+	// CacheDir caches inference results by bundle set hash (see cache.go;
+	// DefaultCacheDir is the usual value). Empty disables the cache.
+	CacheDir string
+
+	// RefreshCache ignores cached inference results, calling the LLM and
+	// overwriting the cache entry.
+	RefreshCache bool
+
+	// Provenance writes the summaries sent to the LLM and its parsed
+	// response under .iguana/provenance/ in the bundle directory and
+	// records the path in Inputs.Provenance (see provenance.go).
+	Provenance bool
+
+	// LLM lists the providers to try for InferSystemModel, in fallback
+	// order (see settings.LLMConfig.Chain). Empty uses the client compiled
+	// into baml_client.
+	LLM []settings.LLMProvider
+}
+
+// DefaultCallGraphLimit is the call-graph edge cap used when
+// GenerateOptions.CallGraphLimit is zero.
+const DefaultCallGraphLimit = 10000
+
+// inferSystemModel is the LLM entry point; tests replace it with a fake.
+var inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, opts ...b.CallOptionFunc) (types.SystemModelInference, error) {
+	return b.InferSystemModel(ctx, summaries, opts...)
+}
+
+// inferenceResult carries the LLM response across the goroutine boundary
+// when GenerateOptions.ParallelLLM is set.
+type inferenceResult struct {
+	inference types.SystemModelInference
+	err       error
+}
+
 // GenerateSystemModel orchestrates: load → compute → build deterministic →
 // build summaries → LLM → assemble. Returns the assembled *SystemModel.
-func GenerateSystemModel(ctx context.Context, root string) (*SystemModel, error) {
-	// Step 1: load all evidence bundles.
-	bundles, err := loadEvidenceBundles(root)
+// root is a bundle directory or an aggregate file (see WriteAggregate).
+func GenerateSystemModel(ctx context.Context, root string, opts GenerateOptions) (*SystemModel, error) {
+	if opts.EffectSort != "" && opts.EffectSort != EffectSortByKind && opts.EffectSort != EffectSortByFile {
+		return nil, fmt.Errorf("unknown effect sort %q (want %s or %s)", opts.EffectSort, EffectSortByKind, EffectSortByFile)
+	}
+	for _, f := range opts.SummaryFields {
+		if !isSummaryField(f) {
+			return nil, fmt.Errorf("unknown summary field %q (want one of %s)", f, strings.Join(summaryFields, ", "))
+		}
+	}
+
+	// Step 1: load all evidence bundles (root may be an aggregate file).
+	bundles, dir, err := loadBundles(root)
 	if err != nil {
 		return nil, fmt.Errorf("load bundles: %w", err)
 	}
@@ -711,46 +2499,131 @@ func GenerateSystemModel(ctx context.Context, root string) (*SystemModel, error)
 	// Step 2: compute bundle set hash.
 	bundleSetHash := computeBundleSetHash(bundles)
 
-	// Step 3: build deterministic sections.
-	inventory := buildInventory(bundles)
+	// Step 3: build package summaries for LLM, filtering denied imports so
+	// the LLM does not wonder about packages it has no evidence for.
+	s, _ := settings.LoadSettings(dir) // nil settings = no filtering
+	mod := readModuleName(dir)
+	var summaries []types.PackageSummary
+	var merged map[string][]string
+	if opts.MergeSummaries {
+		summaries, merged = mergeSmallSummaries(collectPackageSummaries(bundles, s, mod))
+		summaries = capSummaries(summaries)
+	} else {
+		summaries = buildPackageSummaries(bundles, s, mod)
+	}
+	if opts.SummaryFields != nil {
+		selectSummaryFields(summaries, opts.SummaryFields)
+	}
+
+	// Step 4: with ParallelLLM, start inference now (skip if no summaries —
+	// nothing with signals — or NoLLM) so it overlaps the deterministic
+	// sections.
+	var pending chan inferenceResult
+	infer := len(summaries) > 0 && !opts.NoLLM
+	if opts.ParallelLLM && infer {
+		pending = make(chan inferenceResult, 1)
+		go func() {
+			inference, err := cachedInferSystemModel(ctx, opts, bundleSetHash, summaries)
+			pending <- inferenceResult{inference, err}
+		}()
+	}
+
+	// Step 5: build deterministic sections.
+	inventory := buildInventory(bundles, s.EntrypointFuncs())
 	boundaries := buildBoundaries(bundles)
-	effects := buildEffects(bundles)
+	effects := buildEffects(bundles, opts.EffectSort)
 	concurrencyDomains := buildConcurrencyDomains(bundles)
+	callGraph, callGraphTruncated := buildCallGraph(bundles, opts.CallGraphLimit)
+	transitions := buildTransitions(bundles)
+	implementations := buildImplementations(bundles)
+	unreferenced := buildUnreferencedSymbols(bundles, inventory.Entrypoints)
+	cliSurface := buildCLISurface(bundles, inventory.Entrypoints)
+	var testedSymbols []TestedSymbol
+	if dir == root { // test bundles live next to sources, not in aggregates
+		tests, err := loadTestBundles(dir)
+		if err != nil {
+			return nil, fmt.Errorf("load test bundles: %w", err)
+		}
+		testedSymbols = buildTestedSymbols(bundles, tests)
+	}
 
-	// Step 4: build package summaries for LLM, filtering denied imports so
-	// the LLM does not wonder about packages it has no evidence for.
-	s, _ := settings.LoadSettings(root) // nil settings = no filtering
-	mod := readModuleName(root)
-	summaries := buildPackageSummaries(bundles, s, mod)
-
-	// Step 5: call LLM (skip if no summaries — nothing with signals).
-	var stateDomains []StateDomain
+	// Step 6: join (or make) the LLM call.
+	var stateDomains, pendingReview []StateDomain
+	var provenance string
 	var trustZones []TrustZone
 	var openQuestions []OpenQuestion
 
-	if len(summaries) > 0 {
-		inference, err := b.InferSystemModel(ctx, summaries)
-		if err != nil {
-			return nil, fmt.Errorf("infer system model: %w", err)
+	if infer {
+		var res inferenceResult
+		if pending != nil {
+			select {
+			case res = <-pending:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("infer system model: %w", ctx.Err())
+			}
+		} else {
+			res.inference, res.err = cachedInferSystemModel(ctx, opts, bundleSetHash, summaries)
+		}
+		if res.err != nil {
+			return nil, fmt.Errorf("infer system model: %w", res.err)
+		}
+		inference := res.inference
+		if opts.Provenance {
+			provenance, err = writeProvenance(dir, bundleSetHash, summaries, inference)
+			if err != nil {
+				return nil, fmt.Errorf("write provenance: %w", err)
+			}
 		}
+		splitMergedOwners(&inference, merged)
 		stateDomains = mapStateDomains(inference.State_domains, bundles)
+		decisions, err := ReadReviewDecisions(dir)
+		if err != nil {
+			return nil, err
+		}
+		stateDomains, pendingReview = applyReview(stateDomains, s.ReviewThreshold(), decisions)
 		trustZones = mapTrustZones(inference.Trust_zones, bundles)
 		openQuestions = mapOpenQuestions(inference.Open_questions)
 		// Annotate effects with their owning domain (requires LLM output).
 		linkEffectsToDomains(effects, stateDomains, bundles)
+		openQuestions = mergeOpenQuestions(openQuestions, ownershipConflictQuestions(stateDomains))
+		openQuestions = mergeOpenQuestions(openQuestions, unownedEffectQuestions(effects, stateDomains, bundles))
+	}
+	if opts.GroupConcurrency {
+		concurrencyDomains = groupConcurrencyDomains(concurrencyDomains, effects)
 	}
+	openQuestions = mergeOpenQuestions(openQuestions, ignoredErrorQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, droppedErrorQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, terminationQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, unboundedClientQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, defaultClientQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, blockingChannelQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, largeSwitchQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, uncheckedAssertionQuestions(bundles))
+	openQuestions = mergeOpenQuestions(openQuestions, mixedReceiverQuestions(bundles))
+	sharedState := buildSharedStateRisks(bundles, inventory.Packages, stateDomains)
 
 	return &SystemModel{
 		Version:     1,
 		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
 		Inputs: ModelInputs{
-			BundleSetSHA256: bundleSetHash,
+			BundleSetSHA256:  bundleSetHash,
+			InferenceSkipped: opts.NoLLM,
+			Provenance:       provenance,
 		},
 		Inventory:          inventory,
 		StateDomains:       stateDomains,
+		PendingReview:      pendingReview,
 		Boundaries:         boundaries,
 		Effects:            effects,
+		Transitions:        transitions,
+		Implementations:    implementations,
+		TestedSymbols:      testedSymbols,
+		Unreferenced:       unreferenced,
+		CLISurface:         cliSurface,
+		SharedState:        sharedState,
 		ConcurrencyDomains: concurrencyDomains,
+		CallGraph:          callGraph,
+		CallGraphTruncated: callGraphTruncated,
 		TrustZones:         trustZones,
 		OpenQuestions:      openQuestions,
 	}, nil
@@ -7,6 +7,8 @@ import (
 	"os"
 
 	"gopkg.in/yaml.v3"
+
+	"iguana/internal/canonical"
 )
 
 // ReadSystemModel reads and unmarshals a system_model.yaml file.
@@ -23,10 +25,13 @@ func ReadSystemModel(path string) (*SystemModel, error) {
 }
 
 // SystemModelUpToDate returns true if the system model at outputPath was
-// generated from the same set of evidence bundles currently in root (INV-51).
-// Returns false (without error) if the file does not exist or cannot be read.
+// generated from the same set of evidence bundles currently in root (INV-51),
+// a directory or aggregate file. Returns false (without error) if the model
+// file does not exist or cannot be read. A model generated without
+// inference (GenerateOptions.NoLLM) is up to date by this test; callers
+// that want the inferred sections check Inputs.InferenceSkipped.
 func SystemModelUpToDate(root, outputPath string) (bool, error) {
-	bundles, err := loadEvidenceBundles(root)
+	bundles, _, err := loadBundles(root)
 	if err != nil {
 		return false, fmt.Errorf("load bundles: %w", err)
 	}
@@ -42,7 +47,7 @@ func SystemModelUpToDate(root, outputPath string) (bool, error) {
 
 // WriteSystemModel marshals model to YAML and writes it to outputPath.
 func WriteSystemModel(model *SystemModel, outputPath string) error {
-	data, err := yaml.Marshal(model)
+	data, err := canonical.MarshalYAML(model)
 	if err != nil {
 		return fmt.Errorf("marshal: %w", err)
 	}
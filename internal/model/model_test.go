@@ -14,13 +14,24 @@ package model
 //   INV-31  bundle_set_sha256 derived from all bundle hashes
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
 
+	b "iguana/baml_client"
+	"iguana/baml_client/types"
 	"iguana/internal/evidence"
+	"iguana/internal/settings"
+	"iguana/internal/store"
 )
 
 // ---------------------------------------------------------------------------
@@ -148,6 +159,56 @@ func TestLoadEvidenceBundles_Basic(t *testing.T) {
 	}
 }
 
+// TestLoadEvidenceBundles_PackageBundle verifies that a package bundle
+// replaces the per-file Go bundles of its directory, that other
+// directories keep theirs, and that the inventory lists its files.
+func TestLoadEvidenceBundles_PackageBundle(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"p", "q"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := makeTestBundle("p/a.go", "aa", "p", evidence.Signals{})
+	b := makeTestBundle("p/b.go", "bb", "p", evidence.Signals{FSReads: true})
+	writeTestBundle(t, filepath.Join(dir, "p"), "a.go", a)
+	writeTestBundle(t, filepath.Join(dir, "p"), "package", evidence.MergePackageBundle("p", []*evidence.EvidenceBundle{a, b}))
+	writeTestBundle(t, filepath.Join(dir, "q"), "q.go", makeTestBundle("q/q.go", "qq", "q", evidence.Signals{}))
+
+	bundles, err := loadEvidenceBundles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, bnd := range bundles {
+		paths = append(paths, bnd.File.Path)
+	}
+	if want := []string{"p/package", "q/q.go"}; !slices.Equal(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	if !bundles[0].Signals.FSReads {
+		t.Error("package bundle lost the merged fs_reads signal")
+	}
+
+	inv := buildInventory(bundles, nil)
+	if len(inv.Packages) != 2 || !slices.Equal(inv.Packages[0].Files, []string{"p/a.go", "p/b.go"}) {
+		t.Errorf("packages = %+v", inv.Packages)
+	}
+}
+
+// TestLoadEvidenceBundles_FutureVersion verifies that a bundle written by a
+// newer schema fails the load instead of being half-read.
+func TestLoadEvidenceBundles_FutureVersion(t *testing.T) {
+	dir := t.TempDir()
+	bundle := makeTestBundle("foo.go", "abcd", "foo", evidence.Signals{})
+	bundle.Version = evidence.CurrentVersion + 1
+	writeTestBundle(t, dir, "foo.go", bundle)
+
+	if _, err := loadEvidenceBundles(dir); !errors.Is(err, evidence.ErrUnsupportedVersion) {
+		t.Errorf("err = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Unit tests — computeBundleSetHash (INV-31)
 // ---------------------------------------------------------------------------
@@ -179,7 +240,7 @@ func TestBuildInventory_GroupsByPackage(t *testing.T) {
 	b1 := makeTestBundle("pkg/foo.go", "a", "auth", evidence.Signals{})
 	b2 := makeTestBundle("pkg/bar.go", "b", "auth", evidence.Signals{})
 
-	inv := buildInventory([]*evidence.EvidenceBundle{b1, b2})
+	inv := buildInventory([]*evidence.EvidenceBundle{b1, b2}, nil)
 
 	if len(inv.Packages) != 1 {
 		t.Fatalf("expected 1 package, got %d", len(inv.Packages))
@@ -197,6 +258,256 @@ func TestBuildInventory_GroupsByPackage(t *testing.T) {
 	}
 }
 
+// TestBuildInventory_Modules verifies that module membership is recorded
+// only when the bundles span more than one module.
+func TestBuildInventory_Modules(t *testing.T) {
+	api := makeTestBundle("api/api.go", "a", "api", evidence.Signals{})
+	api.Package.Module = "example.com/api"
+	lib := makeTestBundle("lib/lib.go", "b", "lib", evidence.Signals{})
+	lib.Package.Module = "example.com/lib"
+
+	inv := buildInventory([]*evidence.EvidenceBundle{api, lib}, nil)
+	if !reflect.DeepEqual(inv.Modules, []string{"example.com/api", "example.com/lib"}) {
+		t.Errorf("Modules = %v", inv.Modules)
+	}
+	if !reflect.DeepEqual(inv.Packages[1].Modules, []string{"example.com/lib"}) {
+		t.Errorf("lib Modules = %v", inv.Packages[1].Modules)
+	}
+
+	lib.Package.Module = "example.com/api"
+	inv = buildInventory([]*evidence.EvidenceBundle{api, lib}, nil)
+	if inv.Modules != nil || inv.Packages[0].Modules != nil {
+		t.Errorf("single module recorded: %v, %v", inv.Modules, inv.Packages[0].Modules)
+	}
+}
+
+// TestBuildInventory_ModulesSamePackage verifies that same-named packages
+// of two modules stay separate units, keyed by directory, and that imports
+// resolve within the importing package's module.
+func TestBuildInventory_ModulesSamePackage(t *testing.T) {
+	bundle := func(file, sha, name, module string, imports ...string) *evidence.EvidenceBundle {
+		b := makeTestBundle(file, sha, name, evidence.Signals{FSReads: true})
+		b.Package.Module = module
+		for _, imp := range imports {
+			b.Package.Imports = append(b.Package.Imports, evidence.Import{Path: imp})
+		}
+		return b
+	}
+	bundles := []*evidence.EvidenceBundle{
+		bundle("api/main.go", "a", "main", "example.com/api", "example.com/api/internal/config"),
+		bundle("api/internal/config/config.go", "b", "config", "example.com/api"),
+		bundle("worker/main.go", "c", "main", "example.com/worker", "example.com/worker/internal/config", "example.com/api"),
+		bundle("worker/internal/config/config.go", "d", "config", "example.com/worker", "os"),
+	}
+
+	inv := buildInventory(bundles, nil)
+	var names []string
+	imports := make(map[string][]string)
+	for _, p := range inv.Packages {
+		names = append(names, p.Name)
+		imports[p.Name] = p.Imports
+	}
+	if want := []string{"api", "api/internal/config", "worker", "worker/internal/config"}; !slices.Equal(names, want) {
+		t.Fatalf("packages = %v, want %v", names, want)
+	}
+	if got := imports["api"]; !slices.Equal(got, []string{"api/internal/config"}) {
+		t.Errorf("api imports = %v", got)
+	}
+	if got := imports["worker"]; !slices.Equal(got, []string{"api", "worker/internal/config"}) {
+		t.Errorf("worker imports = %v", got)
+	}
+
+	var summaries []string
+	for _, s := range collectPackageSummaries(bundles, nil, "") {
+		summaries = append(summaries, s.Name)
+	}
+	if want := []string{"api", "api/internal/config", "worker", "worker/internal/config"}; !slices.Equal(summaries, want) {
+		t.Errorf("summaries = %v, want %v", summaries, want)
+	}
+}
+
+// TestBuildInventory_ExportedSymbolCount verifies that the public API surface
+// sums exported functions, methods, types, vars, and consts across files,
+// and lists the package's error sentinels.
+func TestBuildInventory_ExportedSymbolCount(t *testing.T) {
+	b1 := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/store.go", SHA256: "a"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Functions: []evidence.Function{
+				{Name: "Get", Exported: true, Receiver: "*Store"},
+				{Name: "NewStore", Exported: true},
+				{Name: "helper", Exported: false},
+			},
+			Types: []evidence.TypeDecl{
+				{Name: "Store", Kind: "struct", Exported: true},
+				{Name: "entry", Kind: "struct", Exported: false},
+			},
+		},
+	}
+	b2 := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/errors.go", SHA256: "b"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Variables:      []evidence.VarDecl{{Name: "ErrNotFound", Exported: true}, {Name: "cache"}},
+			Constants:      []evidence.VarDecl{{Name: "MaxKeys", Exported: true}},
+			ErrorSentinels: []string{"ErrNotFound"},
+		},
+	}
+
+	inv := buildInventory([]*evidence.EvidenceBundle{b1, b2}, nil)
+
+	if len(inv.Packages) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(inv.Packages))
+	}
+	if got := inv.Packages[0].ExportedSymbolCount; got != 5 {
+		t.Errorf("ExportedSymbolCount = %d, want 5", got)
+	}
+	if got := inv.Packages[0].ErrorSentinels; !reflect.DeepEqual(got, []string{"ErrNotFound"}) {
+		t.Errorf("ErrorSentinels = %v, want [ErrNotFound]", got)
+	}
+}
+
+// TestBuildInventory_ExportedSymbolCountDedup verifies that symbols spread
+// across files — a type and its constructor, plus a function declared in two
+// build-tagged variants — are each counted once.
+func TestBuildInventory_ExportedSymbolCountDedup(t *testing.T) {
+	typeFile := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/store.go", SHA256: "a"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Types:     []evidence.TypeDecl{{Name: "Store", Kind: "struct", Exported: true}},
+			Functions: []evidence.Function{{Name: "Close", Exported: true, Receiver: "*Store"}},
+		},
+	}
+	ctorFile := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/new.go", SHA256: "b"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Functions:    []evidence.Function{{Name: "NewStore", Exported: true}},
+			Constructors: []string{"NewStore"},
+		},
+	}
+	linux := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/path_linux.go", SHA256: "c"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Functions: []evidence.Function{{Name: "DefaultPath", Exported: true}},
+		},
+	}
+	darwin := &evidence.EvidenceBundle{
+		Version: 2,
+		File:    evidence.FileMeta{Path: "store/path_darwin.go", SHA256: "d"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Symbols: evidence.Symbols{
+			Functions: []evidence.Function{{Name: "DefaultPath", Exported: true}},
+		},
+	}
+
+	inv := buildInventory([]*evidence.EvidenceBundle{typeFile, ctorFile, linux, darwin}, nil)
+
+	// Store, (*Store).Close, NewStore, DefaultPath.
+	if got := inv.Packages[0].ExportedSymbolCount; got != 4 {
+		t.Errorf("ExportedSymbolCount = %d, want 4", got)
+	}
+}
+
+// TestBuildInventory_GoGenerate verifies that go:generate directives are
+// merged across a package's files, deduped, and sorted.
+func TestBuildInventory_GoGenerate(t *testing.T) {
+	b1 := makeTestBundle("store/a.go", "a", "store", evidence.Signals{})
+	b1.Package.GoGenerate = []string{"stringer -type=Kind"}
+	b2 := makeTestBundle("store/b.go", "b", "store", evidence.Signals{})
+	b2.Package.GoGenerate = []string{"mockgen -source=b.go", "stringer -type=Kind"}
+	b3 := makeTestBundle("api/c.go", "c", "api", evidence.Signals{})
+
+	inv := buildInventory([]*evidence.EvidenceBundle{b1, b2, b3}, nil)
+
+	if len(inv.Packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(inv.Packages))
+	}
+	if got := inv.Packages[0].GoGenerate; got != nil {
+		t.Errorf("api GoGenerate = %v, want nil", got)
+	}
+	want := []string{"mockgen -source=b.go", "stringer -type=Kind"}
+	if got := inv.Packages[1].GoGenerate; !reflect.DeepEqual(got, want) {
+		t.Errorf("store GoGenerate = %v, want %v", got, want)
+	}
+}
+
+// TestBuildInventory_CustomSignals verifies that a package lists the union
+// of its files' user-defined signals, and that its LLM summary carries them
+// even when no built-in signal is set.
+func TestBuildInventory_CustomSignals(t *testing.T) {
+	b1 := makeTestBundle("billing/a.go", "a", "billing", evidence.Signals{Custom: []string{"stripe"}})
+	b2 := makeTestBundle("billing/b.go", "b", "billing", evidence.Signals{Custom: []string{"pii", "stripe"}})
+	b3 := makeTestBundle("api/c.go", "c", "api", evidence.Signals{})
+	bundles := []*evidence.EvidenceBundle{b1, b2, b3}
+
+	inv := buildInventory(bundles, nil)
+	if got := inv.Packages[0].CustomSignals; got != nil {
+		t.Errorf("api CustomSignals = %v, want nil", got)
+	}
+	want := []string{"pii", "stripe"}
+	if got := inv.Packages[1].CustomSignals; !reflect.DeepEqual(got, want) {
+		t.Errorf("billing CustomSignals = %v, want %v", got, want)
+	}
+
+	summaries := collectPackageSummaries(bundles, nil, "")
+	if len(summaries) != 1 || summaries[0].Name != "billing" || !reflect.DeepEqual(summaries[0].Signals.Custom, want) {
+		t.Errorf("summaries = %+v, want billing with custom signals %v", summaries, want)
+	}
+}
+
+// TestBuildInventory_MixedLanguages verifies that a non-Go bundle forms its
+// own language-qualified unit and is excluded from Go import matching.
+func TestBuildInventory_MixedLanguages(t *testing.T) {
+	goUtil := makeTestBundle("util/util.go", "a", "util", evidence.Signals{FSReads: true})
+	goAPI := makeTestBundle("api/api.go", "b", "api", evidence.Signals{})
+	goAPI.Package.Imports = []evidence.Import{{Path: "example.com/app/util"}}
+	pyUtil := makeTestBundle("scripts/util.py", "c", "util", evidence.Signals{NetCalls: true})
+	pyUtil.Language = "python"
+	pyUtil.Package.Imports = []evidence.Import{{Path: "api"}, {Path: "requests"}}
+	bundles := []*evidence.EvidenceBundle{goAPI, pyUtil, goUtil}
+
+	inv := buildInventory(bundles, nil)
+
+	var names []string
+	for _, p := range inv.Packages {
+		names = append(names, p.Name)
+	}
+	if want := []string{"api", "python:util", "util"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("package names = %v, want %v", names, want)
+	}
+	if got := inv.Packages[0].Imports; !reflect.DeepEqual(got, []string{"util"}) {
+		t.Errorf("api Imports = %v, want [util]", got)
+	}
+	py := inv.Packages[1]
+	if py.Language != "python" || py.Imports != nil {
+		t.Errorf("python unit = %+v, want language python and no imports", py)
+	}
+	if inv.Packages[2].Language != "" {
+		t.Errorf("Go unit Language = %q, want empty", inv.Packages[2].Language)
+	}
+
+	summaries := buildPackageSummaries(bundles, nil, "example.com/app")
+	var summaryNames []string
+	for _, s := range summaries {
+		summaryNames = append(summaryNames, s.Name)
+	}
+	if want := []string{"python:util", "util"}; !reflect.DeepEqual(summaryNames, want) {
+		t.Errorf("summary names = %v, want %v", summaryNames, want)
+	}
+	if want := []string{"api", "requests"}; !reflect.DeepEqual(summaries[0].Imports, want) {
+		t.Errorf("python summary Imports = %v, want %v", summaries[0].Imports, want)
+	}
+}
+
 // TestBuildInventory_Entrypoints verifies that a package=main bundle with a
 // main function symbol is identified as an entrypoint.
 func TestBuildInventory_Entrypoints(t *testing.T) {
@@ -209,7 +520,7 @@ func TestBuildInventory_Entrypoints(t *testing.T) {
 		},
 	}
 
-	inv := buildInventory([]*evidence.EvidenceBundle{b1})
+	inv := buildInventory([]*evidence.EvidenceBundle{b1}, nil)
 
 	if len(inv.Entrypoints) != 1 {
 		t.Fatalf("expected 1 entrypoint, got %d", len(inv.Entrypoints))
@@ -223,6 +534,37 @@ func TestBuildInventory_Entrypoints(t *testing.T) {
 	}
 }
 
+// TestBuildInventory_MultipleMains verifies that two cmd/ main packages are
+// recorded as distinct entrypoints keyed by directory, alongside TestMain
+// and a settings-provided entrypoint name; methods are never entrypoints.
+func TestBuildInventory_MultipleMains(t *testing.T) {
+	api := makeTestBundle("cmd/api/main.go", "a", "main", evidence.Signals{})
+	api.Symbols.Functions = []evidence.Function{{Name: "main"}, {Name: "serve"}}
+	worker := makeTestBundle("cmd/worker/main.go", "b", "main", evidence.Signals{})
+	worker.Symbols.Functions = []evidence.Function{{Name: "main"}}
+	tests := makeTestBundle("store/main_test.go", "c", "store", evidence.Signals{})
+	tests.Symbols.Functions = []evidence.Function{{Name: "TestMain", Exported: true}}
+	fn := makeTestBundle("fn/handler.go", "d", "fn", evidence.Signals{})
+	fn.Symbols.Functions = []evidence.Function{
+		{Name: "Handler", Exported: true},
+		{Name: "Handler", Exported: true, Receiver: "*Server"},
+	}
+
+	inv := buildInventory([]*evidence.EvidenceBundle{api, fn, tests, worker}, []string{"Handler"})
+
+	var got []string
+	for _, ep := range inv.Entrypoints {
+		got = append(got, ep.Dir+" "+ep.Package+"."+ep.Symbol)
+	}
+	want := []string{"cmd/api main.main", "cmd/worker main.main", "fn fn.Handler", "store store.TestMain"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entrypoints = %v, want %v", got, want)
+	}
+	if len(inv.Packages) != 3 {
+		t.Errorf("expected both mains grouped under one package entry, got %d packages", len(inv.Packages))
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Unit tests — buildBoundaries
 // ---------------------------------------------------------------------------
@@ -266,6 +608,278 @@ func TestBuildBoundaries_NetCalls(t *testing.T) {
 	}
 }
 
+// TestBuildBoundaries_Subprocess verifies that a bundle with ExecsSubprocess
+// produces a subprocess process boundary and a clean bundle does not.
+func TestBuildBoundaries_Subprocess(t *testing.T) {
+	runner := makeTestBundle("tools/run.go", "x", "tools", evidence.Signals{ExecsSubprocess: true})
+	clean := makeTestBundle("tools/fmt.go", "y", "tools", evidence.Signals{})
+
+	boundaries := buildBoundaries([]*evidence.EvidenceBundle{runner, clean})
+
+	if len(boundaries.Process) != 1 || boundaries.Process[0].Kind != "subprocess" {
+		t.Fatalf("expected one subprocess boundary, got %+v", boundaries.Process)
+	}
+	refs := boundaries.Process[0].EvidenceRefs
+	if len(refs) != 1 || !strings.Contains(refs[0], "tools/run.go") || !strings.Contains(refs[0], "signal:execs_subprocess") {
+		t.Errorf("unexpected evidence refs: %v", refs)
+	}
+
+	if got := buildBoundaries([]*evidence.EvidenceBundle{clean}); len(got.Process) != 0 {
+		t.Errorf("expected no process boundary for a clean bundle, got %+v", got.Process)
+	}
+}
+
+// TestBuildBoundaries_GRPC verifies that each service in a .proto bundle
+// becomes a grpc API boundary listing its methods.
+func TestBuildBoundaries_GRPC(t *testing.T) {
+	proto := makeTestBundle("api/billing.proto", "x", "acme.billing", evidence.Signals{})
+	proto.Language = evidence.LanguageProto
+	proto.Symbols.Types = []evidence.TypeDecl{
+		{Name: "Billing", Kind: "service"},
+		{Name: "ChargeRequest", Kind: "message"},
+	}
+	proto.Symbols.Functions = []evidence.Function{
+		{Name: "Charge", Receiver: "Billing"},
+		{Name: "Refund", Receiver: "Billing"},
+	}
+
+	boundaries := buildBoundaries([]*evidence.EvidenceBundle{proto})
+
+	want := []APIBoundary{{
+		Kind:         "grpc",
+		Service:      "acme.billing.Billing",
+		Methods:      []string{"Charge", "Refund"},
+		EvidenceRefs: []string{"bundle:api/billing.proto@v2#symbol:Billing"},
+	}}
+	if !reflect.DeepEqual(boundaries.API, want) {
+		t.Errorf("API = %+v, want %+v", boundaries.API, want)
+	}
+}
+
+// TestIgnoredErrorQuestions verifies that a package discarding many error
+// results seeds an open question and a package below the threshold does not.
+func TestIgnoredErrorQuestions(t *testing.T) {
+	b1 := makeTestBundle("api/a.go", "a", "api", evidence.Signals{IgnoredErrors: 2})
+	b2 := makeTestBundle("api/b.go", "b", "api", evidence.Signals{IgnoredErrors: 1})
+	b3 := makeTestBundle("store/s.go", "c", "store", evidence.Signals{IgnoredErrors: 1})
+
+	qs := ignoredErrorQuestions([]*evidence.EvidenceBundle{b1, b2, b3})
+
+	if len(qs) != 1 {
+		t.Fatalf("expected 1 question, got %d: %+v", len(qs), qs)
+	}
+	if !strings.Contains(qs[0].Question, "api discards 3 error results") {
+		t.Errorf("unexpected question: %q", qs[0].Question)
+	}
+	want := []string{"api/a.go", "api/b.go"}
+	if !reflect.DeepEqual(qs[0].MissingEvidence, want) {
+		t.Errorf("MissingEvidence = %v, want %v", qs[0].MissingEvidence, want)
+	}
+}
+
+// TestTerminationQuestions verifies that exiting outside main and panicking
+// without a recover in the package each seed a question, while main's exits
+// and recovered panics do not.
+func TestTerminationQuestions(t *testing.T) {
+	bundles := []*evidence.EvidenceBundle{
+		makeTestBundle("cmd/main.go", "a", "main", evidence.Signals{Exits: true}),
+		makeTestBundle("store/a.go", "b", "store", evidence.Signals{Exits: true, Panics: true}),
+		makeTestBundle("worker/w.go", "c", "worker", evidence.Signals{Panics: true}),
+		makeTestBundle("worker/pool.go", "d", "worker", evidence.Signals{Recovers: true}),
+	}
+
+	qs := terminationQuestions(bundles)
+
+	if len(qs) != 2 {
+		t.Fatalf("expected 2 questions, got %d: %+v", len(qs), qs)
+	}
+	for i, want := range []string{"store exits the process", "store panics and never recovers"} {
+		if !strings.Contains(qs[i].Question, want) {
+			t.Errorf("question %d = %q, want it to mention %q", i, qs[i].Question, want)
+		}
+		if !reflect.DeepEqual(qs[i].MissingEvidence, []string{"store/a.go"}) {
+			t.Errorf("question %d MissingEvidence = %v", i, qs[i].MissingEvidence)
+		}
+	}
+}
+
+// TestUncheckedAssertionQuestions verifies that a package with unchecked
+// type assertions seeds one question listing its files.
+func TestUncheckedAssertionQuestions(t *testing.T) {
+	b1 := makeTestBundle("api/a.go", "a", "api", evidence.Signals{UncheckedAssertions: 1})
+	b2 := makeTestBundle("api/b.go", "b", "api", evidence.Signals{UncheckedAssertions: 2})
+	b3 := makeTestBundle("store/s.go", "c", "store", evidence.Signals{})
+
+	qs := uncheckedAssertionQuestions([]*evidence.EvidenceBundle{b1, b2, b3})
+
+	if len(qs) != 1 || !strings.Contains(qs[0].Question, "api has 3 unchecked type assertion(s)") {
+		t.Fatalf("unexpected questions: %+v", qs)
+	}
+	want := []string{"api/a.go", "api/b.go"}
+	if !reflect.DeepEqual(qs[0].MissingEvidence, want) {
+		t.Errorf("MissingEvidence = %v, want %v", qs[0].MissingEvidence, want)
+	}
+}
+
+// TestMixedReceiverQuestions verifies that a type with pointer and value
+// receiver methods in different files is flagged and a type with only
+// pointer receivers is not.
+func TestMixedReceiverQuestions(t *testing.T) {
+	b1 := makeTestBundle("store/get.go", "a", "store", evidence.Signals{})
+	b1.Symbols.Functions = []evidence.Function{
+		{Name: "Get", Receiver: "*Store"},
+		{Name: "Open", Receiver: "*Conn"},
+	}
+	b2 := makeTestBundle("store/len.go", "b", "store", evidence.Signals{})
+	b2.Symbols.Functions = []evidence.Function{
+		{Name: "Len", Receiver: "Store"},
+		{Name: "Close", Receiver: "*Conn"},
+		{Name: "New"},
+	}
+
+	qs := mixedReceiverQuestions([]*evidence.EvidenceBundle{b1, b2})
+
+	if len(qs) != 1 || !strings.Contains(qs[0].Question, "Type store.Store has both pointer and value receiver methods") {
+		t.Fatalf("unexpected questions: %+v", qs)
+	}
+	want := []string{"store/get.go", "store/len.go"}
+	if !reflect.DeepEqual(qs[0].MissingEvidence, want) {
+		t.Errorf("MissingEvidence = %v, want %v", qs[0].MissingEvidence, want)
+	}
+}
+
+// TestUnboundedClientQuestions verifies that files building a timeout-less
+// http.Client seed one reliability question listing them.
+func TestUnboundedClientQuestions(t *testing.T) {
+	b1 := makeTestBundle("api/b.go", "a", "api", evidence.Signals{NetCalls: true, UnboundedHTTPClient: true})
+	b2 := makeTestBundle("api/a.go", "b", "api", evidence.Signals{NetCalls: true, UnboundedHTTPClient: true})
+	b3 := makeTestBundle("hook/h.go", "c", "hook", evidence.Signals{NetCalls: true})
+
+	qs := unboundedClientQuestions([]*evidence.EvidenceBundle{b1, b2, b3})
+
+	if len(qs) != 1 {
+		t.Fatalf("expected 1 question, got %d", len(qs))
+	}
+	want := []string{"api/a.go", "api/b.go"}
+	if !reflect.DeepEqual(qs[0].MissingEvidence, want) {
+		t.Errorf("MissingEvidence = %v, want %v", qs[0].MissingEvidence, want)
+	}
+	if got := unboundedClientQuestions([]*evidence.EvidenceBundle{b3}); got != nil {
+		t.Errorf("expected no question without unbounded clients, got %+v", got)
+	}
+}
+
+// TestDefaultClientQuestions verifies that files using http.DefaultClient
+// seed one question listing them and other net-calling files do not.
+func TestDefaultClientQuestions(t *testing.T) {
+	b1 := makeTestBundle("hook/h.go", "a", "hook", evidence.Signals{NetCalls: true, UsesDefaultHTTPClient: true})
+	b2 := makeTestBundle("api/a.go", "b", "api", evidence.Signals{NetCalls: true})
+
+	qs := defaultClientQuestions([]*evidence.EvidenceBundle{b1, b2})
+
+	if len(qs) != 1 || !reflect.DeepEqual(qs[0].MissingEvidence, []string{"hook/h.go"}) {
+		t.Errorf("unexpected questions: %+v", qs)
+	}
+	if got := defaultClientQuestions([]*evidence.EvidenceBundle{b2}); got != nil {
+		t.Errorf("expected no question without default-client use, got %+v", got)
+	}
+}
+
+// TestBlockingChannelQuestions verifies that files with bare channel
+// operations seed one concurrency question listing them.
+func TestBlockingChannelQuestions(t *testing.T) {
+	b1 := makeTestBundle("worker/pool.go", "a", "worker", evidence.Signals{Concurrency: true, BlockingChannelOps: true})
+	b2 := makeTestBundle("worker/sched.go", "b", "worker", evidence.Signals{Concurrency: true})
+
+	qs := blockingChannelQuestions([]*evidence.EvidenceBundle{b1, b2})
+
+	if len(qs) != 1 {
+		t.Fatalf("expected 1 question, got %d", len(qs))
+	}
+	if want := []string{"worker/pool.go"}; !reflect.DeepEqual(qs[0].MissingEvidence, want) {
+		t.Errorf("MissingEvidence = %v, want %v", qs[0].MissingEvidence, want)
+	}
+}
+
+// TestOwnershipConflicts verifies that a package owned by two domains is
+// reported, both as a conflict and as an open question.
+func TestOwnershipConflicts(t *testing.T) {
+	domains := []StateDomain{
+		{ID: "orders", Owners: []string{"shared", "store"}},
+		{ID: "carts", Owners: []string{"shared"}},
+		{ID: "users", Owners: []string{"auth"}},
+	}
+
+	conflicts := OwnershipConflicts(domains)
+	want := []OwnershipConflict{{Package: "shared", Domains: []string{"carts", "orders"}}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("OwnershipConflicts = %+v, want %+v", conflicts, want)
+	}
+
+	qs := ownershipConflictQuestions(domains)
+	if len(qs) != 1 || qs[0].Question != "Package shared is claimed by domains carts and orders; which one owns its state?" {
+		t.Errorf("unexpected questions: %+v", qs)
+	}
+}
+
+// TestUnownedEffectQuestions verifies that an effectful package absent from
+// every domain's Owners is flagged while owned and effect-free ones are not.
+func TestUnownedEffectQuestions(t *testing.T) {
+	bundles := []*evidence.EvidenceBundle{
+		makeTestBundle("store/db.go", "a", "store", evidence.Signals{DBCalls: true}),
+		makeTestBundle("cache/disk.go", "b", "cache", evidence.Signals{FSReads: true, FSWrites: true}),
+		makeTestBundle("util/strings.go", "c", "util", evidence.Signals{}),
+	}
+	effects := buildEffects(bundles, EffectSortByKind)
+	domains := []StateDomain{{ID: "orders", Owners: []string{"store"}}}
+
+	qs := unownedEffectQuestions(effects, domains, bundles)
+
+	want := "Package cache has effects (fs_read, fs_write) but no owning state domain; which domain does it belong to?"
+	if len(qs) != 1 || qs[0].Question != want {
+		t.Errorf("unexpected questions: %+v", qs)
+	}
+}
+
+// TestLargeSwitchQuestions verifies that only the files with the most large
+// switches are reported.
+func TestLargeSwitchQuestions(t *testing.T) {
+	var bundles []*evidence.EvidenceBundle
+	for i, n := range []int{1, 4, 2, 3, 0} {
+		path := fmt.Sprintf("pkg/f%d.go", i)
+		bundles = append(bundles, makeTestBundle(path, path, "pkg", evidence.Signals{LargeSwitches: n}))
+	}
+
+	qs := largeSwitchQuestions(bundles)
+
+	var got []string
+	for _, q := range qs {
+		got = append(got, q.MissingEvidence[0])
+	}
+	want := []string{"pkg/f1.go", "pkg/f2.go", "pkg/f3.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reported files = %v, want %v", got, want)
+	}
+}
+
+// TestBuildBoundaries_Resilience verifies that files with the resilience
+// signal are listed alongside outbound network files.
+func TestBuildBoundaries_Resilience(t *testing.T) {
+	bundles := []*evidence.EvidenceBundle{
+		makeTestBundle("api/client.go", "x", "api", evidence.Signals{NetCalls: true}),
+		makeTestBundle("api/retry.go", "y", "api", evidence.Signals{Resilience: true}),
+	}
+
+	boundaries := buildBoundaries(bundles)
+
+	if boundaries.Network == nil {
+		t.Fatal("expected network boundary, got nil")
+	}
+	if len(boundaries.Network.Resilient) != 1 || boundaries.Network.Resilient[0].File != "api/retry.go" {
+		t.Errorf("Resilient = %v, want [api/retry.go]", boundaries.Network.Resilient)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Unit tests — buildEffects (INV-28)
 // ---------------------------------------------------------------------------
@@ -279,7 +893,7 @@ func TestBuildEffects_FromSignals(t *testing.T) {
 		makeTestBundle("net.go", "c", "http", evidence.Signals{NetCalls: true}),
 	}
 
-	effects := buildEffects(bundles)
+	effects := buildEffects(bundles, EffectSortByKind)
 
 	kinds := make(map[string]bool)
 	for _, e := range effects {
@@ -300,7 +914,7 @@ func TestBuildEffects_Sorted(t *testing.T) {
 		makeTestBundle("a.go", "b", "pkg", evidence.Signals{FSReads: true, DBCalls: true}),
 	}
 
-	effects := buildEffects(bundles)
+	effects := buildEffects(bundles, EffectSortByKind)
 
 	for i := 1; i < len(effects); i++ {
 		prev, curr := effects[i-1], effects[i]
@@ -313,6 +927,89 @@ func TestBuildEffects_Sorted(t *testing.T) {
 	}
 }
 
+// TestBuildEffects_SortOrders verifies that by-file keeps each file's
+// effects contiguous and that by-kind matches the empty default.
+func TestBuildEffects_SortOrders(t *testing.T) {
+	bundles := []*evidence.EvidenceBundle{
+		makeTestBundle("a.go", "a", "pkg", evidence.Signals{FSReads: true, NetCalls: true}),
+		makeTestBundle("z.go", "b", "pkg", evidence.Signals{FSReads: true, DBCalls: true}),
+	}
+	pairs := func(effects []Effect) []string {
+		var out []string
+		for _, e := range effects {
+			out = append(out, e.Via+":"+e.Kind)
+		}
+		return out
+	}
+
+	byFile := pairs(buildEffects(bundles, EffectSortByFile))
+	wantFile := []string{"a.go:fs_read", "a.go:net_call", "z.go:db_write", "z.go:fs_read"}
+	if !reflect.DeepEqual(byFile, wantFile) {
+		t.Errorf("by-file = %v, want %v", byFile, wantFile)
+	}
+
+	byKind := pairs(buildEffects(bundles, EffectSortByKind))
+	wantKind := []string{"z.go:db_write", "a.go:fs_read", "z.go:fs_read", "a.go:net_call"}
+	if !reflect.DeepEqual(byKind, wantKind) {
+		t.Errorf("by-kind = %v, want %v", byKind, wantKind)
+	}
+	if def := pairs(buildEffects(bundles, "")); !reflect.DeepEqual(def, byKind) {
+		t.Errorf("default = %v, want by-kind %v", def, byKind)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Unit tests — groupMethodsByType
+// ---------------------------------------------------------------------------
+
+// TestGroupMethodsByType verifies that pointer and value receiver methods of
+// the same type are grouped together, while the raw receiver is preserved.
+func TestGroupMethodsByType(t *testing.T) {
+	fns := []evidence.Function{
+		{Name: "Start", Exported: true, Receiver: "*Server"},
+		{Name: "Addr", Exported: true, Receiver: "Server"},
+		{Name: "stop", Exported: false, Receiver: "*Server"},
+		{Name: "Push", Exported: true, Receiver: "*List[E]"},
+		{Name: "New", Exported: true},
+	}
+
+	groups := groupMethodsByType(fns)
+
+	if got := groups["Server"]; len(got) != 2 || got[0] != "Addr" || got[1] != "Start" {
+		t.Errorf("Server methods = %v, want [Addr Start]", got)
+	}
+	if got := groups["List"]; len(got) != 1 || got[0] != "Push" {
+		t.Errorf("List methods = %v, want [Push]", got)
+	}
+	if _, ok := groups["*Server"]; ok {
+		t.Error("pointer receiver should not form its own group")
+	}
+	if len(groups) != 2 {
+		t.Errorf("expected 2 groups, got %d: %v", len(groups), groups)
+	}
+	if fns[0].Receiver != "*Server" {
+		t.Errorf("raw receiver modified: %q", fns[0].Receiver)
+	}
+}
+
+// TestFormatInterfaceDesc verifies that an interface is described by its
+// exported method signatures and other kinds are not described.
+func TestFormatInterfaceDesc(t *testing.T) {
+	td := evidence.TypeDecl{Name: "Store", Kind: "interface", Exported: true, Methods: []evidence.Method{
+		{Name: "Get", Exported: true, Params: []string{"string"}, Returns: []string{"[]byte", "error"}},
+		{Name: "Put", Exported: true, Params: []string{"string", "[]byte"}, Returns: []string{"error"}},
+		{Name: "close", Returns: []string{"error"}},
+	}}
+	want := "Store interface{Get(string) ([]byte, error); Put(string, []byte) error}"
+	if got := formatInterfaceDesc(td); got != want {
+		t.Errorf("formatInterfaceDesc = %q, want %q", got, want)
+	}
+	td.Kind = "struct"
+	if got := formatInterfaceDesc(td); got != "" {
+		t.Errorf("struct description = %q, want empty", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Unit tests — SystemModelUpToDate (INV-51)
 // ---------------------------------------------------------------------------
@@ -407,3 +1104,1067 @@ func TestSystemModelUpToDate_NoBundles(t *testing.T) {
 		t.Error("expected not up to date when no bundles exist")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Unit tests — buildCallGraph
+// ---------------------------------------------------------------------------
+
+// TestBuildCallGraph_UnionsAndDedupes verifies that edges from two files are
+// unioned, package-qualified, deduped, and sorted by (from, to).
+func TestBuildCallGraph_UnionsAndDedupes(t *testing.T) {
+	b1 := makeTestBundle("store/a.go", "a", "store", evidence.Signals{})
+	b1.Calls = []evidence.Call{
+		{From: "Save", To: "encode"},
+		{From: "Save", To: "len"},
+		{From: "Save", To: "os.WriteFile"},
+	}
+	b2 := makeTestBundle("store/b.go", "b", "store", evidence.Signals{})
+	b2.Calls = []evidence.Call{
+		{From: "Save", To: "os.WriteFile"}, // duplicate across files
+		{From: "encode", To: "json.Marshal"},
+	}
+
+	edges, truncated := buildCallGraph([]*evidence.EvidenceBundle{b1, b2}, 0)
+
+	want := []CallEdge{
+		{From: "store.Save", To: "os.WriteFile"},
+		{From: "store.Save", To: "store.encode"},
+		{From: "store.encode", To: "json.Marshal"},
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+	if truncated {
+		t.Error("expected truncated = false under the default limit")
+	}
+
+	edges, truncated = buildCallGraph([]*evidence.EvidenceBundle{b1, b2}, 2)
+	if len(edges) != 2 || !truncated {
+		t.Errorf("limit 2: got %d edges, truncated = %v", len(edges), truncated)
+	}
+}
+
+// TestBuildTransitions_ResolvesAcrossBundles verifies that calls resolve to
+// functions and methods defined in other bundles, same-package targets stay
+// in the caller's directory, and unresolved targets are dropped.
+func TestBuildTransitions_ResolvesAcrossBundles(t *testing.T) {
+	api := makeTestBundle("cmd/api/main.go", "a", "main", evidence.Signals{})
+	api.Symbols.Functions = []evidence.Function{{Name: "main"}, {Name: "run"}}
+	api.Calls = []evidence.Call{
+		{From: "main", To: "run"},
+		{From: "run", To: "store.Open"},
+		{From: "run.<anonymous>", To: "Save"},
+		{From: "run", To: "fmt.Println"},
+	}
+	tool := makeTestBundle("cmd/tool/main.go", "b", "main", evidence.Signals{})
+	tool.Symbols.Functions = []evidence.Function{{Name: "run"}}
+	store := makeTestBundle("store/store.go", "c", "store", evidence.Signals{})
+	store.Symbols.Functions = []evidence.Function{
+		{Name: "Open", Exported: true},
+		{Name: "Save", Exported: true, Receiver: "*Store"},
+		{Name: "encode"},
+	}
+	store.Calls = []evidence.Call{
+		{From: "*Store.Save", To: "encode"},
+		{From: "<global>", To: "Open"},
+	}
+
+	got := buildTransitions([]*evidence.EvidenceBundle{store, tool, api})
+
+	want := []Transition{
+		{From: "main.main", To: "main.run", EvidenceRefs: []string{
+			"bundle:cmd/api/main.go@v2#symbol:main",
+			"bundle:cmd/api/main.go@v2#symbol:run",
+		}},
+		{From: "main.run", To: "store.Open", EvidenceRefs: []string{
+			"bundle:cmd/api/main.go@v2#symbol:run",
+			"bundle:store/store.go@v2#symbol:Open",
+		}},
+		{From: "store.Store.Save", To: "store.encode", EvidenceRefs: []string{
+			"bundle:store/store.go@v2#symbol:Save",
+			"bundle:store/store.go@v2#symbol:encode",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transitions = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestCallPaths verifies that paths are stitched across bundles and out of
+// the module, that only the shortest ones are returned, and that Reachable
+// lists every symbol and target reachable from the start.
+func TestCallPaths(t *testing.T) {
+	dir := t.TempDir()
+	api := makeTestBundle("cmd/api/main.go", "a", "main", evidence.Signals{})
+	api.Symbols.Functions = []evidence.Function{{Name: "main"}, {Name: "run"}}
+	api.Calls = []evidence.Call{
+		{From: "main", To: "run"},
+		{From: "main", To: "store.Open"},
+		{From: "run", To: "store.Open"},
+		{From: "run.<anonymous>", To: "fmt.Println"},
+	}
+	store := makeTestBundle("store/store.go", "b", "store", evidence.Signals{})
+	store.Symbols.Functions = []evidence.Function{
+		{Name: "Open", Exported: true},
+		{Name: "Save", Exported: true, Receiver: "*Store"},
+	}
+	store.Calls = []evidence.Call{
+		{From: "Open", To: "os.WriteFile"},
+		{From: "*Store.Save", To: "os.WriteFile"},
+	}
+	writeTestBundle(t, dir, "main.go.evidence.yaml", api)
+	writeTestBundle(t, dir, "store.go.evidence.yaml", store)
+
+	paths, err := CallPaths(dir, "main.main", "os.WriteFile", 0)
+	if err != nil {
+		t.Fatalf("CallPaths: %v", err)
+	}
+	if want := [][]string{{"main.main", "store.Open", "os.WriteFile"}}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if paths, err := CallPaths(dir, "main.main", "store.Store.Save", 0); err != nil || paths != nil {
+		t.Errorf("unreachable target: paths = %v, err = %v", paths, err)
+	}
+	if _, err := CallPaths(dir, "main.missing", "os.WriteFile", 0); err == nil {
+		t.Error("unknown start symbol: want error")
+	}
+
+	reached, err := Reachable(dir, "main.run")
+	if err != nil {
+		t.Fatalf("Reachable: %v", err)
+	}
+	if want := []string{"fmt.Println", "os.WriteFile", "store.Open"}; !reflect.DeepEqual(reached, want) {
+		t.Errorf("reachable = %v, want %v", reached, want)
+	}
+}
+
+// TestBuildConfigBoundaries verifies that config inputs merge per (source,
+// key) across packages, citing the reading function or, for package-level
+// reads, the file.
+func TestBuildConfigBoundaries(t *testing.T) {
+	a := makeTestBundle("cmd/main.go", "aaa", "main", evidence.Signals{})
+	a.ConfigInputs = []evidence.ConfigInput{
+		{Source: evidence.ConfigEnv, Key: "HOME", From: "<global>"},
+		{Source: evidence.ConfigViper, Key: "db.url", From: "run"},
+	}
+	b := makeTestBundle("store/store.go", "bbb", "store", evidence.Signals{})
+	b.ConfigInputs = []evidence.ConfigInput{
+		{Source: evidence.ConfigEnv, Key: "HOME", From: "*Store.Open"},
+	}
+
+	got := buildBoundaries([]*evidence.EvidenceBundle{b, a}).Config
+	want := []ConfigBoundary{
+		{Source: "env", Key: "HOME", Packages: []string{"main", "store"}, EvidenceRefs: []string{
+			"bundle:cmd/main.go@v2",
+			"bundle:store/store.go@v2#symbol:Open",
+		}},
+		{Source: "viper", Key: "db.url", Packages: []string{"main"}, EvidenceRefs: []string{
+			"bundle:cmd/main.go@v2#symbol:run",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("config = %+v, want %+v", got, want)
+	}
+}
+
+// TestBuildBoundaries_Tables verifies that the db persistence boundary
+// lists the tables each package's queries write and read.
+func TestBuildBoundaries_Tables(t *testing.T) {
+	a := makeTestBundle("store/orders.go", "a", "store", evidence.Signals{DBCalls: true})
+	a.Queries = []evidence.Query{
+		{SQL: "INSERT INTO orders SELECT * FROM carts", Writes: []string{"orders"}, Reads: []string{"carts"}, From: "*Store.Save"},
+		{SQL: "SELECT 1", From: "ping"},
+	}
+	b := makeTestBundle("store/users.go", "b", "store", evidence.Signals{DBCalls: true})
+	b.Queries = []evidence.Query{{SQL: "UPDATE users SET x = 1", Writes: []string{"users"}, From: "<global>"}}
+	c := makeTestBundle("report/report.go", "c", "report", evidence.Signals{DBCalls: true})
+	c.Queries = []evidence.Query{{SQL: "SELECT * FROM orders", Reads: []string{"orders"}, From: "Daily"}}
+
+	got := buildBoundaries([]*evidence.EvidenceBundle{a, b, c}).Persistence[0].Tables
+	want := []TableUse{
+		{Package: "report", Reads: []string{"orders"}, EvidenceRefs: []string{"bundle:report/report.go@v2#symbol:Daily"}},
+		{Package: "store", Writes: []string{"orders", "users"}, Reads: []string{"carts"}, EvidenceRefs: []string{
+			"bundle:store/orders.go@v2#symbol:Save",
+			"bundle:store/users.go@v2#signal:db_calls",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tables = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestBuildBoundaries_HTTP verifies that routes and endpoints are merged
+// per package into the network boundary, even without net_calls files.
+func TestBuildBoundaries_HTTP(t *testing.T) {
+	a := makeTestBundle("api/routes.go", "a", "api", evidence.Signals{})
+	a.Routes = []evidence.Route{
+		{Path: "/healthz", From: "Register"},
+		{Method: "POST", Path: "/orders", From: "Register"},
+		{Method: "POST", Path: "/orders", From: "registerV2"},
+	}
+	b := makeTestBundle("client/client.go", "b", "client", evidence.Signals{})
+	b.Endpoints = []evidence.Endpoint{{Method: "GET", URL: "https://example.com/v1", Host: "example.com", From: "<global>"}}
+
+	n := buildBoundaries([]*evidence.EvidenceBundle{b, a}).Network
+	if n == nil {
+		t.Fatal("network boundary = nil, want routes and endpoints")
+	}
+	wantRoutes := []HTTPRoute{
+		{Path: "/healthz", Package: "api", EvidenceRefs: []string{"bundle:api/routes.go@v2#symbol:Register"}},
+		{Method: "POST", Path: "/orders", Package: "api", EvidenceRefs: []string{
+			"bundle:api/routes.go@v2#symbol:Register",
+			"bundle:api/routes.go@v2#symbol:registerV2",
+		}},
+	}
+	if !reflect.DeepEqual(n.Routes, wantRoutes) {
+		t.Errorf("routes = %+v\nwant %+v", n.Routes, wantRoutes)
+	}
+	wantEndpoints := []HTTPEndpoint{{Method: "GET", URL: "https://example.com/v1", Host: "example.com", Package: "client",
+		EvidenceRefs: []string{"bundle:client/client.go@v2#signal:net_calls"}}}
+	if !reflect.DeepEqual(n.Endpoints, wantEndpoints) {
+		t.Errorf("endpoints = %+v\nwant %+v", n.Endpoints, wantEndpoints)
+	}
+}
+
+// TestBuildUnreferencedSymbols verifies that exported functions and types
+// are reported unless a call or a type string, including the type of a
+// variable or constant, refers to them, and that methods, package main,
+// and entrypoints are never reported.
+func TestBuildUnreferencedSymbols(t *testing.T) {
+	api := makeTestBundle("cmd/api/main.go", "a", "main", evidence.Signals{})
+	api.Symbols.Functions = []evidence.Function{{Name: "main"}, {Name: "Unused", Exported: true}}
+	api.Symbols.Variables = []evidence.VarDecl{{Name: "cfg", TypeStr: "store.Config"}}
+	api.Calls = []evidence.Call{{From: "main", To: "store.Open"}}
+	store := makeTestBundle("store/store.go", "b", "store", evidence.Signals{})
+	store.Symbols.Functions = []evidence.Function{
+		{Name: "Open", Exported: true, Returns: []string{"*Store", "error"}},
+		{Name: "LegacyOpen", Exported: true},
+		{Name: "Handler", Exported: true},
+		{Name: "Save", Exported: true, Receiver: "*Store"},
+		{Name: "decode", Params: []string{"data []byte"}, Returns: []string{"Record"}},
+	}
+	store.Symbols.Types = []evidence.TypeDecl{
+		{Name: "Store", Kind: "struct", Exported: true},
+		{Name: "Record", Kind: "struct", Exported: true},
+		{Name: "Node", Kind: "struct", Exported: true, Fields: []evidence.FieldDecl{{Name: "Next", TypeStr: "*Node"}}},
+		{Name: "Config", Kind: "struct", Exported: true},
+		{Name: "Level", Kind: "alias", Exported: true},
+	}
+	store.Symbols.Constants = []evidence.VarDecl{{Name: "Debug", Exported: true, TypeStr: "Level"}}
+
+	got := buildUnreferencedSymbols([]*evidence.EvidenceBundle{api, store},
+		[]Entrypoint{{Package: "store", Symbol: "Handler"}})
+	want := []UnreferencedSymbol{
+		{Symbol: "store.LegacyOpen", Kind: UnreferencedFunc, EvidenceRefs: []string{"bundle:store/store.go@v2#symbol:LegacyOpen"}},
+		{Symbol: "store.Node", Kind: UnreferencedType, EvidenceRefs: []string{"bundle:store/store.go@v2#symbol:Node"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unreferenced = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestBuildCLISurface verifies that an entrypoint's surface holds the flags
+// of its own directory, of functions it reaches, and of init in packages
+// it reaches, but not those of unreached functions or other main packages.
+func TestBuildCLISurface(t *testing.T) {
+	api := makeTestBundle("cmd/api/main.go", "a", "main", evidence.Signals{})
+	api.Symbols.Functions = []evidence.Function{{Name: "main"}}
+	api.Calls = []evidence.Call{{From: "main", To: "cli.Run"}}
+	api.CLIFlags = []evidence.CLIFlag{{Name: "addr", Type: "string", Default: ":8080", From: "main"}}
+	worker := makeTestBundle("cmd/worker/main.go", "b", "main", evidence.Signals{})
+	worker.Symbols.Functions = []evidence.Function{{Name: "main"}}
+	worker.CLIFlags = []evidence.CLIFlag{{Name: "queue", Type: "string", From: "main"}}
+	cli := makeTestBundle("cli/cli.go", "c", "cli", evidence.Signals{})
+	cli.Symbols.Functions = []evidence.Function{{Name: "Run", Exported: true}, {Name: "init"}, {Name: "Other", Exported: true}}
+	cli.CLIFlags = []evidence.CLIFlag{
+		{Name: "debug", Type: "bool", Default: "false", From: "Other"},
+		{Name: "port", Shorthand: "p", Type: "int", Default: "80", Usage: "port", From: "Run"},
+		{Name: "verbose", Type: "bool", Default: "false", From: "init"},
+	}
+	bundles := []*evidence.EvidenceBundle{api, worker, cli}
+
+	got := buildCLISurface(bundles, buildEntrypoints(bundles, nil))
+	if len(got) != 2 {
+		t.Fatalf("surfaces = %+v, want one per main package", got)
+	}
+	want := CLISurface{Package: "main", Symbol: "main", Dir: "cmd/api", Flags: []CLIFlag{
+		{Name: "addr", Type: "string", Default: ":8080", EvidenceRefs: []string{"bundle:cmd/api/main.go@v2#symbol:main"}},
+		{Name: "port", Shorthand: "p", Type: "int", Default: "80", Usage: "port", EvidenceRefs: []string{"bundle:cli/cli.go@v2#symbol:Run"}},
+		{Name: "verbose", Type: "bool", Default: "false", EvidenceRefs: []string{"bundle:cli/cli.go@v2#symbol:init"}},
+	}}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("api surface = %+v\nwant %+v", got[0], want)
+	}
+	var names []string
+	for _, f := range got[1].Flags {
+		names = append(names, f.Name)
+	}
+	if got[1].Dir != "cmd/worker" || !slices.Contains(names, "queue") || slices.Contains(names, "addr") {
+		t.Errorf("worker flags = %v, want queue and not api's addr", names)
+	}
+
+	if got := buildCLISurface([]*evidence.EvidenceBundle{api}, nil); got != nil {
+		t.Errorf("surface without entrypoints = %+v, want nil", got)
+	}
+}
+
+// TestBuildTestedSymbols verifies that test targets resolve through imported
+// package names, bare same-package names, and method calls on variables,
+// and that targets outside the module are dropped.
+func TestBuildTestedSymbols(t *testing.T) {
+	store := makeTestBundle("store/store.go", "a", "store", evidence.Signals{})
+	store.Symbols.Functions = []evidence.Function{
+		{Name: "Open", Exported: true},
+		{Name: "Save", Exported: true, Receiver: "*Store"},
+		{Name: "encode"},
+	}
+	external := &evidence.TestBundle{
+		Version: 1,
+		File:    evidence.FileMeta{Path: "store/store_test.go"},
+		Package: evidence.PackageMeta{Name: "store_test", Imports: []evidence.Import{{Path: "example.com/m/store"}}},
+		Tests:   []evidence.TestCase{{Name: "TestSave", Exercises: []string{"fmt.Sprint", "s.Save", "store.Open"}}},
+	}
+	internal := &evidence.TestBundle{
+		Version: 1,
+		File:    evidence.FileMeta{Path: "store/encode_test.go"},
+		Package: evidence.PackageMeta{Name: "store"},
+		Tests:   []evidence.TestCase{{Name: "TestEncode", Exercises: []string{"encode"}}},
+	}
+
+	got := buildTestedSymbols([]*evidence.EvidenceBundle{store}, []*evidence.TestBundle{external, internal})
+
+	want := []TestedSymbol{
+		{Symbol: "store.Open", Tests: []string{"store_test.TestSave"}, EvidenceRefs: []string{
+			"bundle:store/store.go@v2#symbol:Open",
+			"bundle:store/store_test.go@v1#symbol:TestSave",
+		}},
+		{Symbol: "store.Store.Save", Tests: []string{"store_test.TestSave"}, EvidenceRefs: []string{
+			"bundle:store/store.go@v2#symbol:Save",
+			"bundle:store/store_test.go@v1#symbol:TestSave",
+		}},
+		{Symbol: "store.encode", Tests: []string{"store.TestEncode"}, EvidenceRefs: []string{
+			"bundle:store/encode_test.go@v1#symbol:TestEncode",
+			"bundle:store/store.go@v2#symbol:encode",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tested symbols = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestBuildImplementations verifies that implements lists become qualified,
+// sorted type → interface entries.
+func TestBuildImplementations(t *testing.T) {
+	store := makeTestBundle("store/store.go", "a", "store", evidence.Signals{})
+	store.Symbols.Types = []evidence.TypeDecl{
+		{Name: "FileStore", Kind: "struct", Implements: []string{"io.Writer", "Store"}},
+		{Name: "Store", Kind: "interface"},
+	}
+	cache := makeTestBundle("cache/cache.go", "b", "cache", evidence.Signals{})
+	cache.Symbols.Types = []evidence.TypeDecl{{Name: "LRU", Kind: "struct", Implements: []string{"store.Store"}}}
+
+	got := buildImplementations([]*evidence.EvidenceBundle{store, cache})
+
+	want := []Implementation{
+		{Type: "cache.LRU", Interface: "store.Store", EvidenceRefs: []string{"bundle:cache/cache.go@v2#symbol:LRU"}},
+		{Type: "store.FileStore", Interface: "io.Writer", EvidenceRefs: []string{"bundle:store/store.go@v2#symbol:FileStore"}},
+		{Type: "store.FileStore", Interface: "store.Store", EvidenceRefs: []string{"bundle:store/store.go@v2#symbol:FileStore"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("implementations = %+v\nwant %+v", got, want)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Unit tests — GenerateSystemModel scheduling
+// ---------------------------------------------------------------------------
+
+// TestGenerateSystemModel_ParallelMatchesSerial verifies that overlapping LLM
+// inference with the deterministic sections assembles the same model.
+func TestGenerateSystemModel_ParallelMatchesSerial(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+	writeTestBundle(t, dir, "api", makeTestBundle("api/api.go", "b", "api", evidence.Signals{NetCalls: true}))
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{
+				{Id: "records", Owners: []string{"store"}, Aggregate: "Record", Confidence: 0.8},
+			},
+			Open_questions: []types.OpenQuestionSpec{{Question: "Who calls the API?"}},
+		}, nil
+	}
+
+	serial, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("serial: %v", err)
+	}
+	parallel, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{ParallelLLM: true})
+	if err != nil {
+		t.Fatalf("parallel: %v", err)
+	}
+	serial.GeneratedAt, parallel.GeneratedAt = "", ""
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Errorf("parallel model differs from serial:\nserial:   %+v\nparallel: %+v", serial, parallel)
+	}
+	// One LLM question plus the seeded unowned-effect question for api.
+	if len(parallel.StateDomains) != 1 || len(parallel.OpenQuestions) != 2 {
+		t.Errorf("expected inferred sections to be assembled, got %+v", parallel)
+	}
+}
+
+// TestGenerateSystemModel_ParallelError verifies that an inference error is
+// propagated when the LLM call runs in the background.
+func TestGenerateSystemModel_ParallelError(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{}, errors.New("llm unavailable")
+	}
+
+	_, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{ParallelLLM: true})
+	if err == nil || !strings.Contains(err.Error(), "llm unavailable") {
+		t.Errorf("expected inference error, got %v", err)
+	}
+}
+
+// TestGenerateSystemModel_NoLLM verifies that NoLLM builds the deterministic
+// sections without calling the LLM and marks the model so it is never up
+// to date.
+func TestGenerateSystemModel_NoLLM(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		t.Error("LLM called with NoLLM set")
+		return types.SystemModelInference{}, nil
+	}
+
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{NoLLM: true, ParallelLLM: true})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if !m.Inputs.InferenceSkipped {
+		t.Error("expected Inputs.InferenceSkipped")
+	}
+	if len(m.Inventory.Packages) != 1 || len(m.Effects) != 1 || m.Effects[0].Domain != "" {
+		t.Errorf("expected deterministic sections only, got %+v", m)
+	}
+	if len(m.StateDomains) != 0 || len(m.TrustZones) != 0 {
+		t.Errorf("expected empty inferred sections, got %+v / %+v", m.StateDomains, m.TrustZones)
+	}
+
+	out := filepath.Join(dir, "system_model.yaml")
+	if err := WriteSystemModel(m, out); err != nil {
+		t.Fatal(err)
+	}
+	if upToDate, err := SystemModelUpToDate(dir, out); err != nil || !upToDate {
+		t.Errorf("SystemModelUpToDate = %v, %v; want true: the bundle set is unchanged", upToDate, err)
+	}
+}
+
+// TestGenerateSystemModel_Cache verifies that a cached inference is reused
+// for the same bundle set and summaries, and that RefreshCache bypasses it.
+func TestGenerateSystemModel_Cache(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+
+	calls := 0
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		calls++
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{{Id: "records", Owners: []string{"store"}, Confidence: 0.8}},
+		}, nil
+	}
+
+	opts := GenerateOptions{CacheDir: filepath.Join(t.TempDir(), "cache")}
+	first, err := GenerateSystemModel(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatalf("first: %v", err)
+	}
+	second, err := GenerateSystemModel(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatalf("second: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("LLM calls = %d after a cached run, want 1", calls)
+	}
+	first.GeneratedAt, second.GeneratedAt = "", ""
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached model differs:\nfirst:  %+v\nsecond: %+v", first, second)
+	}
+
+	opts.RefreshCache = true
+	if _, err := GenerateSystemModel(context.Background(), dir, opts); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{CacheDir: opts.CacheDir, SummaryFields: []string{SummaryTypes}}); err != nil {
+		t.Fatalf("other summaries: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("LLM calls = %d, want 3 (refresh and changed summaries miss)", calls)
+	}
+
+	// inferSystemModel is stubbed below the provider loop, so any chain
+	// "succeeds"; switching models must still miss.
+	for _, model := range []string{"model-a", "model-b", "model-a"} {
+		chain := []settings.LLMProvider{{Name: "p", Provider: "openai", Model: model}}
+		if _, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{CacheDir: opts.CacheDir, LLM: chain}); err != nil {
+			t.Fatalf("chain %s: %v", model, err)
+		}
+	}
+	if calls != 6 {
+		t.Errorf("LLM calls = %d, want 6 (each provider chain change misses)", calls)
+	}
+}
+
+// TestGenerateSystemModel_LLMFallback verifies that inference falls back to
+// the next configured provider when one fails, passing each a client
+// registry.
+func TestGenerateSystemModel_LLMFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+
+	calls := 0
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, opts ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		calls++
+		if len(opts) != 1 {
+			t.Errorf("call %d: got %d call options, want a client registry", calls, len(opts))
+		}
+		if calls == 1 {
+			return types.SystemModelInference{}, errors.New("rate limited")
+		}
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{{Id: "records", Owners: []string{"store"}, Confidence: 0.8}},
+		}, nil
+	}
+
+	providers := []settings.LLMProvider{
+		{Name: "primary", Provider: "openai", Model: "gpt-5", Timeout: "1m"},
+		{Name: "backup", Provider: "anthropic", Model: "claude-sonnet-4-20250514"},
+	}
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{LLM: providers})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if calls != 2 || len(m.StateDomains) != 1 {
+		t.Errorf("calls = %d, state domains = %+v; want the backup's inference", calls, m.StateDomains)
+	}
+
+	providers[1].Timeout = "soon"
+	if _, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{LLM: providers}); err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("expected invalid timeout error, got %v", err)
+	}
+}
+
+// TestGenerateSystemModel_PendingReview verifies that domains below
+// min_confidence are held for review, owning no effects, and that recorded
+// decisions override the threshold.
+func TestGenerateSystemModel_PendingReview(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+	writeTestBundle(t, dir, "cache", makeTestBundle("cache/cache.go", "b", "cache", evidence.Signals{FSWrites: true}))
+	if err := os.MkdirAll(filepath.Join(dir, ".iguana"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".iguana", "settings.yaml"), []byte("min_confidence: 0.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{
+				{Id: "records", Owners: []string{"store"}, Confidence: 0.9},
+				{Id: "hot_cache", Owners: []string{"cache"}, Confidence: 0.4},
+				{Id: "scratch", Owners: []string{"cache"}, Confidence: 0.3},
+			},
+		}, nil
+	}
+
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if len(m.StateDomains) != 1 || m.StateDomains[0].ID != "records" {
+		t.Errorf("StateDomains = %+v, want only records", m.StateDomains)
+	}
+	if len(m.PendingReview) != 2 || m.PendingReview[0].ID != "hot_cache" {
+		t.Errorf("PendingReview = %+v, want hot_cache and scratch", m.PendingReview)
+	}
+	for _, e := range m.Effects {
+		if e.Via == "cache/cache.go" && e.Domain != "" {
+			t.Errorf("effect %+v linked to a pending domain", e)
+		}
+	}
+
+	if err := WriteReviewDecisions(dir, []ReviewDecision{
+		{ID: "hot_cache", Action: ReviewAccept},
+		{ID: "scratch", Action: ReviewReject},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m, err = GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel after review: %v", err)
+	}
+	if len(m.StateDomains) != 2 || m.StateDomains[0].ID != "hot_cache" || len(m.PendingReview) != 0 {
+		t.Errorf("after review: domains %+v, pending %+v", m.StateDomains, m.PendingReview)
+	}
+}
+
+// TestGenerateSystemModel_Provenance verifies that the summaries sent to the
+// LLM and its response are written beside the bundles and referenced from
+// the model inputs.
+func TestGenerateSystemModel_Provenance(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "store", makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true}))
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{{Id: "records", Owners: []string{"store"}, Confidence: 0.8}},
+		}, nil
+	}
+
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{Provenance: true})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if want := ".iguana/provenance/" + m.Inputs.BundleSetSHA256; m.Inputs.Provenance != want {
+		t.Errorf("Inputs.Provenance = %q, want %q", m.Inputs.Provenance, want)
+	}
+	var summaries []types.PackageSummary
+	data, err := os.ReadFile(filepath.Join(dir, m.Inputs.Provenance, "summaries.json"))
+	if err != nil || json.Unmarshal(data, &summaries) != nil || len(summaries) != 1 || summaries[0].Name != "store" {
+		t.Errorf("summaries.json = %s (%v), want the store summary", data, err)
+	}
+	var inference types.SystemModelInference
+	data, err = os.ReadFile(filepath.Join(dir, m.Inputs.Provenance, "response.json"))
+	if err != nil || json.Unmarshal(data, &inference) != nil || len(inference.State_domains) != 1 {
+		t.Errorf("response.json = %s (%v), want the inference", data, err)
+	}
+}
+
+// TestGenerateSystemModel_MergeSummaries verifies that two tiny sibling
+// packages reach the LLM as one summary and that a domain owning the merged
+// summary maps back to both packages.
+func TestGenerateSystemModel_MergeSummaries(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "a", makeTestBundle("svc/a/a.go", "a", "a", evidence.Signals{FSWrites: true}))
+	writeTestBundle(t, dir, "b", makeTestBundle("svc/b/b.go", "b", "b", evidence.Signals{FSReads: true}))
+	writeTestBundle(t, dir, "api", makeTestBundle("api/api.go", "c", "api", evidence.Signals{NetCalls: true}))
+
+	var got []string
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		got = nil
+		for _, s := range summaries {
+			got = append(got, s.Name)
+		}
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{
+				{Id: "records", Owners: []string{"a+b"}, Aggregate: "Record", Confidence: 0.8},
+			},
+		}, nil
+	}
+
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{MergeSummaries: true})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if want := []string{"a+b", "api"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("summaries = %v, want %v", got, want)
+	}
+	if len(m.StateDomains) != 1 || !reflect.DeepEqual(m.StateDomains[0].Owners, []string{"a", "b"}) {
+		t.Fatalf("state domains = %+v, want records owned by a and b", m.StateDomains)
+	}
+	if len(m.StateDomains[0].EvidenceRefs) != 2 {
+		t.Errorf("EvidenceRefs = %v, want refs for both packages", m.StateDomains[0].EvidenceRefs)
+	}
+}
+
+// TestBuildSharedStateRisks verifies that exported variables are flagged
+// when their package or an importer launches goroutines, and that sync
+// values, channels, sentinels, and unexported variables are not.
+func TestBuildSharedStateRisks(t *testing.T) {
+	cfg := makeTestBundle("config/config.go", "a", "config", evidence.Signals{})
+	cfg.Symbols.Variables = []evidence.VarDecl{
+		{Name: "Current", Exported: true},
+		{Name: "Mu", Exported: true},
+		{Name: "ErrMissing", Exported: true},
+		{Name: "cache"},
+	}
+	cfg.Symbols.ErrorSentinels = []string{"ErrMissing"}
+	cfg.Concurrency = &evidence.Concurrency{Primitives: []evidence.SyncPrimitive{{Owner: "<global>", Name: "Mu", Kind: "rwmutex"}}}
+	srv := makeTestBundle("server/server.go", "b", "server", evidence.Signals{})
+	srv.Concurrency = &evidence.Concurrency{Goroutines: []evidence.GoroutineSite{{From: "Serve", Target: "handle", Count: 1}}}
+	quiet := makeTestBundle("quiet/quiet.go", "c", "quiet", evidence.Signals{})
+	quiet.Symbols.Variables = []evidence.VarDecl{{Name: "Level", Exported: true}}
+
+	packages := []PackageEntry{{Name: "config"}, {Name: "quiet"}, {Name: "server", Imports: []string{"config"}}}
+	domains := []StateDomain{{ID: "settings", Owners: []string{"config"}}}
+	got := buildSharedStateRisks([]*evidence.EvidenceBundle{cfg, srv, quiet}, packages, domains)
+	want := []SharedStateRisk{{
+		Variable:     "config.Current",
+		Package:      "config",
+		Launchers:    []string{"server/server.go"},
+		Domain:       "settings",
+		EvidenceRefs: []string{"bundle:config/config.go@v2#symbol:Current"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("risks = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestBuildConcurrencyDomains_Inventory verifies that a file's domain
+// counts its goroutines and names its primitives and channels.
+func TestBuildConcurrencyDomains_Inventory(t *testing.T) {
+	bnd := makeTestBundle("pool/pool.go", "a", "pool", evidence.Signals{Concurrency: true})
+	bnd.Concurrency = &evidence.Concurrency{
+		Goroutines: []evidence.GoroutineSite{{From: "*Pool.Run", Target: "p.work", Count: 4}, {From: "main", Target: "<anonymous>", Count: 1}},
+		Primitives: []evidence.SyncPrimitive{{Owner: "Pool", Name: "mu", Kind: "mutex"}, {Owner: "<global>", Name: "once", Kind: "once"}},
+		Channels:   []evidence.Channel{{Owner: "*Pool.Run", Name: "in", Dir: "recv", Elem: "Job"}},
+	}
+
+	got := buildConcurrencyDomains([]*evidence.EvidenceBundle{bnd})
+	want := []ConcurrencyDomain{{
+		ID:           "pool/pool.go",
+		Files:        []string{"pool/pool.go"},
+		Goroutines:   5,
+		Primitives:   []string{"pool.Pool.mu (mutex)", "pool.once (once)"},
+		Channels:     []string{"pool.Pool.Run.in (<-chan Job)"},
+		EvidenceRefs: []string{"bundle:pool/pool.go@v2#signal:concurrency"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("domains = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestGenerateSystemModel_GroupConcurrency verifies that two concurrent
+// files with effects in one state domain are grouped under that domain,
+// while an unowned concurrent file keeps its per-file domain.
+func TestGenerateSystemModel_GroupConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "a", makeTestBundle("cache/a.go", "a", "cache", evidence.Signals{Concurrency: true, FSWrites: true}))
+	writeTestBundle(t, dir, "b", makeTestBundle("cache/b.go", "b", "cache", evidence.Signals{Concurrency: true, FSReads: true}))
+	writeTestBundle(t, dir, "w", makeTestBundle("worker/w.go", "c", "worker", evidence.Signals{Concurrency: true}))
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{
+			State_domains: []types.StateDomainSpec{
+				{Id: "entries", Owners: []string{"cache"}, Aggregate: "Entry", Confidence: 0.8},
+			},
+		}, nil
+	}
+
+	m, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if len(m.ConcurrencyDomains) != 3 {
+		t.Fatalf("default mode: got %d concurrency domains, want 3 (one per file)", len(m.ConcurrencyDomains))
+	}
+
+	m, err = GenerateSystemModel(context.Background(), dir, GenerateOptions{GroupConcurrency: true})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	want := []ConcurrencyDomain{
+		{ID: "entries", Files: []string{"cache/a.go", "cache/b.go"}},
+		{ID: "worker/w.go", Files: []string{"worker/w.go"}},
+	}
+	if len(m.ConcurrencyDomains) != len(want) {
+		t.Fatalf("grouped: got %+v, want %d domains", m.ConcurrencyDomains, len(want))
+	}
+	for i, w := range want {
+		got := m.ConcurrencyDomains[i]
+		if got.ID != w.ID || !reflect.DeepEqual(got.Files, w.Files) {
+			t.Errorf("domain %d = %s %v, want %s %v", i, got.ID, got.Files, w.ID, w.Files)
+		}
+	}
+	if len(m.ConcurrencyDomains[0].EvidenceRefs) != 2 {
+		t.Errorf("grouped EvidenceRefs = %v, want one per file", m.ConcurrencyDomains[0].EvidenceRefs)
+	}
+}
+
+// TestAggregate_RoundTrip verifies that an aggregate written from a bundle
+// directory reads back with the same bundles and hash, and that a model
+// built from the aggregate matches one built from the directory.
+func TestAggregate_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "b", makeTestBundle("store/b.go", "b", "store", evidence.Signals{FSWrites: true}))
+	writeTestBundle(t, dir, "a", makeTestBundle("api/a.go", "a", "api", evidence.Signals{NetCalls: true}))
+
+	agg, err := BuildAggregate(dir)
+	if err != nil {
+		t.Fatalf("BuildAggregate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "corpus.yaml")
+	if err := WriteAggregate(agg, path); err != nil {
+		t.Fatalf("WriteAggregate: %v", err)
+	}
+	got, err := ReadAggregate(path)
+	if err != nil {
+		t.Fatalf("ReadAggregate: %v", err)
+	}
+	if !reflect.DeepEqual(got, agg) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, agg)
+	}
+	if got.Bundles[0].File.Path != "api/a.go" {
+		t.Errorf("bundles not sorted by path: first is %s", got.Bundles[0].File.Path)
+	}
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{}, nil
+	}
+	fromDir, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel(dir): %v", err)
+	}
+	fromAgg, err := GenerateSystemModel(context.Background(), path, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel(aggregate): %v", err)
+	}
+	fromAgg.GeneratedAt = fromDir.GeneratedAt
+	if !reflect.DeepEqual(fromAgg, fromDir) {
+		t.Errorf("model from aggregate differs from model from directory")
+	}
+	if fromAgg.Inputs.BundleSetSHA256 != agg.BundleSetSHA256 {
+		t.Errorf("model hash %s, want aggregate hash %s", fromAgg.Inputs.BundleSetSHA256, agg.BundleSetSHA256)
+	}
+
+	// A hand-edited aggregate no longer matches its hash.
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "store/b.go", "store/c.go", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadAggregate(path); err == nil {
+		t.Error("expected a hash mismatch error for an edited aggregate")
+	}
+}
+
+// TestGenerateSystemModel_Store verifies that a model built from an
+// evidence store matches one built from the same bundles as companion
+// files.
+func TestGenerateSystemModel_Store(t *testing.T) {
+	dir := t.TempDir()
+	bundles := []*evidence.EvidenceBundle{
+		makeTestBundle("store/b.go", "b", "store", evidence.Signals{FSWrites: true}),
+		makeTestBundle("api/a.go", "a", "api", evidence.Signals{NetCalls: true}),
+	}
+	dbPath := filepath.Join(t.TempDir(), "evidence.db")
+	st, err := store.Open(dbPath)
+	if err != nil {
+		t.Fatalf("store.Open: %v", err)
+	}
+	for i, bundle := range bundles {
+		writeTestBundle(t, dir, fmt.Sprint(i), bundle)
+		data, err := evidence.MarshalBundle(bundle, evidence.SchemaLatest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Put(bundle.File.Path, bundle.File.SHA256, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		return types.SystemModelInference{}, nil
+	}
+	fromDir, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel(dir): %v", err)
+	}
+	fromStore, err := GenerateSystemModel(context.Background(), dbPath, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateSystemModel(store): %v", err)
+	}
+	fromStore.GeneratedAt = fromDir.GeneratedAt
+	if !reflect.DeepEqual(fromStore, fromDir) {
+		t.Errorf("model from store differs from model from directory")
+	}
+}
+
+// TestReadAggregate_BundleVersions verifies that bundles embedded in an
+// aggregate are decoded like companion bundles: version 1 bundles are
+// migrated and bundles from a newer iguana are rejected.
+func TestReadAggregate_BundleVersions(t *testing.T) {
+	hash := computeBundleSetHash([]*evidence.EvidenceBundle{{File: evidence.FileMeta{Path: "api/a.go", SHA256: "abc"}}})
+	aggregate := func(bundleVersion int) string {
+		return fmt.Sprintf(`version: 1
+bundle_set_sha256: %s
+bundles:
+  - version: %d
+    file:
+      path: api/a.go
+      sha256: abc
+    package:
+      name: api
+      imports:
+        - path: net/http
+    symbols:
+      functions:
+        - name: Serve
+          exported: true
+          params: [string]
+          returns: [error]
+    calls:
+      - from: Serve
+        to: http.ListenAndServe
+    signals:
+      net_calls: true
+`, hash, bundleVersion)
+	}
+	path := filepath.Join(t.TempDir(), "corpus.yaml")
+
+	if err := os.WriteFile(path, []byte(aggregate(1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	agg, err := ReadAggregate(path)
+	if err != nil {
+		t.Fatalf("ReadAggregate(v1 bundle): %v", err)
+	}
+	got := agg.Bundles[0]
+	if got.Version != evidence.CurrentVersion || got.Package.Name != "api" || len(got.Symbols.Functions) != 1 ||
+		len(got.Calls) != 1 || !got.Signals.NetCalls {
+		t.Errorf("migrated bundle = %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(aggregate(evidence.CurrentVersion+1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadAggregate(path); !errors.Is(err, evidence.ErrUnsupportedVersion) {
+		t.Errorf("ReadAggregate(future bundle) = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+// TestGenerateSystemModel_SummaryFields verifies that excluding
+// type_descriptions sends summaries without descriptions while the other
+// fields are kept.
+func TestGenerateSystemModel_SummaryFields(t *testing.T) {
+	dir := t.TempDir()
+	bnd := makeTestBundle("store/store.go", "a", "store", evidence.Signals{FSWrites: true})
+	bnd.Symbols.Types = []evidence.TypeDecl{{
+		Name: "Record", Kind: "struct", Exported: true,
+		Fields: []evidence.FieldDecl{{Name: "ID", TypeStr: "string"}},
+	}}
+	bnd.Symbols.Functions = []evidence.Function{{Name: "Save", Exported: true, Params: []string{"Record"}, Returns: []string{"error"}}}
+	writeTestBundle(t, dir, "store", bnd)
+
+	var got []types.PackageSummary
+	orig := inferSystemModel
+	t.Cleanup(func() { inferSystemModel = orig })
+	inferSystemModel = func(ctx context.Context, summaries []types.PackageSummary, _ ...b.CallOptionFunc) (types.SystemModelInference, error) {
+		got = summaries
+		return types.SystemModelInference{}, nil
+	}
+
+	if _, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{}); err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if len(got) != 1 || len(got[0].Type_descriptions) == 0 {
+		t.Fatalf("default summaries = %+v, want type descriptions", got)
+	}
+
+	fields, err := ParseSummaryFields("-type_descriptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateSystemModel(context.Background(), dir, GenerateOptions{SummaryFields: fields}); err != nil {
+		t.Fatalf("GenerateSystemModel: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 summary, got %d", len(got))
+	}
+	if got[0].Type_descriptions != nil {
+		t.Errorf("Type_descriptions = %v, want none", got[0].Type_descriptions)
+	}
+	if len(got[0].Types) == 0 || len(got[0].Functions) == 0 {
+		t.Errorf("summary lost other fields: %+v", got[0])
+	}
+
+	for _, spec := range []string{"docs", "types,-imports"} {
+		if _, err := ParseSummaryFields(spec); err == nil {
+			t.Errorf("ParseSummaryFields(%q): expected an error", spec)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Unit tests — model diff
+// ---------------------------------------------------------------------------
+
+// TestDiffSystemModels verifies the delta for each compared section and that
+// evidence refs and confidence alone do not count as a change.
+func TestDiffSystemModels(t *testing.T) {
+	old := &SystemModel{
+		StateDomains: []StateDomain{
+			{ID: "users", Owners: []string{"auth"}, Confidence: 0.8},
+			{ID: "jobs", Owners: []string{"queue"}},
+		},
+		Effects: []Effect{
+			{Kind: "db_write", Via: "auth/db.go", Domain: "users"},
+			{Kind: "fs_write", Via: "queue/spool.go", Domain: "jobs"},
+		},
+		TrustZones:    []TrustZone{{ID: "internal", Packages: []string{"auth", "queue"}}},
+		OpenQuestions: []OpenQuestion{{Question: "Who retries jobs?"}},
+	}
+	new := &SystemModel{
+		StateDomains: []StateDomain{
+			{ID: "users", Owners: []string{"auth"}, Confidence: 0.9, EvidenceRefs: []string{"bundle:auth/db.go@v2"}},
+			{ID: "billing", Owners: []string{"billing"}},
+		},
+		Effects: []Effect{
+			{Kind: "db_write", Via: "auth/db.go", Domain: "billing"},
+			{Kind: "net_call", Via: "billing/stripe.go"},
+		},
+		TrustZones: []TrustZone{
+			{ID: "internal", Packages: []string{"auth", "billing"}},
+			{ID: "public", Packages: []string{"api"}},
+		},
+		OpenQuestions: []OpenQuestion{{Question: "Is billing idempotent?"}},
+	}
+
+	got := DiffSystemModels(old, new)
+	want := &ModelDiff{
+		StateDomains: evidence.SetDiff{Added: []string{"billing"}, Removed: []string{"jobs"}},
+		Effects: evidence.SetDiff{
+			Added:   []string{"net_call billing/stripe.go"},
+			Removed: []string{"fs_write queue/spool.go"},
+			Changed: []string{"db_write auth/db.go"},
+		},
+		TrustZones:    evidence.SetDiff{Added: []string{"public"}},
+		ZoneMembers:   []ZoneMembers{{ID: "internal", Added: []string{"billing"}, Removed: []string{"queue"}}},
+		OpenQuestions: evidence.SetDiff{Added: []string{"Is billing idempotent?"}, Removed: []string{"Who retries jobs?"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %+v\nwant %+v", got, want)
+	}
+	if d := DiffSystemModels(old, old); !d.IsZero() {
+		t.Errorf("self diff = %+v, want zero", d)
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+// provenance.go — audit trail of LLM inference.
+//
+// With GenerateOptions.Provenance, GenerateSystemModel writes the exact
+// inputs and output of the InferSystemModel call beside the bundles, in
+// .iguana/provenance/<bundle set hash>/:
+//
+//	summaries.json  the package summaries sent to the LLM
+//	response.json   the inference BAML parsed from the LLM response,
+//	                before owners of merged summaries are split
+//
+// ModelInputs.Provenance records that directory (relative to the bundle
+// directory) so the inferred sections of system_model.yaml can be traced
+// to the prompt and response they came from.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"iguana/baml_client/types"
+)
+
+// provenancePath is the provenance directory for a bundle set, relative to
+// the bundle directory, with forward slashes.
+func provenancePath(bundleSetHash string) string {
+	return path.Join(".iguana", "provenance", bundleSetHash)
+}
+
+// writeProvenance writes summaries and inference under dir and returns the
+// provenance directory for ModelInputs.Provenance.
+func writeProvenance(dir, bundleSetHash string, summaries []types.PackageSummary, inference types.SystemModelInference) (string, error) {
+	rel := provenancePath(bundleSetHash)
+	abs := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return "", fmt.Errorf("mkdir %s: %w", abs, err)
+	}
+	for name, v := range map[string]any{"summaries.json": summaries, "response.json": inference} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(abs, name), append(data, '\n'), 0o644); err != nil {
+			return "", fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	return rel, nil
+}
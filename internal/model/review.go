@@ -0,0 +1,164 @@
+package model
+
+// review.go — Human review of low-confidence state domains.
+//
+// With min_confidence set in .iguana/settings.yaml, inferred state domains
+// below the threshold are held in the model's pending_review section rather
+// than used (they own no effects). "iguana review" walks the pending
+// domains and records a decision for each in .iguana/review.yaml beside the
+// bundles:
+//
+//	decisions:
+//	  - id: session_cache
+//	    action: accept
+//	  - id: scratch
+//	    action: reject
+//	  - id: job_queue
+//	    action: edit
+//	    domain: {id: job_queue, description: ..., owners: [worker], ...}
+//
+// Decisions apply to every later generation, whatever the confidence, so a
+// domain is asked about once: accepted domains are kept, edited ones are
+// replaced by the recorded domain, and rejected ones are dropped.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"iguana/internal/canonical"
+)
+
+// Review actions.
+const (
+	ReviewAccept = "accept"
+	ReviewReject = "reject"
+	ReviewEdit   = "edit"
+)
+
+// ReviewDecision is the recorded outcome of reviewing one state domain.
+type ReviewDecision struct {
+	ID     string       `yaml:"id"`
+	Action string       `yaml:"action"`           // ReviewAccept | ReviewReject | ReviewEdit
+	Domain *StateDomain `yaml:"domain,omitempty"` // the edited domain (ReviewEdit)
+}
+
+// reviewFile is the document stored at ReviewPath.
+type reviewFile struct {
+	Decisions []ReviewDecision `yaml:"decisions"`
+}
+
+// ReviewPath returns the review decisions file for the bundle directory dir.
+func ReviewPath(dir string) string {
+	return filepath.Join(dir, ".iguana", "review.yaml")
+}
+
+// ReadReviewDecisions reads the review decisions for dir. A missing file
+// has none.
+func ReadReviewDecisions(dir string) ([]ReviewDecision, error) {
+	path := ReviewPath(dir)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var f reviewFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	for _, d := range f.Decisions {
+		switch d.Action {
+		case ReviewAccept, ReviewReject:
+		case ReviewEdit:
+			if d.Domain == nil {
+				return nil, fmt.Errorf("%s: edit of %q has no domain", path, d.ID)
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown action %q for %q", path, d.Action, d.ID)
+		}
+	}
+	return f.Decisions, nil
+}
+
+// WriteReviewDecisions merges decisions into the review file for dir, a
+// new decision replacing an earlier one for the same domain. Decisions are
+// stored sorted by ID.
+func WriteReviewDecisions(dir string, decisions []ReviewDecision) error {
+	existing, err := ReadReviewDecisions(dir)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]ReviewDecision)
+	for _, d := range append(existing, decisions...) {
+		byID[d.ID] = d
+	}
+	f := reviewFile{Decisions: make([]ReviewDecision, 0, len(byID))}
+	for _, d := range byID {
+		f.Decisions = append(f.Decisions, d)
+	}
+	sort.Slice(f.Decisions, func(i, j int) bool { return f.Decisions[i].ID < f.Decisions[j].ID })
+
+	data, err := canonical.MarshalYAML(f)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	path := ReviewPath(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyReview splits domains into the ones used by the model and the ones
+// pending review. A decision for a domain's ID wins; otherwise a domain
+// below minConfidence is pending. Both results are sorted by ID (INV-28).
+func applyReview(domains []StateDomain, minConfidence float64, decisions []ReviewDecision) (kept, pending []StateDomain) {
+	byID := make(map[string]ReviewDecision, len(decisions))
+	for _, d := range decisions {
+		byID[d.ID] = d
+	}
+	for _, d := range domains {
+		decision, ok := byID[d.ID]
+		switch {
+		case !ok && d.Confidence < minConfidence:
+			pending = append(pending, d)
+		case !ok, decision.Action == ReviewAccept:
+			kept = append(kept, d)
+		case decision.Action == ReviewEdit:
+			kept = append(kept, *decision.Domain)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].ID < kept[j].ID })
+	return kept, pending
+}
+
+// ApplyReviewDecisions moves the pending domains of m that decisions
+// resolve into m.StateDomains (accepted or edited) or drops them
+// (rejected), keeping both lists sorted by ID. Effects are not relinked to
+// the new domains until the model is regenerated.
+func ApplyReviewDecisions(m *SystemModel, decisions []ReviewDecision) {
+	decided := make(map[string]bool, len(decisions))
+	for _, d := range decisions {
+		decided[d.ID] = true
+	}
+	var resolved, still []StateDomain
+	for _, d := range m.PendingReview {
+		if decided[d.ID] {
+			resolved = append(resolved, d)
+		} else {
+			still = append(still, d)
+		}
+	}
+	kept, _ := applyReview(resolved, 0, decisions)
+	m.StateDomains = append(m.StateDomains, kept...)
+	sort.Slice(m.StateDomains, func(i, j int) bool { return m.StateDomains[i].ID < m.StateDomains[j].ID })
+	m.PendingReview = still
+}
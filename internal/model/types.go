@@ -8,22 +8,36 @@ package model
 // SystemModel is the root output artifact written to system_model.yaml.
 // Field order matches desired YAML output order (INV-28: arrays sorted).
 type SystemModel struct {
-	Version            int                 `yaml:"version"`
-	GeneratedAt        string              `yaml:"generated_at"`
-	Inputs             ModelInputs         `yaml:"inputs"`
-	Inventory          Inventory           `yaml:"inventory"`
-	StateDomains       []StateDomain       `yaml:"state_domains,omitempty"`
-	Boundaries         Boundaries          `yaml:"boundaries"`
-	Effects            []Effect            `yaml:"effects,omitempty"`
-	Transitions        []Transition        `yaml:"transitions,omitempty"` // empty in v1
-	TrustZones         []TrustZone         `yaml:"trust_zones,omitempty"`
-	ConcurrencyDomains []ConcurrencyDomain `yaml:"concurrency_domains,omitempty"`
-	OpenQuestions      []OpenQuestion      `yaml:"open_questions,omitempty"`
+	Version            int                  `yaml:"version"`
+	GeneratedAt        string               `yaml:"generated_at"`
+	Inputs             ModelInputs          `yaml:"inputs"`
+	Inventory          Inventory            `yaml:"inventory"`
+	StateDomains       []StateDomain        `yaml:"state_domains,omitempty"`
+	PendingReview      []StateDomain        `yaml:"pending_review,omitempty"` // below min_confidence, awaiting iguana review
+	Boundaries         Boundaries           `yaml:"boundaries"`
+	Effects            []Effect             `yaml:"effects,omitempty"`
+	Transitions        []Transition         `yaml:"transitions,omitempty"`
+	Implementations    []Implementation     `yaml:"implementations,omitempty"`
+	TestedSymbols      []TestedSymbol       `yaml:"tested_symbols,omitempty"`
+	Unreferenced       []UnreferencedSymbol `yaml:"unreferenced_symbols,omitempty"`
+	CLISurface         []CLISurface         `yaml:"cli_surface,omitempty"`
+	SharedState        []SharedStateRisk    `yaml:"shared_state_risks,omitempty"`
+	TrustZones         []TrustZone          `yaml:"trust_zones,omitempty"`
+	ConcurrencyDomains []ConcurrencyDomain  `yaml:"concurrency_domains,omitempty"`
+	CallGraph          []CallEdge           `yaml:"call_graph,omitempty"`
+	CallGraphTruncated bool                 `yaml:"call_graph_truncated,omitempty"` // edges beyond the limit were dropped
+	OpenQuestions      []OpenQuestion       `yaml:"open_questions,omitempty"`
 }
 
 // ModelInputs records provenance of the model (INV-31).
+// InferenceSkipped marks a model generated without LLM inference
+// (GenerateOptions.NoLLM), whose inferred sections are empty by design.
+// Provenance is the directory, relative to the bundle directory, holding
+// the LLM prompt and response (GenerateOptions.Provenance).
 type ModelInputs struct {
-	BundleSetSHA256 string `yaml:"bundle_set_sha256"`
+	BundleSetSHA256  string `yaml:"bundle_set_sha256"`
+	InferenceSkipped bool   `yaml:"inference_skipped,omitempty"`
+	Provenance       string `yaml:"provenance,omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -31,7 +45,9 @@ type ModelInputs struct {
 // ---------------------------------------------------------------------------
 
 // Inventory groups all packages found in the analyzed root.
+// Modules lists the Go module paths when the root holds more than one.
 type Inventory struct {
+	Modules     []string       `yaml:"modules,omitempty"`
 	Packages    []PackageEntry `yaml:"packages,omitempty"`
 	Entrypoints []Entrypoint   `yaml:"entrypoints,omitempty"`
 }
@@ -42,13 +58,40 @@ type PackageEntry struct {
 	Files        []string `yaml:"files,omitempty"`
 	Imports      []string `yaml:"imports,omitempty"` // internal package dependencies (by name)
 	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+
+	// ExportedSymbolCount is the public API surface: exported functions
+	// (including methods), types, variables, and constants, each unique
+	// symbol counted once across all files.
+	ExportedSymbolCount int `yaml:"exported_symbol_count"`
+
+	// ErrorSentinels lists the package's Err* sentinel variables (deduped,
+	// sorted): the errors its API documents for errors.Is checks.
+	ErrorSentinels []string `yaml:"error_sentinels,omitempty"`
+
+	// GoGenerate lists the package's //go:generate commands (deduped,
+	// sorted). Such packages may have generated siblings not analyzed here.
+	GoGenerate []string `yaml:"go_generate,omitempty"`
+
+	// CustomSignals lists the user-defined signals (settings "signals")
+	// any of the package's files raised (deduped, sorted).
+	CustomSignals []string `yaml:"custom_signals,omitempty"`
+
+	// Language is set for non-Go units only (see unitNamer).
+	Language string `yaml:"language,omitempty"`
+
+	// Modules lists the Go modules the package's files belong to, when the
+	// root holds more than one module (see Inventory.Modules).
+	Modules []string `yaml:"modules,omitempty"`
 }
 
-// Entrypoint identifies a package+symbol that is a program entry point
-// (package main with a main function).
+// Entrypoint identifies a package+symbol that is a program entry point:
+// main in package main, TestMain, or a function named in settings.
+// Dir is the declaring file's directory, which tells apart several main
+// packages (e.g. cmd/api and cmd/worker).
 type Entrypoint struct {
 	Package      string   `yaml:"package"`
 	Symbol       string   `yaml:"symbol"`
+	Dir          string   `yaml:"dir,omitempty"`
 	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
 }
 
@@ -80,11 +123,14 @@ type Persistence struct {
 // Boundaries
 // ---------------------------------------------------------------------------
 
-// Boundaries groups process, persistence, and network boundary information.
+// Boundaries groups process, persistence, network, API, and configuration
+// boundary information.
 type Boundaries struct {
 	Process     []ProcessBoundary     `yaml:"process,omitempty"`
 	Persistence []PersistenceBoundary `yaml:"persistence,omitempty"`
 	Network     *NetworkBoundary      `yaml:"network,omitempty"`
+	API         []APIBoundary         `yaml:"api,omitempty"`
+	Config      []ConfigBoundary      `yaml:"config,omitempty"`
 }
 
 // ProcessBoundary describes a subprocess or command boundary.
@@ -94,16 +140,70 @@ type ProcessBoundary struct {
 }
 
 // PersistenceBoundary describes a storage system used by the codebase.
+// Tables (db only) lists, per package, the tables its SQL statements write
+// and read.
 type PersistenceBoundary struct {
 	Kind         string      `yaml:"kind"` // "db" | "fs"
 	Writers      []SymbolRef `yaml:"writers,omitempty"`
+	Tables       []TableUse  `yaml:"tables,omitempty"`
 	EvidenceRefs []string    `yaml:"evidence_refs,omitempty"`
 }
 
-// NetworkBoundary describes outbound network usage.
+// TableUse is the set of database tables one package's constant SQL
+// statements touch (see evidence.Query).
+type TableUse struct {
+	Package      string   `yaml:"package"`
+	Writes       []string `yaml:"writes,omitempty"`
+	Reads        []string `yaml:"reads,omitempty"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// NetworkBoundary describes network usage. Outbound lists the files with
+// net_calls; Resilient those that import a retry/backoff or
+// circuit-breaker library. Routes are the HTTP routes the codebase serves
+// and Endpoints the constant URLs it requests.
 type NetworkBoundary struct {
-	Outbound     []SymbolRef `yaml:"outbound,omitempty"`
-	EvidenceRefs []string    `yaml:"evidence_refs,omitempty"`
+	Outbound     []SymbolRef    `yaml:"outbound,omitempty"`
+	Resilient    []SymbolRef    `yaml:"resilient,omitempty"`
+	Routes       []HTTPRoute    `yaml:"routes,omitempty"`
+	Endpoints    []HTTPEndpoint `yaml:"endpoints,omitempty"`
+	EvidenceRefs []string       `yaml:"evidence_refs,omitempty"`
+}
+
+// HTTPRoute is an inbound HTTP route registered by a package. Method is
+// empty when the route accepts any method.
+type HTTPRoute struct {
+	Method       string   `yaml:"method,omitempty"`
+	Path         string   `yaml:"path"`
+	Package      string   `yaml:"package"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// HTTPEndpoint is an outbound HTTP request a package makes to a constant
+// URL.
+type HTTPEndpoint struct {
+	Method       string   `yaml:"method,omitempty"`
+	URL          string   `yaml:"url"`
+	Host         string   `yaml:"host,omitempty"`
+	Package      string   `yaml:"package"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// APIBoundary is a service the codebase exposes, declared in a .proto file.
+type APIBoundary struct {
+	Kind         string   `yaml:"kind"`    // "grpc"
+	Service      string   `yaml:"service"` // "<proto package>.<service>"
+	Methods      []string `yaml:"methods,omitempty"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// ConfigBoundary is one configuration input the codebase reads: an
+// environment variable or a viper key, with the packages that read it.
+type ConfigBoundary struct {
+	Source       string   `yaml:"source"` // "env" | "viper"
+	Key          string   `yaml:"key"`
+	Packages     []string `yaml:"packages"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
 }
 
 // SymbolRef points to a source file (with optional symbol fragment).
@@ -125,16 +225,104 @@ type Effect struct {
 }
 
 // ---------------------------------------------------------------------------
-// Transitions (empty in v1)
+// Transitions
 // ---------------------------------------------------------------------------
 
-// Transition is reserved for v2 (call-graph-based state transitions).
+// Transition is one call from a function or method defined in the analyzed
+// module to another, e.g. "store.Store.Save" → "store.encode", resolved
+// across bundles (see buildTransitions).
 type Transition struct {
 	From         string   `yaml:"from"`
 	To           string   `yaml:"to"`
 	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
 }
 
+// ---------------------------------------------------------------------------
+// Implementations
+// ---------------------------------------------------------------------------
+
+// Implementation records that a type satisfies an interface, e.g.
+// "store.FileStore" → "io.Writer", as found by the type checker (see
+// buildImplementations).
+type Implementation struct {
+	Type         string   `yaml:"type"`
+	Interface    string   `yaml:"interface"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Tested symbols
+// ---------------------------------------------------------------------------
+
+// TestedSymbol is a function or method of the analyzed module exercised by
+// at least one test, from test bundles (analyze --include-tests; see
+// buildTestedSymbols).
+type TestedSymbol struct {
+	Symbol       string   `yaml:"symbol"` // "store.Store.Save"
+	Tests        []string `yaml:"tests"`  // "store_test.TestSave"
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// CLI surface
+// ---------------------------------------------------------------------------
+
+// CLISurface lists the command-line flags of one entrypoint (see
+// buildCLISurface): the flags registered in its own directory plus those
+// registered by functions it reaches, or package-level and in init by
+// packages it reaches. Flags are sorted by name.
+type CLISurface struct {
+	Package string    `yaml:"package"`
+	Symbol  string    `yaml:"symbol"`
+	Dir     string    `yaml:"dir,omitempty"`
+	Flags   []CLIFlag `yaml:"flags"`
+}
+
+// CLIFlag is one command-line flag, as recorded in the evidence bundles.
+type CLIFlag struct {
+	Name         string   `yaml:"name"`
+	Shorthand    string   `yaml:"shorthand,omitempty"`
+	Type         string   `yaml:"type"`
+	Default      string   `yaml:"default,omitempty"`
+	Usage        string   `yaml:"usage,omitempty"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Unreferenced symbols
+// ---------------------------------------------------------------------------
+
+// Unreferenced symbol kinds.
+const (
+	UnreferencedFunc = "func"
+	UnreferencedType = "type"
+)
+
+// UnreferencedSymbol is an exported function or type that no call edge or
+// type in the analyzed bundles refers to (see buildUnreferencedSymbols):
+// dead code, or API used only from outside the module.
+type UnreferencedSymbol struct {
+	Symbol       string   `yaml:"symbol" json:"symbol"` // "store.LegacyOpen"
+	Kind         string   `yaml:"kind" json:"kind"`     // UnreferencedFunc | UnreferencedType
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty" json:"evidence_refs,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Shared mutable state
+// ---------------------------------------------------------------------------
+
+// SharedStateRisk is an exported package-level variable that goroutines may
+// touch without synchronization (see buildSharedStateRisks): its package,
+// or a package importing it, launches goroutines. Launchers lists those
+// files; Domain is the state domain owning the package, if any.
+type SharedStateRisk struct {
+	Variable     string   `yaml:"variable"` // "config.Current"
+	Package      string   `yaml:"package"`
+	Launchers    []string `yaml:"launchers"`
+	Domain       string   `yaml:"domain,omitempty"`
+	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
+}
+
 // ---------------------------------------------------------------------------
 // Trust zones (inferred)
 // ---------------------------------------------------------------------------
@@ -147,14 +335,32 @@ type TrustZone struct {
 	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
 }
 
+// ---------------------------------------------------------------------------
+// Call graph
+// ---------------------------------------------------------------------------
+
+// CallEdge is one package-qualified caller → callee edge in the union of all
+// bundles' calls, e.g. "store.Save" → "os.WriteFile".
+type CallEdge struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
 // ---------------------------------------------------------------------------
 // Concurrency domains
 // ---------------------------------------------------------------------------
 
-// ConcurrencyDomain identifies a file with concurrent code.
+// ConcurrencyDomain identifies a file (or a group of files sharing a state
+// domain) with concurrent code. Goroutines counts the go statements;
+// Primitives ("store.Cache.mu (rwmutex)") and Channels ("store.Cache.evict
+// (chan<- string)") name the sync values and channels declared, qualified
+// by package and owner (see evidence.Concurrency).
 type ConcurrencyDomain struct {
 	ID           string   `yaml:"id"`
 	Files        []string `yaml:"files,omitempty"`
+	Goroutines   int      `yaml:"goroutines,omitempty"`
+	Primitives   []string `yaml:"primitives,omitempty"`
+	Channels     []string `yaml:"channels,omitempty"`
 	EvidenceRefs []string `yaml:"evidence_refs,omitempty"`
 }
 
@@ -5,9 +5,13 @@ package obsidian
 // This file only verifies the wrapper delegates correctly (INV-44).
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"iguana/internal/model"
 )
@@ -100,3 +104,61 @@ func TestGenerateObsidianVault_Idempotent(t *testing.T) {
 		t.Fatalf("walk after second run: %v", err)
 	}
 }
+
+// TestWatchModel_RegeneratesOnChange verifies that editing the model file
+// re-syncs the vault and reports only the pages whose content changed.
+func TestWatchModel_RegeneratesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "system_model.yaml")
+	vault := filepath.Join(dir, "vault")
+	m := minimalModel()
+	if err := model.WriteSystemModel(m, modelPath); err != nil {
+		t.Fatal(err)
+	}
+
+	syncs := make(chan []string, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchModel(ctx, modelPath, vault, 10*time.Millisecond, func(changed []string) {
+			syncs <- changed
+		})
+	}()
+
+	wait := func() []string {
+		t.Helper()
+		select {
+		case changed := <-syncs:
+			return changed
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for vault sync")
+			return nil
+		}
+	}
+
+	initial := wait()
+	if len(initial) == 0 {
+		t.Fatal("expected the initial sync to write pages")
+	}
+
+	m.StateDomains[0].Description = "Stores evidence bundles on disk"
+	if err := model.WriteSystemModel(m, modelPath); err != nil {
+		t.Fatal(err)
+	}
+	changed := wait()
+	if len(changed) >= len(initial) {
+		t.Errorf("expected only affected pages to be rewritten, got %v", changed)
+	}
+	if !slices.Contains(changed, "domains/evidence_store.md") {
+		t.Errorf("changed = %v, want domains/evidence_store.md included", changed)
+	}
+	content := readFile(t, filepath.Join(vault, "domains", "evidence_store.md"))
+	if !strings.Contains(content, "Stores evidence bundles on disk") {
+		t.Errorf("domain page not regenerated:\n%s", content)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchModel returned %v", err)
+	}
+}
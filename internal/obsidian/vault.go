@@ -18,3 +18,13 @@ func GenerateObsidianVault(sys *model.SystemModel, outputDir string) error {
 	}
 	return export.WriteKnowledgeBundle(bundle, outputDir)
 }
+
+// DiffObsidianVault returns the unified diff GenerateObsidianVault would
+// apply to outputDir, without writing anything; "" means no change.
+func DiffObsidianVault(sys *model.SystemModel, outputDir string) (string, error) {
+	bundle, err := export.GenerateKnowledgeBundle(sys)
+	if err != nil {
+		return "", err
+	}
+	return export.DiffKnowledgeBundle(bundle, outputDir)
+}
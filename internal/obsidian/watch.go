@@ -0,0 +1,109 @@
+package obsidian
+
+// watch.go — Keep a vault in sync with a system model file on disk.
+//
+// Polls the model file's content hash (no external file-watching
+// dependency). A change is acted on only once the hash has been stable for
+// one full interval, which debounces editors and generators that write the
+// file in several steps.
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"iguana/internal/export"
+	"iguana/internal/model"
+)
+
+// SyncObsidianVault is GenerateObsidianVault that leaves up-to-date notes
+// untouched and returns the sorted vault-relative paths it wrote.
+func SyncObsidianVault(sys *model.SystemModel, outputDir string) ([]string, error) {
+	bundle, err := export.GenerateKnowledgeBundle(sys)
+	if err != nil {
+		return nil, err
+	}
+	return export.SyncKnowledgeBundle(bundle, outputDir)
+}
+
+// WatchModel regenerates the vault at outputDir from modelPath whenever the
+// model file's content changes, until ctx is cancelled. The vault is synced
+// once at startup. onSync, if non-nil, receives the pages written by each
+// sync. Read or parse errors for a half-written model are retried on the
+// next change; a vault write error stops the watch and is returned.
+func WatchModel(ctx context.Context, modelPath, outputDir string, interval time.Duration, onSync func(changed []string)) error {
+	synced, err := fileHash(modelPath)
+	if err != nil {
+		return err
+	}
+	if err := syncFromFile(modelPath, outputDir, onSync); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pending := synced
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		h, err := fileHash(modelPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // mid-rename; wait for the file to reappear
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case h != pending:
+			pending = h // changed: wait one more interval for it to settle
+		case h != synced:
+			err := syncFromFile(modelPath, outputDir, onSync)
+			var pe *modelParseError
+			if errors.As(err, &pe) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			synced = h
+		}
+	}
+}
+
+// modelParseError marks a model that could not be read, so WatchModel can
+// wait for the next write instead of giving up.
+type modelParseError struct{ err error }
+
+func (e *modelParseError) Error() string { return e.err.Error() }
+func (e *modelParseError) Unwrap() error { return e.err }
+
+// syncFromFile reads the model at modelPath and syncs the vault from it.
+func syncFromFile(modelPath, outputDir string, onSync func(changed []string)) error {
+	sys, err := model.ReadSystemModel(modelPath)
+	if err != nil {
+		return &modelParseError{err}
+	}
+	changed, err := SyncObsidianVault(sys, outputDir)
+	if err != nil {
+		return err
+	}
+	if onSync != nil {
+		onSync(changed)
+	}
+	return nil
+}
+
+// fileHash returns the SHA256 of the file at path.
+func fileHash(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,216 @@
+package server
+
+// server.go — HTTP/JSON API over one analysis root.
+//
+// Lets dashboards browse evidence and system models without shelling out
+// to the CLI and parsing its output.
+//
+// Routes:
+//
+//	GET  /api/bundles         — bundle-set hash and one summary per bundle
+//	GET  /api/bundles/{path}  — one bundle, by its root-relative source path
+//	GET  /api/model           — the system model file, keys as in YAML
+//	POST /api/analyze         — run iguana analyze on the root
+//
+// Errors are returned as {"error": "..."} with a 4xx/5xx status.
+//
+// The API is for the local user only. Every request must name a loopback
+// host (localhost, 127.0.0.1, ::1) in Host and, when sent, Origin, which
+// stops cross-site pages and DNS rebinding. POST /api/analyze writes
+// bundles and runs the settings on_bundle hook, so it also requires the
+// per-session token in the X-Iguana-Token header; a custom header cannot
+// be sent cross-origin without a CORS preflight, which is never granted.
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"iguana/internal/evidence"
+	"iguana/internal/model"
+)
+
+// Server serves the evidence bundles under a root and one system model file.
+type Server struct {
+	root      string
+	modelPath string
+	token     string
+
+	// analyzeMu serializes analysis runs; two walks of one tree would race
+	// on the same bundle files.
+	analyzeMu sync.Mutex
+}
+
+// TokenHeader is the request header carrying the session token that
+// POST /api/analyze requires.
+const TokenHeader = "X-Iguana-Token"
+
+// New returns a Server for the bundles under root and the system model at
+// modelPath, with a fresh random session token (see Token).
+func New(root, modelPath string) *Server {
+	buf := make([]byte, 16)
+	rand.Read(buf) // never returns an error
+	return &Server{root: root, modelPath: modelPath, token: hex.EncodeToString(buf)}
+}
+
+// Token returns the session token clients send in TokenHeader to run
+// analysis.
+func (s *Server) Token() string {
+	return s.token
+}
+
+// BundleSummary is one entry of the GET /api/bundles listing.
+type BundleSummary struct {
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+	Package string `json:"package"`
+}
+
+// BundleList is the GET /api/bundles response.
+type BundleList struct {
+	BundleSetSHA256 string          `json:"bundle_set_sha256"`
+	Bundles         []BundleSummary `json:"bundles"`
+}
+
+// AnalyzeResult is the POST /api/analyze response.
+type AnalyzeResult struct {
+	Written int      `json:"written"`
+	Skipped int      `json:"skipped"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Handler returns the API's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/bundles", s.listBundles)
+	mux.HandleFunc("GET /api/bundles/{path...}", s.getBundle)
+	mux.HandleFunc("GET /api/model", s.getModel)
+	mux.HandleFunc("POST /api/analyze", s.analyze)
+	return localOnly(mux)
+}
+
+// localOnly rejects requests whose Host or Origin is not a loopback host.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not a loopback address", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isLoopbackHost(u.Host) {
+				writeError(w, http.StatusForbidden, fmt.Errorf("origin %q is not a loopback address", origin))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether hostport (with or without a port) names
+// localhost or a loopback IP address.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) listBundles(w http.ResponseWriter, r *http.Request) {
+	agg, ok := s.aggregate(w)
+	if !ok {
+		return
+	}
+	list := BundleList{BundleSetSHA256: agg.BundleSetSHA256, Bundles: []BundleSummary{}}
+	for _, b := range agg.Bundles {
+		list.Bundles = append(list.Bundles, BundleSummary{Path: b.File.Path, SHA256: b.File.SHA256, Package: b.Package.Name})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getBundle(w http.ResponseWriter, r *http.Request) {
+	agg, ok := s.aggregate(w)
+	if !ok {
+		return
+	}
+	path := r.PathValue("path")
+	for _, b := range agg.Bundles {
+		if b.File.Path == path {
+			writeJSON(w, http.StatusOK, b)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no bundle for %s", path))
+}
+
+func (s *Server) getModel(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile(s.modelPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no system model at %s (run iguana system-model first)", s.modelPath))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// Decode generically so the JSON keys match the YAML file's.
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("unmarshal %s: %w", s.modelPath, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *Server) analyze(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or wrong %s header", TokenHeader))
+		return
+	}
+	s.analyzeMu.Lock()
+	written, skipped, errs := evidence.WalkAndGenerate(s.root, evidence.WalkOptions{})
+	s.analyzeMu.Unlock()
+
+	res := AnalyzeResult{Written: written, Skipped: skipped}
+	for _, err := range errs {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// aggregate loads the root's bundles, writing an error response and
+// returning false on failure.
+func (s *Server) aggregate(w http.ResponseWriter) (*model.Aggregate, bool) {
+	agg, err := model.BuildAggregate(s.root)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	return agg, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,160 @@
+package server
+
+// server_test.go — Tests for the HTTP/JSON API.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestServer writes one Go source file under a fresh root and returns a
+// test server for it with the model path root/system_model.yaml. The
+// server's token is sent with every request.
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "store"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := "package store\n\nfunc Save() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "store", "store.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(root, filepath.Join(root, "system_model.yaml"))
+	testToken = srv.Token()
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, root
+}
+
+// testToken is the token of the server newTestServer last started.
+var testToken string
+
+// getJSON fetches url, checks the status, and decodes the body into v.
+func getJSON(t *testing.T, method, url string, wantStatus int, v any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(TokenHeader, testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d", method, url, resp.StatusCode, wantStatus)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: decode: %v", method, url, err)
+	}
+}
+
+// TestServer_AnalyzeThenBrowse verifies that an analysis run triggered over
+// the API produces bundles that can then be listed and fetched.
+func TestServer_AnalyzeThenBrowse(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	var errBody map[string]string
+	getJSON(t, "GET", ts.URL+"/api/bundles", http.StatusNotFound, &errBody)
+	if errBody["error"] == "" {
+		t.Error("expected an error message before analysis")
+	}
+
+	var res AnalyzeResult
+	getJSON(t, "POST", ts.URL+"/api/analyze", http.StatusOK, &res)
+	if res.Written != 1 || len(res.Errors) != 0 {
+		t.Fatalf("analyze = %+v, want 1 written and no errors", res)
+	}
+
+	var list BundleList
+	getJSON(t, "GET", ts.URL+"/api/bundles", http.StatusOK, &list)
+	if len(list.Bundles) != 1 || list.Bundles[0].Path != "store/store.go" || list.Bundles[0].Package != "store" {
+		t.Fatalf("bundles = %+v", list.Bundles)
+	}
+	if list.BundleSetSHA256 == "" {
+		t.Error("expected a bundle set hash")
+	}
+
+	var bundle struct {
+		File    struct{ Path string }
+		Symbols struct {
+			Functions []struct{ Name string }
+		}
+	}
+	getJSON(t, "GET", ts.URL+"/api/bundles/store/store.go", http.StatusOK, &bundle)
+	if bundle.File.Path != "store/store.go" || len(bundle.Symbols.Functions) != 1 || bundle.Symbols.Functions[0].Name != "Save" {
+		t.Errorf("bundle = %+v", bundle)
+	}
+	getJSON(t, "GET", ts.URL+"/api/bundles/store/missing.go", http.StatusNotFound, &errBody)
+}
+
+// TestServer_Model verifies that the model is served with its YAML keys and
+// that a missing model is a 404.
+func TestServer_Model(t *testing.T) {
+	ts, root := newTestServer(t)
+
+	var errBody map[string]string
+	getJSON(t, "GET", ts.URL+"/api/model", http.StatusNotFound, &errBody)
+
+	yml := "version: 1\ninputs:\n  bundle_set_sha256: abc\n"
+	if err := os.WriteFile(filepath.Join(root, "system_model.yaml"), []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	getJSON(t, "GET", ts.URL+"/api/model", http.StatusOK, &doc)
+	inputs, _ := doc["inputs"].(map[string]any)
+	if inputs["bundle_set_sha256"] != "abc" {
+		t.Errorf("model = %v, want inputs.bundle_set_sha256 = abc", doc)
+	}
+}
+
+// TestServer_RejectsCrossSite verifies that analysis needs the session
+// token and that non-loopback Host and Origin headers are refused.
+func TestServer_RejectsCrossSite(t *testing.T) {
+	ts, root := newTestServer(t)
+
+	do := func(method, path string, header map[string]string, host string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := do("POST", "/api/analyze", nil, ""); got != http.StatusUnauthorized {
+		t.Errorf("analyze without token: status %d, want 401", got)
+	}
+	if got := do("POST", "/api/analyze", map[string]string{TokenHeader: "wrong"}, ""); got != http.StatusUnauthorized {
+		t.Errorf("analyze with wrong token: status %d, want 401", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "store", "store.go.evidence.yaml")); !os.IsNotExist(err) {
+		t.Errorf("rejected analyze wrote a bundle: %v", err)
+	}
+	if got := do("GET", "/api/model", nil, "evil.example:7070"); got != http.StatusForbidden {
+		t.Errorf("rebinding Host: status %d, want 403", got)
+	}
+	if got := do("POST", "/api/analyze", map[string]string{TokenHeader: testToken, "Origin": "https://evil.example"}, ""); got != http.StatusForbidden {
+		t.Errorf("cross-site Origin: status %d, want 403", got)
+	}
+	if got := do("GET", "/api/model", map[string]string{"Origin": "http://localhost:3000"}, "localhost:7070"); got != http.StatusNotFound {
+		t.Errorf("local Origin: status %d, want 404 (no model yet)", got)
+	}
+}
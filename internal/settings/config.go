@@ -0,0 +1,204 @@
+package settings
+
+// config.go — user preferences loaded from .iguana/config.yaml.
+//
+// Preferences live at two levels: a user-global ~/.iguana/config.yaml and a
+// per-repo <root>/.iguana/config.yaml. The repo file overrides the global one
+// field by field; command-line flags override both.
+//
+// The "llm" key selects the LLM backends used for the BAML functions in
+// place of the clients compiled into baml_client, so each repository (or
+// container image, through its ~/.iguana) can pick its own:
+//
+//	llm:
+//	  providers:
+//	    - name: local
+//	      provider: openai-generic
+//	      model: llama4
+//	      base_url: http://localhost:11434/v1
+//	      timeout: 2m
+//	    - name: claude
+//	      provider: anthropic
+//	      model: claude-sonnet-4-20250514
+//	      api_key_env: ANTHROPIC_API_KEY
+//	  functions:
+//	    TypeOfState: [local]
+//
+// Providers are tried in order until one succeeds; "functions" overrides
+// that fallback order per BAML function.
+//
+// A provider's base_url and api_key_env decide where requests go and which
+// environment variable is sent as the key, so a repository cloned from
+// elsewhere must not set them: they are honored only in the global file,
+// unless it sets trust_repo_llm: true. Otherwise they are dropped from the
+// repo's providers with a warning (Config.Warnings).
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds user preferences. Empty fields mean "not set" so that a lower
+// level can supply the value.
+type Config struct {
+	// Format is the default output format for commands that accept --format
+	// (e.g. "yaml" or "table" for system-model).
+	Format string `yaml:"format"`
+
+	// PinCommit, when set, is the commit analyze expects HEAD to be at; a
+	// moved ref is an error rather than a silent change of input. A commit
+	// names one repository, so it is read only from a repo config (see
+	// PinnedCommit), never from the global one.
+	PinCommit string `yaml:"pin_commit"`
+
+	// LLM selects the LLM providers for BAML function calls. A level that
+	// lists providers replaces the lower level's LLM section entirely.
+	LLM LLMConfig `yaml:"llm"`
+
+	// TrustRepoLLM lets repo configs set base_url and api_key_env on their
+	// LLM providers. Only read from the global config.
+	TrustRepoLLM bool `yaml:"trust_repo_llm"`
+
+	// Warnings describes repo settings LoadConfig ignored; not read from
+	// any file.
+	Warnings []string `yaml:"-"`
+}
+
+// LLMConfig is the "llm" section of a config file.
+type LLMConfig struct {
+	// Providers are the configured backends, in default fallback order.
+	Providers []LLMProvider `yaml:"providers"`
+
+	// Functions maps a BAML function name (e.g. "InferSystemModel") to the
+	// names of the providers to try for it, in order.
+	Functions map[string][]string `yaml:"functions"`
+}
+
+// LLMProvider is one LLM backend, registered with BAML as a client.
+type LLMProvider struct {
+	Name      string `yaml:"name"`
+	Provider  string `yaml:"provider"` // BAML provider: "openai", "anthropic", "openai-generic", ...
+	Model     string `yaml:"model"`
+	BaseURL   string `yaml:"base_url"`
+	APIKeyEnv string `yaml:"api_key_env"` // environment variable holding the API key
+	Timeout   string `yaml:"timeout"`     // per-call timeout, e.g. "90s"; empty for none
+}
+
+// TimeoutDuration parses Timeout, returning zero when it is empty.
+func (p LLMProvider) TimeoutDuration() (time.Duration, error) {
+	if p.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("llm provider %q: invalid timeout %q: %w", p.Name, p.Timeout, err)
+	}
+	return d, nil
+}
+
+// Chain returns the providers to try for the BAML function fn, in order:
+// the ones named under Functions[fn], else all Providers. An empty chain
+// means the client compiled into baml_client.
+func (c LLMConfig) Chain(fn string) ([]LLMProvider, error) {
+	names, ok := c.Functions[fn]
+	if !ok {
+		return c.Providers, nil
+	}
+	byName := make(map[string]LLMProvider, len(c.Providers))
+	for _, p := range c.Providers {
+		byName[p.Name] = p
+	}
+	chain := make([]LLMProvider, 0, len(names))
+	for _, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("llm function %s: unknown provider %q", fn, name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+// Merge returns c with every non-empty field of over applied on top.
+func (c Config) Merge(over Config) Config {
+	if over.Format != "" {
+		c.Format = over.Format
+	}
+	if over.PinCommit != "" {
+		c.PinCommit = over.PinCommit
+	}
+	if len(over.LLM.Providers) > 0 {
+		c.LLM = over.LLM
+	}
+	return c
+}
+
+// LoadConfig returns the global config merged beneath the repo config at
+// root. Missing files are not an error; an unreadable or invalid file is.
+func LoadConfig(root string) (Config, error) {
+	var cfg Config
+	if home, err := os.UserHomeDir(); err == nil {
+		global, err := readConfig(filepath.Join(home, ".iguana", "config.yaml"))
+		if err != nil {
+			return Config{}, err
+		}
+		global.PinCommit = ""
+		cfg = cfg.Merge(global)
+		cfg.TrustRepoLLM = global.TrustRepoLLM
+	}
+	path := filepath.Join(root, ".iguana", "config.yaml")
+	repo, err := readConfig(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if !cfg.TrustRepoLLM {
+		cfg.Warnings = repo.LLM.dropEndpoints(path)
+	}
+	return cfg.Merge(repo), nil
+}
+
+// PinnedCommit returns the pin_commit of root's own .iguana/config.yaml,
+// the repository analyze reads, or "" if it sets none.
+func PinnedCommit(root string) (string, error) {
+	cfg, err := readConfig(filepath.Join(root, ".iguana", "config.yaml"))
+	if err != nil {
+		return "", err
+	}
+	return cfg.PinCommit, nil
+}
+
+// dropEndpoints clears base_url and api_key_env from c's providers,
+// returning a warning for each provider changed in the file at path.
+func (c LLMConfig) dropEndpoints(path string) []string {
+	var warnings []string
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		if p.BaseURL == "" && p.APIKeyEnv == "" {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: ignoring base_url and api_key_env of llm provider %q; set them in ~/.iguana/config.yaml or set trust_repo_llm: true there", path, p.Name))
+		p.BaseURL, p.APIKeyEnv = "", ""
+	}
+	return warnings
+}
+
+// readConfig parses one config file, returning the zero Config if it does
+// not exist.
+func readConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	return cfg, nil
+}
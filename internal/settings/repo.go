@@ -0,0 +1,132 @@
+package settings
+
+// repo.go — path configuration from .iguana.yaml in the repository root.
+//
+// .iguana.yaml is the one file a repository commits to control which paths
+// iguana reads; every reader (analyze, the plugin and test-bundle walks,
+// and system-model's bundle loading) gets it through LoadSettings, so the
+// rules apply uniformly:
+//
+//	deny:              # globs of paths never read (MatchGlob syntax)
+//	  - "gen/**"
+//	allow:             # exceptions to deny, even beneath a denied directory
+//	  - "gen/api/**"
+//	include_tests: true       # also write test bundles (analyze --include-tests)
+//	include_generated: false  # skip "// Code generated ... DO NOT EDIT." files
+//
+// Unknown keys and malformed globs are errors, reported by "iguana config
+// validate". The file layers on top of .iguana/settings.yaml: its deny and
+// allow lists are appended and its scalars win when set.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the name of the path configuration file in the
+// repository root.
+const RepoFileName = ".iguana.yaml"
+
+// RepoConfig is the content of .iguana.yaml.
+type RepoConfig struct {
+	// Deny lists globs of root-relative paths iguana does not read, as in
+	// Permissions.Deny.
+	Deny []string `yaml:"deny"`
+
+	// Allow lists globs exempted from Deny; a denied directory is still
+	// walked when an allow glob may match beneath it.
+	Allow []string `yaml:"allow"`
+
+	// IncludeTests writes a test bundle for every _test.go file, as if
+	// analyze were given --include-tests.
+	IncludeTests bool `yaml:"include_tests"`
+
+	// IncludeGenerated, when false, skips Go files marked generated
+	// ("// Code generated ... DO NOT EDIT."). Unset means true.
+	IncludeGenerated *bool `yaml:"include_generated"`
+}
+
+// ReadRepoConfig reads and validates root's .iguana.yaml, returning nil
+// (not an error) if it does not exist.
+func ReadRepoConfig(root string) (*RepoConfig, error) {
+	p := filepath.Join(root, RepoFileName)
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", p, err)
+	}
+	c, err := ParseRepoConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return c, nil
+}
+
+// ParseRepoConfig decodes .iguana.yaml content, rejecting unknown keys,
+// values of the wrong type, and malformed globs. An empty document is a
+// valid, empty configuration.
+func ParseRepoConfig(data []byte) (*RepoConfig, error) {
+	var c RepoConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate returns an error naming every empty or malformed glob.
+func (c *RepoConfig) Validate() error {
+	var errs []error
+	for _, list := range []struct {
+		key   string
+		globs []string
+	}{{"deny", c.Deny}, {"allow", c.Allow}} {
+		for i, g := range list.globs {
+			if err := validGlob(g); err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d] %q: %w", list.key, i, g, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validGlob checks a MatchGlob pattern, after stripping a Read(...) wrapper
+// and "./" as deny rules are.
+func validGlob(g string) error {
+	g = parseDenyRule(g)
+	if g == "" {
+		return errors.New("empty glob")
+	}
+	for _, seg := range strings.Split(g, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settings returns c as a Settings layer for Merge.
+func (c *RepoConfig) settings() *Settings {
+	if c == nil {
+		return nil
+	}
+	return &Settings{
+		Permissions:      Permissions{Deny: c.Deny, Allow: c.Allow},
+		IncludeTests:     c.IncludeTests,
+		IncludeGenerated: c.IncludeGenerated,
+	}
+}
@@ -7,12 +7,30 @@ package settings
 // written as bare globs ("baml_client/**") or wrapped in a Read() verb
 // ("Read(./baml_client/**)") for familiarity.
 //
+// Like config.yaml, settings live at two levels: a user-global
+// ~/.iguana/settings.yaml holds defaults shared by every repository on the
+// machine (or container image), and the repo's own file builds on them.
+// Deny rules and entrypoints accumulate, a repo signal replaces the global
+// one of the same name, and scalar fields set in the repo win. The path
+// rules of .iguana.yaml (see repo.go) are layered on top last.
+//
+// on_bundle runs a program on every analyze, so a repository cloned from
+// elsewhere must not set it: the repo's value is honored only when the
+// global file sets trust_repo_hooks: true, and is otherwise dropped with a
+// warning (Settings.Warnings).
+//
 // See INVARIANT.md INV-39.
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -21,6 +39,55 @@ import (
 // Settings holds iguana configuration from .iguana/settings.yaml.
 type Settings struct {
 	Permissions Permissions `yaml:"permissions"`
+
+	// OnBundle is a command run after each bundle is written, given as an
+	// argv list (no shell is involved). "{file}" and "{bundle}" in any
+	// argument are replaced with the root-relative source and bundle paths.
+	// Example: ["indexer", "add", "{bundle}"]
+	// A repo's own value is ignored unless the global file trusts it.
+	OnBundle []string `yaml:"on_bundle"`
+
+	// TrustRepoHooks lets repo settings set OnBundle. Only read from the
+	// global settings file.
+	TrustRepoHooks bool `yaml:"trust_repo_hooks"`
+
+	// Entrypoints names extra top-level functions that start a program,
+	// such as a serverless handler or plugin hook, recorded as entrypoints
+	// in the system model alongside main and TestMain.
+	// Example: ["Handler", "Register"]
+	Entrypoints []string `yaml:"entrypoints"`
+
+	// Signals defines extra signals, recorded by name in each matching
+	// bundle's signals.custom list next to the built-in signals.
+	// Example: [{name: kafka, imports: ["github.com/segmentio/kafka-go"]}]
+	Signals []SignalDef `yaml:"signals"`
+
+	// MinConfidence is the confidence below which an inferred state domain
+	// is held in the system model's pending_review section, for
+	// "iguana review", instead of being used. Zero accepts every domain.
+	// Example: 0.7
+	MinConfidence float64 `yaml:"min_confidence"`
+
+	// IncludeTests makes analyze write test bundles as with --include-tests.
+	IncludeTests bool `yaml:"include_tests"`
+
+	// IncludeGenerated, when false, skips Go files marked generated ("//
+	// Code generated ... DO NOT EDIT."). Unset means true.
+	IncludeGenerated *bool `yaml:"include_generated"`
+
+	// Warnings describes repo settings LoadSettings ignored; not read from
+	// any file.
+	Warnings []string `yaml:"-"`
+}
+
+// SignalDef is one user-defined signal. A file has the signal when any of
+// its imports starts with an Imports prefix or any of its call targets
+// matches a Calls glob ("sarama.NewSyncProducer", "redis.*"; path.Match
+// syntax).
+type SignalDef struct {
+	Name    string   `yaml:"name"`
+	Imports []string `yaml:"imports"`
+	Calls   []string `yaml:"calls"`
 }
 
 // Permissions controls which files iguana reads.
@@ -29,12 +96,51 @@ type Permissions struct {
 	// Patterns may be bare globs or wrapped in Read(...).
 	// Example: ["Read(./baml_client/**)"]
 	Deny []string `yaml:"deny"`
+
+	// Allow is a list of glob patterns exempted from Deny.
+	// Example: ["baml_client/types/**"]
+	Allow []string `yaml:"allow"`
 }
 
-// LoadSettings reads .iguana/settings.yaml relative to root.
-// Returns nil (not an error) if the file does not exist.
+// LoadSettings reads .iguana/settings.yaml relative to root, merged on top
+// of the global ~/.iguana/settings.yaml, with root's .iguana.yaml on top of
+// both. Returns nil (not an error) if none of the files exists. The repo's
+// on_bundle is dropped with a warning unless the global file sets
+// trust_repo_hooks.
 func LoadSettings(root string) (*Settings, error) {
+	var global *Settings
+	if home, err := os.UserHomeDir(); err == nil {
+		if global, err = readSettings(filepath.Join(home, ".iguana", "settings.yaml")); err != nil {
+			return nil, err
+		}
+	}
 	path := filepath.Join(root, ".iguana", "settings.yaml")
+	repo, err := readSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := ReadRepoConfig(root)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	if repo != nil {
+		repo.TrustRepoHooks = false
+		if len(repo.OnBundle) > 0 && (global == nil || !global.TrustRepoHooks) {
+			warnings = append(warnings, fmt.Sprintf("%s: ignoring on_bundle; set it in ~/.iguana/settings.yaml or set trust_repo_hooks: true there", path))
+			repo.OnBundle = nil
+		}
+	}
+	s := global.Merge(repo).Merge(paths.settings())
+	if s != nil {
+		s.Warnings = warnings
+	}
+	return s, nil
+}
+
+// readSettings parses one settings file, returning nil if it does not
+// exist.
+func readSettings(path string) (*Settings, error) {
 	data, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
 		return nil, nil
@@ -49,14 +155,182 @@ func LoadSettings(root string) (*Settings, error) {
 	return &s, nil
 }
 
+// Merge returns s with over layered on top: deny and allow rules and
+// entrypoints are appended (duplicates dropped), a signal in over replaces
+// the one of the same name in s, and OnBundle, MinConfidence,
+// IncludeTests, and IncludeGenerated are taken from over when set.
+// TrustRepoHooks is kept from s. Either receiver may be nil; the result is
+// nil only if both are.
+func (s *Settings) Merge(over *Settings) *Settings {
+	if s == nil || over == nil {
+		if s == nil {
+			return over
+		}
+		return s
+	}
+	m := *s
+	m.Permissions.Deny = appendNew(slices.Clone(s.Permissions.Deny), over.Permissions.Deny)
+	m.Permissions.Allow = appendNew(slices.Clone(s.Permissions.Allow), over.Permissions.Allow)
+	m.Entrypoints = appendNew(slices.Clone(s.Entrypoints), over.Entrypoints)
+	m.Signals = nil
+	for _, d := range s.Signals {
+		if !slices.ContainsFunc(over.Signals, func(o SignalDef) bool { return o.Name == d.Name }) {
+			m.Signals = append(m.Signals, d)
+		}
+	}
+	m.Signals = append(m.Signals, over.Signals...)
+	if len(over.OnBundle) > 0 {
+		m.OnBundle = over.OnBundle
+	}
+	if over.MinConfidence != 0 {
+		m.MinConfidence = over.MinConfidence
+	}
+	if over.IncludeTests {
+		m.IncludeTests = true
+	}
+	if over.IncludeGenerated != nil {
+		m.IncludeGenerated = over.IncludeGenerated
+	}
+	return &m
+}
+
+// appendNew appends the elements of add not already in dst.
+func appendNew(dst, add []string) []string {
+	for _, v := range add {
+		if !slices.Contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// BundleHook returns the OnBundle argv with {file} and {bundle} substituted,
+// or nil when no hook is configured. Safe to call on a nil *Settings receiver.
+func (s *Settings) BundleHook(file, bundle string) []string {
+	if s == nil || len(s.OnBundle) == 0 {
+		return nil
+	}
+	r := strings.NewReplacer("{file}", file, "{bundle}", bundle)
+	argv := make([]string, len(s.OnBundle))
+	for i, arg := range s.OnBundle {
+		argv[i] = r.Replace(arg)
+	}
+	return argv
+}
+
+// EntrypointFuncs returns the configured entrypoint function names. Safe to
+// call on a nil *Settings receiver.
+func (s *Settings) EntrypointFuncs() []string {
+	if s == nil {
+		return nil
+	}
+	return s.Entrypoints
+}
+
+// ReviewThreshold returns MinConfidence. Safe to call on a nil *Settings
+// receiver.
+func (s *Settings) ReviewThreshold() float64 {
+	if s == nil {
+		return 0
+	}
+	return s.MinConfidence
+}
+
+// CustomSignals returns the sorted, deduplicated names of the configured
+// signals that imports (import paths) or calls (call targets) match. Safe to
+// call on a nil *Settings receiver.
+func (s *Settings) CustomSignals(imports, calls []string) []string {
+	if s == nil {
+		return nil
+	}
+	var names []string
+	for _, def := range s.Signals {
+		if def.Name != "" && !slices.Contains(names, def.Name) && def.matches(imports, calls) {
+			names = append(names, def.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SignalsSHA256 returns the hex SHA-256 of the signal definitions, or ""
+// when none are defined. Bundles record it so that editing signals makes
+// them stale. Safe to call on a nil *Settings receiver.
+func (s *Settings) SignalsSHA256() string {
+	if s == nil || len(s.Signals) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(s.Signals)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// matches reports whether any import or call satisfies d.
+func (d SignalDef) matches(imports, calls []string) bool {
+	for _, prefix := range d.Imports {
+		for _, imp := range imports {
+			if strings.HasPrefix(imp, prefix) {
+				return true
+			}
+		}
+	}
+	for _, pattern := range d.Calls {
+		for _, call := range calls {
+			if ok, _ := path.Match(pattern, call); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestsIncluded returns IncludeTests. Safe to call on a nil *Settings
+// receiver.
+func (s *Settings) TestsIncluded() bool {
+	return s != nil && s.IncludeTests
+}
+
+// SkipsGenerated reports whether generated Go files are excluded, i.e.
+// IncludeGenerated is set to false. Safe to call on a nil *Settings
+// receiver.
+func (s *Settings) SkipsGenerated() bool {
+	return s != nil && s.IncludeGenerated != nil && !*s.IncludeGenerated
+}
+
 // IsDenied reports whether relPath (forward-slash, relative to root) matches
-// any deny rule. Safe to call on a nil *Settings receiver.
+// any deny rule and no allow rule. Safe to call on a nil *Settings receiver.
 func (s *Settings) IsDenied(relPath string) bool {
 	if s == nil {
 		return false
 	}
 	for _, rule := range s.Permissions.Deny {
 		if matchDenyPattern(parseDenyRule(rule), relPath) {
+			return !s.isAllowed(relPath)
+		}
+	}
+	return false
+}
+
+// IsDirDenied reports whether the directory relPath should not be walked:
+// it is denied and no allow rule can match it or anything beneath it. Safe
+// to call on a nil *Settings receiver.
+func (s *Settings) IsDirDenied(relPath string) bool {
+	if !s.IsDenied(relPath) {
+		return false
+	}
+	segs := strings.Split(relPath, "/")
+	for _, rule := range s.Permissions.Allow {
+		if matchPrefixSegments(strings.Split(parseDenyRule(rule), "/"), segs) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAllowed reports whether relPath matches any allow rule.
+func (s *Settings) isAllowed(relPath string) bool {
+	for _, rule := range s.Permissions.Allow {
+		if MatchGlob(parseDenyRule(rule), relPath) {
 			return true
 		}
 	}
@@ -79,10 +353,56 @@ func parseDenyRule(rule string) string {
 // "prefix/**" matches the prefix directory itself and every path beneath it.
 // All other patterns use filepath.Match semantics (single * does not cross /).
 func matchDenyPattern(pattern, path string) bool {
-	if strings.HasSuffix(pattern, "/**") {
-		prefix := strings.TrimSuffix(pattern, "/**")
-		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	return MatchGlob(pattern, path)
+}
+
+// MatchGlob reports whether the forward-slash path matches pattern.
+//
+// A "**" segment matches zero or more whole path segments, so "prefix/**"
+// matches the prefix itself and everything beneath it, and "internal/**/*.go"
+// matches Go files at any depth under internal/. Every other segment uses
+// path.Match semantics (single * does not cross /).
+func MatchGlob(pattern, relPath string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+// matchPrefixSegments reports whether path segments segs can be the start
+// of a path pattern segments pat matches.
+func matchPrefixSegments(pat, segs []string) bool {
+	for len(segs) > 0 {
+		if len(pat) == 0 {
+			return false
+		}
+		if pat[0] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return true
+}
+
+// matchSegments matches pattern segments against path segments, expanding
+// "**" to every possible run of path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
 	}
-	matched, _ := filepath.Match(pattern, path)
-	return matched
+	return len(segs) == 0
 }
@@ -5,6 +5,8 @@ package settings
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +71,40 @@ func TestMatchDenyPattern(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// MatchGlob
+// ---------------------------------------------------------------------------
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// ** in the middle matches zero or more segments.
+		{"internal/**/*.go", "internal/store.go", true},
+		{"internal/**/*.go", "internal/store/store.go", true},
+		{"internal/**/*.go", "internal/a/b/c.go", true},
+		{"internal/**/*.go", "cmd/main.go", false},
+		{"internal/**/*.go", "internal/store/README.md", false},
+		// Leading ** matches at any depth.
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/main.go", true},
+		// Trailing ** matches the prefix itself.
+		{"vendor/**", "vendor", true},
+		{"vendor/**", "vendor/x/y.go", true},
+		// Plain patterns behave like path.Match.
+		{"*.go", "dir/main.go", false},
+		{"cmd/*/main.go", "cmd/iguana/main.go", true},
+	}
+	for _, tc := range tests {
+		got := MatchGlob(tc.pattern, tc.path)
+		if got != tc.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // IsDenied
 // ---------------------------------------------------------------------------
@@ -108,6 +144,36 @@ func TestSettings_IsDenied(t *testing.T) {
 	}
 }
 
+// TestSettings_Allow verifies that allow rules exempt paths from deny rules
+// and keep a denied directory walkable when they reach beneath it.
+func TestSettings_Allow(t *testing.T) {
+	s := &Settings{Permissions: Permissions{
+		Deny:  []string{"gen/**", "tools/**"},
+		Allow: []string{"gen/api/**"},
+	}}
+	for p, want := range map[string]bool{
+		"gen/x.go":        true,
+		"gen/api/x.go":    false,
+		"gen/api/v1/y.go": false,
+		"tools/z.go":      true,
+		"main.go":         false,
+	} {
+		if got := s.IsDenied(p); got != want {
+			t.Errorf("IsDenied(%q) = %v, want %v", p, got, want)
+		}
+	}
+	for p, want := range map[string]bool{
+		"gen":       false,
+		"gen/api":   false,
+		"gen/other": true,
+		"tools":     true,
+	} {
+		if got := s.IsDirDenied(p); got != want {
+			t.Errorf("IsDirDenied(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
 func TestSettings_IsDenied_NilReceiver(t *testing.T) {
 	var s *Settings
 	if s.IsDenied("anything") {
@@ -115,11 +181,53 @@ func TestSettings_IsDenied_NilReceiver(t *testing.T) {
 	}
 }
 
+func TestSettings_BundleHook(t *testing.T) {
+	s := &Settings{OnBundle: []string{"indexer", "--src={file}", "{bundle}"}}
+	got := s.BundleHook("pkg/a.go", "pkg/a.go.evidence.yaml")
+	want := []string{"indexer", "--src=pkg/a.go", "pkg/a.go.evidence.yaml"}
+	if len(got) != len(want) {
+		t.Fatalf("BundleHook = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("BundleHook[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	var nilSettings *Settings
+	if argv := nilSettings.BundleHook("a.go", "a.go.evidence.yaml"); argv != nil {
+		t.Errorf("nil Settings.BundleHook = %v, want nil", argv)
+	}
+}
+
+func TestSettings_CustomSignals(t *testing.T) {
+	s := &Settings{Signals: []SignalDef{
+		{Name: "redis", Calls: []string{"redis.*"}},
+		{Name: "kafka", Imports: []string{"github.com/segmentio/kafka-go"}},
+		{Name: "kafka", Imports: []string{"github.com/IBM/sarama"}},
+		{Name: "grpc", Imports: []string{"google.golang.org/grpc"}},
+	}}
+	got := s.CustomSignals(
+		[]string{"github.com/segmentio/kafka-go/compress", "github.com/IBM/sarama"},
+		[]string{"redis.NewClient", "fmt.Println"},
+	)
+	want := []string{"kafka", "redis"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CustomSignals = %v, want %v", got, want)
+	}
+
+	var nilSettings *Settings
+	if names := nilSettings.CustomSignals([]string{"google.golang.org/grpc"}, nil); names != nil {
+		t.Errorf("nil Settings.CustomSignals = %v, want nil", names)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // LoadSettings
 // ---------------------------------------------------------------------------
 
 func TestLoadSettings_FileNotExist(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	dir := t.TempDir()
 	s, err := LoadSettings(dir)
 	if err != nil {
@@ -179,3 +287,291 @@ func TestLoadSettings_InvalidYAML(t *testing.T) {
 		t.Error("expected error for invalid YAML, got nil")
 	}
 }
+
+func TestLoadSettings_GlobalMerge(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	write := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".iguana"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".iguana", "settings.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(home, `
+permissions:
+  deny: ["vendor/**"]
+signals:
+  - {name: kafka, imports: ["github.com/segmentio/kafka-go"]}
+  - {name: redis, imports: ["github.com/redis/go-redis"]}
+min_confidence: 0.5
+`)
+
+	// Global settings alone apply to a repo without its own file.
+	s, err := LoadSettings(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if s == nil || !s.IsDenied("vendor/x.go") || s.ReviewThreshold() != 0.5 {
+		t.Fatalf("global settings not inherited: %+v", s)
+	}
+
+	root := t.TempDir()
+	write(root, `
+permissions:
+  deny: ["gen/**", "vendor/**"]
+signals:
+  - {name: kafka, imports: ["github.com/IBM/sarama"]}
+min_confidence: 0.8
+`)
+	s, err = LoadSettings(root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if want := []string{"vendor/**", "gen/**"}; !reflect.DeepEqual(s.Permissions.Deny, want) {
+		t.Errorf("Deny = %v, want %v", s.Permissions.Deny, want)
+	}
+	if s.ReviewThreshold() != 0.8 {
+		t.Errorf("MinConfidence = %v, want repo value 0.8", s.MinConfidence)
+	}
+	if got := s.CustomSignals([]string{"github.com/segmentio/kafka-go"}, nil); len(got) != 0 {
+		t.Errorf("global kafka signal should be overridden, got %v", got)
+	}
+	if got := s.CustomSignals([]string{"github.com/IBM/sarama", "github.com/redis/go-redis"}, nil); !reflect.DeepEqual(got, []string{"kafka", "redis"}) {
+		t.Errorf("CustomSignals = %v, want [kafka redis]", got)
+	}
+}
+
+// TestLoadSettings_RepoOnBundle verifies that a repo's on_bundle is dropped
+// with a warning unless the global settings set trust_repo_hooks, which the
+// repo cannot set for itself, and that a global on_bundle always applies.
+func TestLoadSettings_RepoOnBundle(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	write := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".iguana"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".iguana", "settings.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	write(root, "trust_repo_hooks: true\non_bundle: [evil, \"{file}\"]\n")
+
+	s, err := LoadSettings(root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if s.BundleHook("a.go", "a.go.evidence.yaml") != nil || s.TrustRepoHooks {
+		t.Errorf("untrusted repo hook kept: %+v", s)
+	}
+	if len(s.Warnings) != 1 || !strings.Contains(s.Warnings[0], "ignoring on_bundle") {
+		t.Errorf("Warnings = %v, want one about on_bundle", s.Warnings)
+	}
+
+	write(home, "on_bundle: [indexer, \"{bundle}\"]\n")
+	if s, err = LoadSettings(t.TempDir()); err != nil || !reflect.DeepEqual(s.BundleHook("a.go", "b"), []string{"indexer", "b"}) {
+		t.Errorf("global hook: %+v, %v", s, err)
+	}
+
+	write(home, "trust_repo_hooks: true\n")
+	s, err = LoadSettings(root)
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if !reflect.DeepEqual(s.BundleHook("a.go", "b"), []string{"evil", "a.go"}) || len(s.Warnings) != 0 {
+		t.Errorf("trusted repo hook: %+v", s)
+	}
+}
+
+// TestParseRepoConfig verifies that .iguana.yaml decoding rejects unknown
+// keys, wrong types, and malformed globs.
+func TestParseRepoConfig(t *testing.T) {
+	c, err := ParseRepoConfig([]byte("deny: [\"gen/**\"]\nallow: [\"gen/api/**\"]\ninclude_tests: true\ninclude_generated: false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Deny, []string{"gen/**"}) || !c.IncludeTests || c.IncludeGenerated == nil || *c.IncludeGenerated {
+		t.Errorf("config = %+v", c)
+	}
+	if _, err := ParseRepoConfig(nil); err != nil {
+		t.Errorf("empty file: %v", err)
+	}
+	for _, doc := range []string{
+		"denny: [\"gen/**\"]\n",
+		"include_tests: maybe\n",
+		"deny: [\"gen/[\"]\n",
+		"allow: [\"\"]\n",
+	} {
+		if _, err := ParseRepoConfig([]byte(doc)); err == nil {
+			t.Errorf("ParseRepoConfig(%q): want error", doc)
+		}
+	}
+}
+
+// TestLoadSettings_RepoConfig verifies that .iguana.yaml is layered on top
+// of .iguana/settings.yaml.
+func TestLoadSettings_RepoConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".iguana"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".iguana", "settings.yaml"), []byte("permissions:\n  deny: [\"vendor/**\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, RepoFileName), []byte("deny: [\"gen/**\"]\nallow: [\"gen/keep.go\"]\ninclude_generated: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSettings(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsDenied("vendor/a.go") || !s.IsDenied("gen/a.go") || s.IsDenied("gen/keep.go") {
+		t.Errorf("deny/allow not merged: %+v", s.Permissions)
+	}
+	if !s.SkipsGenerated() || s.TestsIncluded() {
+		t.Errorf("SkipsGenerated = %v, TestsIncluded = %v; want true, false", s.SkipsGenerated(), s.TestsIncluded())
+	}
+
+	if err := os.WriteFile(filepath.Join(root, RepoFileName), []byte("deny: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSettings(root); err == nil {
+		t.Error("invalid .iguana.yaml: want error")
+	}
+}
+
+// writeConfigFile writes content to <dir>/.iguana/config.yaml.
+func writeConfigFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".iguana"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".iguana", "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfig_GlobalDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeConfigFile(t, home, "format: table\n")
+
+	cfg, err := LoadConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Format != "table" {
+		t.Errorf("Format = %q, want %q", cfg.Format, "table")
+	}
+}
+
+func TestLoadConfig_RepoOverridesGlobal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeConfigFile(t, home, "format: table\n")
+	root := t.TempDir()
+	writeConfigFile(t, root, "format: yaml\n")
+
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Format != "yaml" {
+		t.Errorf("Format = %q, want %q", cfg.Format, "yaml")
+	}
+}
+
+// TestPinnedCommit verifies that pin_commit comes from the analyzed root's
+// config only: a global pin would apply to every repository.
+func TestPinnedCommit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeConfigFile(t, home, "pin_commit: 1111111\n")
+	root := t.TempDir()
+
+	if pin, err := PinnedCommit(root); err != nil || pin != "" {
+		t.Errorf("PinnedCommit without a repo pin = %q, %v; want none", pin, err)
+	}
+	if cfg, err := LoadConfig(root); err != nil || cfg.PinCommit != "" {
+		t.Errorf("LoadConfig PinCommit = %q, %v; want the global pin ignored", cfg.PinCommit, err)
+	}
+	writeConfigFile(t, root, "pin_commit: 2222222\n")
+	if pin, err := PinnedCommit(root); err != nil || pin != "2222222" {
+		t.Errorf("PinnedCommit = %q, %v; want 2222222", pin, err)
+	}
+}
+
+func TestLoadConfig_LLM(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeConfigFile(t, home, "trust_repo_llm: true\nllm:\n  providers:\n    - {name: global, provider: openai, model: gpt-5}\n")
+	root := t.TempDir()
+	writeConfigFile(t, root, `llm:
+  providers:
+    - {name: local, provider: openai-generic, model: llama4, base_url: "http://localhost:11434/v1", timeout: 2m}
+    - {name: claude, provider: anthropic, model: claude-sonnet-4-20250514, api_key_env: ANTHROPIC_API_KEY}
+  functions:
+    TypeOfState: [claude]
+`)
+
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	chain, err := cfg.LLM.Chain("InferSystemModel")
+	if err != nil || len(chain) != 2 || chain[0].Name != "local" {
+		t.Errorf("InferSystemModel chain = %+v, %v; want the repo providers in order", chain, err)
+	}
+	if d, err := chain[0].TimeoutDuration(); err != nil || d.Minutes() != 2 {
+		t.Errorf("TimeoutDuration = %v, %v; want 2m", d, err)
+	}
+	chain, err = cfg.LLM.Chain("TypeOfState")
+	if err != nil || len(chain) != 1 || chain[0].APIKeyEnv != "ANTHROPIC_API_KEY" {
+		t.Errorf("TypeOfState chain = %+v, %v; want [claude]", chain, err)
+	}
+
+	cfg.LLM.Functions["TypeOfState"] = []string{"missing"}
+	if _, err := cfg.LLM.Chain("TypeOfState"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+// TestLoadConfig_UntrustedRepoLLM verifies that a repo config cannot pick
+// the endpoint or key variable of an LLM provider unless the global config
+// trusts it, and that the repo cannot grant itself that trust.
+func TestLoadConfig_UntrustedRepoLLM(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	writeConfigFile(t, root, `trust_repo_llm: true
+llm:
+  providers:
+    - {name: evil, provider: openai-generic, model: m, base_url: "https://evil.example/v1", api_key_env: AWS_SECRET_ACCESS_KEY}
+`)
+
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	p := cfg.LLM.Providers[0]
+	if p.BaseURL != "" || p.APIKeyEnv != "" || p.Model != "m" {
+		t.Errorf("provider = %+v, want base_url and api_key_env dropped", p)
+	}
+	if len(cfg.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one", cfg.Warnings)
+	}
+
+	writeConfigFile(t, home, "trust_repo_llm: true\n")
+	if cfg, err = LoadConfig(root); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if p := cfg.LLM.Providers[0]; p.BaseURL == "" || len(cfg.Warnings) != 0 {
+		t.Errorf("trusted: provider = %+v, warnings = %v", p, cfg.Warnings)
+	}
+}
@@ -0,0 +1,166 @@
+package store
+
+// store.go — SQLite-backed evidence store.
+//
+// The default layout writes one <file>.evidence.yaml companion per source
+// file. A Store keeps the same bundles in a single SQLite database
+// instead (analyze --store), one row per source file keyed by its
+// root-relative path and source hash. Rows hold the bundle YAML exactly as
+// a companion file would, so reading goes through evidence.Decode and
+// migrates older versions the same way. GenerateSystemModel accepts a
+// store file in place of a directory, as it does an aggregate.
+//
+// The driver is modernc.org/sqlite, a pure-Go SQLite, so the binary still
+// builds without cgo.
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	"iguana/internal/evidence"
+)
+
+// schema creates the bundles table. Put keeps at most one row per path.
+const schema = `CREATE TABLE IF NOT EXISTS bundles (
+	path   TEXT NOT NULL,
+	sha256 TEXT NOT NULL,
+	data   BLOB NOT NULL,
+	PRIMARY KEY (path, sha256)
+)`
+
+// header starts every SQLite database file.
+var header = []byte("SQLite format 3\x00")
+
+// Store is an evidence store backed by one SQLite database. It implements
+// evidence.BundleStore and is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the store at path, creating the database if it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?_pragma=busy_timeout(5000)&_pragma=synchronous(normal)")
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	// SQLite allows one writer at a time; a single connection serializes
+	// the walk's parallel directories instead of failing them as busy.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// IsStore reports whether path is an SQLite database file.
+func IsStore(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, len(header))
+	n, _ := f.Read(buf)
+	return bytes.Equal(buf[:n], header)
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Has reports whether the store holds the bundle of path generated from
+// source hash sha256.
+func (s *Store) Has(path, sha256 string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM bundles WHERE path = ? AND sha256 = ?`, path, sha256).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("look up %s: %w", path, err)
+	}
+	return n > 0, nil
+}
+
+// Put stores the marshaled bundle of path, generated from source hash
+// sha256, replacing any earlier bundle of path.
+func (s *Store) Put(path, sha256 string, data []byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("put %s: %w", path, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM bundles WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("put %s: %w", path, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO bundles (path, sha256, data) VALUES (?, ?, ?)`, path, sha256, data); err != nil {
+		return fmt.Errorf("put %s: %w", path, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("put %s: %w", path, err)
+	}
+	return nil
+}
+
+// Bundles decodes every stored bundle, sorted by path.
+func (s *Store) Bundles() ([]*evidence.EvidenceBundle, error) {
+	rows, err := s.db.Query(`SELECT path, data FROM bundles ORDER BY path`)
+	if err != nil {
+		return nil, fmt.Errorf("read bundles: %w", err)
+	}
+	defer rows.Close()
+	var bundles []*evidence.EvidenceBundle
+	for rows.Next() {
+		var path string
+		var data []byte
+		if err := rows.Scan(&path, &data); err != nil {
+			return nil, fmt.Errorf("read bundles: %w", err)
+		}
+		b, err := evidence.Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		bundles = append(bundles, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read bundles: %w", err)
+	}
+	return bundles, nil
+}
+
+// Prune deletes the bundles whose source file no longer exists under root,
+// the store's counterpart of an orphaned companion file, and returns the
+// paths removed.
+func (s *Store) Prune(root string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT path FROM bundles ORDER BY path`)
+	if err != nil {
+		return nil, fmt.Errorf("prune: %w", err)
+	}
+	var gone []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("prune: %w", err)
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); errors.Is(err, fs.ErrNotExist) {
+			gone = append(gone, path)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("prune: %w", err)
+	}
+	for _, path := range gone {
+		if _, err := s.db.Exec(`DELETE FROM bundles WHERE path = ?`, path); err != nil {
+			return nil, fmt.Errorf("prune %s: %w", path, err)
+		}
+	}
+	return gone, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+// store_test.go — Tests for the SQLite evidence store.
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"iguana/internal/evidence"
+)
+
+// putBundle marshals b and stores it under its file path and hash.
+func putBundle(t *testing.T, s *Store, b *evidence.EvidenceBundle) {
+	t.Helper()
+	data, err := evidence.MarshalBundle(b, evidence.SchemaLatest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(b.File.Path, b.File.SHA256, data); err != nil {
+		t.Fatalf("Put(%s): %v", b.File.Path, err)
+	}
+}
+
+// TestStore_RoundTrip verifies that bundles read back decoded and sorted by
+// path, that Put replaces the earlier bundle of a path, that Has matches
+// path and hash, and that the database survives reopening.
+func TestStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evidence.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	putBundle(t, s, &evidence.EvidenceBundle{Version: evidence.CurrentVersion, File: evidence.FileMeta{Path: "store/b.go", SHA256: "b1"}, Package: evidence.PackageMeta{Name: "store"}})
+	putBundle(t, s, &evidence.EvidenceBundle{Version: evidence.CurrentVersion, File: evidence.FileMeta{Path: "api/a.go", SHA256: "a1"}, Package: evidence.PackageMeta{Name: "api"}})
+	putBundle(t, s, &evidence.EvidenceBundle{Version: evidence.CurrentVersion, File: evidence.FileMeta{Path: "store/b.go", SHA256: "b2"}, Package: evidence.PackageMeta{Name: "store"}})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsStore(path) {
+		t.Fatal("IsStore = false for a store database")
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	for _, c := range []struct {
+		path, sha256 string
+		want         bool
+	}{{"store/b.go", "b2", true}, {"store/b.go", "b1", false}, {"api/a.go", "a1", true}, {"api/c.go", "a1", false}} {
+		if got, err := s.Has(c.path, c.sha256); err != nil || got != c.want {
+			t.Errorf("Has(%s, %s) = %v, %v; want %v", c.path, c.sha256, got, err, c.want)
+		}
+	}
+	bundles, err := s.Bundles()
+	if err != nil {
+		t.Fatalf("Bundles: %v", err)
+	}
+	var got []string
+	for _, b := range bundles {
+		got = append(got, b.File.Path+"@"+b.File.SHA256)
+	}
+	if want := []string{"api/a.go@a1", "store/b.go@b2"}; !slices.Equal(got, want) {
+		t.Errorf("Bundles = %v, want %v", got, want)
+	}
+}
+
+// TestStore_Prune verifies that Prune drops the bundles of deleted source
+// files and keeps the rest.
+func TestStore_Prune(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "kept.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(filepath.Join(t.TempDir(), "evidence.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	putBundle(t, s, &evidence.EvidenceBundle{Version: evidence.CurrentVersion, File: evidence.FileMeta{Path: "kept.go", SHA256: "k"}})
+	putBundle(t, s, &evidence.EvidenceBundle{Version: evidence.CurrentVersion, File: evidence.FileMeta{Path: "gone/deleted.go", SHA256: "d"}})
+
+	pruned, err := s.Prune(root)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if !slices.Equal(pruned, []string{"gone/deleted.go"}) {
+		t.Errorf("pruned = %v, want [gone/deleted.go]", pruned)
+	}
+	bundles, err := s.Bundles()
+	if err != nil || len(bundles) != 1 || bundles[0].File.Path != "kept.go" {
+		t.Errorf("Bundles after prune = %v, %v", bundles, err)
+	}
+}
+
+// TestIsStore verifies that YAML files and missing paths are not stores.
+func TestIsStore(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "corpus.yaml")
+	if err := os.WriteFile(yamlPath, []byte("version: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{yamlPath, filepath.Join(dir, "missing.db"), dir} {
+		if IsStore(p) {
+			t.Errorf("IsStore(%s) = true", p)
+		}
+	}
+}